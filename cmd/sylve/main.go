@@ -25,6 +25,7 @@ import (
 	"github.com/alchemillahq/sylve/internal/db"
 	clusterModels "github.com/alchemillahq/sylve/internal/db/models/cluster"
 	"github.com/alchemillahq/sylve/internal/handlers"
+	lifecycleServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/lifecycle"
 	"github.com/alchemillahq/sylve/internal/logger"
 	"github.com/alchemillahq/sylve/internal/services"
 	"github.com/alchemillahq/sylve/internal/services/auth"
@@ -33,6 +34,7 @@ import (
 	"github.com/alchemillahq/sylve/internal/services/info"
 	"github.com/alchemillahq/sylve/internal/services/jail"
 	"github.com/alchemillahq/sylve/internal/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/services/lifecycle"
 	"github.com/alchemillahq/sylve/internal/services/network"
 	"github.com/alchemillahq/sylve/internal/services/samba"
 	"github.com/alchemillahq/sylve/internal/services/system"
@@ -66,6 +68,7 @@ func main() {
 	smbS := serviceRegistry.SambaService
 	jS := serviceRegistry.JailService
 	cS := serviceRegistry.ClusterService
+	lcS := serviceRegistry.LifecycleService
 
 	err := sS.Initialize(aS.(*auth.Service))
 
@@ -87,6 +90,7 @@ func main() {
 	go aS.ClearExpiredJWTTokens()
 	go uS.StartWOLServer()
 	go lvS.WolTasks()
+	go lcS.StartGuests()
 
 	gin.SetMode(gin.ReleaseMode)
 	gin.DefaultWriter = io.Discard
@@ -112,6 +116,7 @@ func main() {
 		smbS.(*samba.Service),
 		jS.(*jail.Service),
 		cS.(*cluster.Service),
+		lcS.(*lifecycle.Service),
 		fsm,
 		d,
 	)
@@ -143,6 +148,8 @@ func main() {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
+	// The server goes first so nothing can start a guest while they are
+	// being stopped.
 	logger.L.Info().Msg("Shutting down server gracefully...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -153,5 +160,9 @@ func main() {
 	}
 
 	wg.Wait()
+
+	logger.L.Info().Msg("Stopping guests...")
+	lcS.ShutdownGuests(lifecycleServiceInterfaces.ShutdownOptions{})
+
 	logger.L.Info().Msg("Server exited properly")
 }
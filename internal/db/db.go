@@ -57,14 +57,16 @@ func SetupDatabase(cfg *internal.SylveConfig, isTest bool) *gorm.DB {
 		&models.Group{},
 		&models.Token{},
 		&models.SystemSecrets{},
+		&models.Sysctl{},
+		&models.GuestBootConfig{},
 
 		&vmModels.Storage{},
 		&vmModels.Network{},
-		&vmModels.VMStats{},
 		&vmModels.VM{},
+		&vmModels.CloudInit{},
+		&vmModels.Snapshot{},
 
 		&jailModels.Network{},
-		&jailModels.JailStats{},
 		&jailModels.Jail{},
 
 		&models.PassedThroughIDs{},
@@ -74,17 +76,20 @@ func SetupDatabase(cfg *internal.SylveConfig, isTest bool) *gorm.DB {
 		&networkModels.ObjectEntry{},
 		&networkModels.ObjectResolution{},
 
-		&infoModels.CPU{},
-		&infoModels.RAM{},
-		&infoModels.Swap{},
-		&infoModels.IODelay{},
-		&infoModels.NetworkInterface{},
 		&infoModels.Note{},
 		&infoModels.AuditRecord{},
-
-		&infoModels.ZPoolHistorical{},
+		&infoModels.MetricSample{},
+		&infoModels.MetricRollup{},
 
 		&zfsModels.PeriodicSnapshot{},
+		&zfsModels.SnapshotPruneRun{},
+		&zfsModels.BackupJob{},
+		&zfsModels.BackupJobRun{},
+		&zfsModels.ScrubSchedule{},
+		&zfsModels.ScrubRun{},
+		&zfsModels.DatasetKey{},
+		&zfsModels.PoolEvent{},
+		&zfsModels.PoolAlert{},
 
 		&networkModels.StandardSwitch{},
 		&networkModels.NetworkPort{},
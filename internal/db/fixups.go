@@ -9,9 +9,13 @@
 package db
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
 	networkModels "github.com/alchemillahq/sylve/internal/db/models/network"
 	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
 
@@ -23,6 +27,10 @@ func Fixups(db *gorm.DB) error {
 		return err
 	}
 
+	if err := LegacyMetricsFixups(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -153,3 +161,236 @@ func NetworkObjectFixups(db *gorm.DB) error {
 
 	return nil
 }
+
+// LegacyMetricsFixups moves the rows of the per-metric tables that were
+// replaced by metric_samples and metric_rollups into the shared store, then
+// drops the tables. It runs before any collector, so rolling up right after
+// covers the whole imported history.
+func LegacyMetricsFixups(db *gorm.DB) error {
+	tables := []struct {
+		name    string
+		samples func(*gorm.DB) ([]infoModels.MetricSample, error)
+	}{
+		{"cpus", legacyUsageSamples("cpus", "cpu", "usage")},
+		{"rams", legacyUsageSamples("rams", "ram", "usage")},
+		{"swaps", legacyUsageSamples("swaps", "swap", "usage")},
+		{"io_delays", legacyUsageSamples("io_delays", "iodelay", "delay")},
+		{"network_interfaces", legacyNetworkSamples},
+		{"z_pool_historicals", legacyPoolSamples},
+		{"vm_stats", legacyVMSamples},
+		{"jail_stats", legacyJailSamples},
+	}
+
+	migrated := false
+
+	for _, table := range tables {
+		if !db.Migrator().HasTable(table.name) {
+			continue
+		}
+
+		samples, err := table.samples(db)
+		if err != nil {
+			return fmt.Errorf("read legacy table %s: %w", table.name, err)
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if len(samples) > 0 {
+				if err := tx.CreateInBatches(samples, 500).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Migrator().DropTable(table.name)
+		}); err != nil {
+			return fmt.Errorf("migrate legacy table %s: %w", table.name, err)
+		}
+
+		migrated = true
+	}
+
+	if migrated {
+		if err := RollupMetrics(db, time.Now()); err != nil {
+			return fmt.Errorf("rollup legacy metrics: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func legacySamples(series, label string, at int64, values map[string]float64) []infoModels.MetricSample {
+	samples := make([]infoModels.MetricSample, 0, len(values))
+	for field, value := range values {
+		samples = append(samples, infoModels.MetricSample{
+			Series:    series,
+			Label:     label,
+			Field:     field,
+			Value:     value,
+			CreatedAt: at,
+		})
+	}
+
+	return samples
+}
+
+// legacyUsageSamples reads the single value tables (cpus, rams, swaps and
+// io_delays), whose value column is named after the field.
+func legacyUsageSamples(table, series, field string) func(*gorm.DB) ([]infoModels.MetricSample, error) {
+	return func(db *gorm.DB) ([]infoModels.MetricSample, error) {
+		var rows []struct {
+			Value     float64
+			CreatedAt time.Time
+		}
+
+		if err := db.Table(table).Select(field + " AS value, created_at").Order("created_at ASC").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		samples := make([]infoModels.MetricSample, 0, len(rows))
+		for _, row := range rows {
+			samples = append(samples, legacySamples(series, "", row.CreatedAt.UnixMilli(), map[string]float64{field: row.Value})...)
+		}
+
+		return samples, nil
+	}
+}
+
+// legacyNetworkSamples turns the cumulative counters of the link rows into
+// the bytes moved since the previous row of the interface, the way they are
+// recorded now. Rows written in one pass are a few milliseconds apart, so
+// they are aligned to the sampling step to sum them up per pass.
+func legacyNetworkSamples(db *gorm.DB) ([]infoModels.MetricSample, error) {
+	var rows []struct {
+		Name          string
+		SentBytes     int64
+		ReceivedBytes int64
+		CreatedAt     time.Time
+	}
+
+	if err := db.Table("network_interfaces").
+		Where("network LIKE ?", "<Link%").
+		Order("name ASC, created_at ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	type totals struct{ sent, received int64 }
+
+	var samples []infoModels.MetricSample
+	sums := make(map[int64]*totals)
+
+	for i := 1; i < len(rows); i++ {
+		prev, row := rows[i-1], rows[i]
+		if prev.Name != row.Name || row.SentBytes < prev.SentBytes || row.ReceivedBytes < prev.ReceivedBytes {
+			continue
+		}
+
+		at := row.CreatedAt.Truncate(rawMetricStep).UnixMilli()
+		sent := row.SentBytes - prev.SentBytes
+		received := row.ReceivedBytes - prev.ReceivedBytes
+
+		samples = append(samples, legacySamples("network", row.Name, at, map[string]float64{
+			"sentBytes":     float64(sent),
+			"receivedBytes": float64(received),
+		})...)
+
+		if sums[at] == nil {
+			sums[at] = &totals{}
+		}
+		sums[at].sent += sent
+		sums[at].received += received
+	}
+
+	for at, sum := range sums {
+		samples = append(samples, legacySamples("network", "", at, map[string]float64{
+			"sentBytes":     float64(sum.sent),
+			"receivedBytes": float64(sum.received),
+		})...)
+	}
+
+	return samples, nil
+}
+
+func legacyPoolSamples(db *gorm.DB) ([]infoModels.MetricSample, error) {
+	var rows []struct {
+		Pools     string
+		CreatedAt int64
+	}
+
+	if err := db.Table("z_pool_historicals").Order("created_at ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var samples []infoModels.MetricSample
+	for _, row := range rows {
+		var pool struct {
+			Name       string  `json:"name"`
+			Allocated  uint64  `json:"allocated"`
+			Size       uint64  `json:"size"`
+			Free       uint64  `json:"free"`
+			DedupRatio float64 `json:"dedupRatio"`
+		}
+
+		if err := json.Unmarshal([]byte(row.Pools), &pool); err != nil || pool.Name == "" {
+			continue
+		}
+
+		samples = append(samples, legacySamples("pool", pool.Name, row.CreatedAt, map[string]float64{
+			"allocated":  float64(pool.Allocated),
+			"free":       float64(pool.Free),
+			"size":       float64(pool.Size),
+			"dedupRatio": pool.DedupRatio,
+		})...)
+	}
+
+	return samples, nil
+}
+
+func legacyVMSamples(db *gorm.DB) ([]infoModels.MetricSample, error) {
+	var rows []struct {
+		VMID        uint `gorm:"column:vm_id"`
+		CPUUsage    float64
+		MemoryUsage float64
+		MemoryUsed  float64
+		CreatedAt   time.Time
+	}
+
+	if err := db.Table("vm_stats").Order("created_at ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var samples []infoModels.MetricSample
+	for _, row := range rows {
+		samples = append(samples, legacySamples("vm", strconv.FormatUint(uint64(row.VMID), 10), row.CreatedAt.UnixMilli(), map[string]float64{
+			"cpuUsage":    row.CPUUsage,
+			"memoryUsage": row.MemoryUsage,
+			"memoryUsed":  row.MemoryUsed,
+		})...)
+	}
+
+	return samples, nil
+}
+
+// legacyJailSamples labels the rows with ct_id, which despite its name held
+// the ID of the jail row, the label of the "jail" series.
+func legacyJailSamples(db *gorm.DB) ([]infoModels.MetricSample, error) {
+	var rows []struct {
+		CTID        uint `gorm:"column:ct_id"`
+		CPUUsage    float64
+		MemoryUsage float64
+		CreatedAt   time.Time
+	}
+
+	if err := db.Table("jail_stats").Order("created_at ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var samples []infoModels.MetricSample
+	for _, row := range rows {
+		samples = append(samples, legacySamples("jail", strconv.FormatUint(uint64(row.CTID), 10), row.CreatedAt.UnixMilli(), map[string]float64{
+			"cpuUsage":    row.CPUUsage,
+			"memoryUsage": row.MemoryUsage,
+		})...)
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,473 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal/config"
+	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ResolutionAuto = "auto"
+	ResolutionRaw  = "raw"
+	Resolution1m   = "1m"
+	Resolution1h   = "1h"
+	Resolution1d   = "1d"
+)
+
+const (
+	// rawMetricStep is how often the fastest collectors sample.
+	rawMetricStep = 10 * time.Second
+
+	// maxMetricPoints is what an automatically picked resolution keeps a
+	// range under.
+	maxMetricPoints = 1000
+
+	defaultMetricRange = time.Hour
+)
+
+type metricResolution struct {
+	name   string
+	step   time.Duration
+	source string
+}
+
+// metricResolutions are rolled up in order, each from the one before it.
+var metricResolutions = []metricResolution{
+	{Resolution1m, time.Minute, ResolutionRaw},
+	{Resolution1h, time.Hour, Resolution1m},
+	{Resolution1d, 24 * time.Hour, Resolution1h},
+}
+
+type MetricRetention struct {
+	Raw    time.Duration
+	Minute time.Duration
+	Hour   time.Duration
+	Day    time.Duration
+}
+
+func GetMetricRetention() MetricRetention {
+	retention := MetricRetention{
+		Raw:    24 * time.Hour,
+		Minute: 7 * 24 * time.Hour,
+		Hour:   90 * 24 * time.Hour,
+		Day:    5 * 365 * 24 * time.Hour,
+	}
+
+	if config.ParsedConfig == nil {
+		return retention
+	}
+
+	cfg := config.ParsedConfig.Metrics
+	if cfg.RawRetentionHours > 0 {
+		retention.Raw = time.Duration(cfg.RawRetentionHours) * time.Hour
+	}
+	if cfg.MinuteRetentionDays > 0 {
+		retention.Minute = time.Duration(cfg.MinuteRetentionDays) * 24 * time.Hour
+	}
+	if cfg.HourRetentionDays > 0 {
+		retention.Hour = time.Duration(cfg.HourRetentionDays) * 24 * time.Hour
+	}
+	if cfg.DayRetentionDays > 0 {
+		retention.Day = time.Duration(cfg.DayRetentionDays) * 24 * time.Hour
+	}
+
+	return retention
+}
+
+func (r MetricRetention) of(resolution string) time.Duration {
+	switch resolution {
+	case ResolutionRaw:
+		return r.Raw
+	case Resolution1m:
+		return r.Minute
+	case Resolution1h:
+		return r.Hour
+	default:
+		return r.Day
+	}
+}
+
+// MetricRange is the time range and resolution asked of a historical
+// endpoint.
+type MetricRange struct {
+	From       time.Time
+	To         time.Time
+	Resolution string
+}
+
+// ParseMetricRange parses the from and to (unix milliseconds) and resolution
+// query parameters of the historical endpoints. Without from the last hour is
+// returned, without a resolution one is picked that fits the range.
+func ParseMetricRange(from, to, resolution string) (MetricRange, error) {
+	r := MetricRange{To: time.Now(), Resolution: ResolutionAuto}
+
+	if to != "" {
+		ms, err := strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return MetricRange{}, fmt.Errorf("invalid_to")
+		}
+		r.To = time.UnixMilli(ms)
+	}
+
+	r.From = r.To.Add(-defaultMetricRange)
+	if from != "" {
+		ms, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return MetricRange{}, fmt.Errorf("invalid_from")
+		}
+		r.From = time.UnixMilli(ms)
+	}
+
+	if !r.From.Before(r.To) {
+		return MetricRange{}, fmt.Errorf("invalid_range")
+	}
+
+	switch resolution {
+	case "":
+	case ResolutionAuto, ResolutionRaw, Resolution1m, Resolution1h, Resolution1d:
+		r.Resolution = resolution
+	default:
+		return MetricRange{}, fmt.Errorf("invalid_resolution")
+	}
+
+	return r, nil
+}
+
+// resolve picks the finest resolution that still holds data for the start of
+// the range and keeps it under maxMetricPoints.
+func (r MetricRange) resolve(retention MetricRetention) string {
+	if r.Resolution != ResolutionAuto {
+		return r.Resolution
+	}
+
+	span := r.To.Sub(r.From)
+	now := time.Now()
+
+	candidates := []struct {
+		name string
+		step time.Duration
+	}{
+		{ResolutionRaw, rawMetricStep},
+		{Resolution1m, time.Minute},
+		{Resolution1h, time.Hour},
+	}
+
+	for _, c := range candidates {
+		if r.From.Before(now.Add(-retention.of(c.name))) {
+			continue
+		}
+
+		if span/c.step <= maxMetricPoints {
+			return c.name
+		}
+	}
+
+	return Resolution1d
+}
+
+// MetricPoint holds every field of a series at one point in time. Values are
+// the samples, or the bucket averages for rolled up resolutions, where Min
+// and Max are set too. Labels are copied into the point as they are.
+type MetricPoint struct {
+	Time   time.Time
+	Labels map[string]any
+	Values map[string]float64
+	Min    map[string]float64
+	Max    map[string]float64
+}
+
+// MarshalJSON flattens the point so the values sit next to createdAt, the
+// shape the historical endpoints have always returned.
+func (p MetricPoint) MarshalJSON() ([]byte, error) {
+	out := make(map[string]any, len(p.Labels)+len(p.Values)+3)
+
+	for k, v := range p.Labels {
+		out[k] = v
+	}
+
+	for k, v := range p.Values {
+		out[k] = v
+	}
+
+	out["createdAt"] = p.Time
+
+	if p.Min != nil {
+		out["min"] = p.Min
+		out["max"] = p.Max
+	}
+
+	return json.Marshal(out)
+}
+
+// RecordMetrics stores one sample of each field of a series.
+func RecordMetrics(db *gorm.DB, series, label string, at time.Time, values map[string]float64) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	samples := make([]infoModels.MetricSample, 0, len(values))
+	for field, value := range values {
+		samples = append(samples, infoModels.MetricSample{
+			Series:    series,
+			Label:     label,
+			Field:     field,
+			Value:     value,
+			CreatedAt: at.UnixMilli(),
+		})
+	}
+
+	if err := db.Create(&samples).Error; err != nil {
+		return fmt.Errorf("failed_to_record_metrics: %w", err)
+	}
+
+	return nil
+}
+
+// QueryMetrics returns the points of a series in the range, oldest first.
+func QueryMetrics(db *gorm.DB, series, label string, r MetricRange) ([]MetricPoint, error) {
+	resolution := r.resolve(GetMetricRetention())
+	from, to := r.From.UnixMilli(), r.To.UnixMilli()
+
+	var points []MetricPoint
+	index := make(map[int64]int)
+
+	point := func(ts int64) *MetricPoint {
+		i, ok := index[ts]
+		if !ok {
+			p := MetricPoint{Time: time.UnixMilli(ts), Values: make(map[string]float64)}
+			if resolution != ResolutionRaw {
+				p.Min = make(map[string]float64)
+				p.Max = make(map[string]float64)
+			}
+			points = append(points, p)
+			i = len(points) - 1
+			index[ts] = i
+		}
+		return &points[i]
+	}
+
+	if resolution == ResolutionRaw {
+		var samples []infoModels.MetricSample
+		if err := db.Where("series = ? AND label = ? AND created_at >= ? AND created_at <= ?", series, label, from, to).
+			Order("created_at ASC").
+			Find(&samples).Error; err != nil {
+			return nil, fmt.Errorf("failed_to_query_metrics: %w", err)
+		}
+
+		for _, sample := range samples {
+			point(sample.CreatedAt).Values[sample.Field] = sample.Value
+		}
+
+		return points, nil
+	}
+
+	var rollups []infoModels.MetricRollup
+	if err := db.Where("series = ? AND label = ? AND resolution = ? AND bucket >= ? AND bucket <= ?", series, label, resolution, from, to).
+		Order("bucket ASC").
+		Find(&rollups).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_query_metrics: %w", err)
+	}
+
+	for _, rollup := range rollups {
+		p := point(rollup.Bucket)
+		p.Values[rollup.Field] = rollup.Avg
+		p.Min[rollup.Field] = rollup.Min
+		p.Max[rollup.Field] = rollup.Max
+	}
+
+	return points, nil
+}
+
+// MetricLabels lists the labels a series has data for.
+func MetricLabels(db *gorm.DB, series string) ([]string, error) {
+	var labels []string
+	if err := db.Raw(
+		"SELECT DISTINCT label FROM metric_samples WHERE series = ? UNION SELECT DISTINCT label FROM metric_rollups WHERE series = ?",
+		series, series,
+	).Scan(&labels).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_metric_labels: %w", err)
+	}
+
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// OldestMetric returns when the oldest data of a series still kept was
+// recorded, or the zero time if there is none. Rollup buckets start before
+// the data in them, so a coarser bucket only counts when it ends before what
+// the finer resolutions have.
+func OldestMetric(db *gorm.DB, series string) (time.Time, error) {
+	var raw sql.NullInt64
+	if err := db.Model(&infoModels.MetricSample{}).
+		Where("series = ?", series).
+		Select("MIN(created_at)").
+		Row().Scan(&raw); err != nil {
+		return time.Time{}, fmt.Errorf("failed_to_get_oldest_metric: %w", err)
+	}
+
+	oldest := raw.Int64
+	found := raw.Valid
+
+	for _, res := range metricResolutions {
+		var bucket sql.NullInt64
+		if err := db.Model(&infoModels.MetricRollup{}).
+			Where("series = ? AND resolution = ?", series, res.name).
+			Select("MIN(bucket)").
+			Row().Scan(&bucket); err != nil {
+			return time.Time{}, fmt.Errorf("failed_to_get_oldest_metric: %w", err)
+		}
+
+		if bucket.Valid && (!found || bucket.Int64+res.step.Milliseconds() <= oldest) {
+			oldest = bucket.Int64
+			found = true
+		}
+	}
+
+	if !found {
+		return time.Time{}, nil
+	}
+
+	return time.UnixMilli(oldest), nil
+}
+
+// DeleteMetrics drops all history of the given labels of a series.
+func DeleteMetrics(db *gorm.DB, series string, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series = ? AND label IN ?", series, labels).Delete(&infoModels.MetricSample{}).Error; err != nil {
+			return fmt.Errorf("failed_to_delete_metric_samples: %w", err)
+		}
+
+		if err := tx.Where("series = ? AND label IN ?", series, labels).Delete(&infoModels.MetricRollup{}).Error; err != nil {
+			return fmt.Errorf("failed_to_delete_metric_rollups: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteMetricsExcept drops the history of every label of a series that is
+// not in keep, used to forget VMs, jails and pools that are gone.
+func DeleteMetricsExcept(db *gorm.DB, series string, keep []string) error {
+	labels, err := MetricLabels(db, series)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool, len(keep))
+	for _, label := range keep {
+		kept[label] = true
+	}
+
+	var stale []string
+	for _, label := range labels {
+		if !kept[label] {
+			stale = append(stale, label)
+		}
+	}
+
+	return DeleteMetrics(db, series, stale)
+}
+
+// PruneOrphanedMetrics drops the history of every label of a series whose
+// row is gone from table, the label being the ID of that row.
+func PruneOrphanedMetrics(db *gorm.DB, series string, table string) error {
+	live := fmt.Sprintf("SELECT CAST(id AS TEXT) FROM %s", table)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series = ? AND label NOT IN ("+live+")", series).Delete(&infoModels.MetricSample{}).Error; err != nil {
+			return fmt.Errorf("failed_to_prune_orphaned_metric_samples: %w", err)
+		}
+
+		if err := tx.Where("series = ? AND label NOT IN ("+live+")", series).Delete(&infoModels.MetricRollup{}).Error; err != nil {
+			return fmt.Errorf("failed_to_prune_orphaned_metric_rollups: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RollupMetrics aggregates raw samples into 1m buckets, 1m into 1h and 1h
+// into 1d. Every run starts again from the newest bucket of a resolution, so
+// a bucket that was still filling up last time is completed.
+func RollupMetrics(db *gorm.DB, now time.Time) error {
+	for _, res := range metricResolutions {
+		step := res.step.Milliseconds()
+		end := now.Truncate(res.step).UnixMilli()
+
+		var last sql.NullInt64
+		if err := db.Model(&infoModels.MetricRollup{}).
+			Where("resolution = ?", res.name).
+			Select("MAX(bucket)").
+			Row().Scan(&last); err != nil {
+			return fmt.Errorf("failed_to_get_last_rollup: %w", err)
+		}
+
+		start := last.Int64
+
+		var query string
+		var args []any
+
+		if res.source == ResolutionRaw {
+			query = `INSERT INTO metric_rollups (series, label, field, resolution, bucket, min, avg, max, count)
+				SELECT series, label, field, ?, (created_at / ?) * ?, MIN(value), AVG(value), MAX(value), COUNT(*)
+				FROM metric_samples
+				WHERE created_at >= ? AND created_at < ?
+				GROUP BY series, label, field, created_at / ?`
+			args = []any{res.name, step, step, start, end, step}
+		} else {
+			query = `INSERT INTO metric_rollups (series, label, field, resolution, bucket, min, avg, max, count)
+				SELECT series, label, field, ?, (bucket / ?) * ?, MIN(min), SUM(avg * count) / SUM(count), MAX(max), SUM(count)
+				FROM metric_rollups
+				WHERE resolution = ? AND bucket >= ? AND bucket < ?
+				GROUP BY series, label, field, bucket / ?`
+			args = []any{res.name, step, step, res.source, start, end, step}
+		}
+
+		query += `
+				ON CONFLICT (series, label, field, resolution, bucket) DO UPDATE SET
+				min = excluded.min, avg = excluded.avg, max = excluded.max, count = excluded.count`
+
+		if err := db.Exec(query, args...).Error; err != nil {
+			return fmt.Errorf("failed_to_rollup_metrics_%s: %w", res.name, err)
+		}
+	}
+
+	return nil
+}
+
+// PruneMetrics drops raw samples and rollups older than their retention.
+func PruneMetrics(db *gorm.DB, now time.Time, retention MetricRetention) error {
+	if err := db.Where("created_at < ?", now.Add(-retention.Raw).UnixMilli()).
+		Delete(&infoModels.MetricSample{}).Error; err != nil {
+		return fmt.Errorf("failed_to_prune_metric_samples: %w", err)
+	}
+
+	for _, res := range metricResolutions {
+		if err := db.Where("resolution = ? AND bucket < ?", res.name, now.Add(-retention.of(res.name)).UnixMilli()).
+			Delete(&infoModels.MetricRollup{}).Error; err != nil {
+			return fmt.Errorf("failed_to_prune_metric_rollups_%s: %w", res.name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMetricsDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&infoModels.MetricSample{}, &infoModels.MetricRollup{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
+
+func record(t *testing.T, db *gorm.DB, label string, at time.Time, value float64) {
+	t.Helper()
+
+	if err := RecordMetrics(db, "cpu", label, at, map[string]float64{"usage": value}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+}
+
+func rollup(t *testing.T, db *gorm.DB, label, resolution string, bucket time.Time) (infoModels.MetricRollup, bool) {
+	t.Helper()
+
+	var rows []infoModels.MetricRollup
+	if err := db.Where("series = ? AND label = ? AND field = ? AND resolution = ? AND bucket = ?",
+		"cpu", label, "usage", resolution, bucket.UnixMilli()).Find(&rows).Error; err != nil {
+		t.Fatalf("query rollup: %v", err)
+	}
+
+	if len(rows) > 1 {
+		t.Fatalf("expected one %s rollup at %v, got %d", resolution, bucket, len(rows))
+	}
+
+	if len(rows) == 0 {
+		return infoModels.MetricRollup{}, false
+	}
+
+	return rows[0], true
+}
+
+func TestRollupMetrics(t *testing.T) {
+	db := openMetricsDB(t)
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	record(t, db, "", t0, 1)
+	record(t, db, "", t0.Add(10*time.Second), 2)
+	record(t, db, "", t0.Add(20*time.Second), 3)
+	record(t, db, "", t0.Add(time.Minute), 10)
+	record(t, db, "other", t0.Add(5*time.Second), 50)
+
+	// Still in the running minute, must not be rolled up yet.
+	record(t, db, "", t0.Add(2*time.Hour+5*time.Second), 99)
+
+	if err := RollupMetrics(db, t0.Add(2*time.Hour+30*time.Second)); err != nil {
+		t.Fatalf("rollup: %v", err)
+	}
+
+	tests := []struct {
+		label      string
+		resolution string
+		bucket     time.Time
+		want       infoModels.MetricRollup
+	}{
+		{"", Resolution1m, t0, infoModels.MetricRollup{Min: 1, Avg: 2, Max: 3, Count: 3}},
+		{"", Resolution1m, t0.Add(time.Minute), infoModels.MetricRollup{Min: 10, Avg: 10, Max: 10, Count: 1}},
+		{"other", Resolution1m, t0, infoModels.MetricRollup{Min: 50, Avg: 50, Max: 50, Count: 1}},
+		{"", Resolution1h, t0, infoModels.MetricRollup{Min: 1, Avg: 4, Max: 10, Count: 4}},
+	}
+
+	for _, tt := range tests {
+		got, ok := rollup(t, db, tt.label, tt.resolution, tt.bucket)
+		if !ok {
+			t.Errorf("missing %s rollup of %q at %v", tt.resolution, tt.label, tt.bucket)
+			continue
+		}
+
+		if got.Min != tt.want.Min || got.Avg != tt.want.Avg || got.Max != tt.want.Max || got.Count != tt.want.Count {
+			t.Errorf("%s rollup of %q at %v = %+v, want %+v", tt.resolution, tt.label, tt.bucket, got, tt.want)
+		}
+	}
+
+	if _, ok := rollup(t, db, "", Resolution1m, t0.Add(2*time.Hour)); ok {
+		t.Errorf("the running minute was rolled up")
+	}
+
+	if _, ok := rollup(t, db, "", Resolution1d, t0); ok {
+		t.Errorf("the running day was rolled up")
+	}
+
+	if err := RollupMetrics(db, t0.Add(2*time.Hour+90*time.Second)); err != nil {
+		t.Fatalf("second rollup: %v", err)
+	}
+
+	got, ok := rollup(t, db, "", Resolution1m, t0.Add(2*time.Hour))
+	if !ok || got.Count != 1 || got.Avg != 99 {
+		t.Errorf("expected the finished minute to be rolled up, got %+v", got)
+	}
+}
+
+func TestRollupMetricsCompletesNewestBucket(t *testing.T) {
+	db := openMetricsDB(t)
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	record(t, db, "", t0, 1)
+	if err := RollupMetrics(db, t0.Add(time.Minute)); err != nil {
+		t.Fatalf("rollup: %v", err)
+	}
+
+	record(t, db, "", t0.Add(30*time.Second), 3)
+	if err := RollupMetrics(db, t0.Add(time.Minute)); err != nil {
+		t.Fatalf("second rollup: %v", err)
+	}
+
+	got, ok := rollup(t, db, "", Resolution1m, t0)
+	if !ok || got.Count != 2 || got.Avg != 2 || got.Min != 1 || got.Max != 3 {
+		t.Errorf("expected the newest bucket to be completed, got %+v", got)
+	}
+}
+
+func TestPruneMetrics(t *testing.T) {
+	db := openMetricsDB(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	retention := MetricRetention{
+		Raw:    time.Hour,
+		Minute: 24 * time.Hour,
+		Hour:   7 * 24 * time.Hour,
+		Day:    30 * 24 * time.Hour,
+	}
+
+	record(t, db, "", now.Add(-2*time.Hour), 1)
+	record(t, db, "", now.Add(-30*time.Minute), 2)
+
+	rollups := []infoModels.MetricRollup{
+		{Resolution: Resolution1m, Bucket: now.Add(-48 * time.Hour).UnixMilli()},
+		{Resolution: Resolution1m, Bucket: now.Add(-time.Hour).UnixMilli()},
+		{Resolution: Resolution1h, Bucket: now.Add(-48 * time.Hour).UnixMilli()},
+		{Resolution: Resolution1h, Bucket: now.Add(-8 * 24 * time.Hour).UnixMilli()},
+		{Resolution: Resolution1d, Bucket: now.Add(-8 * 24 * time.Hour).UnixMilli()},
+		{Resolution: Resolution1d, Bucket: now.Add(-31 * 24 * time.Hour).UnixMilli()},
+	}
+	for i := range rollups {
+		rollups[i].Series, rollups[i].Label, rollups[i].Field = "cpu", "", "usage"
+	}
+	if err := db.Create(&rollups).Error; err != nil {
+		t.Fatalf("create rollups: %v", err)
+	}
+
+	if err := PruneMetrics(db, now, retention); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	var samples []infoModels.MetricSample
+	if err := db.Find(&samples).Error; err != nil {
+		t.Fatalf("query samples: %v", err)
+	}
+	if len(samples) != 1 || samples[0].Value != 2 {
+		t.Errorf("expected only the recent sample to be kept, got %+v", samples)
+	}
+
+	for _, tt := range []struct {
+		resolution string
+		bucket     time.Time
+		kept       bool
+	}{
+		{Resolution1m, now.Add(-48 * time.Hour), false},
+		{Resolution1m, now.Add(-time.Hour), true},
+		{Resolution1h, now.Add(-48 * time.Hour), true},
+		{Resolution1h, now.Add(-8 * 24 * time.Hour), false},
+		{Resolution1d, now.Add(-8 * 24 * time.Hour), true},
+		{Resolution1d, now.Add(-31 * 24 * time.Hour), false},
+	} {
+		if _, ok := rollup(t, db, "", tt.resolution, tt.bucket); ok != tt.kept {
+			t.Errorf("%s rollup at %v: kept = %v, want %v", tt.resolution, tt.bucket, ok, tt.kept)
+		}
+	}
+}
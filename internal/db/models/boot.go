@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package models
+
+// GuestBootConfig holds the boot settings of a VM or jail on top of its
+// start order. Guests are referred to as "vm:<vmid>" or "jail:<ctid>".
+type GuestBootConfig struct {
+	ID        int    `json:"id" gorm:"primaryKey"`
+	GuestType string `json:"guestType" gorm:"uniqueIndex:idx_guest_boot_config"`
+	GuestID   int    `json:"guestId" gorm:"uniqueIndex:idx_guest_boot_config"`
+
+	// StartDelay is the number of seconds to wait after the guest has
+	// started before guests after it are started.
+	StartDelay int `json:"startDelay"`
+
+	WaitType    string `json:"waitType"`
+	WaitHost    string `json:"waitHost"`
+	WaitPort    int    `json:"waitPort"`
+	WaitTimeout int    `json:"waitTimeout"`
+
+	DependsOn []string `json:"dependsOn" gorm:"serializer:json;type:json"`
+}
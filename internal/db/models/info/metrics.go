@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package infoModels
+
+// MetricSample is a raw sample of one field of a series, e.g. the cpuUsage
+// field of the "vm" series for the VM labelled "3". All fields collected in
+// one pass share the same CreatedAt.
+type MetricSample struct {
+	ID        int64   `json:"id" gorm:"primaryKey"`
+	Series    string  `json:"series" gorm:"index:idx_metric_samples_series,priority:1"`
+	Label     string  `json:"label" gorm:"index:idx_metric_samples_series,priority:2"`
+	Field     string  `json:"field"`
+	Value     float64 `json:"value"`
+	CreatedAt int64   `json:"createdAt" gorm:"index:idx_metric_samples_series,priority:3;index"`
+}
+
+// MetricRollup aggregates the samples of a field over one bucket of a
+// resolution (1m, 1h or 1d). Bucket is the start of the bucket in unix
+// milliseconds.
+type MetricRollup struct {
+	ID         int64   `json:"id" gorm:"primaryKey"`
+	Series     string  `json:"series" gorm:"uniqueIndex:idx_metric_rollups_bucket,priority:1"`
+	Label      string  `json:"label" gorm:"uniqueIndex:idx_metric_rollups_bucket,priority:2"`
+	Field      string  `json:"field" gorm:"uniqueIndex:idx_metric_rollups_bucket,priority:3"`
+	Resolution string  `json:"resolution" gorm:"uniqueIndex:idx_metric_rollups_bucket,priority:4"`
+	Bucket     int64   `json:"bucket" gorm:"uniqueIndex:idx_metric_rollups_bucket,priority:5"`
+	Min        float64 `json:"min"`
+	Avg        float64 `json:"avg"`
+	Max        float64 `json:"max"`
+	Count      int64   `json:"count"`
+}
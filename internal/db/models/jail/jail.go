@@ -43,15 +43,6 @@ type Network struct {
 	CTID uint `json:"ctId" gorm:"index"`
 }
 
-type JailStats struct {
-	ID          uint    `json:"id" gorm:"primaryKey"`
-	CTID        int     `json:"ctId"`
-	CPUUsage    float64 `json:"cpuUsage"`
-	MemoryUsage float64 `json:"memoryUsage"`
-
-	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
-}
-
 type Jail struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
 	CTID        int    `json:"ctId" gorm:"unique;not null;uniqueIndex"`
@@ -70,8 +61,7 @@ type Jail struct {
 	CPUSet         []int `json:"cpuSet" gorm:"serializer:json;type:json"`
 	Memory         int   `json:"memory"`
 
-	Networks []Network   `json:"networks" gorm:"foreignKey:CTID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
-	Stats    []JailStats `json:"-" gorm:"foreignKey:CTID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Networks []Network `json:"networks" gorm:"foreignKey:CTID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 
 	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
@@ -38,3 +38,11 @@ type Triggers struct {
 	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
 	CompletedAt time.Time `json:"completedAt" gorm:"autoUpdateTime"`
 }
+
+// Sysctl is a tunable set through the API, SysctlSync applies it again on
+// every start.
+type Sysctl struct {
+	ID    int    `json:"id" gorm:"primaryKey"`
+	Name  string `json:"name" gorm:"uniqueIndex"`
+	Value int64  `json:"value"`
+}
@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package vmModels
+
+import "time"
+
+type CloudInitUser struct {
+	Name         string   `json:"name"`
+	SSHKeys      []string `json:"sshKeys"`
+	PasswordHash string   `json:"passwordHash"`
+	Sudo         bool     `json:"sudo"`
+	Shell        string   `json:"shell"`
+}
+
+// CloudInit holds the NoCloud seed settings of a VM, the seed ISO itself is
+// generated from these and the VM's networks.
+type CloudInit struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	VMID        uint            `json:"vmId" gorm:"uniqueIndex"`
+	Hostname    string          `json:"hostname"`
+	Users       []CloudInitUser `json:"users" gorm:"serializer:json;type:json"`
+	Packages    []string        `json:"packages" gorm:"serializer:json;type:json"`
+	RunCmd      []string        `json:"runCmd" gorm:"serializer:json;type:json"`
+	Nameservers []string        `json:"nameservers" gorm:"serializer:json;type:json"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
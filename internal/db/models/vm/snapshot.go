@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package vmModels
+
+import "time"
+
+// Snapshot is a point in time of a whole VM. Every storage dataset of the VM
+// carries a ZFS snapshot named SnapshotName, next to that the VM's settings,
+// domain XML and UEFI variables are kept so a rollback restores those too.
+type Snapshot struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	VMID        uint   `json:"vmId" gorm:"index"`
+	ParentID    *uint  `json:"parentId" gorm:"index"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	SnapshotName string   `json:"snapshotName"`
+	Datasets     []string `json:"datasets" gorm:"serializer:json;type:json"`
+
+	Config    string `json:"-"`
+	DomainXML string `json:"-"`
+	UEFIVars  []byte `json:"-"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
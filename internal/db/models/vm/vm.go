@@ -35,19 +35,22 @@ type Network struct {
 	SwitchID uint                         `json:"switchId" gorm:"not null;index"`
 	Switch   networkModels.StandardSwitch `gorm:"foreignKey:SwitchID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 
-	Emulation string `json:"emulation"`
+	IPv4ID    *uint                 `json:"ipv4Id" gorm:"column:ipv4_id"`
+	IPv4Obj   *networkModels.Object `json:"ipv4Obj" gorm:"foreignKey:IPv4ID"`
+	IPv4GwID  *uint                 `json:"ipv4GwId" gorm:"column:ipv4_gw_id"`
+	IPv4GwObj *networkModels.Object `json:"ipv4GwObj" gorm:"foreignKey:IPv4GwID"`
 
-	VMID uint `json:"vmId" gorm:"index"`
-}
+	IPv6ID    *uint                 `json:"ipv6Id" gorm:"column:ipv6_id"`
+	IPv6Obj   *networkModels.Object `json:"ipv6Obj" gorm:"foreignKey:IPv6ID"`
+	IPv6GwID  *uint                 `json:"ipv6GwId" gorm:"column:ipv6_gw_id"`
+	IPv6GwObj *networkModels.Object `json:"ipv6GwObj" gorm:"foreignKey:IPv6GwID"`
 
-type VMStats struct {
-	ID          uint    `gorm:"primaryKey" json:"id"`
-	VMID        uint    `json:"vmId" gorm:"index"`
-	CPUUsage    float64 `json:"cpuUsage"`
-	MemoryUsage float64 `json:"memoryUsage"`
-	MemoryUsed  float64 `json:"memoryUsed"`
+	DHCP  bool `json:"dhcp" gorm:"default:false"`
+	SLAAC bool `json:"slaac" gorm:"default:false"`
 
-	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	Emulation string `json:"emulation"`
+
+	VMID uint `json:"vmId" gorm:"index"`
 }
 
 type VM struct {
@@ -68,14 +71,21 @@ type VM struct {
 	StartOrder    int    `json:"startOrder"`
 	WoL           bool   `json:"wol" gorm:"default:false"`
 
-	ISO        string    `json:"iso"`
-	Storages   []Storage `json:"storages" gorm:"foreignKey:VMID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Networks   []Network `json:"networks" gorm:"foreignKey:VMID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	PCIDevices []int     `json:"pciDevices" gorm:"serializer:json;type:json"`
-	CPUPinning []int     `json:"cpuPinning" gorm:"serializer:json;type:json"`
+	Template    bool   `json:"template" gorm:"default:false;index"`
+	OSType      string `json:"osType" gorm:"default:''"`
+	SourceImage string `json:"sourceImage" gorm:"default:''"`
+	TemplateID  *uint  `json:"templateId" gorm:"default:null"`
+
+	CurrentSnapshotID *uint `json:"currentSnapshotId" gorm:"default:null"`
+
+	ISO        string     `json:"iso"`
+	Storages   []Storage  `json:"storages" gorm:"foreignKey:VMID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Networks   []Network  `json:"networks" gorm:"foreignKey:VMID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	PCIDevices []int      `json:"pciDevices" gorm:"serializer:json;type:json"`
+	CPUPinning []int      `json:"cpuPinning" gorm:"serializer:json;type:json"`
+	CloudInit  *CloudInit `json:"cloudInit,omitempty" gorm:"foreignKey:VMID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 
-	Stats []VMStats `json:"-" gorm:"foreignKey:VMID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	State string    `json:"state" gorm:"-"`
+	State string `json:"state" gorm:"-"`
 
 	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
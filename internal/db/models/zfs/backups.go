@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsModels
+
+import "time"
+
+type BackupJob struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	Name          string `gorm:"uniqueIndex" json:"name"`
+	Type          string `json:"type" gorm:"default:'sylve'"`
+	SourceGUID    string `json:"sourceGuid"`
+	TargetNode    string `json:"targetNode"`
+	TargetDataset string `json:"targetDataset"`
+	S3ConfigID    uint   `json:"s3ConfigId"`
+	S3Prefix      string `json:"s3Prefix"`
+	Prefix        string `json:"prefix"`
+	Interval      int    `json:"interval"`
+	CronExpr      string `json:"cronExpr"`
+	Enabled       bool   `json:"enabled" gorm:"default:true"`
+
+	LastSnapshot     string    `json:"lastSnapshot"`
+	LastSnapshotGUID string    `json:"lastSnapshotGuid"`
+	LastStatus       string    `json:"lastStatus"`
+	LastError        string    `json:"lastError"`
+	LastRunAt        time.Time `json:"lastRunAt,omitempty"`
+
+	Runs []BackupJobRun `json:"runs,omitempty" gorm:"foreignKey:JobID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+type BackupJobRun struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	JobID        uint   `gorm:"index" json:"jobId"`
+	Status       string `json:"status"`
+	Mode         string `json:"mode"`
+	Snapshot     string `json:"snapshot"`
+	BaseSnapshot string `json:"baseSnapshot"`
+	BytesSent    int64  `json:"bytesSent"`
+	Error        string `json:"error"`
+
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt" gorm:"default:null"`
+}
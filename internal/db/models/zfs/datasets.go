@@ -19,4 +19,41 @@ type PeriodicSnapshot struct {
 	CronExpr  string    `json:"cronExpr"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt,omitempty"`
 	LastRunAt time.Time `json:"lastRunAt,omitempty"`
+
+	KeepHourly  int `json:"keepHourly"`
+	KeepDaily   int `json:"keepDaily"`
+	KeepWeekly  int `json:"keepWeekly"`
+	KeepMonthly int `json:"keepMonthly"`
+	KeepYearly  int `json:"keepYearly"`
+
+	PruneRuns []SnapshotPruneRun `json:"pruneRuns,omitempty" gorm:"foreignKey:PolicyID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (p PeriodicSnapshot) HasRetention() bool {
+	return p.KeepHourly > 0 || p.KeepDaily > 0 || p.KeepWeekly > 0 || p.KeepMonthly > 0 || p.KeepYearly > 0
+}
+
+type SnapshotPruneRun struct {
+	ID       uint     `gorm:"primaryKey" json:"id"`
+	PolicyID uint     `gorm:"index" json:"policyId"`
+	DryRun   bool     `json:"dryRun"`
+	Kept     []string `json:"kept" gorm:"serializer:json;type:json"`
+	Deleted  []string `json:"deleted" gorm:"serializer:json;type:json"`
+	Skipped  []string `json:"skipped" gorm:"serializer:json;type:json"`
+	Error    string   `json:"error"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// DatasetKey tracks an encryption root whose key Sylve keeps. The key itself
+// lives encrypted in SystemSecrets under SecretName.
+type DatasetKey struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	GUID       string    `gorm:"uniqueIndex" json:"guid"`
+	Name       string    `json:"name"`
+	KeyFormat  string    `json:"keyFormat"`
+	SecretName string    `json:"-"`
+	AutoUnlock bool      `json:"autoUnlock"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 }
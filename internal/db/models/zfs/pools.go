@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsModels
+
+import "time"
+
+type ScrubSchedule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PoolGUID  string    `gorm:"uniqueIndex" json:"poolGuid"`
+	PoolName  string    `json:"poolName"`
+	Interval  int       `json:"interval"`
+	CronExpr  string    `json:"cronExpr"`
+	Enabled   bool      `json:"enabled" gorm:"default:true"`
+	LastRunAt time.Time `json:"lastRunAt,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// ScrubRun records a scrub of a pool. Scrubs started outside of Sylve are
+// recorded with the "external" trigger once they complete.
+type ScrubRun struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	PoolGUID string `gorm:"index" json:"poolGuid"`
+	PoolName string `json:"poolName"`
+	Trigger  string `json:"trigger"`
+	Status   string `json:"status"`
+	Repaired uint64 `json:"repaired"`
+	Errors   uint64 `json:"errors"`
+	Duration string `json:"duration"`
+	Error    string `json:"error"`
+
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt" gorm:"default:null"`
+}
+
+// PoolEvent is a stored entry of `zpool events`.
+type PoolEvent struct {
+	ID        uint              `gorm:"primaryKey" json:"id"`
+	EID       uint64            `json:"eid"`
+	Time      time.Time         `gorm:"index" json:"time"`
+	Class     string            `gorm:"index" json:"class"`
+	Pool      string            `gorm:"index" json:"pool"`
+	PoolGUID  string            `json:"poolGuid"`
+	VdevPath  string            `json:"vdevPath"`
+	VdevGUID  string            `json:"vdevGuid"`
+	VdevState string            `json:"vdevState"`
+	Attrs     map[string]string `json:"attrs" gorm:"serializer:json;type:json"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// PoolAlert is a health change derived from pool events: a pool or vdev
+// changing state, a removed device or checksum and I/O errors.
+type PoolAlert struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	EventID      uint   `gorm:"index" json:"eventId"`
+	Pool         string `gorm:"index" json:"pool"`
+	PoolGUID     string `json:"poolGuid"`
+	Kind         string `json:"kind"`
+	Severity     string `json:"severity"`
+	Device       string `json:"device"`
+	PrevState    string `json:"prevState"`
+	State        string `json:"state"`
+	Message      string `json:"message"`
+	Acknowledged bool   `json:"acknowledged"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
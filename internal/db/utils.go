@@ -9,47 +9,9 @@
 package db
 
 import (
-	"reflect"
-
 	"gorm.io/gorm"
-	"gorm.io/gorm/schema"
 )
 
-func GetHistorical[T any](db *gorm.DB, limit int) ([]T, error) {
-	var records []T
-
-	tableName := schema.NamingStrategy{}.TableName(reflect.TypeOf((*T)(nil)).Elem().Name())
-
-	err := db.Table(tableName).
-		Order("strftime('%Y-%m-%d %H:%M', created_at) ASC").
-		Limit(limit).
-		Find(&records).Error
-	if err != nil {
-		return nil, err
-	}
-	return records, nil
-}
-
-func StoreAndTrimRecords[T any](db *gorm.DB, model *T, limit int) {
-	db.Create(model)
-
-	var count int64
-	db.Model(model).Count(&count)
-
-	if count > int64(limit) {
-		var oldestIDs []uint
-		db.Model(model).
-			Order("id ASC").
-			Limit(int(count-int64(limit))).
-			Pluck("id", &oldestIDs)
-
-		if len(oldestIDs) > 0 {
-			var emptyModel T
-			db.Where("id IN ?", oldestIDs).Delete(&emptyModel)
-		}
-	}
-}
-
 func Count(db *gorm.DB, model interface{}, cond string, args ...interface{}) (int64, error) {
 	var count int64
 	if err := db.Model(model).
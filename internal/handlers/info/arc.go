@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package infoHandlers
+
+import (
+	"net/http"
+
+	"github.com/alchemillahq/sylve/internal"
+	"github.com/alchemillahq/sylve/internal/db"
+	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
+	"github.com/alchemillahq/sylve/internal/services/info"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get ARC Info
+// @Description Get the current ZFS ARC and L2ARC statistics
+// @Tags Info
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} internal.APIResponse[infoServiceInterfaces.ARCInfo] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /info/arc [get]
+func ARCInfo(infoService *info.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info, err := infoService.GetARCInfo()
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[infoServiceInterfaces.ARCInfo]{
+			Status:  "success",
+			Message: "arc_info",
+			Error:   "",
+			Data:    info,
+		})
+	}
+}
+
+// @Summary Get Historical ARC information
+// @Description Retrieves historical ARC and L2ARC statistics
+// @Tags Info
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query int false "Start of the range in unix milliseconds"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
+// @Success 200 {object} internal.APIResponse[[]db.MetricPoint] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /info/arc/historical [get]
+func HistoricalARCInfoHandler(infoService *info.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		info, err := infoService.GetARCHistorical(r)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]db.MetricPoint]{
+			Status:  "success",
+			Message: "arc_info",
+			Error:   "",
+			Data:    info,
+		})
+	}
+}
+
+// @Summary Set ARC Max
+// @Description Set vfs.zfs.arc_max in bytes, persisted across reboots. 0 restores the kernel default.
+// @Tags Info
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body infoServiceInterfaces.SetARCMaxRequest true "ARC max"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /info/arc/max [put]
+func SetARCMax(infoService *info.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req infoServiceInterfaces.SetARCMaxRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := infoService.SetARCMax(*req.Max); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "arc_max_set",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
@@ -12,11 +12,11 @@ import (
 	"net/http"
 
 	"github.com/alchemillahq/sylve/internal"
+	"github.com/alchemillahq/sylve/internal/db"
 	"github.com/alchemillahq/sylve/internal/services/info"
 
 	"github.com/gin-gonic/gin"
 
-	_ "github.com/alchemillahq/sylve/internal/db/models/info"
 	_ "github.com/alchemillahq/sylve/internal/interfaces/services/info"
 )
 
@@ -55,12 +55,27 @@ func RealTimeCPUInfoHandler(infoService *info.Service) gin.HandlerFunc {
 // @Tags system
 // @Accept json
 // @Produce json
-// @Success 200 {object} internal.APIResponse[[]infoModels.CPU]
+// @Param from query int false "Start of the range in unix milliseconds"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
+// @Success 200 {object} internal.APIResponse[[]db.MetricPoint]
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
 // @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
 // @Router /info/cpu/historical [get]
 func HistoricalCPUInfoHandler(infoService *info.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		info, err := infoService.GetCPUUsageHistorical()
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		info, err := infoService.GetCPUUsageHistorical(r)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
 				Status:  "error",
@@ -12,30 +12,39 @@ import (
 	"net/http"
 
 	"github.com/alchemillahq/sylve/internal"
-	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
+	"github.com/alchemillahq/sylve/internal/db"
 	"github.com/alchemillahq/sylve/internal/services/info"
 
 	"github.com/gin-gonic/gin"
 )
 
-type HistoricalNetworkInterfaceResponse struct {
-	Status  string                                             `json:"status"`
-	Message string                                             `json:"message"`
-	Error   string                                             `json:"error"`
-	Data    []infoServiceInterfaces.HistoricalNetworkInterface `json:"data"`
-}
-
 // @Summary Get Historical Network information
-// @Description Retrieves historical Network info
+// @Description Retrieves bytes sent and received per sample, for one interface or the sum of all
 // @Tags system
 // @Accept json
 // @Produce json
-// @Success 200 {object} HistoricalNetworkInterfaceResponse
+// @Param interface query string false "Interface name, all interfaces if empty"
+// @Param from query int false "Start of the range in unix milliseconds"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
+// @Success 200 {object} internal.APIResponse[[]db.MetricPoint]
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
 // @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
 // @Router /info/network-interfaces/historical [get]
 func HistoricalNetworkInterfacesInfoHandler(infoService *info.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		info, err := infoService.GetNetworkInterfacesHistorical()
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		info, err := infoService.GetNetworkInterfacesHistorical(r, c.Query("interface"))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
 				Status:  "error",
@@ -46,7 +55,7 @@ func HistoricalNetworkInterfacesInfoHandler(infoService *info.Service) gin.Handl
 			return
 		}
 
-		c.JSON(http.StatusOK, internal.APIResponse[[]infoServiceInterfaces.HistoricalNetworkInterface]{
+		c.JSON(http.StatusOK, internal.APIResponse[[]db.MetricPoint]{
 			Status:  "success",
 			Message: "network_interfaces_info",
 			Error:   "",
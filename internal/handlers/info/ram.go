@@ -12,7 +12,7 @@ import (
 	"net/http"
 
 	"github.com/alchemillahq/sylve/internal"
-	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
+	"github.com/alchemillahq/sylve/internal/db"
 	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
 	"github.com/alchemillahq/sylve/internal/services/info"
 
@@ -51,24 +51,32 @@ func RAMInfo(infoService *info.Service) gin.HandlerFunc {
 	}
 }
 
-type HistoricalRamInfoResponse struct {
-	Status  string           `json:"status"`
-	Message string           `json:"message"`
-	Error   string           `json:"error"`
-	Data    []infoModels.RAM `json:"data"`
-}
-
 // @Summary Get Historical RAM information
 // @Description Retrieves historical RAM info
 // @Tags system
 // @Accept json
 // @Produce json
-// @Success 200 {object} HistoricalRamInfoResponse
+// @Param from query int false "Start of the range in unix milliseconds"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
+// @Success 200 {object} internal.APIResponse[[]db.MetricPoint]
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
 // @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
 // @Router /info/ram/historical [get]
 func HistoricalRAMInfoHandler(infoService *info.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		info, err := infoService.GetRAMUsageHistorical()
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		info, err := infoService.GetRAMUsageHistorical(r)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
 				Status:  "error",
@@ -79,7 +87,7 @@ func HistoricalRAMInfoHandler(infoService *info.Service) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, internal.APIResponse[[]infoModels.RAM]{
+		c.JSON(http.StatusOK, internal.APIResponse[[]db.MetricPoint]{
 			Status:  "success",
 			Message: "ram_info",
 			Error:   "",
@@ -125,12 +133,27 @@ func SwapInfo(infoService *info.Service) gin.HandlerFunc {
 // @Tags system
 // @Accept json
 // @Produce json
-// @Success 200 {object} internal.APIResponse[[]infoModels.Swap]
+// @Param from query int false "Start of the range in unix milliseconds"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
+// @Success 200 {object} internal.APIResponse[[]db.MetricPoint]
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
 // @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
 // @Router /info/swap/historical [get]
 func HistoricalSwapInfoHandler(infoService *info.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		info, err := infoService.GetSwapUsageHistorical()
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		info, err := infoService.GetSwapUsageHistorical(r)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
 				Status:  "error",
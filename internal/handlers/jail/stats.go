@@ -12,7 +12,7 @@ import (
 	"strconv"
 
 	"github.com/alchemillahq/sylve/internal"
-	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
+	"github.com/alchemillahq/sylve/internal/db"
 	jailServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/jail"
 	"github.com/alchemillahq/sylve/internal/services/jail"
 	"github.com/alchemillahq/sylve/pkg/utils"
@@ -118,7 +118,10 @@ func GetJailLogs(jailService *jail.Service) gin.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} internal.APIResponse[[]jailModels.JailStats] "Success"
+// @Param from query int false "Start of the range in unix milliseconds"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
+// @Success 200 {object} internal.APIResponse[[]db.MetricPoint] "Success"
 // @Failure 400 {object} internal.APIResponse[any] "Bad Request"
 // @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
 // @Router /jail/stats/:ctId/:limit [get]
@@ -136,7 +139,18 @@ func GetJailStats(jailService *jail.Service) gin.HandlerFunc {
 			return
 		}
 
-		stats, err := jailService.GetJailUsage(int(utils.StringToUint64(ctId)), int(utils.StringToUint64(limit)))
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		stats, err := jailService.GetJailUsage(int(utils.StringToUint64(ctId)), int(utils.StringToUint64(limit)), r)
 		if err != nil {
 			c.JSON(500, internal.APIResponse[any]{
 				Status:  "error",
@@ -147,7 +161,7 @@ func GetJailStats(jailService *jail.Service) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(200, internal.APIResponse[[]jailModels.JailStats]{
+		c.JSON(200, internal.APIResponse[[]db.MetricPoint]{
 			Status:  "success",
 			Message: "jail_stats_retrieved",
 			Data:    stats,
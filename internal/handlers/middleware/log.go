@@ -108,7 +108,12 @@ func RequestLoggerMiddleware(db *gorm.DB, authService *authService.Service) gin.
 			}
 		}
 
-		if strings.Contains(c.Request.URL.Path, "file-explorer/upload") {
+		// Uploads and streams are too large to log, migrations carry UEFI
+		// variables and cloud-init secrets.
+		if strings.Contains(c.Request.URL.Path, "file-explorer/upload") ||
+			strings.Contains(c.Request.URL.Path, "zfs/replication/receive") ||
+			strings.Contains(c.Request.URL.Path, "vm/migration/prepare") ||
+			strings.Contains(c.Request.URL.Path, "vm/migration/receive") {
 			c.Next()
 			return
 		}
@@ -36,6 +36,7 @@ import (
 	infoService "github.com/alchemillahq/sylve/internal/services/info"
 	"github.com/alchemillahq/sylve/internal/services/jail"
 	"github.com/alchemillahq/sylve/internal/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/services/lifecycle"
 	networkService "github.com/alchemillahq/sylve/internal/services/network"
 	"github.com/alchemillahq/sylve/internal/services/samba"
 	systemService "github.com/alchemillahq/sylve/internal/services/system"
@@ -78,6 +79,7 @@ func RegisterRoutes(r *gin.Engine,
 	sambaService *samba.Service,
 	jailService *jail.Service,
 	clusterService *cluster.Service,
+	lifecycleService *lifecycle.Service,
 	fsm *clusterModels.FSMDispatcher,
 	db *gorm.DB,
 ) {
@@ -107,6 +109,10 @@ func RegisterRoutes(r *gin.Engine,
 		info.GET("/swap", infoHandlers.SwapInfo(infoService))
 		info.GET("/swap/historical", infoHandlers.HistoricalSwapInfoHandler(infoService))
 
+		info.GET("/arc", infoHandlers.ARCInfo(infoService))
+		info.GET("/arc/historical", infoHandlers.HistoricalARCInfoHandler(infoService))
+		info.PUT("/arc/max", infoHandlers.SetARCMax(infoService))
+
 		info.GET("/network-interfaces/historical", infoHandlers.HistoricalNetworkInterfacesInfoHandler(infoService))
 
 		notes := info.Group("/notes")
@@ -130,16 +136,37 @@ func RegisterRoutes(r *gin.Engine,
 		zfs.GET("/pool/stats/:interval/:limit", zfsHandlers.PoolStats(zfsService))
 		zfs.GET("/pool/io-delay", zfsHandlers.AvgIODelay(zfsService))
 		zfs.GET("/pool/io-delay/historical", zfsHandlers.AvgIODelayHistorical(zfsService))
+		zfs.GET("/pool/iostat/historical", zfsHandlers.VdevIOStatsHistorical(zfsService))
 
 		pools := zfs.Group("/pools")
 		{
 			pools.GET("", zfsHandlers.GetPools(zfsService))
 			pools.GET("/disks-usage", zfsHandlers.GetDisksUsage(zfsService))
 			pools.POST("", zfsHandlers.CreatePool(infoService, zfsService))
+			pools.GET("/importable", zfsHandlers.GetImportablePools(zfsService))
+			pools.POST("/import", zfsHandlers.ImportPool(zfsService))
 			pools.PATCH("", zfsHandlers.EditPool(infoService, zfsService))
 			pools.POST("/:guid/scrub", zfsHandlers.ScrubPool(infoService, zfsService))
+			pools.GET("/:guid/scrub", zfsHandlers.GetScrubStatus(zfsService))
+			pools.POST("/:guid/scrub/pause", zfsHandlers.PauseScrub(zfsService))
+			pools.POST("/:guid/scrub/resume", zfsHandlers.ResumeScrub(zfsService))
+			pools.POST("/:guid/scrub/cancel", zfsHandlers.CancelScrub(zfsService))
+			pools.GET("/:guid/scrub/runs", zfsHandlers.GetScrubRuns(zfsService))
+			pools.GET("/scrub-schedules", zfsHandlers.GetScrubSchedules(zfsService))
+			pools.POST("/scrub-schedules", zfsHandlers.CreateScrubSchedule(zfsService))
+			pools.PUT("/scrub-schedules/:id", zfsHandlers.EditScrubSchedule(zfsService))
+			pools.DELETE("/scrub-schedules/:id", zfsHandlers.DeleteScrubSchedule(zfsService))
+			pools.GET("/events", zfsHandlers.GetPoolEvents(zfsService))
+			pools.GET("/alerts", zfsHandlers.GetPoolAlerts(zfsService))
+			pools.POST("/alerts/:id/ack", zfsHandlers.AcknowledgePoolAlert(zfsService))
 			pools.DELETE("/:guid", zfsHandlers.DeletePool(infoService, zfsService))
 			pools.POST("/:guid/replace-device", zfsHandlers.ReplaceDevice(infoService, zfsService))
+			pools.GET("/:guid/topology", zfsHandlers.GetPoolTopology(zfsService))
+			pools.POST("/:guid/vdevs", zfsHandlers.AddPoolVdevs(zfsService))
+			pools.POST("/:guid/vdevs/remove", zfsHandlers.RemovePoolVdev(zfsService))
+			pools.POST("/:guid/attach", zfsHandlers.AttachPoolDevice(zfsService))
+			pools.POST("/:guid/detach", zfsHandlers.DetachPoolDevice(zfsService))
+			pools.POST("/:guid/export", zfsHandlers.ExportPool(zfsService))
 		}
 
 		datasets := zfs.Group("/datasets")
@@ -147,11 +174,24 @@ func RegisterRoutes(r *gin.Engine,
 			datasets.GET("", zfsHandlers.GetDatasets(zfsService))
 			datasets.POST("/snapshot", zfsHandlers.CreateSnapshot(zfsService))
 			datasets.POST("/snapshot/rollback", zfsHandlers.RollbackSnapshot(zfsService))
+			datasets.POST("/snapshot/clone", zfsHandlers.CloneSnapshot(zfsService))
 			datasets.DELETE("/snapshot/:guid", zfsHandlers.DeleteSnapshot(zfsService))
+			datasets.GET("/snapshot/:guid/files", zfsHandlers.ListSnapshotFiles(zfsService))
+			datasets.GET("/snapshot/:guid/diff", zfsHandlers.DiffSnapshot(zfsService))
+			datasets.POST("/snapshot/:guid/restore", zfsHandlers.RestoreSnapshotFiles(zfsService))
+			datasets.GET("/snapshot/:guid/holds", zfsHandlers.GetSnapshotHolds(zfsService))
+			datasets.POST("/snapshot/:guid/holds", zfsHandlers.HoldSnapshot(zfsService))
+			datasets.POST("/snapshot/:guid/release", zfsHandlers.ReleaseSnapshot(zfsService))
+			datasets.POST("/snapshot/:guid/bookmark", zfsHandlers.CreateBookmark(zfsService))
+			datasets.GET("/bookmarks/:guid", zfsHandlers.GetBookmarks(zfsService))
+			datasets.DELETE("/bookmarks/:guid/:name", zfsHandlers.DeleteBookmark(zfsService))
 
 			datasets.GET("/snapshot/periodic", zfsHandlers.GetPeriodicSnapshots(zfsService))
 			datasets.POST("/snapshot/periodic", zfsHandlers.CreatePeriodicSnapshot(zfsService))
 			datasets.DELETE("/snapshot/periodic/:guid", zfsHandlers.DeletePeriodicSnapshot(zfsService))
+			datasets.PUT("/snapshot/periodic/:id/retention", zfsHandlers.EditPeriodicSnapshotRetention(zfsService))
+			datasets.POST("/snapshot/periodic/:id/prune", zfsHandlers.PrunePeriodicSnapshot(zfsService))
+			datasets.GET("/snapshot/periodic/:id/prune-runs", zfsHandlers.GetSnapshotPruneRuns(zfsService))
 
 			datasets.POST("/filesystem", zfsHandlers.CreateFilesystem(zfsService))
 			datasets.PATCH("/filesystem", zfsHandlers.EditFilesystem(zfsService))
@@ -160,9 +200,40 @@ func RegisterRoutes(r *gin.Engine,
 			datasets.POST("/volume", zfsHandlers.CreateVolume(zfsService))
 			datasets.PATCH("/volume", zfsHandlers.EditVolume(zfsService))
 			datasets.POST("/volume/flash", zfsHandlers.FlashVolume(zfsService))
+			datasets.GET("/volume/flash/:guid", zfsHandlers.GetVolumeFlashProgress(zfsService))
+			datasets.POST("/raw-disk/flash", zfsHandlers.FlashRawDisk(zfsService))
+			datasets.GET("/raw-disk/flash/:id", zfsHandlers.GetRawDiskFlashProgress(zfsService))
 			datasets.DELETE("/volume/:guid", zfsHandlers.DeleteVolume(zfsService))
 
 			datasets.POST("/bulk-delete", zfsHandlers.BulkDeleteDataset(zfsService))
+			datasets.POST("/promote", zfsHandlers.PromoteDataset(zfsService))
+			datasets.POST("/rename", zfsHandlers.RenameDataset(zfsService, sambaService))
+
+			datasets.POST("/encryption/:guid/load-key", zfsHandlers.LoadDatasetKey(zfsService))
+			datasets.POST("/encryption/:guid/unload-key", zfsHandlers.UnloadDatasetKey(zfsService))
+			datasets.POST("/encryption/:guid/change-key", zfsHandlers.ChangeDatasetKey(zfsService))
+
+			datasets.GET("/quotas/:guid", zfsHandlers.GetDatasetSpaceUsage(zfsService))
+			datasets.PUT("/quotas/:guid", zfsHandlers.SetDatasetQuota(zfsService))
+		}
+
+		backups := zfs.Group("/backups")
+		{
+			backups.GET("/jobs", zfsHandlers.GetBackupJobs(zfsService))
+			backups.POST("/jobs", zfsHandlers.CreateBackupJob(zfsService))
+			backups.PUT("/jobs/:id", zfsHandlers.EditBackupJob(zfsService))
+			backups.DELETE("/jobs/:id", zfsHandlers.DeleteBackupJob(zfsService))
+			backups.POST("/jobs/:id/run", zfsHandlers.RunBackupJob(zfsService))
+			backups.GET("/jobs/:id/runs", zfsHandlers.GetBackupJobRuns(zfsService))
+			backups.GET("/s3/manifest", zfsHandlers.GetS3BackupManifest(zfsService))
+			backups.POST("/s3/restore", zfsHandlers.RestoreS3Backup(zfsService))
+			backups.GET("/s3/restore", zfsHandlers.GetS3RestoreProgress(zfsService))
+		}
+
+		replication := zfs.Group("/replication")
+		{
+			replication.GET("/state", zfsHandlers.ReplicationState(zfsService))
+			replication.POST("/receive", zfsHandlers.ReceiveReplication(zfsService))
 		}
 	}
 
@@ -178,6 +249,7 @@ func RegisterRoutes(r *gin.Engine,
 		samba.POST("/shares", sambaHandlers.CreateShare(sambaService))
 		samba.PUT("/shares", sambaHandlers.UpdateShare(sambaService))
 		samba.DELETE("/shares/:id", sambaHandlers.DeleteShare(sambaService))
+		samba.GET("/shares/:id/quotas", sambaHandlers.GetShareQuotas(sambaService))
 
 		samba.GET("/audit-logs", sambaHandlers.GetAuditLogs(sambaService))
 	}
@@ -221,6 +293,12 @@ func RegisterRoutes(r *gin.Engine,
 		system.GET("/ppt-devices", systemHandlers.ListPPTDevices(systemService))
 		system.POST("/ppt-devices", systemHandlers.AddPPTDevice(systemService))
 		system.DELETE("/ppt-devices/:id", systemHandlers.RemovePPTDevice(systemService))
+		system.POST("/power", systemHandlers.HostPower(lifecycleService))
+
+		system.GET("/boot", systemHandlers.BootStatus(lifecycleService))
+		system.GET("/boot/config", systemHandlers.BootConfigs(lifecycleService))
+		system.PUT("/boot/config", systemHandlers.SetBootConfig(lifecycleService))
+		system.DELETE("/boot/config/:type/:id", systemHandlers.DeleteBootConfig(lifecycleService))
 	}
 
 	fileExplorer := system.Group("/file-explorer")
@@ -254,6 +332,33 @@ func RegisterRoutes(r *gin.Engine,
 		vm.GET("", vmHandlers.ListVMs(libvirtService))
 		vm.POST("", vmHandlers.CreateVM(libvirtService))
 		vm.DELETE("/:id", vmHandlers.RemoveVM(libvirtService))
+		vm.POST("/clone/:id", vmHandlers.CloneVM(libvirtService))
+
+		vm.GET("/templates", vmHandlers.ListTemplates(libvirtService))
+		vm.POST("/templates/:id", vmHandlers.ConvertToTemplate(libvirtService))
+		vm.DELETE("/templates/:id", vmHandlers.ConvertFromTemplate(libvirtService))
+		vm.POST("/templates/:id/instantiate", vmHandlers.CreateVMFromTemplate(libvirtService))
+
+		vm.GET("/cloud-init/:id", vmHandlers.GetCloudInit(libvirtService))
+		vm.PUT("/cloud-init/:id", vmHandlers.SetCloudInit(libvirtService))
+		vm.DELETE("/cloud-init/:id", vmHandlers.DeleteCloudInit(libvirtService))
+
+		vm.GET("/snapshots/:id", vmHandlers.ListVMSnapshots(libvirtService))
+		vm.POST("/snapshots/:id", vmHandlers.CreateVMSnapshot(libvirtService))
+		vm.POST("/snapshots/:id/rollback/:snapshotId", vmHandlers.RollbackVMSnapshot(libvirtService))
+		vm.DELETE("/snapshots/:id/:snapshotId", vmHandlers.DeleteVMSnapshot(libvirtService))
+
+		vm.POST("/migrate/:id", vmHandlers.MigrateVM(libvirtService))
+		vm.GET("/migrate/:id", vmHandlers.GetMigrationProgress(libvirtService))
+		vm.POST("/migration/prepare", vmHandlers.PrepareMigration(libvirtService))
+		vm.POST("/migration/receive", vmHandlers.ReceiveMigration(libvirtService))
+		vm.POST("/migration/abort", vmHandlers.AbortMigration(libvirtService))
+
+		vm.POST("/export/:id", vmHandlers.ExportVM(libvirtService))
+		vm.POST("/import", vmHandlers.ImportVM(libvirtService))
+		vm.GET("/import/:id", vmHandlers.GetImportProgress(libvirtService))
+
+		vm.GET("/console", vmHandlers.HandleVMConsoleWebsocket(libvirtService))
 		vm.GET("/domain/:id", vmHandlers.GetLvDomain(libvirtService))
 		vm.GET("/stats/:vmId/:limit", vmHandlers.GetVMStats(libvirtService))
 		vm.PUT("/description", vmHandlers.UpdateVMDescription(libvirtService))
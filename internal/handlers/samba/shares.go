@@ -14,6 +14,7 @@ import (
 
 	"github.com/alchemillahq/sylve/internal"
 	sambaModels "github.com/alchemillahq/sylve/internal/db/models/samba"
+	sambaServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/samba"
 	"github.com/alchemillahq/sylve/internal/services/samba"
 
 	"github.com/gin-gonic/gin"
@@ -242,3 +243,46 @@ func DeleteShare(smbService *samba.Service) gin.HandlerFunc {
 		})
 	}
 }
+
+// @Summary Get Samba Share Quotas
+// @Description List the users with access to a share with their usage and remaining quota on its dataset
+// @Tags Samba
+// @Accept json
+// @Produce json
+// @Param id path uint true "Share ID"
+// @Success 200 {object} internal.APIResponse[[]sambaServiceInterfaces.ShareUserQuota] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /samba/shares/{id}/quotas [get]
+func GetShareQuotas(smbService *samba.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idInt, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_share_id",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		quotas, err := smbService.GetShareQuotas(uint(idInt))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_get_share_quotas",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]sambaServiceInterfaces.ShareUserQuota]{
+			Status:  "success",
+			Message: "share_quotas_retrieved",
+			Error:   "",
+			Data:    quotas,
+		})
+	}
+}
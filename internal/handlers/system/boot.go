@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package systemHandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal"
+	"github.com/alchemillahq/sylve/internal/db/models"
+	lifecycleServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/lifecycle"
+	"github.com/alchemillahq/sylve/internal/services/lifecycle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get boot progress
+// @Description Get the progress of starting guests at boot, including what each guest is waiting on
+// @Tags System
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} internal.APIResponse[lifecycleServiceInterfaces.BootStatus] "Success"
+// @Router /system/boot [get]
+func BootStatus(lifecycleService *lifecycle.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, internal.APIResponse[lifecycleServiceInterfaces.BootStatus]{
+			Status:  "success",
+			Message: "boot_status",
+			Error:   "",
+			Data:    lifecycleService.GetBootStatus(),
+		})
+	}
+}
+
+// @Summary List guest boot configs
+// @Description List the startup delays, reachability checks and dependencies of guests
+// @Tags System
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} internal.APIResponse[[]models.GuestBootConfig] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /system/boot/config [get]
+func BootConfigs(lifecycleService *lifecycle.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		configs, err := lifecycleService.GetBootConfigs()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_list_boot_configs",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]models.GuestBootConfig]{
+			Status:  "success",
+			Message: "boot_configs",
+			Error:   "",
+			Data:    configs,
+		})
+	}
+}
+
+// @Summary Set guest boot config
+// @Description Set the startup delay, reachability check and dependencies of a VM or jail
+// @Tags System
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body lifecycleServiceInterfaces.GuestBootConfigRequest true "Guest Boot Config Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /system/boot/config [put]
+func SetBootConfig(lifecycleService *lifecycle.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req lifecycleServiceInterfaces.GuestBootConfigRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Error:   "Invalid request data: " + err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := lifecycleService.SetBootConfig(req); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_set_boot_config",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "boot_config_set",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Delete guest boot config
+// @Description Remove the boot config of a VM or jail, it then starts by start order alone
+// @Tags System
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "Guest type (vm or jail)"
+// @Param id path int true "VM ID or CT ID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /system/boot/config/{type}/{id} [delete]
+func DeleteBootConfig(lifecycleService *lifecycle.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guestType := c.Param("type")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || (guestType != "vm" && guestType != "jail") {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "bad_request",
+				Error:   "invalid guest type or id",
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := lifecycleService.DeleteBootConfig(guestType, id); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_delete_boot_config",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "boot_config_deleted",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
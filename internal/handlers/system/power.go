@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package systemHandlers
+
+import (
+	"net/http"
+
+	"github.com/alchemillahq/sylve/internal"
+	lifecycleServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/lifecycle"
+	"github.com/alchemillahq/sylve/internal/services/lifecycle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Reboot or power off the host
+// @Description Stop all VMs and jails in reverse start order, then reboot or power off the host.
+// @Description The request returns once the shutdown has started.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body lifecycleServiceInterfaces.HostPowerRequest true "Host Power Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /system/power [post]
+func HostPower(lifecycleService *lifecycle.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req lifecycleServiceInterfaces.HostPowerRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Error:   "Invalid request data: " + err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := lifecycleService.HostPower(req); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_start_host_power_action",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "host_power_action_started",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
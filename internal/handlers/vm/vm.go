@@ -236,6 +236,63 @@ func RemoveVM(libvirtService *libvirt.Service) gin.HandlerFunc {
 	}
 }
 
+// @Summary Clone a Virtual Machine
+// @Description Clone a shut off virtual machine. A full clone copies every disk with zfs send and receive,
+// @Description a linked clone is a ZFS clone of a snapshot of every disk.
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Param request body libvirtServiceInterfaces.CloneVMRequest true "Clone Virtual Machine Request"
+// @Success 200 {object} internal.APIResponse[vmModels.VM] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/clone/{id} [post]
+func CloneVM(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		var req libvirtServiceInterfaces.CloneVMRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+
+		vm, err := libvirtService.CloneVM(uint(vmInt), req)
+		if err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_clone_vm",
+				Data:    nil,
+				Error:   "failed_to_clone: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[vmModels.VM]{
+			Status:  "success",
+			Message: "vm_cloned",
+			Data:    *vm,
+			Error:   "",
+		})
+	}
+}
+
 // @Summary Perform an action on a Virtual Machine
 // @Description Perform a specified action (start, stop, reboot) on a virtual machine by its ID
 // @Tags VM
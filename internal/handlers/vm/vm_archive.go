@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirtHandlers
+
+import (
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/services/libvirt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Export a VM
+// @Description Write a shut off virtual machine with its UEFI variables and disks into an archive on this host,
+// @Description disks are stored as sparse raw images or zstd compressed zfs streams
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Param request body libvirtServiceInterfaces.ExportVMRequest true "Export VM Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/export/{id} [post]
+func ExportVM(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		var req libvirtServiceInterfaces.ExportVMRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+
+		if err := libvirtService.ExportVM(uint(vmInt), req); err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_export_vm",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "vm_exported",
+			Data:    nil,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Import a VM
+// @Description Create a virtual machine from an archive written by a VM export, from a path on this host or a download.
+// @Description The import runs in the background and returns its ID, follow it with GET /vm/import/{id}.
+// @Description The VM ID, VNC port and switches are remapped when they do not fit this host, networks get new MAC
+// @Description addresses unless keepMacs is set.
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body libvirtServiceInterfaces.ImportVMRequest true "Import VM Request"
+// @Success 200 {object} internal.APIResponse[string] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/import [post]
+func ImportVM(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req libvirtServiceInterfaces.ImportVMRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+
+		if (req.Path == "") == (req.Download == "") {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Exactly one of path or download is required",
+			})
+			return
+		}
+
+		id, err := libvirtService.ImportVM(req)
+		if err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_import_vm",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[string]{
+			Status:  "success",
+			Message: "vm_import_started",
+			Data:    id,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Get VM import progress
+// @Description Get the progress of a VM import, the created VM is set once it completed
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Import ID"
+// @Success 200 {object} internal.APIResponse[libvirtServiceInterfaces.VMImportProgress] "Success"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Router /vm/import/{id} [get]
+func GetImportProgress(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		progress, err := libvirtService.GetImportProgress(c.Param("id"))
+		if err != nil {
+			c.JSON(404, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "import_not_found",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[*libvirtServiceInterfaces.VMImportProgress]{
+			Status:  "success",
+			Message: "vm_import_progress",
+			Data:    progress,
+			Error:   "",
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirtHandlers
+
+import (
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/services/libvirt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get cloud-init settings
+// @Description Retrieve the cloud-init settings of a virtual machine, null if none are configured
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Success 200 {object} internal.APIResponse[vmModels.CloudInit] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/cloud-init/{id} [get]
+func GetCloudInit(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		cloudInit, err := libvirtService.GetCloudInit(uint(vmInt))
+		if err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_get_cloud_init",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[*vmModels.CloudInit]{
+			Status:  "success",
+			Message: "cloud_init_retrieved",
+			Data:    cloudInit,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Set cloud-init settings
+// @Description Save the cloud-init settings of a shut off virtual machine and regenerate its NoCloud seed
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Param request body libvirtServiceInterfaces.CloudInitRequest true "Cloud-init Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/cloud-init/{id} [put]
+func SetCloudInit(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		var req libvirtServiceInterfaces.CloudInitRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+
+		if err := libvirtService.SetCloudInit(uint(vmInt), req); err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_set_cloud_init",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "cloud_init_set",
+			Data:    nil,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Remove cloud-init settings
+// @Description Remove the cloud-init settings of a shut off virtual machine and detach its NoCloud seed
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/cloud-init/{id} [delete]
+func DeleteCloudInit(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		if err := libvirtService.DeleteCloudInit(uint(vmInt)); err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_delete_cloud_init",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "cloud_init_deleted",
+			Data:    nil,
+			Error:   "",
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirtHandlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/internal/services/libvirt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var WSUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// HandleVMConsoleWebsocket bridges the serial console of a VM to a
+// terminal. Messages follow the jail console: binary messages starting with
+// 0 carry input, resize messages are accepted and ignored since the guest
+// decides the size of a serial console.
+func HandleVMConsoleWebsocket(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmId, err := strconv.Atoi(c.Query("vmid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid vmid"})
+			return
+		}
+
+		scrollback, output, detach, err := libvirtService.AttachConsole(vmId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer detach()
+
+		conn, err := WSUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			logger.L.Error().Err(err).Msg("WebSocket upgrade failed")
+			return
+		}
+		defer conn.Close()
+
+		var wsWriteMu sync.Mutex
+		safeWrite := func(mt int, data []byte) error {
+			wsWriteMu.Lock()
+			defer wsWriteMu.Unlock()
+			return conn.WriteMessage(mt, data)
+		}
+
+		if len(scrollback) > 0 {
+			if err := safeWrite(websocket.BinaryMessage, scrollback); err != nil {
+				return
+			}
+		}
+
+		go func() {
+			for data := range output {
+				if err := safeWrite(websocket.BinaryMessage, data); err != nil {
+					conn.Close()
+					return
+				}
+			}
+
+			safeWrite(websocket.TextMessage, []byte("Console session closed."))
+			conn.Close()
+		}()
+
+		for {
+			messageType, reader, err := conn.NextReader()
+			if err != nil {
+				return
+			}
+
+			if messageType == websocket.TextMessage {
+				safeWrite(websocket.TextMessage, []byte("Unexpected text message"))
+				continue
+			}
+
+			header := make([]byte, 1)
+			if _, err := reader.Read(header); err != nil {
+				return
+			}
+
+			if header[0] != 0 {
+				continue
+			}
+
+			input, err := io.ReadAll(reader)
+			if err != nil {
+				return
+			}
+
+			if err := libvirtService.WriteConsole(vmId, input); err != nil {
+				safeWrite(websocket.TextMessage, []byte(err.Error()))
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirtHandlers
+
+import (
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/services/libvirt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List VM snapshots
+// @Description Retrieve the snapshots of a virtual machine as a tree
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Success 200 {object} internal.APIResponse[[]libvirtServiceInterfaces.VMSnapshotNode] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/snapshots/{id} [get]
+func ListVMSnapshots(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		snapshots, err := libvirtService.ListVMSnapshots(uint(vmInt))
+		if err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_list_snapshots",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[[]libvirtServiceInterfaces.VMSnapshotNode]{
+			Status:  "success",
+			Message: "snapshots_listed",
+			Data:    snapshots,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Create a VM snapshot
+// @Description Snapshot all disks of a virtual machine at once together with its configuration
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Param request body libvirtServiceInterfaces.CreateVMSnapshotRequest true "Create VM Snapshot Request"
+// @Success 200 {object} internal.APIResponse[vmModels.Snapshot] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/snapshots/{id} [post]
+func CreateVMSnapshot(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		var req libvirtServiceInterfaces.CreateVMSnapshotRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+
+		snapshot, err := libvirtService.CreateVMSnapshot(uint(vmInt), req)
+		if err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_create_snapshot",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[*vmModels.Snapshot]{
+			Status:  "success",
+			Message: "snapshot_created",
+			Data:    snapshot,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Roll back a VM snapshot
+// @Description Return the disks, hardware configuration and domain of a virtual machine to a snapshot
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Param snapshotId path string true "Snapshot ID"
+// @Param request body libvirtServiceInterfaces.RollbackVMSnapshotRequest true "Rollback VM Snapshot Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/snapshots/{id}/rollback/{snapshotId} [post]
+func RollbackVMSnapshot(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		snapshotInt, err := strconv.Atoi(c.Param("snapshotId"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_snapshot_id_format",
+				Data:    nil,
+				Error:   "Snapshot ID must be a valid integer",
+			})
+			return
+		}
+
+		var req libvirtServiceInterfaces.RollbackVMSnapshotRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+
+		if err := libvirtService.RollbackVMSnapshot(uint(vmInt), uint(snapshotInt), req); err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_rollback_snapshot",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "snapshot_rolled_back",
+			Data:    nil,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Delete a VM snapshot
+// @Description Destroy the disk snapshots of a VM snapshot and remove it from the snapshot tree
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Param snapshotId path string true "Snapshot ID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/snapshots/{id}/{snapshotId} [delete]
+func DeleteVMSnapshot(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		snapshotInt, err := strconv.Atoi(c.Param("snapshotId"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_snapshot_id_format",
+				Data:    nil,
+				Error:   "Snapshot ID must be a valid integer",
+			})
+			return
+		}
+
+		if err := libvirtService.DeleteVMSnapshot(uint(vmInt), uint(snapshotInt)); err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_delete_snapshot",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "snapshot_deleted",
+			Data:    nil,
+			Error:   "",
+		})
+	}
+}
@@ -10,7 +10,7 @@ package libvirtHandlers
 
 import (
 	"github.com/alchemillahq/sylve/internal"
-	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	"github.com/alchemillahq/sylve/internal/db"
 	"github.com/alchemillahq/sylve/internal/services/libvirt"
 	"github.com/alchemillahq/sylve/pkg/utils"
 
@@ -23,7 +23,10 @@ import (
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} internal.APIResponse[[]vmModels.VMStats] "Success"
+// @Param from query int false "Start of the range in unix milliseconds"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
+// @Success 200 {object} internal.APIResponse[[]db.MetricPoint] "Success"
 // @Failure 400 {object} internal.APIResponse[any] "Bad Request"
 // @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
 // @Router /vm/stats/:vmId/:limit [get]
@@ -41,7 +44,18 @@ func GetVMStats(libvirtService *libvirt.Service) gin.HandlerFunc {
 			return
 		}
 
-		stats, err := libvirtService.GetVMUsage(int(utils.StringToUint64(vmId)), int(utils.StringToUint64(limit)))
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		stats, err := libvirtService.GetVMUsage(int(utils.StringToUint64(vmId)), int(utils.StringToUint64(limit)), r)
 		if err != nil {
 			c.JSON(500, internal.APIResponse[any]{
 				Status:  "error",
@@ -52,7 +66,7 @@ func GetVMStats(libvirtService *libvirt.Service) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(200, internal.APIResponse[[]vmModels.VMStats]{
+		c.JSON(200, internal.APIResponse[[]db.MetricPoint]{
 			Status:  "success",
 			Message: "vm_stats_retrieved",
 			Data:    stats,
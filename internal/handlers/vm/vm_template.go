@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirtHandlers
+
+import (
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/services/libvirt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List VM templates
+// @Description Retrieve a list of all virtual machine templates
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} internal.APIResponse[[]vmModels.VM] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/templates [get]
+func ListTemplates(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		templates, err := libvirtService.ListTemplates()
+		if err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_list_templates",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[[]vmModels.VM]{
+			Status:  "success",
+			Message: "templates_listed",
+			Data:    templates,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Convert a VM to a template
+// @Description Mark a shut off virtual machine as a template, which keeps it from being changed or started
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Param request body libvirtServiceInterfaces.ConvertToTemplateRequest true "Convert To Template Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/templates/{id} [post]
+func ConvertToTemplate(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		var req libvirtServiceInterfaces.ConvertToTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+
+		if err := libvirtService.ConvertToTemplate(uint(vmInt), req); err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_convert_to_template",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "converted_to_template",
+			Data:    nil,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Convert a template back to a VM
+// @Description Remove the template mark from a virtual machine so it can be changed and started again
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Virtual Machine ID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/templates/{id} [delete]
+func ConvertFromTemplate(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vmInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_vm_id_format",
+				Data:    nil,
+				Error:   "Virtual Machine ID must be a valid integer",
+			})
+			return
+		}
+
+		if err := libvirtService.ConvertFromTemplate(uint(vmInt)); err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_convert_from_template",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "converted_from_template",
+			Data:    nil,
+			Error:   "",
+		})
+	}
+}
+
+// @Summary Create a VM from a template
+// @Description Create a new virtual machine from a template through a linked clone by default, or a full
+// @Description clone. Name, VM ID, CPU, RAM, disk sizes and network switches can be overridden.
+// @Tags VM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID"
+// @Param request body libvirtServiceInterfaces.CreateFromTemplateRequest true "Create From Template Request"
+// @Success 200 {object} internal.APIResponse[vmModels.VM] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /vm/templates/{id}/instantiate [post]
+func CreateVMFromTemplate(libvirtService *libvirt.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		templateInt, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_template_id_format",
+				Data:    nil,
+				Error:   "Template ID must be a valid integer",
+			})
+			return
+		}
+
+		var req libvirtServiceInterfaces.CreateFromTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request_data",
+				Data:    nil,
+				Error:   "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+
+		vm, err := libvirtService.CreateVMFromTemplate(uint(templateInt), req)
+		if err != nil {
+			c.JSON(500, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_create_from_template",
+				Data:    nil,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, internal.APIResponse[vmModels.VM]{
+			Status:  "success",
+			Message: "vm_created",
+			Data:    *vm,
+			Error:   "",
+		})
+	}
+}
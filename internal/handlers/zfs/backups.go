@@ -0,0 +1,486 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsHandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal"
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/services/zfs"
+
+	"github.com/gin-gonic/gin"
+)
+
+func parseBackupJobID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+			Status:  "error",
+			Message: "invalid_job_id",
+			Error:   err.Error(),
+			Data:    nil,
+		})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+// @Summary Get all ZFS backup jobs
+// @Description Get all ZFS backup (replication and S3) jobs
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} internal.APIResponse[[]zfsModels.BackupJob] "OK"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/backups/jobs [get]
+func GetBackupJobs(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobs, err := zfsService.GetBackupJobs()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsModels.BackupJob]{
+			Status:  "success",
+			Message: "backup_jobs",
+			Error:   "",
+			Data:    jobs,
+		})
+	}
+}
+
+// @Summary Get runs of a ZFS backup job
+// @Description Get the run history of a ZFS backup job, newest first
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Backup Job ID"
+// @Param limit query int false "Maximum number of runs"
+// @Success 200 {object} internal.APIResponse[[]zfsModels.BackupJobRun] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/backups/jobs/{id}/runs [get]
+func GetBackupJobRuns(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseBackupJobID(c)
+		if !ok {
+			return
+		}
+
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+		runs, err := zfsService.GetBackupJobRuns(id, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsModels.BackupJobRun]{
+			Status:  "success",
+			Message: "backup_job_runs",
+			Error:   "",
+			Data:    runs,
+		})
+	}
+}
+
+// @Summary Create a ZFS backup job
+// @Description Create a scheduled replication job to another Sylve node or an S3 bucket
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body zfsServiceInterfaces.BackupJobRequest true "Backup Job Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/backups/jobs [post]
+func CreateBackupJob(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.BackupJobRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.CreateBackupJob(request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "created_backup_job",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Edit a ZFS backup job
+// @Description Edit a scheduled replication job
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Backup Job ID"
+// @Param request body zfsServiceInterfaces.BackupJobRequest true "Backup Job Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/backups/jobs/{id} [put]
+func EditBackupJob(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseBackupJobID(c)
+		if !ok {
+			return
+		}
+
+		var request zfsServiceInterfaces.BackupJobRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.EditBackupJob(id, request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "edited_backup_job",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Delete a ZFS backup job
+// @Description Delete a replication job and its run history
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Backup Job ID"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/backups/jobs/{id} [delete]
+func DeleteBackupJob(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseBackupJobID(c)
+		if !ok {
+			return
+		}
+
+		if err := zfsService.DeleteBackupJob(id); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "deleted_backup_job",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Run a ZFS backup job
+// @Description Start a replication job immediately in the background
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Backup Job ID"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/backups/jobs/{id}/run [post]
+func RunBackupJob(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseBackupJobID(c)
+		if !ok {
+			return
+		}
+
+		if err := zfsService.RunBackupJob(id); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "started_backup_job",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Get replication target state
+// @Description Get the snapshots and resume token of a dataset receiving replication streams
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param dataset query string true "Dataset name"
+// @Success 200 {object} internal.APIResponse[zfsServiceInterfaces.ReplicationState] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/replication/state [get]
+func ReplicationState(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dataset := c.Query("dataset")
+		if dataset == "" {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   "dataset_required",
+				Data:    nil,
+			})
+			return
+		}
+
+		state, err := zfsService.GetReplicationState(dataset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[zfsServiceInterfaces.ReplicationState]{
+			Status:  "success",
+			Message: "replication_state",
+			Error:   "",
+			Data:    *state,
+		})
+	}
+}
+
+// @Summary Receive a replication stream
+// @Description Receive a raw zfs send stream into a dataset; interrupted receives can be resumed
+// @Tags ZFS
+// @Accept octet-stream
+// @Produce json
+// @Security BearerAuth
+// @Param dataset query string true "Dataset name"
+// @Param force query bool false "Roll back the target before receiving"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/replication/receive [post]
+func ReceiveReplication(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dataset := c.Query("dataset")
+		if dataset == "" {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   "dataset_required",
+				Data:    nil,
+			})
+			return
+		}
+
+		force := c.Query("force") == "true"
+
+		if err := zfsService.ReceiveReplicationStream(dataset, c.Request.Body, force); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "received_stream",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Get an S3 backup manifest
+// @Description Get the snapshot chain recorded for an S3 backup prefix
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param s3ConfigId query int true "S3 Config ID"
+// @Param prefix query string true "S3 key prefix of the backup"
+// @Success 200 {object} internal.APIResponse[zfsServiceInterfaces.S3BackupManifest] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/backups/s3/manifest [get]
+func GetS3BackupManifest(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		configID, err := strconv.ParseUint(c.Query("s3ConfigId"), 10, 32)
+		if err != nil || c.Query("prefix") == "" {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   "s3_config_id_and_prefix_required",
+				Data:    nil,
+			})
+			return
+		}
+
+		manifest, err := zfsService.GetS3BackupManifest(uint(configID), c.Query("prefix"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[zfsServiceInterfaces.S3BackupManifest]{
+			Status:  "success",
+			Message: "s3_backup_manifest",
+			Error:   "",
+			Data:    *manifest,
+		})
+	}
+}
+
+// @Summary Restore an S3 backup
+// @Description Rebuild a dataset by receiving the full and incremental streams of an S3 backup chain.
+// @Description The streams are received in the background, the progress is available from
+// @Description /zfs/backups/s3/restore?target={targetDataset}.
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body zfsServiceInterfaces.S3RestoreRequest true "S3 Restore Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/backups/s3/restore [post]
+func RestoreS3Backup(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.S3RestoreRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.RestoreS3Backup(request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "s3_restore_started",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Get S3 restore progress
+// @Description Get the progress of the last S3 backup restore into a dataset
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param target query string true "Target dataset"
+// @Success 200 {object} internal.APIResponse[zfsServiceInterfaces.S3RestoreProgress] "OK"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Router /zfs/backups/s3/restore [get]
+func GetS3RestoreProgress(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		progress, err := zfsService.GetS3RestoreProgress(c.Query("target"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "restore_not_found",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[*zfsServiceInterfaces.S3RestoreProgress]{
+			Status:  "success",
+			Message: "s3_restore_progress",
+			Error:   "",
+			Data:    progress,
+		})
+	}
+}
@@ -9,11 +9,14 @@
 package zfsHandlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/alchemillahq/sylve/internal"
 	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
 	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/services/samba"
 	"github.com/alchemillahq/sylve/internal/services/zfs"
 
 	"github.com/gin-gonic/gin"
@@ -31,6 +34,8 @@ type CreatePeriodicSnapshotJobRequest struct {
 	Recursive bool   `json:"recursive"`
 	Interval  *int   `json:"interval" binding:"required"`
 	CronExpr  string `json:"cronExpr"`
+
+	zfsServiceInterfaces.SnapshotRetention
 }
 
 type CreateFilesystemRequest struct {
@@ -64,9 +69,33 @@ type BulkDeleteRequest struct {
 	GUIDs []string `json:"guids" binding:"required"`
 }
 
+type CloneSnapshotRequest struct {
+	GUID       string            `json:"guid" binding:"required"`
+	Name       string            `json:"name" binding:"required"`
+	Properties map[string]string `json:"properties"`
+}
+
+type PromoteDatasetRequest struct {
+	GUID string `json:"guid" binding:"required"`
+}
+
+type RenameDatasetRequest struct {
+	GUID         string `json:"guid" binding:"required"`
+	NewName      string `json:"newName" binding:"required"`
+	CreateParent bool   `json:"createParent"`
+	Recursive    bool   `json:"recursive"`
+}
+
 type FlashVolumeRequest struct {
 	GUID string `json:"guid" binding:"required"`
 	UUID string `json:"uuid" binding:"required"`
+	Grow bool   `json:"grow"`
+}
+
+type FlashRawDiskRequest struct {
+	StorageID int    `json:"storageId" binding:"required"`
+	UUID      string `json:"uuid" binding:"required"`
+	Grow      bool   `json:"grow"`
 }
 
 // @Summary Get all ZFS datasets
@@ -308,7 +337,7 @@ func CreatePeriodicSnapshot(zfsService *zfs.Service) gin.HandlerFunc {
 			cronExpr = request.CronExpr
 		}
 
-		err := zfsService.AddPeriodicSnapshot(request.GUID, request.Prefix, request.Recursive, interval, cronExpr)
+		err := zfsService.AddPeriodicSnapshot(request.GUID, request.Prefix, request.Recursive, interval, cronExpr, request.SnapshotRetention)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
@@ -656,9 +685,157 @@ func BulkDeleteDataset(zfsService *zfs.Service) gin.HandlerFunc {
 	}
 }
 
+// @Summary Clone a ZFS snapshot
+// @Description Clone a snapshot into a new filesystem or volume, optionally overriding properties
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CloneSnapshotRequest true "Clone Snapshot Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/clone [post]
+func CloneSnapshot(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request CloneSnapshotRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.CloneSnapshot(request.GUID, request.Name, request.Properties); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "cloned_snapshot",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Promote a ZFS clone
+// @Description Promote a clone so it no longer depends on its origin snapshot
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body PromoteDatasetRequest true "Promote Dataset Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/promote [post]
+func PromoteDataset(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request PromoteDatasetRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.PromoteDataset(request.GUID); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "promoted_dataset",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Rename a ZFS dataset
+// @Description Rename or move a dataset or snapshot, updating VM storages, jails and Samba shares that use it
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body RenameDatasetRequest true "Rename Dataset Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/rename [post]
+func RenameDataset(zfsService *zfs.Service, sambaService *samba.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request RenameDatasetRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		renameErr := zfsService.RenameDataset(request.GUID, request.NewName, request.CreateParent, request.Recursive)
+
+		// The rename may have gone through even when updating its users
+		// failed, so the shares are rewritten either way.
+		if shares, err := sambaService.GetShares(); err == nil && len(shares) > 0 {
+			if err := sambaService.WriteConfig(true); err != nil {
+				c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+					Status:  "error",
+					Message: "failed_to_update_samba_config",
+					Error:   errors.Join(renameErr, err).Error(),
+					Data:    nil,
+				})
+				return
+			}
+		}
+
+		if renameErr != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   renameErr.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "renamed_dataset",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
 // flash volume handler
 // @Summary Flash a ZFS volume
-// @Description Flash a ZFS volume with a UUID pointing to a disk iso/img
+// @Description Flash a ZFS volume with a UUID pointing to a disk iso/img. Raw, qcow2, VMDK and VHDX
+// @Description images are converted on the fly and may be gzip, bzip2 or xz compressed. With grow set,
+// @Description a volume smaller than the image is resized to fit it. The image is written in the
+// @Description background, its progress is available from /zfs/datasets/volume/flash/{guid}.
 // @Tags ZFS
 // @Accept json
 // @Produce json
@@ -682,7 +859,7 @@ func FlashVolume(zfsService *zfs.Service) gin.HandlerFunc {
 			return
 		}
 
-		err := zfsService.FlashVolume(request.GUID, request.UUID)
+		err := zfsService.FlashVolume(request.GUID, request.UUID, request.Grow)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
@@ -696,9 +873,131 @@ func FlashVolume(zfsService *zfs.Service) gin.HandlerFunc {
 
 		c.JSON(http.StatusOK, internal.APIResponse[any]{
 			Status:  "success",
-			Message: "flashed_volume",
+			Message: "volume_flash_started",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Get volume flash progress
+// @Description Get the progress of the last image flashed onto a ZFS volume
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Volume GUID"
+// @Success 200 {object} internal.APIResponse[zfsServiceInterfaces.FlashProgress] "OK"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Router /zfs/datasets/volume/flash/{guid} [get]
+func GetVolumeFlashProgress(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		progress, err := zfsService.GetVolumeFlashProgress(c.Param("guid"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "flash_not_found",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[*zfsServiceInterfaces.FlashProgress]{
+			Status:  "success",
+			Message: "flash_progress",
+			Error:   "",
+			Data:    progress,
+		})
+	}
+}
+
+// @Summary Flash a raw VM disk
+// @Description Replace the contents of a raw VM disk image with a downloaded disk image, converting
+// @Description qcow2, VMDK and VHDX images on the fly. The VM has to be shut off. The image is written
+// @Description in the background, its progress is available from /zfs/datasets/raw-disk/flash/{id}.
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body FlashRawDiskRequest true "Flash Raw Disk Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/raw-disk/flash [post]
+func FlashRawDisk(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request FlashRawDiskRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.FlashRawDisk(request.StorageID, request.UUID, request.Grow); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "raw_disk_flash_started",
 			Error:   "",
 			Data:    nil,
 		})
 	}
 }
+
+// @Summary Get raw disk flash progress
+// @Description Get the progress of the last image flashed onto a raw VM disk
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Storage ID"
+// @Success 200 {object} internal.APIResponse[zfsServiceInterfaces.FlashProgress] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Router /zfs/datasets/raw-disk/flash/{id} [get]
+func GetRawDiskFlashProgress(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_storage_id",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		progress, err := zfsService.GetRawDiskFlashProgress(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "flash_not_found",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[*zfsServiceInterfaces.FlashProgress]{
+			Status:  "success",
+			Message: "flash_progress",
+			Error:   "",
+			Data:    progress,
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsHandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal"
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	"github.com/alchemillahq/sylve/internal/services/zfs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get Pool Events
+// @Description Get stored zpool events, newest first
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pool query string false "Only events of this pool"
+// @Param limit query int false "Maximum number of events"
+// @Success 200 {object} internal.APIResponse[[]zfsModels.PoolEvent] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/events [get]
+func GetPoolEvents(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.Query("limit"))
+
+		events, err := zfsService.GetPoolEvents(c.Query("pool"), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsModels.PoolEvent]{
+			Status:  "success",
+			Message: "pool_events",
+			Error:   "",
+			Data:    events,
+		})
+	}
+}
+
+// @Summary Get Pool Alerts
+// @Description Get health alerts raised from pool events, newest first
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param unacknowledged query bool false "Only alerts that were not acknowledged"
+// @Param limit query int false "Maximum number of alerts"
+// @Success 200 {object} internal.APIResponse[[]zfsModels.PoolAlert] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/alerts [get]
+func GetPoolAlerts(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.Query("limit"))
+		unacknowledged := c.Query("unacknowledged") == "true"
+
+		alerts, err := zfsService.GetPoolAlerts(unacknowledged, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsModels.PoolAlert]{
+			Status:  "success",
+			Message: "pool_alerts",
+			Error:   "",
+			Data:    alerts,
+		})
+	}
+}
+
+// @Summary Acknowledge Pool Alert
+// @Description Mark a pool alert as acknowledged
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Alert ID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/alerts/{id}/ack [post]
+func AcknowledgePoolAlert(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_alert_id",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.AcknowledgePoolAlert(uint(id)); err != nil {
+			status := http.StatusInternalServerError
+			if err.Error() == "alert_not_found" {
+				status = http.StatusNotFound
+			}
+
+			c.JSON(status, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "failed_to_acknowledge_alert",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "alert_acknowledged",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
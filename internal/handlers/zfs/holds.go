@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsHandlers
+
+import (
+	"net/http"
+
+	"github.com/alchemillahq/sylve/internal"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/services/zfs"
+	zfsUtils "github.com/alchemillahq/sylve/pkg/zfs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List snapshot holds
+// @Description List the user holds on a snapshot
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Snapshot GUID"
+// @Success 200 {object} internal.APIResponse[[]zfsUtils.Hold] "OK"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/{guid}/holds [get]
+func GetSnapshotHolds(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := zfsService.GetSnapshotHolds(c.Param("guid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsUtils.Hold]{
+			Status:  "success",
+			Message: "snapshot_holds",
+			Error:   "",
+			Data:    result,
+		})
+	}
+}
+
+// @Summary Hold a snapshot
+// @Description Place a user hold on a snapshot so it cannot be destroyed
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Snapshot GUID"
+// @Param request body zfsServiceInterfaces.SnapshotHoldRequest true "Hold Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/{guid}/holds [post]
+func HoldSnapshot(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.SnapshotHoldRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.HoldSnapshot(c.Param("guid"), request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "held_snapshot",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Release a snapshot hold
+// @Description Release a user hold from a snapshot
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Snapshot GUID"
+// @Param request body zfsServiceInterfaces.SnapshotHoldRequest true "Release Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/{guid}/release [post]
+func ReleaseSnapshot(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.SnapshotHoldRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.ReleaseSnapshot(c.Param("guid"), request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "released_snapshot",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary List bookmarks
+// @Description List the bookmarks of a filesystem or volume
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Dataset GUID"
+// @Success 200 {object} internal.APIResponse[[]*zfsUtils.Dataset] "OK"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/bookmarks/{guid} [get]
+func GetBookmarks(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := zfsService.GetBookmarks(c.Param("guid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]*zfsUtils.Dataset]{
+			Status:  "success",
+			Message: "bookmarks",
+			Error:   "",
+			Data:    result,
+		})
+	}
+}
+
+// @Summary Bookmark a snapshot
+// @Description Create a bookmark of a snapshot that can serve as an incremental send base
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Snapshot GUID"
+// @Param request body zfsServiceInterfaces.CreateBookmarkRequest true "Create Bookmark Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/{guid}/bookmark [post]
+func CreateBookmark(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.CreateBookmarkRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.CreateBookmark(c.Param("guid"), request.Name); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "created_bookmark",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Delete a bookmark
+// @Description Delete a bookmark of a filesystem or volume
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Dataset GUID"
+// @Param name path string true "Bookmark name"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/bookmarks/{guid}/{name} [delete]
+func DeleteBookmark(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := zfsService.DeleteBookmark(c.Param("guid"), c.Param("name")); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "deleted_bookmark",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
@@ -12,11 +12,11 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alchemillahq/sylve/internal"
 
 	"github.com/alchemillahq/sylve/internal/db"
-	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
 	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
 	"github.com/alchemillahq/sylve/internal/services/info"
 	"github.com/alchemillahq/sylve/internal/services/zfs"
@@ -80,12 +80,27 @@ func AvgIODelay(zfsService *zfs.Service) gin.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} internal.APIResponse[[]infoModels.IODelay] "Success"
+// @Param from query int false "Start of the range in unix milliseconds"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
+// @Success 200 {object} internal.APIResponse[[]db.MetricPoint] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
 // @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
 // @Router /zfs/pool/io-delay/historical [get]
 func AvgIODelayHistorical(zfsService *zfs.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		info, err := zfsService.GetTotalIODelayHisorical()
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		info, err := zfsService.GetTotalIODelayHisorical(r)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
 				Status:  "error",
@@ -96,7 +111,7 @@ func AvgIODelayHistorical(zfsService *zfs.Service) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, internal.APIResponse[[]infoModels.IODelay]{
+		c.JSON(http.StatusOK, internal.APIResponse[[]db.MetricPoint]{
 			Status:  "success",
 			Message: "avg_io_delay_historical",
 			Error:   "",
@@ -246,9 +261,9 @@ func ScrubPool(infoService *info.Service, zfsService *zfs.Service) gin.HandlerFu
 	return func(c *gin.Context) {
 		guid := c.Param("guid")
 
-		err := zfsUtils.ScrubPool(guid)
+		err := zfsService.StartScrub(guid, "manual")
 		if err != nil {
-			if strings.HasPrefix(err.Error(), "error_getting_pool") {
+			if strings.HasPrefix(err.Error(), "pool_not_found") {
 				c.JSON(http.StatusNotFound, internal.APIResponse[any]{
 					Status:  "error",
 					Message: "pool_not_found",
@@ -389,7 +404,11 @@ func ReplaceDevice(infoService *info.Service, zfsService *zfs.Service) gin.Handl
 // @Security BearerAuth
 // @Param interval path int true "Interval in minutes"
 // @Param limit path int true "Limit"
+// @Param from query int false "Start of the range in unix milliseconds, defaults to limit intervals back"
+// @Param to query int false "End of the range in unix milliseconds"
+// @Param resolution query string false "auto, raw, 1m, 1h or 1d"
 // @Success 200 {object} internal.APIResponse[PoolStatPointResponse] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
 // @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
 // @Router /zfs/pool/stats/{interval}/{limit} [get]
 func PoolStats(zfsService *zfs.Service) gin.HandlerFunc {
@@ -419,7 +438,23 @@ func PoolStats(zfsService *zfs.Service) gin.HandlerFunc {
 			return
 		}
 
-		stats, count, err := zfsService.GetZpoolHistoricalStats(intervalInt, limitInt)
+		r, err := db.ParseMetricRange(c.Query("from"), c.Query("to"), c.Query("resolution"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		// Without from the range follows the interval and limit.
+		if c.Query("from") == "" {
+			r.From = time.Time{}
+		}
+
+		stats, count, err := zfsService.GetZpoolHistoricalStats(intervalInt, limitInt, r)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsHandlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/alchemillahq/sylve/internal"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/services/zfs"
+
+	"github.com/gin-gonic/gin"
+
+	zfsUtils "github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+// @Summary Get Importable Pools
+// @Description List pools that can be imported along with their state and missing devices
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} internal.APIResponse[[]zfsUtils.ImportablePool] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/importable [get]
+func GetImportablePools(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pools, err := zfsService.GetImportablePools()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsUtils.ImportablePool]{
+			Status:  "success",
+			Message: "importable_pools",
+			Error:   "",
+			Data:    pools,
+		})
+	}
+}
+
+// @Summary Import Pool
+// @Description Import a pool by name or GUID, optionally renamed, read-only or under an alternate root
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body zfsServiceInterfaces.ImportPoolRequest true "Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/import [post]
+func ImportPool(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.ImportPoolRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.ImportPool(request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "pool_import_failed",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "pool_imported",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Export Pool
+// @Description Export a pool after checking that no VM, jail or Samba share still uses it
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Pool GUID"
+// @Param request body zfsServiceInterfaces.ExportPoolRequest false "Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Failure 409 {object} internal.APIResponse[any] "Conflict"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/{guid}/export [post]
+func ExportPool(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.ExportPoolRequest
+
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+					Status:  "error",
+					Message: "invalid_request",
+					Error:   err.Error(),
+					Data:    nil,
+				})
+				return
+			}
+		}
+
+		if err := zfsService.ExportPool(c.Param("guid"), request.Force); err != nil {
+			status := http.StatusInternalServerError
+			message := "pool_export_failed"
+
+			switch {
+			case strings.HasPrefix(err.Error(), "pool_not_found"):
+				status = http.StatusNotFound
+				message = "pool_not_found"
+			case strings.HasPrefix(err.Error(), "pool_in_use"):
+				status = http.StatusConflict
+				message = "pool_in_use"
+			}
+
+			c.JSON(status, internal.APIResponse[any]{
+				Status:  "error",
+				Message: message,
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "pool_exported",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
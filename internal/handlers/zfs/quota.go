@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsHandlers
+
+import (
+	"net/http"
+
+	"github.com/alchemillahq/sylve/internal"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/services/zfs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get dataset space usage
+// @Description List per-user, per-group and per-project space usage and quotas of a filesystem
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Dataset GUID"
+// @Success 200 {object} internal.APIResponse[zfsServiceInterfaces.DatasetSpaceUsage] "OK"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/quotas/{guid} [get]
+func GetDatasetSpaceUsage(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		usage, err := zfsService.GetDatasetSpaceUsage(c.Param("guid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[*zfsServiceInterfaces.DatasetSpaceUsage]{
+			Status:  "success",
+			Message: "dataset_space_usage",
+			Error:   "",
+			Data:    usage,
+		})
+	}
+}
+
+// @Summary Set a user, group or project quota
+// @Description Set or clear (with 0) the space and object quota of a user, group or project on a filesystem
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Dataset GUID"
+// @Param request body zfsServiceInterfaces.SetQuotaRequest true "Set Quota Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/quotas/{guid} [put]
+func SetDatasetQuota(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.SetQuotaRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.SetDatasetQuota(c.Param("guid"), request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "quota_set",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsHandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal"
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/services/zfs"
+
+	"github.com/gin-gonic/gin"
+)
+
+func parsePeriodicSnapshotID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+			Status:  "error",
+			Message: "invalid_periodic_snapshot_id",
+			Error:   err.Error(),
+			Data:    nil,
+		})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+// @Summary Edit periodic snapshot retention
+// @Description Set how many hourly, daily, weekly, monthly and yearly snapshots a periodic snapshot job keeps
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Periodic Snapshot ID"
+// @Param request body zfsServiceInterfaces.SnapshotRetention true "Snapshot Retention"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/periodic/{id}/retention [put]
+func EditPeriodicSnapshotRetention(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parsePeriodicSnapshotID(c)
+		if !ok {
+			return
+		}
+
+		var request zfsServiceInterfaces.SnapshotRetention
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.EditPeriodicSnapshotRetention(id, request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "edited_snapshot_retention",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Prune periodic snapshots
+// @Description Apply the retention of a periodic snapshot job, optionally as a dry run that only reports what would be deleted
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Periodic Snapshot ID"
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 200 {object} internal.APIResponse[zfsModels.SnapshotPruneRun] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/periodic/{id}/prune [post]
+func PrunePeriodicSnapshot(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parsePeriodicSnapshotID(c)
+		if !ok {
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+
+		run, err := zfsService.PrunePeriodicSnapshot(id, dryRun)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    run,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[zfsModels.SnapshotPruneRun]{
+			Status:  "success",
+			Message: "pruned_snapshots",
+			Error:   "",
+			Data:    *run,
+		})
+	}
+}
+
+// @Summary Get prune runs of a periodic snapshot job
+// @Description Get the audit log of retention runs of a periodic snapshot job, newest first
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Periodic Snapshot ID"
+// @Param limit query int false "Maximum number of runs"
+// @Success 200 {object} internal.APIResponse[[]zfsModels.SnapshotPruneRun] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/periodic/{id}/prune-runs [get]
+func GetSnapshotPruneRuns(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parsePeriodicSnapshotID(c)
+		if !ok {
+			return
+		}
+
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+		runs, err := zfsService.GetSnapshotPruneRuns(id, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsModels.SnapshotPruneRun]{
+			Status:  "success",
+			Message: "snapshot_prune_runs",
+			Error:   "",
+			Data:    runs,
+		})
+	}
+}
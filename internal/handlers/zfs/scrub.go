@@ -0,0 +1,362 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsHandlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alchemillahq/sylve/internal"
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/services/zfs"
+
+	"github.com/gin-gonic/gin"
+
+	zfsUtils "github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+func parseScrubScheduleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+			Status:  "error",
+			Message: "invalid_scrub_schedule_id",
+			Error:   err.Error(),
+			Data:    nil,
+		})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func scrubError(c *gin.Context, err error) {
+	if strings.HasPrefix(err.Error(), "pool_not_found") {
+		c.JSON(http.StatusNotFound, internal.APIResponse[any]{
+			Status:  "error",
+			Message: "pool_not_found",
+			Error:   err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+		Status:  "error",
+		Message: "internal_server_error",
+		Error:   err.Error(),
+		Data:    nil,
+	})
+}
+
+// @Summary Get Scrub Status
+// @Description Get the progress of a running scrub or resilver, or the outcome of the last one
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Pool GUID"
+// @Success 200 {object} internal.APIResponse[zfsUtils.ScanStatus] "Success"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/{guid}/scrub [get]
+func GetScrubStatus(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, err := zfsService.GetScrubStatus(c.Param("guid"))
+		if err != nil {
+			scrubError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[*zfsUtils.ScanStatus]{
+			Status:  "success",
+			Message: "scrub_status",
+			Error:   "",
+			Data:    status,
+		})
+	}
+}
+
+// @Summary Pause Scrub
+// @Description Pause a running scrub
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Pool GUID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/{guid}/scrub/pause [post]
+func PauseScrub(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := zfsService.PauseScrub(c.Param("guid")); err != nil {
+			scrubError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "scrub_paused",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Resume Scrub
+// @Description Resume a paused scrub
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Pool GUID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/{guid}/scrub/resume [post]
+func ResumeScrub(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := zfsService.ResumeScrub(c.Param("guid")); err != nil {
+			scrubError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "scrub_resumed",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Cancel Scrub
+// @Description Cancel a running or paused scrub
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Pool GUID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 404 {object} internal.APIResponse[any] "Not Found"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/{guid}/scrub/cancel [post]
+func CancelScrub(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := zfsService.CancelScrub(c.Param("guid")); err != nil {
+			scrubError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "scrub_canceled",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Get Scrub History
+// @Description Get completed and running scrubs of a pool, newest first
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Pool GUID"
+// @Param limit query int false "Maximum number of runs"
+// @Success 200 {object} internal.APIResponse[[]zfsModels.ScrubRun] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/{guid}/scrub/runs [get]
+func GetScrubRuns(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.Query("limit"))
+
+		runs, err := zfsService.GetScrubRuns(c.Param("guid"), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsModels.ScrubRun]{
+			Status:  "success",
+			Message: "scrub_runs",
+			Error:   "",
+			Data:    runs,
+		})
+	}
+}
+
+// @Summary Get Scrub Schedules
+// @Description Get all scheduled pool scrubs
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} internal.APIResponse[[]zfsModels.ScrubSchedule] "Success"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/scrub-schedules [get]
+func GetScrubSchedules(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schedules, err := zfsService.GetScrubSchedules()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsModels.ScrubSchedule]{
+			Status:  "success",
+			Message: "scrub_schedules",
+			Error:   "",
+			Data:    schedules,
+		})
+	}
+}
+
+// @Summary Create Scrub Schedule
+// @Description Scrub a pool on an interval or cron expression
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body zfsServiceInterfaces.ScrubScheduleRequest true "Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/scrub-schedules [post]
+func CreateScrubSchedule(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.ScrubScheduleRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.CreateScrubSchedule(request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "scrub_schedule_created",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Edit Scrub Schedule
+// @Description Change the interval, cron expression or enabled state of a scrub schedule
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Scrub Schedule ID"
+// @Param request body zfsServiceInterfaces.ScrubScheduleRequest true "Request"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/scrub-schedules/{id} [put]
+func EditScrubSchedule(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseScrubScheduleID(c)
+		if !ok {
+			return
+		}
+
+		var request zfsServiceInterfaces.ScrubScheduleRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.EditScrubSchedule(id, request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "scrub_schedule_updated",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
+
+// @Summary Delete Scrub Schedule
+// @Description Delete a scrub schedule, the scrub history of the pool is kept
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Scrub Schedule ID"
+// @Success 200 {object} internal.APIResponse[any] "Success"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/pools/scrub-schedules/{id} [delete]
+func DeleteScrubSchedule(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseScrubScheduleID(c)
+		if !ok {
+			return
+		}
+
+		if err := zfsService.DeleteScrubSchedule(id); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "scrub_schedule_deleted",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
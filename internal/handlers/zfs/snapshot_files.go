@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfsHandlers
+
+import (
+	"net/http"
+
+	"github.com/alchemillahq/sylve/internal"
+	systemServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/system"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/services/zfs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List files in a snapshot
+// @Description List the files and folders of a path inside a snapshot through .zfs/snapshot
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Snapshot GUID"
+// @Param path query string false "Path relative to the snapshot root"
+// @Success 200 {object} internal.APIResponse[[]systemServiceInterfaces.FileNode] "OK"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/{guid}/files [get]
+func ListSnapshotFiles(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		files, err := zfsService.ListSnapshotFiles(c.Param("guid"), c.Query("path"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]systemServiceInterfaces.FileNode]{
+			Status:  "success",
+			Message: "snapshot_files",
+			Error:   "",
+			Data:    files,
+		})
+	}
+}
+
+// @Summary Diff a snapshot
+// @Description List the changes between a snapshot and a later snapshot, or the live dataset if no snapshot is given
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Snapshot GUID"
+// @Param compare query string false "GUID of a later snapshot of the same dataset"
+// @Success 200 {object} internal.APIResponse[[]zfsServiceInterfaces.SnapshotDiffEntry] "OK"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/{guid}/diff [get]
+func DiffSnapshot(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		changes, err := zfsService.DiffSnapshot(c.Param("guid"), c.Query("compare"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[[]zfsServiceInterfaces.SnapshotDiffEntry]{
+			Status:  "success",
+			Message: "snapshot_diff",
+			Error:   "",
+			Data:    changes,
+		})
+	}
+}
+
+// @Summary Restore files from a snapshot
+// @Description Copy files or folders from a snapshot back into the live dataset, or into an alternate path
+// @Tags ZFS
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param guid path string true "Snapshot GUID"
+// @Param request body zfsServiceInterfaces.RestoreSnapshotFilesRequest true "Restore Snapshot Files Request"
+// @Success 200 {object} internal.APIResponse[any] "OK"
+// @Failure 400 {object} internal.APIResponse[any] "Bad Request"
+// @Failure 500 {object} internal.APIResponse[any] "Internal Server Error"
+// @Router /zfs/datasets/snapshot/{guid}/restore [post]
+func RestoreSnapshotFiles(zfsService *zfs.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request zfsServiceInterfaces.RestoreSnapshotFilesRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "invalid_request",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		if err := zfsService.RestoreSnapshotFiles(c.Param("guid"), request); err != nil {
+			c.JSON(http.StatusInternalServerError, internal.APIResponse[any]{
+				Status:  "error",
+				Message: "internal_server_error",
+				Error:   err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, internal.APIResponse[any]{
+			Status:  "success",
+			Message: "restored_snapshot_files",
+			Error:   "",
+			Data:    nil,
+		})
+	}
+}
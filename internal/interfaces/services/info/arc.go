@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package infoServiceInterfaces
+
+type ARCInfo struct {
+	Size    uint64 `json:"size"`
+	Target  uint64 `json:"target"`
+	Min     uint64 `json:"min"`
+	Max     uint64 `json:"max"`
+	MRUSize uint64 `json:"mruSize"`
+	MFUSize uint64 `json:"mfuSize"`
+
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hitRatio"`
+
+	L2Hits     uint64  `json:"l2Hits"`
+	L2Misses   uint64  `json:"l2Misses"`
+	L2HitRatio float64 `json:"l2HitRatio"`
+	L2Size     uint64  `json:"l2Size"`
+
+	MemoryThrottleCount uint64 `json:"memoryThrottleCount"`
+
+	// ConfiguredMax is the persisted vfs.zfs.arc_max, 0 when the kernel
+	// default is used.
+	ConfiguredMax uint64 `json:"configuredMax"`
+}
+
+type SetARCMaxRequest struct {
+	Max *uint64 `json:"max" binding:"required"`
+}
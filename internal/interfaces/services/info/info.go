@@ -15,6 +15,7 @@ type InfoServiceInterface interface {
 	GetCPUInfo(usageOnly bool) (cpuInfo CPUInfo, err error)
 	GetRAMInfo() (ramInfo RAMInfo, err error)
 	GetSwapInfo() (swapInfo SwapInfo, err error)
+	GetARCInfo() (arcInfo ARCInfo, err error)
 
 	GetNoteByID(id int) (infoModels.Note, error)
 	GetNotes() ([]infoModels.Note, error)
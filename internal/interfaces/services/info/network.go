@@ -8,8 +8,6 @@
 
 package infoServiceInterfaces
 
-import "time"
-
 type NetworkInterface struct {
 	Name    string `json:"name"`
 	Flags   string `json:"flags"`
@@ -27,9 +25,3 @@ type NetworkInterface struct {
 
 	Collisions int64 `json:"collisions"`
 }
-
-type HistoricalNetworkInterface struct {
-	SentBytes     int64     `json:"sentBytes"`
-	ReceivedBytes int64     `json:"receivedBytes"`
-	CreatedAt     time.Time `json:"createdAt"`
-}
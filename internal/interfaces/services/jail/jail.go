@@ -55,6 +55,8 @@ type State struct {
 
 type JailServiceInterface interface {
 	StoreJailUsage() error
-	PruneOrphanedJailStats([]uint) error
 	WatchNetworkObjectChanges() error
+
+	JailAction(ctId int, action string) error
+	GetJidByCtId(ctId int) int
 }
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirtServiceInterfaces
+
+import (
+	"time"
+
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+)
+
+type ExportVMRequest struct {
+	Path       string `json:"path" binding:"required"`
+	DiskFormat string `json:"diskFormat" binding:"omitempty,oneof=raw zfs"`
+}
+
+// ImportVMRequest imports a VM archive. Networks get new MAC addresses
+// unless KeepMACs is set, as the exported VM may still exist elsewhere.
+type ImportVMRequest struct {
+	Path     string            `json:"path"`
+	Download string            `json:"download"`
+	Name     string            `json:"name"`
+	VMID     *int              `json:"vmId"`
+	VNCPort  int               `json:"vncPort"`
+	Parent   string            `json:"parent" binding:"required"`
+	Switches map[string]string `json:"switches"`
+	KeepMACs bool              `json:"keepMacs"`
+}
+
+// VMImportProgress is the state of an import running in the background, VM
+// is the ID of the created VM once it is done.
+type VMImportProgress struct {
+	ID         string     `json:"id"`
+	Archive    string     `json:"archive"`
+	Stage      string     `json:"stage"`
+	VM         uint       `json:"vm"`
+	VMID       int        `json:"vmId"`
+	Done       bool       `json:"done"`
+	Error      string     `json:"error"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+// VMArchiveDisk is a storage of an archived VM. Disks with a File point at
+// the archive entry holding their contents, ISOs only name the download.
+type VMArchiveDisk struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Size      int64  `json:"size"`
+	Emulation string `json:"emulation"`
+	Format    string `json:"format"`
+	File      string `json:"file"`
+	ISO       string `json:"iso"`
+}
+
+// VMArchiveManifest is the first entry of a VM archive.
+type VMArchiveManifest struct {
+	Version   int                `json:"version"`
+	CreatedAt time.Time          `json:"createdAt"`
+	VM        vmModels.VM        `json:"vm"`
+	Disks     []VMArchiveDisk    `json:"disks"`
+	Networks  []MigrationNetwork `json:"networks"`
+	UEFIVars  string             `json:"uefiVars"`
+}
@@ -8,7 +8,11 @@
 
 package libvirtServiceInterfaces
 
-import vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+import (
+	"time"
+
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+)
 
 type LibvirtServiceInterface interface {
 	CheckVersion() error
@@ -22,6 +26,9 @@ type LibvirtServiceInterface interface {
 	NetworkDetach(vmId int, networkId int) error
 	NetworkAttach(vmId int, switchId int, emulation string, macObjId uint) error
 	FindAndChangeMAC(vmId int, oldMac string, newMac string) error
+	RefreshCloudInitSeed(vmId int) error
+
+	RewriteStoragePaths(vmId int, paths map[string]string) error
 
 	StoreVMUsage() error
 
@@ -29,6 +36,8 @@ type LibvirtServiceInterface interface {
 
 	GetLvDomain(vmId int) (*LvDomain, error)
 	IsDomainInactive(vmId int) (bool, error)
+	LvVMAction(vm vmModels.VM, action string) error
+	ShutdownVM(vmId int, timeout time.Duration) (bool, error)
 
 	FindVmByMac(mac string) (vmModels.VM, error)
 	WolTasks()
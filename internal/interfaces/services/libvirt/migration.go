@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirtServiceInterfaces
+
+import (
+	"time"
+
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+)
+
+type MigrateVMRequest struct {
+	TargetNode string            `json:"targetNode" binding:"required"`
+	Pools      map[string]string `json:"pools"`
+	PCIDevices map[string]string `json:"pciDevices"`
+}
+
+type MigrationProgress struct {
+	VMID       int        `json:"vmId"`
+	TargetNode string     `json:"targetNode"`
+	Stage      string     `json:"stage"`
+	Dataset    string     `json:"dataset"`
+	BytesSent  int64      `json:"bytesSent"`
+	Done       bool       `json:"done"`
+	Error      string     `json:"error"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+type MigrationStorage struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Dataset   string `json:"dataset"`
+	Encrypted bool   `json:"encrypted"`
+	Size      int64  `json:"size"`
+	Emulation string `json:"emulation"`
+}
+
+type MigrationNetwork struct {
+	Switch    string `json:"switch"`
+	MAC       string `json:"mac"`
+	MACName   string `json:"macName"`
+	Emulation string `json:"emulation"`
+	DHCP      bool   `json:"dhcp"`
+	SLAAC     bool   `json:"slaac"`
+}
+
+// VMMigration is what the source node sends to the target node, storages
+// name their dataset on the target and networks their switch by name.
+type VMMigration struct {
+	VM         vmModels.VM        `json:"vm"`
+	Storages   []MigrationStorage `json:"storages"`
+	Networks   []MigrationNetwork `json:"networks"`
+	PCIDevices []string           `json:"pciDevices"`
+	UEFIVars   []byte             `json:"uefiVars"`
+	Start      bool               `json:"start"`
+}
+
+type AbortMigrationRequest struct {
+	Datasets []string `json:"datasets"`
+}
@@ -8,7 +8,11 @@
 
 package libvirtServiceInterfaces
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+)
 
 type CreateVMRequest struct {
 	Name                 string  `json:"name" binding:"required"`
@@ -37,6 +41,77 @@ type CreateVMRequest struct {
 	StartOrder           int     `json:"startOrder"`
 }
 
+type CloneVMNetwork struct {
+	NetworkID uint `json:"networkId" binding:"required"`
+	SwitchID  uint `json:"switchId" binding:"required"`
+}
+
+type CloneVMRequest struct {
+	Mode     string           `json:"mode" binding:"required,oneof=full linked"`
+	Name     string           `json:"name"`
+	VMID     *int             `json:"vmId"`
+	VNCPort  int              `json:"vncPort"`
+	Networks []CloneVMNetwork `json:"networks"`
+}
+
+type ConvertToTemplateRequest struct {
+	OSType      string `json:"osType"`
+	SourceImage string `json:"sourceImage"`
+}
+
+type TemplateDisk struct {
+	StorageID uint  `json:"storageId" binding:"required"`
+	Size      int64 `json:"size" binding:"required"`
+}
+
+type CreateFromTemplateRequest struct {
+	Mode       string           `json:"mode" binding:"omitempty,oneof=full linked"`
+	Name       string           `json:"name" binding:"required"`
+	VMID       *int             `json:"vmId"`
+	VNCPort    int              `json:"vncPort"`
+	CPUSockets int              `json:"cpuSockets"`
+	CPUCores   int              `json:"cpuCores"`
+	CPUThreads int              `json:"cpuThreads"`
+	RAM        int              `json:"ram"`
+	Disks      []TemplateDisk   `json:"disks"`
+	Networks   []CloneVMNetwork `json:"networks"`
+}
+
+type CloudInitNetwork struct {
+	NetworkID uint `json:"networkId" binding:"required"`
+	IPv4      uint `json:"ipv4"`
+	IPv4Gw    uint `json:"ipv4Gw"`
+	IPv6      uint `json:"ipv6"`
+	IPv6Gw    uint `json:"ipv6Gw"`
+	DHCP      bool `json:"dhcp"`
+	SLAAC     bool `json:"slaac"`
+}
+
+type CloudInitRequest struct {
+	Hostname    string                   `json:"hostname"`
+	Users       []vmModels.CloudInitUser `json:"users"`
+	Packages    []string                 `json:"packages"`
+	RunCmd      []string                 `json:"runCmd"`
+	Nameservers []string                 `json:"nameservers"`
+	Networks    []CloudInitNetwork       `json:"networks"`
+}
+
+type CreateVMSnapshotRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type RollbackVMSnapshotRequest struct {
+	Force        bool `json:"force"`
+	DestroyNewer bool `json:"destroyNewer"`
+}
+
+type VMSnapshotNode struct {
+	vmModels.Snapshot
+	Current  bool             `json:"current"`
+	Children []VMSnapshotNode `json:"children"`
+}
+
 type Memory struct {
 	Unit string `xml:"unit,attr"`
 	Text string `xml:",chardata"`
@@ -128,6 +203,16 @@ type Input struct {
 	Bus  string `xml:"bus,attr"`
 }
 
+type SerialSource struct {
+	Master string `xml:"master,attr"`
+	Slave  string `xml:"slave,attr"`
+}
+
+type Serial struct {
+	Type   string       `xml:"type,attr"`
+	Source SerialSource `xml:"source"`
+}
+
 type Address struct {
 	Type     string `xml:"type,attr,omitempty"`
 	Domain   string `xml:"domain,attr,omitempty"`
@@ -148,6 +233,7 @@ type Devices struct {
 	Interfaces  []Interface  `xml:"interface,omitempty"`
 	Controllers []Controller `xml:"controller,omitempty"`
 	Inputs      []Input      `xml:"input,omitempty"`
+	Serials     []Serial     `xml:"serial,omitempty"`
 }
 
 type BhyveArg struct {
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package lifecycleServiceInterfaces
+
+import (
+	"time"
+
+	"github.com/alchemillahq/sylve/internal/db/models"
+)
+
+// ShutdownOptions controls how guests are stopped, zero values fall back
+// to the shutdown settings of the config and then to the defaults. Timeout
+// bounds stopping all guests, VMTimeout a single VM.
+type ShutdownOptions struct {
+	Timeout     time.Duration
+	VMTimeout   time.Duration
+	Parallelism int
+}
+
+type GuestShutdownResult struct {
+	Type       string `json:"type"`
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	StartOrder int    `json:"startOrder"`
+	Outcome    string `json:"outcome"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+type HostPowerRequest struct {
+	Action      string `json:"action" binding:"required,oneof=reboot poweroff"`
+	Timeout     int    `json:"timeout"`
+	VMTimeout   int    `json:"vmTimeout"`
+	Parallelism int    `json:"parallelism"`
+}
+
+const (
+	BootStateIdle      = "idle"
+	BootStateRunning   = "running"
+	BootStateDone      = "done"
+	BootStateCancelled = "cancelled"
+)
+
+// Guest boot states, blocked means a dependency failed or is not running and
+// cancelled that guests started shutting down before it was up.
+const (
+	GuestBootPending        = "pending"
+	GuestBootWaiting        = "waiting_dependencies"
+	GuestBootStarting       = "starting"
+	GuestBootDelaying       = "delaying"
+	GuestBootWaitReachable  = "waiting_reachable"
+	GuestBootReady          = "ready"
+	GuestBootAlreadyRunning = "already_running"
+	GuestBootFailed         = "failed"
+	GuestBootBlocked        = "blocked"
+	GuestBootCancelled      = "cancelled"
+)
+
+type GuestBootStatus struct {
+	Type       string     `json:"type"`
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	StartOrder int        `json:"startOrder"`
+	DependsOn  []string   `json:"dependsOn"`
+	State      string     `json:"state"`
+	BlockedOn  []string   `json:"blockedOn"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	ReadyAt    *time.Time `json:"readyAt,omitempty"`
+}
+
+type BootStatus struct {
+	State      string            `json:"state"`
+	StartedAt  *time.Time        `json:"startedAt,omitempty"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+	Guests     []GuestBootStatus `json:"guests"`
+}
+
+type GuestBootConfigRequest struct {
+	Type        string   `json:"type" binding:"required,oneof=vm jail"`
+	ID          int      `json:"id" binding:"required"`
+	StartDelay  int      `json:"startDelay" binding:"min=0"`
+	WaitType    string   `json:"waitType" binding:"omitempty,oneof=tcp ping"`
+	WaitHost    string   `json:"waitHost"`
+	WaitPort    int      `json:"waitPort" binding:"min=0,max=65535"`
+	WaitTimeout int      `json:"waitTimeout" binding:"min=0"`
+	DependsOn   []string `json:"dependsOn"`
+}
+
+type LifecycleServiceInterface interface {
+	ShutdownGuests(opts ShutdownOptions) []GuestShutdownResult
+	HostPower(req HostPowerRequest) error
+
+	StartGuests()
+	GetBootStatus() BootStatus
+	GetBootConfigs() ([]models.GuestBootConfig, error)
+	SetBootConfig(req GuestBootConfigRequest) error
+	DeleteBootConfig(guestType string, guestId int) error
+}
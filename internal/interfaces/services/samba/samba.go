@@ -19,3 +19,11 @@ type AuditLogsResponse struct {
 	LastPage int                         `json:"last_page"`
 	Data     []sambaModels.SambaAuditLog `json:"data"`
 }
+
+type ShareUserQuota struct {
+	UserID    uint   `json:"userId"`
+	Username  string `json:"username"`
+	Used      uint64 `json:"used"`
+	Quota     uint64 `json:"quota"`
+	Remaining uint64 `json:"remaining"`
+}
@@ -10,4 +10,7 @@ package systemServiceInterfaces
 
 type SystemServiceInterface interface {
 	SyncPPTDevices() error
+
+	Traverse(path string) ([]FileNode, error)
+	CopyOrMoveFilesOrFolders(pairs [][2]string, move bool) error
 }
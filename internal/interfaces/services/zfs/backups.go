@@ -7,3 +7,72 @@
 // under sponsorship from the FreeBSD Foundation.
 
 package zfsServiceInterfaces
+
+import "time"
+
+type BackupJobRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Type          string `json:"type"`
+	SourceGUID    string `json:"sourceGuid" binding:"required"`
+	TargetNode    string `json:"targetNode"`
+	TargetDataset string `json:"targetDataset"`
+	S3ConfigID    uint   `json:"s3ConfigId"`
+	S3Prefix      string `json:"s3Prefix"`
+	Prefix        string `json:"prefix"`
+	Interval      int    `json:"interval"`
+	CronExpr      string `json:"cronExpr"`
+	Enabled       *bool  `json:"enabled"`
+}
+
+type ReplicationSnapshot struct {
+	Name      string `json:"name"`
+	GUID      string `json:"guid"`
+	CreateTXG uint64 `json:"createTxg"`
+}
+
+type ReplicationState struct {
+	Dataset     string                `json:"dataset"`
+	Exists      bool                  `json:"exists"`
+	ResumeToken string                `json:"resumeToken"`
+	Snapshots   []ReplicationSnapshot `json:"snapshots"`
+}
+
+type S3BackupEntry struct {
+	Snapshot  string    `json:"snapshot"`
+	GUID      string    `json:"guid"`
+	Base      string    `json:"base"`
+	BaseGUID  string    `json:"baseGuid"`
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type S3BackupManifest struct {
+	Version    int             `json:"version"`
+	Dataset    string          `json:"dataset"`
+	SourceGUID string          `json:"sourceGuid"`
+	Type       string          `json:"type"`
+	Snapshots  []S3BackupEntry `json:"snapshots"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+}
+
+type S3RestoreRequest struct {
+	S3ConfigID    uint   `json:"s3ConfigId" binding:"required"`
+	S3Prefix      string `json:"s3Prefix" binding:"required"`
+	Snapshot      string `json:"snapshot"`
+	TargetDataset string `json:"targetDataset" binding:"required"`
+}
+
+// S3RestoreProgress is the state of a restore running in the background, Step
+// counts the streams of the chain received so far including Current.
+type S3RestoreProgress struct {
+	Target     string     `json:"target"`
+	Snapshot   string     `json:"snapshot"`
+	Current    string     `json:"current"`
+	Step       int        `json:"step"`
+	Steps      int        `json:"steps"`
+	Done       bool       `json:"done"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
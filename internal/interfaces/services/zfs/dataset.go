@@ -8,6 +8,8 @@
 
 package zfsServiceInterfaces
 
+import "time"
+
 // type zDataset struct {
 // 	Dataset zfs.Dataset
 // }
@@ -36,4 +38,90 @@ type Dataset struct {
 	ACLMode       string `json:"aclmode"`
 	PrimaryCache  string `json:"primarycache"`
 	VolMode       string `json:"volmode"`
+
+	Encryption     string `json:"encryption"`
+	EncryptionRoot string `json:"encryptionroot"`
+	KeyStatus      string `json:"keystatus"`
+	KeyFormat      string `json:"keyformat"`
+}
+
+type SnapshotRetention struct {
+	KeepHourly  int `json:"keepHourly"`
+	KeepDaily   int `json:"keepDaily"`
+	KeepWeekly  int `json:"keepWeekly"`
+	KeepMonthly int `json:"keepMonthly"`
+	KeepYearly  int `json:"keepYearly"`
+}
+
+type SnapshotDiffEntry struct {
+	Change               string `json:"change"`
+	Type                 string `json:"type"`
+	Path                 string `json:"path"`
+	NewPath              string `json:"newPath,omitempty"`
+	ReferenceCountChange int    `json:"referenceCountChange,omitempty"`
+}
+
+type RestoreSnapshotFilesRequest struct {
+	Paths       []string `json:"paths" binding:"required"`
+	Destination string   `json:"destination"`
+}
+
+type LoadKeyRequest struct {
+	Key      string `json:"key"`
+	Remember bool   `json:"remember"`
+}
+
+type ChangeKeyRequest struct {
+	KeyFormat  string `json:"keyFormat" binding:"required,oneof=passphrase hex raw"`
+	Key        string `json:"key"`
+	AutoUnlock *bool  `json:"autoUnlock"`
+}
+
+type SpaceUsageEntry struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Used        uint64 `json:"used"`
+	Quota       uint64 `json:"quota"`
+	ObjUsed     uint64 `json:"objUsed"`
+	ObjQuota    uint64 `json:"objQuota"`
+	Available   uint64 `json:"available"`
+	UserID      *uint  `json:"userId,omitempty"`
+	GroupID     *uint  `json:"groupId,omitempty"`
+	UnixAccount bool   `json:"unixAccount"`
+}
+
+type DatasetSpaceUsage struct {
+	Dataset  string            `json:"dataset"`
+	Users    []SpaceUsageEntry `json:"users"`
+	Groups   []SpaceUsageEntry `json:"groups"`
+	Projects []SpaceUsageEntry `json:"projects"`
+}
+
+type SetQuotaRequest struct {
+	Type     string  `json:"type" binding:"required,oneof=user group project"`
+	Name     string  `json:"name" binding:"required"`
+	Quota    *uint64 `json:"quota"`
+	ObjQuota *uint64 `json:"objQuota"`
+}
+
+type SnapshotHoldRequest struct {
+	Tag       string `json:"tag" binding:"required"`
+	Recursive bool   `json:"recursive"`
+}
+
+type CreateBookmarkRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type FlashProgress struct {
+	Target      string     `json:"target"`
+	Format      string     `json:"format"`
+	Compression string     `json:"compression"`
+	VirtualSize int64      `json:"virtualSize"`
+	Written     int64      `json:"written"`
+	Percent     float64    `json:"percent"`
+	Done        bool       `json:"done"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
 }
@@ -34,3 +34,43 @@ type PoolStatPoint struct {
 	Size       uint64  `json:"size"`
 	DedupRatio float64 `json:"dedupRatio"`
 }
+
+type AddVdevsRequest struct {
+	Class    string `json:"class" binding:"required,oneof=data log special dedup cache"`
+	RaidType string `json:"raidType" binding:"omitempty,oneof=mirror raidz raidz2 raidz3"`
+	Vdevs    []Vdev `json:"vdevs" binding:"required,min=1"`
+	Force    bool   `json:"force"`
+}
+
+type AttachDeviceRequest struct {
+	Device    string `json:"device" binding:"required"`
+	NewDevice string `json:"newDevice" binding:"required"`
+	Force     bool   `json:"force"`
+}
+
+type DetachDeviceRequest struct {
+	Device string `json:"device" binding:"required"`
+}
+
+type RemoveVdevRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type ImportPoolRequest struct {
+	Pool     string `json:"pool" binding:"required"`
+	NewName  string `json:"newName"`
+	ReadOnly bool   `json:"readOnly"`
+	AltRoot  string `json:"altRoot"`
+	Force    bool   `json:"force"`
+}
+
+type ExportPoolRequest struct {
+	Force bool `json:"force"`
+}
+
+type ScrubScheduleRequest struct {
+	PoolGUID string `json:"poolGuid" binding:"required"`
+	Interval int    `json:"interval"`
+	CronExpr string `json:"cronExpr"`
+	Enabled  *bool  `json:"enabled"`
+}
@@ -10,14 +10,17 @@ package zfsServiceInterfaces
 
 import (
 	"context"
+	"io"
 
-	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
+	"github.com/alchemillahq/sylve/internal/db"
 	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	systemServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/system"
+	"github.com/alchemillahq/sylve/pkg/zfs"
 )
 
 type ZfsServiceInterface interface {
-	GetTotalIODelayHisorical() ([]infoModels.IODelay, error)
-	GetZpoolHistoricalStats(intervalMinutes int, limit int) (map[string][]PoolStatPoint, int, error)
+	GetTotalIODelayHisorical(r db.MetricRange) ([]db.MetricPoint, error)
+	GetZpoolHistoricalStats(intervalMinutes int, limit int, r db.MetricRange) (map[string][]PoolStatPoint, int, error)
 
 	CreatePool(Zpool) error
 	DeletePool(poolName string) error
@@ -25,18 +28,67 @@ type ZfsServiceInterface interface {
 	GetDatasets(t string) ([]*Dataset, error)
 	BulkDeleteDataset(guids []string) error
 
+	CloneSnapshot(guid string, name string, props map[string]string) error
+	PromoteDataset(guid string) error
+	RenameDataset(guid string, newName string, createParent bool, recursive bool) error
+
 	CreateSnapshot(guid string, name string, recursive bool) error
 	RollbackSnapshot(guid string, destroyMoreRecent bool) error
 	DeleteSnapshot(guid string, recursive bool) error
 
+	GetSnapshotHolds(guid string) ([]zfs.Hold, error)
+	HoldSnapshot(guid string, req SnapshotHoldRequest) error
+	ReleaseSnapshot(guid string, req SnapshotHoldRequest) error
+	GetBookmarks(guid string) ([]*zfs.Dataset, error)
+	CreateBookmark(guid string, name string) error
+	DeleteBookmark(guid string, name string) error
+
+	ListSnapshotFiles(guid string, path string) ([]systemServiceInterfaces.FileNode, error)
+	DiffSnapshot(guid string, compareGUID string) ([]SnapshotDiffEntry, error)
+	RestoreSnapshotFiles(guid string, req RestoreSnapshotFilesRequest) error
+
 	GetPeriodicSnapshots() ([]zfsModels.PeriodicSnapshot, error)
-	AddPeriodicSnapshot(guid string, prefix string, recursive bool, interval int, cronExpr string) error
+	AddPeriodicSnapshot(guid string, prefix string, recursive bool, interval int, cronExpr string, retention SnapshotRetention) error
+	EditPeriodicSnapshotRetention(id uint, retention SnapshotRetention) error
+	PrunePeriodicSnapshot(id uint, dryRun bool) (*zfsModels.SnapshotPruneRun, error)
+	GetSnapshotPruneRuns(id uint, limit int) ([]zfsModels.SnapshotPruneRun, error)
 	DeletePeriodicSnapshot(guid string) error
 	StartSnapshotScheduler(ctx context.Context)
 
+	GetBackupJobs() ([]zfsModels.BackupJob, error)
+	GetBackupJobRuns(id uint, limit int) ([]zfsModels.BackupJobRun, error)
+	CreateBackupJob(req BackupJobRequest) error
+	EditBackupJob(id uint, req BackupJobRequest) error
+	DeleteBackupJob(id uint) error
+	RunBackupJob(id uint) error
+	StartBackupScheduler(ctx context.Context)
+
+	GetS3BackupManifest(s3ConfigID uint, prefix string) (*S3BackupManifest, error)
+	RestoreS3Backup(req S3RestoreRequest) error
+	GetS3RestoreProgress(target string) (*S3RestoreProgress, error)
+
+	GetScrubSchedules() ([]zfsModels.ScrubSchedule, error)
+	CreateScrubSchedule(req ScrubScheduleRequest) error
+	EditScrubSchedule(id uint, req ScrubScheduleRequest) error
+	DeleteScrubSchedule(id uint) error
+	StartScrub(guid string, trigger string) error
+	PauseScrub(guid string) error
+	ResumeScrub(guid string) error
+	CancelScrub(guid string) error
+	GetScrubRuns(guid string, limit int) ([]zfsModels.ScrubRun, error)
+	StartScrubScheduler(ctx context.Context)
+
+	SubscribePoolAlerts() (<-chan zfsModels.PoolAlert, func())
+	StartEventWatcher(ctx context.Context)
+
+	GetReplicationState(dataset string) (*ReplicationState, error)
+	ReceiveReplicationStream(dataset string, input io.Reader, force bool) error
+
 	CreateFilesystem(name string, props map[string]string) error
 	DeleteFilesystem(guid string) error
 
+	UnlockEncryptedDatasets() error
+
 	SyncLibvirtPools() error
 
 	StoreStats(interval int)
@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package info
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal/db"
+	"github.com/alchemillahq/sylve/internal/db/models"
+	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
+	"github.com/alchemillahq/sylve/internal/logger"
+	sysctl "github.com/alchemillahq/sylve/pkg/utils/sysctl"
+
+	"gorm.io/gorm"
+)
+
+const (
+	arcStatsPrefix = "kstat.zfs.misc.arcstats."
+	arcMaxSysctl   = "vfs.zfs.arc_max"
+
+	// OpenZFS refuses an arc_max below 64 MiB.
+	arcMinLimit = 64 << 20
+)
+
+func readARCStat(name string) (uint64, error) {
+	v, err := sysctl.GetInt64(arcStatsPrefix + name)
+	if err != nil {
+		return 0, fmt.Errorf("failed_to_read_arcstat_%s: %w", name, err)
+	}
+
+	return uint64(v), nil
+}
+
+func hitRatio(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+
+	return float64(hits) / float64(hits+misses) * 100
+}
+
+func (s *Service) GetARCInfo() (infoServiceInterfaces.ARCInfo, error) {
+	var info infoServiceInterfaces.ARCInfo
+
+	stats := []struct {
+		name string
+		ptr  *uint64
+	}{
+		{"size", &info.Size},
+		{"c", &info.Target},
+		{"c_min", &info.Min},
+		{"c_max", &info.Max},
+		{"mru_size", &info.MRUSize},
+		{"mfu_size", &info.MFUSize},
+		{"hits", &info.Hits},
+		{"misses", &info.Misses},
+		{"l2_hits", &info.L2Hits},
+		{"l2_misses", &info.L2Misses},
+		{"l2_size", &info.L2Size},
+		{"memory_throttle_count", &info.MemoryThrottleCount},
+	}
+
+	for _, stat := range stats {
+		v, err := readARCStat(stat.name)
+		if err != nil {
+			return infoServiceInterfaces.ARCInfo{}, err
+		}
+		*stat.ptr = v
+	}
+
+	info.HitRatio = hitRatio(info.Hits, info.Misses)
+	info.L2HitRatio = hitRatio(info.L2Hits, info.L2Misses)
+
+	var tunable models.Sysctl
+	if err := s.DB.Where("name = ?", arcMaxSysctl).First(&tunable).Error; err == nil {
+		info.ConfiguredMax = uint64(tunable.Value)
+	}
+
+	return info, nil
+}
+
+// StoreARCStats records a sample of the ARC. Hits and misses are cumulative
+// kernel counters, so the stored ratios cover the interval since the previous
+// sample instead of the whole uptime.
+func (s *Service) StoreARCStats() {
+	info, err := s.GetARCInfo()
+	if err != nil {
+		return
+	}
+
+	s.statsMutex.Lock()
+	last := s.lastARC
+	s.lastARC = &info
+	s.statsMutex.Unlock()
+
+	hits, misses := info.Hits, info.Misses
+	l2Hits, l2Misses := info.L2Hits, info.L2Misses
+
+	// Counters reset when the zfs module is reloaded.
+	if last != nil && info.Hits >= last.Hits && info.Misses >= last.Misses &&
+		info.L2Hits >= last.L2Hits && info.L2Misses >= last.L2Misses {
+		hits, misses = info.Hits-last.Hits, info.Misses-last.Misses
+		l2Hits, l2Misses = info.L2Hits-last.L2Hits, info.L2Misses-last.L2Misses
+	}
+
+	hitPct := hitRatio(hits, misses)
+	missPct := 100 - hitPct
+	if hits+misses == 0 {
+		missPct = 0
+	}
+
+	if err := db.RecordMetrics(s.DB, "arc", "", time.Now(), map[string]float64{
+		"size":                float64(info.Size),
+		"target":              float64(info.Target),
+		"mruSize":             float64(info.MRUSize),
+		"mfuSize":             float64(info.MFUSize),
+		"hitRatio":            hitPct,
+		"missRatio":           missPct,
+		"l2Hits":              float64(l2Hits),
+		"l2Misses":            float64(l2Misses),
+		"l2HitRatio":          hitRatio(l2Hits, l2Misses),
+		"l2Size":              float64(info.L2Size),
+		"memoryThrottleCount": float64(info.MemoryThrottleCount),
+	}); err != nil {
+		logger.L.Debug().Err(err).Msg("Failed to store ARC stats")
+	}
+}
+
+func (s *Service) GetARCHistorical(r db.MetricRange) ([]db.MetricPoint, error) {
+	return db.QueryMetrics(s.DB, "arc", "", r)
+}
+
+// SetARCMax changes vfs.zfs.arc_max and persists it so SysctlSync applies it
+// on the next start. A value of 0 goes back to the kernel default.
+func (s *Service) SetARCMax(max uint64) error {
+	if max != 0 {
+		physmem, err := sysctl.GetInt64("hw.physmem")
+		if err != nil {
+			return fmt.Errorf("failed_to_get_physmem: %w", err)
+		}
+
+		arcMin, err := readARCStat("c_min")
+		if err != nil {
+			return err
+		}
+
+		if max < arcMinLimit || max <= arcMin {
+			return fmt.Errorf("arc_max_too_small")
+		}
+
+		if max >= uint64(physmem) {
+			return fmt.Errorf("arc_max_exceeds_physical_memory")
+		}
+	}
+
+	if err := sysctl.SetInt64(arcMaxSysctl, int64(max)); err != nil {
+		return fmt.Errorf("failed_to_set_arc_max: %w", err)
+	}
+
+	if max == 0 {
+		if err := s.DB.Where("name = ?", arcMaxSysctl).Delete(&models.Sysctl{}).Error; err != nil {
+			return fmt.Errorf("failed_to_delete_arc_max: %w", err)
+		}
+		return nil
+	}
+
+	var tunable models.Sysctl
+	err := s.DB.Where("name = ?", arcMaxSysctl).First(&tunable).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed_to_get_arc_max: %w", err)
+	}
+
+	tunable.Name = arcMaxSysctl
+	tunable.Value = int64(max)
+
+	if err := s.DB.Save(&tunable).Error; err != nil {
+		return fmt.Errorf("failed_to_save_arc_max: %w", err)
+	}
+
+	return nil
+}
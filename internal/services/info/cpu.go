@@ -12,7 +12,6 @@ import (
 	"time"
 
 	"github.com/alchemillahq/sylve/internal/db"
-	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
 	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
 
 	cpuid "github.com/klauspost/cpuid/v2"
@@ -61,12 +60,6 @@ func (s *Service) GetCPUInfo(usageOnly bool) (infoServiceInterfaces.CPUInfo, err
 	}, nil
 }
 
-func (s *Service) GetCPUUsageHistorical() ([]infoModels.CPU, error) {
-	historicalData, err := db.GetHistorical[infoModels.CPU](s.DB, 128)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return historicalData, nil
+func (s *Service) GetCPUUsageHistorical(r db.MetricRange) ([]db.MetricPoint, error) {
+	return db.QueryMetrics(s.DB, "cpu", "", r)
 }
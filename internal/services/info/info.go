@@ -10,6 +10,7 @@ package info
 
 import (
 	"errors"
+	"sync"
 
 	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
 	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
@@ -21,6 +22,10 @@ var _ infoServiceInterfaces.InfoServiceInterface = (*Service)(nil)
 
 type Service struct {
 	DB *gorm.DB
+
+	statsMutex  sync.Mutex
+	lastARC     *infoServiceInterfaces.ARCInfo
+	lastNetwork map[string]infoServiceInterfaces.NetworkInterface
 }
 
 func NewInfoService(db *gorm.DB) infoServiceInterfaces.InfoServiceInterface {
@@ -10,7 +10,6 @@ package info
 
 import (
 	"github.com/alchemillahq/sylve/internal/db"
-	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
 	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
 	"github.com/alchemillahq/sylve/pkg/system/swapctl"
 
@@ -57,22 +56,10 @@ func (s *Service) GetSwapInfo() (infoServiceInterfaces.SwapInfo, error) {
 	}, nil
 }
 
-func (s *Service) GetRAMUsageHistorical() ([]infoModels.RAM, error) {
-	historicalData, err := db.GetHistorical[infoModels.RAM](s.DB, 128)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return historicalData, nil
+func (s *Service) GetRAMUsageHistorical(r db.MetricRange) ([]db.MetricPoint, error) {
+	return db.QueryMetrics(s.DB, "ram", "", r)
 }
 
-func (s *Service) GetSwapUsageHistorical() ([]infoModels.Swap, error) {
-	historicalData, err := db.GetHistorical[infoModels.Swap](s.DB, 128)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return historicalData, nil
+func (s *Service) GetSwapUsageHistorical(r db.MetricRange) ([]db.MetricPoint, error) {
+	return db.QueryMetrics(s.DB, "swap", "", r)
 }
@@ -9,47 +9,46 @@
 package info
 
 import (
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/alchemillahq/sylve/internal/db"
-	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
+	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
+	"github.com/alchemillahq/sylve/internal/logger"
 )
 
 func (s *Service) StoreStats() {
 	type task struct {
-		get func() (float64, error)
-		ptr func(float64) interface{}
+		series string
+		get    func() (float64, error)
 	}
 
 	jobs := []task{
 		{
-			get: func() (float64, error) { c, err := s.GetCPUInfo(true); return c.Usage, err },
-			ptr: func(v float64) interface{} { return &infoModels.CPU{Usage: v} },
+			series: "cpu",
+			get:    func() (float64, error) { c, err := s.GetCPUInfo(true); return c.Usage, err },
 		},
 		{
-			get: func() (float64, error) { r, err := s.GetRAMInfo(); return r.UsedPercent, err },
-			ptr: func(v float64) interface{} { return &infoModels.RAM{Usage: v} },
+			series: "ram",
+			get:    func() (float64, error) { r, err := s.GetRAMInfo(); return r.UsedPercent, err },
 		},
 		{
-			get: func() (float64, error) { sw, err := s.GetSwapInfo(); return sw.UsedPercent, err },
-			ptr: func(v float64) interface{} { return &infoModels.Swap{Usage: v} },
+			series: "swap",
+			get:    func() (float64, error) { sw, err := s.GetSwapInfo(); return sw.UsedPercent, err },
 		},
 	}
 
+	now := time.Now()
+
 	var wg sync.WaitGroup
 	for _, job := range jobs {
 		wg.Add(1)
 		go func(j task) {
 			defer wg.Done()
 			if v, err := j.get(); err == nil {
-				switch ptr := j.ptr(v).(type) {
-				case *infoModels.CPU:
-					db.StoreAndTrimRecords[*infoModels.CPU](s.DB, &ptr, 128)
-				case *infoModels.RAM:
-					db.StoreAndTrimRecords[*infoModels.RAM](s.DB, &ptr, 128)
-				case *infoModels.Swap:
-					db.StoreAndTrimRecords[*infoModels.Swap](s.DB, &ptr, 128)
+				if err := db.RecordMetrics(s.DB, j.series, "", now, map[string]float64{"usage": v}); err != nil {
+					logger.L.Debug().Err(err).Msgf("Failed to store %s usage", j.series)
 				}
 			}
 		}(job)
@@ -57,29 +56,82 @@ func (s *Service) StoreStats() {
 	wg.Wait()
 }
 
+// StoreNetworkInterfaceStats records the bytes sent and received by every
+// interface since the previous call, and their sum under an empty label.
 func (s *Service) StoreNetworkInterfaceStats() {
 	interfaces, err := s.GetNetworkInterfacesInfo()
 	if err != nil {
 		return
 	}
 
+	current := make(map[string]infoServiceInterfaces.NetworkInterface)
 	for _, iface := range interfaces {
-		ifaceModel := &infoModels.NetworkInterface{
-			Name:            iface.Name,
-			Flags:           iface.Flags,
-			Network:         iface.Network,
-			Address:         iface.Address,
-			ReceivedPackets: iface.ReceivedPackets,
-			ReceivedErrors:  iface.ReceivedErrors,
-			DroppedPackets:  iface.DroppedPackets,
-			ReceivedBytes:   iface.ReceivedBytes,
-			SentPackets:     iface.SentPackets,
-			SendErrors:      iface.SendErrors,
-			SentBytes:       iface.SentBytes,
-			Collisions:      iface.Collisions,
+		// netstat repeats the interface for each address, the link row
+		// carries the counters of the interface itself.
+		if strings.HasPrefix(iface.Network, "<Link") {
+			current[iface.Name] = iface
 		}
+	}
+
+	s.statsMutex.Lock()
+	last := s.lastNetwork
+	s.lastNetwork = current
+	s.statsMutex.Unlock()
+
+	if last == nil {
+		return
+	}
+
+	now := time.Now()
+	var totalSent, totalReceived int64
+
+	for name, iface := range current {
+		prev, ok := last[name]
+		if !ok || iface.SentBytes < prev.SentBytes || iface.ReceivedBytes < prev.ReceivedBytes {
+			continue
+		}
+
+		sent := iface.SentBytes - prev.SentBytes
+		received := iface.ReceivedBytes - prev.ReceivedBytes
+		totalSent += sent
+		totalReceived += received
+
+		if err := db.RecordMetrics(s.DB, "network", name, now, map[string]float64{
+			"sentBytes":     float64(sent),
+			"receivedBytes": float64(received),
+		}); err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to store network stats of %s", name)
+		}
+	}
 
-		db.StoreAndTrimRecords(s.DB, ifaceModel, len(interfaces)*128)
+	if err := db.RecordMetrics(s.DB, "network", "", now, map[string]float64{
+		"sentBytes":     float64(totalSent),
+		"receivedBytes": float64(totalReceived),
+	}); err != nil {
+		logger.L.Debug().Err(err).Msg("Failed to store network stats")
+	}
+}
+
+// MaintainMetrics rolls up the shared metrics store and, every ten minutes,
+// drops what is past its retention along with the history of VMs and jails
+// that no longer exist.
+func (s *Service) MaintainMetrics() {
+	now := time.Now()
+
+	if err := db.RollupMetrics(s.DB, now); err != nil {
+		logger.L.Error().Err(err).Msg("Failed to roll up metrics")
+	}
+
+	if now.Minute()%10 == 0 {
+		if err := db.PruneMetrics(s.DB, now, db.GetMetricRetention()); err != nil {
+			logger.L.Error().Err(err).Msg("Failed to prune metrics")
+		}
+
+		for series, table := range map[string]string{"vm": "vms", "jail": "jails"} {
+			if err := db.PruneOrphanedMetrics(s.DB, series, table); err != nil {
+				logger.L.Error().Err(err).Msgf("Failed to prune orphaned %s metrics", series)
+			}
+		}
 	}
 }
 
@@ -87,11 +139,21 @@ func (s *Service) Cron() {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
+	rollup := time.NewTicker(time.Minute)
+	defer rollup.Stop()
+
 	s.StoreStats()
+	s.StoreARCStats()
 	s.StoreNetworkInterfaceStats()
 
-	for range ticker.C {
-		s.StoreStats()
-		s.StoreNetworkInterfaceStats()
+	for {
+		select {
+		case <-ticker.C:
+			s.StoreStats()
+			s.StoreARCStats()
+			s.StoreNetworkInterfaceStats()
+		case <-rollup.C:
+			s.MaintainMetrics()
+		}
 	}
 }
@@ -13,10 +13,12 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/alchemillahq/sylve/internal/config"
+	"github.com/alchemillahq/sylve/internal/db/models"
 	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
 	networkModels "github.com/alchemillahq/sylve/internal/db/models/network"
 	utilitiesModels "github.com/alchemillahq/sylve/internal/db/models/utilities"
@@ -704,6 +706,15 @@ func (s *Service) DeleteJail(ctId uint, deleteMacs bool) error {
 		}
 	}
 
+	if err := sdb.DeleteMetrics(s.DB, "jail", []string{strconv.FormatUint(uint64(jail.ID), 10)}); err != nil {
+		return fmt.Errorf("failed_to_delete_jail_stats: %w", err)
+	}
+
+	if err := s.DB.Where("guest_type = ? AND guest_id = ?", "jail", jail.CTID).
+		Delete(&models.GuestBootConfig{}).Error; err != nil {
+		return fmt.Errorf("failed_to_delete_boot_config: %w", err)
+	}
+
 	if err := s.DB.Delete(&jail).Error; err != nil {
 		return fmt.Errorf("failed_to_delete_jail: %w", err)
 	}
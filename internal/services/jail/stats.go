@@ -15,7 +15,9 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/alchemillahq/sylve/internal/db"
 	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
 	jailServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/jail"
 	"github.com/alchemillahq/sylve/pkg/utils"
@@ -158,10 +160,8 @@ func (s *Service) StoreJailUsage() error {
 		return fmt.Errorf("failed_to_load_jails: %w", err)
 	}
 
-	jDBIDs := make([]uint, 0, len(jails))
-
 	if len(jails) == 0 {
-		return s.PruneOrphanedJailStats(jDBIDs)
+		return nil
 	}
 
 	states, err := s.GetStates()
@@ -184,6 +184,8 @@ func (s *Service) StoreJailUsage() error {
 		}
 	}
 
+	now := time.Now()
+
 	for _, j := range jails {
 		live, ok := stateByCTID[j.CTID]
 		if !ok || !live.Active {
@@ -209,66 +211,20 @@ func (s *Service) StoreJailUsage() error {
 			memPct = math.Round((float64(live.MemBytesUsed)/float64(sysRAM))*10000.0) / 100.0
 		}
 
-		stat := &jailModels.JailStats{
-			CTID:        int(j.ID),
-			CPUUsage:    cpuPct,
-			MemoryUsage: memPct,
-		}
-		if err := s.DB.Save(stat).Error; err != nil {
+		if err := db.RecordMetrics(s.DB, "jail", strconv.FormatUint(uint64(j.ID), 10), now, map[string]float64{
+			"cpuUsage":    cpuPct,
+			"memoryUsage": memPct,
+		}); err != nil {
 			continue
 		}
 	}
 
-	for _, j := range jails {
-		jDBIDs = append(jDBIDs, j.ID)
-	}
-
-	for _, dbID := range jDBIDs {
-		var stats []jailModels.JailStats
-		if err := s.DB.
-			Where("ct_id = ?", dbID).
-			Order("id DESC").
-			Limit(256).
-			Find(&stats).Error; err != nil {
-			return fmt.Errorf("failed_to_get_jail_stats: %w", err)
-		}
-		if len(stats) < 256 {
-			continue
-		}
-		cutoff := stats[len(stats)-1].ID
-		if err := s.DB.
-			Where("ct_id = ? AND id < ?", dbID, cutoff).
-			Delete(&jailModels.JailStats{}).Error; err != nil {
-			return fmt.Errorf("failed_to_delete_old_jail_stats: %w", err)
-		}
-	}
-
-	if err := s.PruneOrphanedJailStats(jDBIDs); err != nil {
-		return err
-	}
-
 	return nil
 }
 
-func (s *Service) PruneOrphanedJailStats(validJailDBIDs []uint) error {
-	if len(validJailDBIDs) == 0 {
-		return s.DB.Where("1 = 1").Delete(&jailModels.JailStats{}).Error
-	}
-
-	valid := make([]int, len(validJailDBIDs))
-	for i, id := range validJailDBIDs {
-		valid[i] = int(id)
-	}
-
-	if err := s.DB.
-		Where("ct_id NOT IN ?", valid).
-		Delete(&jailModels.JailStats{}).Error; err != nil {
-		return fmt.Errorf("failed_to_prune_orphaned_jail_stats: %w", err)
-	}
-	return nil
-}
-
-func (s *Service) GetJailUsage(ctId int, limit int) ([]jailModels.JailStats, error) {
+// GetJailUsage returns the usage of a jail over the range, trimmed to the
+// last limit points when limit is set.
+func (s *Service) GetJailUsage(ctId int, limit int, r db.MetricRange) ([]db.MetricPoint, error) {
 	var jailDbId uint
 	if err := s.DB.Model(&jailModels.Jail{}).
 		Where("ct_id = ?", ctId).
@@ -281,18 +237,18 @@ func (s *Service) GetJailUsage(ctId int, limit int) ([]jailModels.JailStats, err
 		return nil, fmt.Errorf("jail_not_found")
 	}
 
-	var jailStats []jailModels.JailStats
-	sub := s.DB.
-		Model(&jailModels.JailStats{}).
-		Where("ct_id = ?", jailDbId).
-		Order("id DESC").
-		Limit(limit)
-
-	if err := s.DB.Table("(?) as sub", sub).
-		Order("id ASC").
-		Find(&jailStats).Error; err != nil {
+	points, err := db.QueryMetrics(s.DB, "jail", strconv.FormatUint(uint64(jailDbId), 10), r)
+	if err != nil {
 		return nil, fmt.Errorf("failed_to_get_jail_usage: %w", err)
 	}
 
-	return jailStats, nil
+	if limit > 0 && len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+
+	for i := range points {
+		points[i].Labels = map[string]any{"id": i + 1, "ctId": jailDbId}
+	}
+
+	return points, nil
 }
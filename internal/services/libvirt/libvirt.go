@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"sync"
 
+	serviceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services"
 	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
 	"github.com/alchemillahq/sylve/internal/logger"
 
@@ -24,12 +25,22 @@ var _ libvirtServiceInterfaces.LibvirtServiceInterface = (*Service)(nil)
 type Service struct {
 	DB   *gorm.DB
 	Conn *libvirt.Libvirt
+	Auth serviceInterfaces.AuthServiceInterface
 
 	actionMutex sync.Mutex
 	crudMutex   sync.Mutex
+
+	migrationMutex sync.Mutex
+	migrations     map[uint]*libvirtServiceInterfaces.MigrationProgress
+
+	importMutex sync.Mutex
+	imports     map[string]*libvirtServiceInterfaces.VMImportProgress
+
+	consoleMutex sync.Mutex
+	consoles     map[int]*vmConsole
 }
 
-func NewLibvirtService(db *gorm.DB) libvirtServiceInterfaces.LibvirtServiceInterface {
+func NewLibvirtService(db *gorm.DB, auth serviceInterfaces.AuthServiceInterface) libvirtServiceInterfaces.LibvirtServiceInterface {
 	uri, _ := url.Parse("bhyve:///system")
 	l, err := libvirt.ConnectToURI(uri)
 	if err != nil {
@@ -45,8 +56,12 @@ func NewLibvirtService(db *gorm.DB) libvirtServiceInterfaces.LibvirtServiceInter
 	logger.L.Info().Msgf("Libvirt version: %d", v)
 
 	return &Service{
-		DB:   db,
-		Conn: l,
+		DB:         db,
+		Conn:       l,
+		Auth:       auth,
+		migrations: make(map[uint]*libvirtServiceInterfaces.MigrationProgress),
+		imports:    make(map[string]*libvirtServiceInterfaces.VMImportProgress),
+		consoles:   make(map[int]*vmConsole),
 	}
 }
 
@@ -324,6 +324,10 @@ func (s *Service) ModifyCPU(
 		return err
 	}
 
+	if vm.Template {
+		return fmt.Errorf("vm_is_template")
+	}
+
 	shutoff, err := s.IsDomainShutOff(vm.VmID)
 
 	if err != nil {
@@ -391,6 +395,10 @@ func (s *Service) ModifyRAM(vmId int, ram int) error {
 		return err
 	}
 
+	if vm.Template {
+		return fmt.Errorf("vm_is_template")
+	}
+
 	shutoff, err := s.IsDomainShutOff(vm.VmID)
 
 	if err != nil {
@@ -446,6 +454,10 @@ func (s *Service) ModifyVNC(vmId int, vncPort int, vncResolution string, vncPass
 		return err
 	}
 
+	if vm.Template {
+		return fmt.Errorf("vm_is_template")
+	}
+
 	shutoff, err := s.IsDomainShutOff(vm.VmID)
 
 	if err != nil {
@@ -508,6 +520,10 @@ func (s *Service) ModifyPassthrough(vmId int, pciDevices []int) error {
 		return err
 	}
 
+	if vm.Template {
+		return fmt.Errorf("vm_is_template")
+	}
+
 	shutoff, err := s.IsDomainShutOff(vm.VmID)
 
 	if err != nil {
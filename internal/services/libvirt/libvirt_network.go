@@ -22,6 +22,10 @@ import (
 )
 
 func (s *Service) NetworkDetach(vmId int, networkId int) error {
+	if err := s.ensureNotTemplate(vmId); err != nil {
+		return err
+	}
+
 	inactive, err := s.IsDomainInactive(vmId)
 	if err != nil {
 		return fmt.Errorf("failed_to_check_vm_inactive: %w", err)
@@ -77,7 +81,7 @@ func (s *Service) NetworkDetach(vmId int, networkId int) error {
 		if err := s.DB.Delete(&network).Error; err != nil {
 			return fmt.Errorf("failed_to_delete_network_record: %w", err)
 		}
-		return nil
+		return s.RefreshCloudInitSeed(vmId)
 	}
 
 	newXML, err := doc.WriteToString()
@@ -102,10 +106,14 @@ func (s *Service) NetworkDetach(vmId int, networkId int) error {
 		return fmt.Errorf("failed_to_delete_network_record: %w", err)
 	}
 
-	return nil
+	return s.RefreshCloudInitSeed(vmId)
 }
 
 func (s *Service) NetworkAttach(vmId int, switchId int, emulation string, macObjId uint) error {
+	if err := s.ensureNotTemplate(vmId); err != nil {
+		return err
+	}
+
 	inactive, err := s.IsDomainInactive(vmId)
 	if err != nil {
 		return fmt.Errorf("failed_to_check_vm_inactive: %w", err)
@@ -293,7 +301,7 @@ func (s *Service) NetworkAttach(vmId int, switchId int, emulation string, macObj
 		return fmt.Errorf("failed_to_define_domain_with_modified_xml: %w", err)
 	}
 
-	return nil
+	return s.RefreshCloudInitSeed(vmId)
 }
 
 func (s *Service) FindAndChangeMAC(vmId int, oldMac string, newMac string) error {
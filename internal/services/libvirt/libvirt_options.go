@@ -3,6 +3,10 @@ package libvirt
 import vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
 
 func (s *Service) ModifyWakeOnLan(vmId int, enabled bool) error {
+	if err := s.ensureNotTemplate(vmId); err != nil {
+		return err
+	}
+
 	err := s.DB.
 		Model(&vmModels.VM{}).
 		Where("vm_id = ?", vmId).
@@ -11,6 +15,10 @@ func (s *Service) ModifyWakeOnLan(vmId int, enabled bool) error {
 }
 
 func (s *Service) ModifyBootOrder(vmId int, startAtBoot bool, bootOrder int) error {
+	if err := s.ensureNotTemplate(vmId); err != nil {
+		return err
+	}
+
 	err := s.DB.
 		Model(&vmModels.VM{}).
 		Where("vm_id = ?", vmId).
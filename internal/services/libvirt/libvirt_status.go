@@ -15,26 +15,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alchemillahq/sylve/internal/db"
 	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
 	systemServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/system"
 	"github.com/alchemillahq/sylve/pkg/utils"
 )
 
-func (s *Service) PruneOrphanedVMStats() error {
-	if err := s.DB.
-		Where(
-			"vm_id NOT IN (?)",
-			s.DB.
-				Model(&vmModels.VM{}).
-				Select("id"),
-		).
-		Delete(&vmModels.VMStats{}).
-		Error; err != nil {
-		return fmt.Errorf("failed to prune orphaned VMStats: %w", err)
-	}
-	return nil
-}
-
 func (s *Service) StoreVMUsage() error {
 	if s.crudMutex.TryLock() == false {
 		return nil
@@ -107,52 +93,21 @@ func (s *Service) StoreVMUsage() error {
 			return fmt.Errorf("failed_to_get_actual_vm_id: %w", err)
 		}
 
-		vmStats := &vmModels.VMStats{
-			VMID:        uint(vmDbId),
-			CPUUsage:    cpuUsage,
-			MemoryUsage: memUsagePercent,
-			MemoryUsed:  usedMemMB,
-		}
-
-		if err := s.DB.Save(vmStats).Error; err != nil {
+		if err := db.RecordMetrics(s.DB, "vm", strconv.FormatUint(uint64(vmDbId), 10), time.Now(), map[string]float64{
+			"cpuUsage":    cpuUsage,
+			"memoryUsage": memUsagePercent,
+			"memoryUsed":  usedMemMB,
+		}); err != nil {
 			continue
 		}
 	}
 
-	var vmIdsToKeep []int
-	if err := s.DB.Model(&vmModels.VMStats{}).
-		Select("DISTINCT vm_id").
-		Pluck("vm_id", &vmIdsToKeep).Error; err != nil {
-		return fmt.Errorf("failed_to_get_vm_ids_to_keep: %w", err)
-	}
-
-	for _, vmId := range vmIdsToKeep {
-		var vmStats []vmModels.VMStats
-		if err := s.DB.Where("vm_id = ?", vmId).
-			Order("id DESC").
-			Limit(256).
-			Find(&vmStats).Error; err != nil {
-			return fmt.Errorf("failed_to_get_vm_stats: %w", err)
-		}
-
-		if len(vmStats) < 256 {
-			continue
-		}
-
-		if err := s.DB.Where("vm_id = ? AND id < ?", vmId, vmStats[255].ID).
-			Delete(&vmModels.VMStats{}).Error; err != nil {
-			return fmt.Errorf("failed_to_delete_old_vm_stats: %w", err)
-		}
-	}
-
-	if err := s.PruneOrphanedVMStats(); err != nil {
-		return err
-	}
-
 	return nil
 }
 
-func (s *Service) GetVMUsage(vmId int, limit int) ([]vmModels.VMStats, error) {
+// GetVMUsage returns the usage of a VM over the range, trimmed to the last
+// limit points when limit is set.
+func (s *Service) GetVMUsage(vmId int, limit int, r db.MetricRange) ([]db.MetricPoint, error) {
 	var vmDbId uint
 	if err := s.DB.Model(&vmModels.VM{}).
 		Where("vm_id = ?", vmId).
@@ -165,22 +120,18 @@ func (s *Service) GetVMUsage(vmId int, limit int) ([]vmModels.VMStats, error) {
 		return nil, fmt.Errorf("vm_not_found")
 	}
 
-	var vmStats []vmModels.VMStats
-	sub := s.DB.
-		Model(&vmModels.VMStats{}).
-		Where("vm_id = ?", vmDbId).
-		Order("id DESC").
-		Limit(limit)
-
-	if err := s.DB.Table("(?) as sub", sub).
-		Order("id ASC").
-		Find(&vmStats).Error; err != nil {
+	points, err := db.QueryMetrics(s.DB, "vm", strconv.FormatUint(uint64(vmDbId), 10), r)
+	if err != nil {
 		return nil, fmt.Errorf("failed_to_get_vm_usage: %w", err)
 	}
 
-	if len(vmStats) == 0 {
-		return vmStats, nil
+	if limit > 0 && len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+
+	for i := range points {
+		points[i].Labels = map[string]any{"vmId": vmDbId}
 	}
 
-	return vmStats, nil
+	return points, nil
 }
@@ -83,6 +83,10 @@ func (s *Service) CreateDiskImage(vmId int, guid string, size int64, name string
 }
 
 func (s *Service) StorageDetach(vmId int, storageId int) error {
+	if err := s.ensureNotTemplate(vmId); err != nil {
+		return err
+	}
+
 	var storage vmModels.Storage
 
 	if err := s.DB.Find(&storage, "id = ?", storageId).Error; err != nil {
@@ -204,6 +208,10 @@ func (s *Service) StorageDetach(vmId int, storageId int) error {
 }
 
 func (s *Service) StorageAttach(vmId int, sType string, dataset string, emulation string, size int64, name string) error {
+	if err := s.ensureNotTemplate(vmId); err != nil {
+		return err
+	}
+
 	domain, err := s.Conn.DomainLookupByName(strconv.Itoa(vmId))
 	if err != nil {
 		return fmt.Errorf("failed_to_lookup_domain_by_name: %w", err)
@@ -454,3 +462,81 @@ func (s *Service) StorageAttach(vmId int, sType string, dataset string, emulatio
 
 	return nil
 }
+
+// RewriteStoragePaths updates the disk paths in a VM's bhyve command line
+// after the datasets backing them were renamed. paths maps an old zvol device
+// or mountpoint to its new location.
+func (s *Service) RewriteStoragePaths(vmId int, paths map[string]string) error {
+	domain, err := s.Conn.DomainLookupByName(strconv.Itoa(vmId))
+	if err != nil {
+		return fmt.Errorf("failed_to_lookup_domain_by_name: %w", err)
+	}
+
+	state, _, err := s.Conn.DomainGetState(domain, 0)
+	if err != nil {
+		return fmt.Errorf("failed_to_get_domain_state: %w", err)
+	}
+	if state != 5 {
+		return fmt.Errorf("domain_state_not_shutoff: %d", vmId)
+	}
+
+	xml, err := s.Conn.DomainGetXMLDesc(domain, 0)
+	if err != nil {
+		return fmt.Errorf("failed_to_get_domain_xml_desc: %w", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(xml); err != nil {
+		return fmt.Errorf("failed_to_parse_xml: %w", err)
+	}
+
+	bhyveCommandline := doc.FindElement("//commandline")
+	if bhyveCommandline == nil {
+		return nil
+	}
+
+	changed := false
+
+	for _, arg := range bhyveCommandline.ChildElements() {
+		valAttr := arg.SelectAttr("value")
+		if valAttr == nil || !strings.HasPrefix(valAttr.Value, "-s ") {
+			continue
+		}
+
+		parts := strings.Split(valAttr.Value, ",")
+		for i, part := range parts {
+			for oldPath, newPath := range paths {
+				if part == oldPath {
+					parts[i] = newPath
+				} else if strings.HasPrefix(part, oldPath+"/") {
+					parts[i] = newPath + strings.TrimPrefix(part, oldPath)
+				} else {
+					continue
+				}
+
+				changed = true
+				break
+			}
+		}
+
+		valAttr.Value = strings.Join(parts, ",")
+	}
+
+	if !changed {
+		return nil
+	}
+
+	out, err := doc.WriteToString()
+	if err != nil {
+		return fmt.Errorf("failed_to_serialize_xml: %w", err)
+	}
+
+	if err := s.Conn.DomainUndefineFlags(domain, 0); err != nil {
+		return fmt.Errorf("failed_to_undefine_domain: %w", err)
+	}
+	if _, err := s.Conn.DomainDefineXML(out); err != nil {
+		return fmt.Errorf("failed_to_define_domain_with_modified_xml: %w", err)
+	}
+
+	return nil
+}
@@ -17,6 +17,25 @@ import (
 	utilitiesModels "github.com/alchemillahq/sylve/internal/db/models/utilities"
 )
 
+var diskImageExtensions = []string{".img", ".raw", ".qcow2", ".vmdk", ".vhdx"}
+
+// isDiskImageName reports whether name looks like a disk image that can be
+// flashed, optionally compressed.
+func isDiskImageName(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".gz", ".xz", ".bz2"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	for _, ext := range diskImageExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s *Service) FindISOByUUID(uuid string, includeImg bool) (string, error) {
 	var download utilitiesModels.Downloads
 	if err := s.DB.
@@ -38,7 +57,7 @@ func (s *Service) FindISOByUUID(uuid string, includeImg bool) (string, error) {
 	case "torrent":
 		torrentsDir := config.GetDownloadsPath("torrents")
 		for _, file := range download.Files {
-			if strings.HasSuffix(file.Name, ".iso") || (includeImg && strings.HasSuffix(file.Name, ".img")) {
+			if strings.HasSuffix(file.Name, ".iso") || (includeImg && isDiskImageName(file.Name)) {
 				isoPath := fmt.Sprintf("%s/%s/%s", torrentsDir, uuid, file.Name)
 				if _, err := os.Stat(isoPath); os.IsNotExist(err) {
 					return "", fmt.Errorf("iso_not_found: %s", isoPath)
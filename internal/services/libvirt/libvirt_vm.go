@@ -56,6 +56,14 @@ func (s *Service) CreateVmXML(vm vmModels.VM, vmPath string) (string, error) {
 		},
 	}
 
+	master, slave := vmConsoleDevices(vm.VmID)
+	devices.Serials = []libvirtServiceInterfaces.Serial{
+		{
+			Type:   "nmdm",
+			Source: libvirtServiceInterfaces.SerialSource{Master: master, Slave: slave},
+		},
+	}
+
 	sIndex := 10
 	uefi := fmt.Sprintf("%s,%s/%d_vars.fd", "/usr/local/share/uefi-firmware/BHYVE_UEFI.fd", vmPath, vm.VmID)
 
@@ -166,6 +174,16 @@ func (s *Service) CreateVmXML(vm vmModels.VM, vmPath string) (string, error) {
 		}
 	}
 
+	if vm.CloudInit != nil {
+		bhyveArgs = append(bhyveArgs, []libvirtServiceInterfaces.BhyveArg{
+			{
+				Value: fmt.Sprintf("-s %d:0,ahci-cd,%s", sIndex, cloudInitSeedPath(vmPath, vm.VmID)),
+			},
+		})
+
+		sIndex++
+	}
+
 	var interfaces []libvirtServiceInterfaces.Interface
 
 	if vm.Networks != nil && len(vm.Networks) > 0 {
@@ -313,11 +331,17 @@ func (s *Service) CreateVmXML(vm vmModels.VM, vmPath string) (string, error) {
 }
 
 func (s *Service) CreateLvVm(id int) error {
+	return s.createLvVm(id, true)
+}
+
+// createLvVm sets up the VM directory and defines the domain. Raw disk images
+// are only created when createDisks is set, clones bring their own.
+func (s *Service) createLvVm(id int, createDisks bool) error {
 	s.crudMutex.Lock()
 	defer s.crudMutex.Unlock()
 
 	var vm vmModels.VM
-	if err := s.DB.Preload("Storages").Preload("Networks.Switch").First(&vm, id).Error; err != nil {
+	if err := preloadCloudInit(s.DB).Preload("Storages").First(&vm, id).Error; err != nil {
 		return fmt.Errorf("failed_to_find_vm: %w", err)
 	}
 
@@ -348,7 +372,7 @@ func (s *Service) CreateLvVm(id int) error {
 		return fmt.Errorf("failed to copy UEFI vars file: %w", err)
 	}
 
-	if vm.Storages != nil && len(vm.Storages) > 0 {
+	if createDisks && vm.Storages != nil && len(vm.Storages) > 0 {
 		for _, storage := range vm.Storages {
 			if storage.Type == "raw" {
 				err = s.CreateDiskImage(vm.VmID, storage.Dataset, storage.Size, "")
@@ -359,6 +383,12 @@ func (s *Service) CreateLvVm(id int) error {
 		}
 	}
 
+	if vm.CloudInit != nil {
+		if err := writeCloudInitSeed(vm, vmPath); err != nil {
+			return fmt.Errorf("failed to write cloud-init seed: %w", err)
+		}
+	}
+
 	generated, err := s.CreateVmXML(vm, vmPath)
 	if err != nil {
 		return fmt.Errorf("failed to generate VM XML: %w", err)
@@ -402,6 +432,8 @@ func (s *Service) RemoveLvVm(vmId int) error {
 		return fmt.Errorf("failed to stop TPM for VM %d: %w", vmId, err)
 	}
 
+	s.closeConsole(vmId)
+
 	vmPath := filepath.Join(vmDir, strconv.Itoa(vmId))
 	if _, err := os.Stat(vmPath); err == nil {
 		if err := os.RemoveAll(vmPath); err != nil {
@@ -616,6 +648,10 @@ func (s *Service) LvVMAction(vm vmModels.VM, action string) error {
 	s.actionMutex.Lock()
 	defer s.actionMutex.Unlock()
 
+	if vm.Template && (action == "start" || action == "reboot") {
+		return fmt.Errorf("vm_is_template")
+	}
+
 	domain, err := s.Conn.DomainLookupByName(strconv.Itoa(vm.VmID))
 	if err != nil {
 		return fmt.Errorf("failed_to_lookup_domain: %w", err)
@@ -643,6 +679,10 @@ func (s *Service) LvVMAction(vm vmModels.VM, action string) error {
 			return fmt.Errorf("failed_to_start_tpm: %w", err)
 		}
 
+		if err := s.ensureConsoleDevice(domain, vm.VmID); err != nil {
+			return err
+		}
+
 		if err := s.Conn.DomainCreate(domain); err != nil {
 			return fmt.Errorf("failed_to_start_domain: %w", err)
 		}
@@ -663,6 +703,10 @@ func (s *Service) LvVMAction(vm vmModels.VM, action string) error {
 			return fmt.Errorf("failed_to_set_start_date: %w", err)
 		}
 
+		if _, err := s.openConsole(vm.VmID); err != nil {
+			logger.L.Warn().Err(err).Msgf("Failed to capture serial console of VM %d", vm.VmID)
+		}
+
 	case "stop":
 		shutdown := false
 		if err := s.Conn.DomainShutdown(domain); err == nil {
@@ -720,6 +764,58 @@ func (s *Service) LvVMAction(vm vmModels.VM, action string) error {
 	return nil
 }
 
+// ShutdownVM asks a VM to shut down through ACPI and powers it off if it is
+// still running after timeout. It reports whether the VM had to be powered
+// off. Unlike LvVMAction it does not serialize with other actions, so many
+// VMs can be shut down at once.
+func (s *Service) ShutdownVM(vmId int, timeout time.Duration) (bool, error) {
+	vm, err := s.GetVmByVmId(vmId)
+	if err != nil {
+		return false, err
+	}
+
+	domain, err := s.Conn.DomainLookupByName(strconv.Itoa(vmId))
+	if err != nil {
+		return false, fmt.Errorf("failed_to_lookup_domain: %w", err)
+	}
+
+	state, _, err := s.Conn.DomainGetState(domain, 0)
+	if err != nil {
+		return false, fmt.Errorf("could_not_get_state: %w", err)
+	}
+
+	if state == 5 {
+		return false, nil
+	}
+
+	if err := s.Conn.DomainShutdown(domain); err != nil {
+		logger.L.Warn().Err(err).Msgf("ACPI shutdown of VM %d failed", vmId)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+
+		if state, _, err = s.Conn.DomainGetState(domain, 0); err == nil && state == 5 {
+			break
+		}
+	}
+
+	poweredOff := false
+	if state != 5 {
+		if err := s.Conn.DomainDestroy(domain); err != nil {
+			return false, fmt.Errorf("failed_to_stop_domain: %w", err)
+		}
+		poweredOff = true
+	}
+
+	if err := s.SetActionDate(vm, "stop"); err != nil {
+		return poweredOff, fmt.Errorf("failed_to_set_stop_date: %w", err)
+	}
+
+	return poweredOff, nil
+}
+
 func (s *Service) SetActionDate(vm vmModels.VM, action string) error {
 	now := time.Now().UTC()
 
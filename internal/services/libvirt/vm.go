@@ -11,6 +11,7 @@ package libvirt
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/alchemillahq/sylve/internal/db/models"
@@ -30,7 +31,7 @@ import (
 
 func (s *Service) ListVMs() ([]vmModels.VM, error) {
 	var vms []vmModels.VM
-	if err := s.DB.Preload("Networks").Preload("Storages").Find(&vms).Error; err != nil {
+	if err := s.DB.Preload("Networks").Preload("Storages").Where("template = ?", false).Find(&vms).Error; err != nil {
 		return nil, fmt.Errorf("failed_to_list_vms: %w", err)
 	}
 
@@ -50,11 +51,23 @@ func (s *Service) ListVMs() ([]vmModels.VM, error) {
 	return vms, nil
 }
 
+func (s *Service) findVM(id uint) (vmModels.VM, error) {
+	var vm vmModels.VM
+	if err := s.DB.Preload("Storages").Preload("Networks").Preload("CloudInit").First(&vm, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return vm, fmt.Errorf("vm_not_found: %d", id)
+		}
+		return vm, fmt.Errorf("failed_to_find_vm: %w", err)
+	}
+
+	return vm, nil
+}
+
 func (s *Service) SimpleListVM() ([]libvirtServiceInterfaces.SimpleList, error) {
 	var vms []vmModels.VM
 	var list []libvirtServiceInterfaces.SimpleList
 
-	if err := s.DB.Find(&vms).Error; err != nil {
+	if err := s.DB.Where("template = ?", false).Find(&vms).Error; err != nil {
 		return nil, fmt.Errorf("failed_to_list_vms: %w", err)
 	}
 
@@ -346,6 +359,55 @@ func validateCreate(data libvirtServiceInterfaces.CreateVMRequest, db *gorm.DB)
 	return nil
 }
 
+// createMacObject creates a MAC object holding a random address. It is named
+// base, with a numeric suffix when that name is already taken.
+func (s *Service) createMacObject(base string) (uint, error) {
+	return s.createMacObjectWithAddress(base, utils.GenerateRandomMAC())
+}
+
+// createMacObjectWithAddress creates a Mac object holding macAddress, named
+// base or base with the first free numeric suffix.
+func (s *Service) createMacObjectWithAddress(base string, macAddress string) (uint, error) {
+	name := base
+
+	for i := 0; ; i++ {
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		var exists int64
+		if err := s.DB.
+			Model(&networkModels.Object{}).
+			Where("name = ?", name).
+			Limit(1).
+			Count(&exists).Error; err != nil {
+			return 0, fmt.Errorf("failed_to_check_mac_object_exists: %w", err)
+		}
+		if exists == 0 {
+			break
+		}
+	}
+
+	macObj := networkModels.Object{
+		Type: "Mac",
+		Name: name,
+	}
+
+	if err := s.DB.Create(&macObj).Error; err != nil {
+		return 0, fmt.Errorf("failed_to_create_mac_object: %w", err)
+	}
+
+	macEntry := networkModels.ObjectEntry{
+		ObjectID: macObj.ID,
+		Value:    macAddress,
+	}
+
+	if err := s.DB.Create(&macEntry).Error; err != nil {
+		return 0, fmt.Errorf("failed_to_create_mac_entry: %w", err)
+	}
+
+	return macObj.ID, nil
+}
+
 func (s *Service) CreateVM(data libvirtServiceInterfaces.CreateVMRequest) error {
 	if err := validateCreate(data, s.DB); err != nil {
 		logger.L.Debug().Err(err).Msg("create_vm: validation failed")
@@ -389,46 +451,12 @@ func (s *Service) CreateVM(data libvirtServiceInterfaces.CreateVMRequest) error
 		}
 
 		if macId == 0 {
-			base := fmt.Sprintf("%s-%s", data.Name, sw.Name)
-			name := base
-
-			for i := 0; ; i++ {
-				if i > 0 {
-					name = fmt.Sprintf("%s-%d", base, i)
-				}
-				var exists int64
-				if err := s.DB.
-					Model(&networkModels.Object{}).
-					Where("name = ?", name).
-					Limit(1).
-					Count(&exists).Error; err != nil {
-					return fmt.Errorf("failed_to_check_mac_object_exists: %w", err)
-				}
-				if exists == 0 {
-					break
-				}
-			}
-
-			macAddress := utils.GenerateRandomMAC()
-			macObj := networkModels.Object{
-				Type: "Mac",
-				Name: name,
-			}
-
-			if err := s.DB.Create(&macObj).Error; err != nil {
-				return fmt.Errorf("failed_to_create_mac_object: %w", err)
-			}
-
-			macEntry := networkModels.ObjectEntry{
-				ObjectID: macObj.ID,
-				Value:    macAddress,
-			}
-
-			if err := s.DB.Create(&macEntry).Error; err != nil {
-				return fmt.Errorf("failed_to_create_mac_entry: %w", err)
+			id, err := s.createMacObject(fmt.Sprintf("%s-%s", data.Name, sw.Name))
+			if err != nil {
+				return err
 			}
 
-			macId = macObj.ID
+			macId = id
 		}
 
 		networks = append(networks, vmModels.Network{
@@ -530,13 +558,17 @@ func (s *Service) CreateVM(data libvirtServiceInterfaces.CreateVMRequest) error
 
 func (s *Service) RemoveVM(id uint, cleanUpMacs bool) error {
 	var vm vmModels.VM
-	if err := s.DB.Preload("Stats").Preload("Networks").Preload("Storages").First(&vm, "id = ?", id).Error; err != nil {
+	if err := s.DB.Preload("Networks").Preload("Storages").First(&vm, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("vm_not_found: %d", id)
 		}
 		return fmt.Errorf("failed_to_find_vm: %w", err)
 	}
 
+	if err := s.destroyVMSnapshotDatasets(vm.ID); err != nil {
+		return err
+	}
+
 	filesystems, err := zfs.Filesystems("")
 
 	if err != nil {
@@ -591,10 +623,21 @@ func (s *Service) RemoveVM(id uint, cleanUpMacs bool) error {
 		}
 	}
 
-	for _, stat := range vm.Stats {
-		if err := s.DB.Delete(&stat).Error; err != nil {
-			return fmt.Errorf("failed_to_delete_vm_stat: %w", err)
-		}
+	if err := s.DB.Where("vm_id = ?", vm.ID).Delete(&vmModels.CloudInit{}).Error; err != nil {
+		return fmt.Errorf("failed_to_delete_cloud_init: %w", err)
+	}
+
+	if err := s.DB.Where("vm_id = ?", vm.ID).Delete(&vmModels.Snapshot{}).Error; err != nil {
+		return fmt.Errorf("failed_to_delete_snapshots: %w", err)
+	}
+
+	if err := s.DB.Where("guest_type = ? AND guest_id = ?", "vm", vm.VmID).
+		Delete(&models.GuestBootConfig{}).Error; err != nil {
+		return fmt.Errorf("failed_to_delete_boot_config: %w", err)
+	}
+
+	if err := sdb.DeleteMetrics(s.DB, "vm", []string{strconv.FormatUint(uint64(vm.ID), 10)}); err != nil {
+		return fmt.Errorf("failed_to_delete_vm_stat: %w", err)
 	}
 
 	if err := s.DB.Delete(&vm).Error; err != nil {
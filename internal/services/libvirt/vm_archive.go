@@ -0,0 +1,763 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirt
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	networkModels "github.com/alchemillahq/sylve/internal/db/models/network"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/utils"
+	"github.com/alchemillahq/sylve/pkg/vmarchive"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	vmArchiveVersion     = 1
+	vmArchiveManifest    = "manifest.json"
+	vmArchiveUEFIVars    = "uefi_vars.fd"
+	exportSnapshotPrefix = "sylve-export"
+)
+
+// rawImageName is the name of the image file of a raw disk, without its
+// .img extension.
+func rawImageName(name string, vmId int) string {
+	if name == "" {
+		return strconv.Itoa(vmId)
+	}
+
+	return name
+}
+
+// exportDisk is a dataset written into a VM archive, either as a raw disk or
+// as a zfs stream.
+type exportDisk struct {
+	file    string
+	format  string
+	dataset *zfs.Dataset
+	storage vmModels.Storage
+}
+
+// ExportVM writes a shut off VM into an archive at path: a manifest
+// describing the VM, its UEFI variables and its disks. Raw disks are stored
+// sparse, zfs streams keep everything in the datasets and are compressed
+// with zstd. The archive is written next to path and only renamed into place
+// once complete.
+func (s *Service) ExportVM(id uint, req libvirtServiceInterfaces.ExportVMRequest) error {
+	if !filepath.IsAbs(req.Path) {
+		return fmt.Errorf("archive_path_must_be_absolute")
+	}
+
+	if _, err := os.Stat(req.Path); err == nil {
+		return fmt.Errorf("archive_already_exists")
+	}
+
+	format := req.DiskFormat
+	if format == "" {
+		format = "raw"
+	}
+
+	var vm vmModels.VM
+	if err := preloadCloudInit(s.DB).Preload("Storages").First(&vm, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("vm_not_found: %d", id)
+		}
+		return fmt.Errorf("failed_to_find_vm: %w", err)
+	}
+
+	inactive, err := s.IsDomainInactive(vm.VmID)
+	if err != nil {
+		return err
+	}
+
+	if !inactive {
+		return fmt.Errorf("domain_state_not_shutoff: %d", vm.VmID)
+	}
+
+	byGUID, err := datasetsByGUID()
+	if err != nil {
+		return err
+	}
+
+	manifest := libvirtServiceInterfaces.VMArchiveManifest{
+		Version:   vmArchiveVersion,
+		CreatedAt: time.Now(),
+		VM:        portableVM(vm),
+		Networks:  portableNetworks(vm),
+	}
+
+	var disks []exportDisk
+	streams := make(map[string]string)
+
+	for i, storage := range vm.Storages {
+		disk := libvirtServiceInterfaces.VMArchiveDisk{
+			Name:      storage.Name,
+			Type:      storage.Type,
+			Size:      storage.Size,
+			Emulation: storage.Emulation,
+		}
+
+		if storage.Type == "iso" {
+			disk.ISO = storage.Dataset
+			manifest.Disks = append(manifest.Disks, disk)
+			continue
+		}
+
+		dataset, ok := byGUID[storage.Dataset]
+		if !ok {
+			return fmt.Errorf("dataset_not_found: %s", storage.Dataset)
+		}
+
+		disk.Format = format
+
+		// A zfs stream carries the whole dataset, raw disks sharing one are
+		// sent once.
+		if format == "zfs" {
+			file, ok := streams[dataset.GUID]
+			if !ok {
+				file = fmt.Sprintf("disks/%d.zfs.zst", i)
+				streams[dataset.GUID] = file
+				disks = append(disks, exportDisk{file: file, format: format, dataset: dataset, storage: storage})
+			}
+			disk.File = file
+		} else {
+			disk.File = fmt.Sprintf("disks/%d.raw", i)
+			disks = append(disks, exportDisk{file: disk.File, format: format, dataset: dataset, storage: storage})
+		}
+
+		manifest.Disks = append(manifest.Disks, disk)
+	}
+
+	varsPath, err := vmUEFIVarsPath(vm.VmID)
+	if err != nil {
+		return err
+	}
+
+	vars, err := os.ReadFile(varsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed_to_read_uefi_vars: %w", err)
+	}
+
+	if len(vars) > 0 {
+		manifest.UEFIVars = vmArchiveUEFIVars
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed_to_encode_manifest: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(req.Path), ".sylve-export-*")
+	if err != nil {
+		return fmt.Errorf("failed_to_create_archive: %w", err)
+	}
+
+	if err := writeVMArchive(tmp, data, vars, disks, vm.VmID); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed_to_write_archive: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), req.Path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed_to_move_archive: %w", err)
+	}
+
+	return nil
+}
+
+func writeVMArchive(f *os.File, manifest []byte, vars []byte, disks []exportDisk, vmId int) error {
+	bw := bufio.NewWriterSize(f, 1<<20)
+	w := vmarchive.NewWriter(bw)
+
+	if err := w.WriteFile(vmArchiveManifest, manifest); err != nil {
+		return fmt.Errorf("failed_to_write_archive: %w", err)
+	}
+
+	if len(vars) > 0 {
+		if err := w.WriteFile(vmArchiveUEFIVars, vars); err != nil {
+			return fmt.Errorf("failed_to_write_archive: %w", err)
+		}
+	}
+
+	for _, d := range disks {
+		var err error
+		if d.format == "zfs" {
+			err = writeZFSDisk(w, d, filepath.Dir(f.Name()))
+		} else {
+			err = writeRawDisk(w, d, vmId)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed_to_write_archive: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed_to_write_archive: %w", err)
+	}
+
+	return nil
+}
+
+func writeRawDisk(w *vmarchive.Writer, d exportDisk, vmId int) error {
+	path := filepath.Join("/dev/zvol", d.dataset.Name)
+	size := int64(d.dataset.Volsize)
+
+	if d.storage.Type == "raw" {
+		path = filepath.Join(d.dataset.Mountpoint, rawImageName(d.storage.Name, vmId)+".img")
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("image_file_not_found: %s", path)
+		}
+		size = info.Size()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed_to_open_disk: %w", err)
+	}
+	defer f.Close()
+
+	if err := w.WriteSparse(d.file, f, size); err != nil {
+		return fmt.Errorf("failed_to_write_disk: %w", err)
+	}
+
+	return nil
+}
+
+// writeZFSDisk sends a snapshot of the dataset through zstd into a scratch
+// file in dir first, tar needs the size of an entry before its contents.
+func writeZFSDisk(w *vmarchive.Writer, d exportDisk, dir string) error {
+	snapshot, err := d.dataset.Snapshot(fmt.Sprintf("%s-%d", exportSnapshotPrefix, time.Now().Unix()), false)
+	if err != nil {
+		return fmt.Errorf("failed_to_snapshot_dataset: %w", err)
+	}
+	defer snapshot.Destroy(zfs.DestroyDefault)
+
+	tmp, err := os.CreateTemp(dir, ".sylve-export-*.zst")
+	if err != nil {
+		return fmt.Errorf("failed_to_create_scratch_file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(snapshot.Send(zfs.SendOptions{}, pw))
+	}()
+
+	err = vmarchive.Compress(tmp, pr)
+	pr.Close()
+	if err != nil {
+		return fmt.Errorf("failed_to_send_dataset: %w", err)
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed_to_read_scratch_file: %w", err)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed_to_read_scratch_file: %w", err)
+	}
+
+	if err := w.WriteSized(d.file, tmp, size); err != nil {
+		return fmt.Errorf("failed_to_write_disk: %w", err)
+	}
+
+	return nil
+}
+
+// importArchiveDisk creates dataset name from a disk entry of an archive.
+// Raw disks go into a new volume or into imageName on a new filesystem, zfs
+// streams are received as they are.
+func importArchiveDisk(r io.Reader, size int64, disk libvirtServiceInterfaces.VMArchiveDisk, name string, imageName string) (*zfs.Dataset, error) {
+	if _, err := zfs.GetDataset(name); err == nil {
+		return nil, fmt.Errorf("dataset_already_exists: %s", name)
+	}
+
+	if disk.Format == "zfs" {
+		d, err := vmarchive.NewDecompressor(r)
+		if err != nil {
+			return nil, err
+		}
+
+		dataset, err := zfs.ReceiveSnapshot(d, name)
+		if cErr := d.Close(); err == nil {
+			err = cErr
+		}
+
+		if err != nil {
+			if partial, gErr := zfs.GetDataset(name); gErr == nil {
+				partial.Destroy(zfs.DestroyRecursive)
+			}
+			return nil, fmt.Errorf("failed_to_receive_disk: %w", err)
+		}
+
+		snapshots, err := zfs.Snapshots(name)
+		if err == nil {
+			for _, snap := range snapshots {
+				if strings.HasPrefix(snap.Name, name+"@"+exportSnapshotPrefix+"-") {
+					if err := snap.Destroy(zfs.DestroyDefault); err != nil {
+						logger.L.Warn().Err(err).Msgf("Failed to destroy snapshot %s", snap.Name)
+					}
+				}
+			}
+		}
+
+		return dataset, nil
+	}
+
+	var dataset *zfs.Dataset
+	var target *os.File
+	var err error
+
+	switch disk.Type {
+	case "zvol":
+		if dataset, err = zfs.CreateVolume(name, uint64(size), nil); err != nil {
+			return nil, fmt.Errorf("failed_to_create_volume: %w", err)
+		}
+
+		target, err = os.OpenFile(filepath.Join("/dev/zvol", name), os.O_WRONLY, 0)
+	case "raw":
+		if dataset, err = zfs.CreateFilesystem(name, nil); err != nil {
+			return nil, fmt.Errorf("failed_to_create_filesystem: %w", err)
+		}
+
+		target, err = os.OpenFile(filepath.Join(dataset.Mountpoint, imageName+".img"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			err = target.Truncate(size)
+		}
+	default:
+		return nil, fmt.Errorf("invalid_storage_type: %s", disk.Type)
+	}
+
+	if err == nil {
+		_, err = vmarchive.CopySparse(target, r)
+	}
+
+	if target != nil {
+		if cErr := target.Close(); err == nil {
+			err = cErr
+		}
+	}
+
+	if err != nil {
+		dataset.Destroy(zfs.DestroyRecursive)
+		return nil, fmt.Errorf("failed_to_write_disk: %w", err)
+	}
+
+	return dataset, nil
+}
+
+// ImportVM checks an import request and imports the archive in the
+// background, see importVM. It returns the ID to follow the import with.
+func (s *Service) ImportVM(req libvirtServiceInterfaces.ImportVMRequest) (string, error) {
+	archivePath := req.Path
+	if req.Download != "" {
+		var err error
+		if archivePath, err = s.FindISOByUUID(req.Download, true); err != nil {
+			return "", fmt.Errorf("archive_not_found: %w", err)
+		}
+	}
+
+	if !filepath.IsAbs(archivePath) {
+		return "", fmt.Errorf("archive_path_must_be_absolute")
+	}
+
+	if _, err := os.Stat(archivePath); err != nil {
+		return "", fmt.Errorf("archive_not_found: %w", err)
+	}
+
+	parent, err := zfs.GetDataset(req.Parent)
+	if err != nil || parent.Type != zfs.DatasetFilesystem {
+		return "", fmt.Errorf("parent_dataset_not_found: %s", req.Parent)
+	}
+
+	progress := &libvirtServiceInterfaces.VMImportProgress{
+		ID:        uuid.NewString(),
+		Archive:   archivePath,
+		Stage:     "reading_archive",
+		StartedAt: time.Now(),
+	}
+
+	s.importMutex.Lock()
+	s.imports[progress.ID] = progress
+	s.importMutex.Unlock()
+
+	go func() {
+		vm, err := s.importVM(archivePath, parent, req, progress)
+		if err != nil {
+			logger.L.Error().Err(err).Msgf("Failed to import VM from %s", archivePath)
+		}
+
+		s.finishImport(progress, vm, err)
+	}()
+
+	return progress.ID, nil
+}
+
+func (s *Service) setImportStage(progress *libvirtServiceInterfaces.VMImportProgress, stage string) {
+	s.importMutex.Lock()
+	defer s.importMutex.Unlock()
+
+	progress.Stage = stage
+}
+
+func (s *Service) finishImport(progress *libvirtServiceInterfaces.VMImportProgress, vm *vmModels.VM, err error) {
+	s.importMutex.Lock()
+	defer s.importMutex.Unlock()
+
+	now := time.Now()
+	progress.Done = true
+	progress.FinishedAt = &now
+
+	if err != nil {
+		progress.Stage = "failed"
+		progress.Error = err.Error()
+		return
+	}
+
+	progress.Stage = "completed"
+	progress.VM = vm.ID
+	progress.VMID = vm.VmID
+}
+
+func (s *Service) GetImportProgress(id string) (*libvirtServiceInterfaces.VMImportProgress, error) {
+	s.importMutex.Lock()
+	defer s.importMutex.Unlock()
+
+	progress, ok := s.imports[id]
+	if !ok {
+		return nil, fmt.Errorf("import_not_found: %s", id)
+	}
+
+	copied := *progress
+	return &copied, nil
+}
+
+// importVM creates a VM from an archive written by ExportVM. The VM keeps
+// its VM ID and VNC port when they are free on this node and gets new ones
+// otherwise. Networks are attached to the switch mapped to their switch
+// name, or the switch of the same name, and get new MAC addresses; with
+// req.KeepMACs they keep theirs unless a VM here already uses it. Disks are
+// created below the parent dataset, ISOs this node does not have are
+// dropped.
+func (s *Service) importVM(
+	archivePath string,
+	parent *zfs.Dataset,
+	req libvirtServiceInterfaces.ImportVMRequest,
+	progress *libvirtServiceInterfaces.VMImportProgress,
+) (*vmModels.VM, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_open_archive: %w", err)
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(bufio.NewReaderSize(f, 1<<20))
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != vmArchiveManifest {
+		return nil, fmt.Errorf("invalid_vm_archive")
+	}
+
+	var manifest libvirtServiceInterfaces.VMArchiveManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid_vm_archive_manifest: %w", err)
+	}
+
+	if manifest.Version != vmArchiveVersion {
+		return nil, fmt.Errorf("unsupported_vm_archive_version: %d", manifest.Version)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = manifest.VM.Name
+	}
+
+	if !utils.IsValidVMName(name) {
+		return nil, fmt.Errorf("invalid_vm_name")
+	}
+
+	oldVmId := manifest.VM.VmID
+
+	var vmId int
+	if req.VMID != nil {
+		vmId, err = s.cloneVMID(req.VMID)
+	} else if vmId, err = s.cloneVMID(&oldVmId); err != nil {
+		vmId, err = s.cloneVMID(nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var vncPort int
+	if req.VNCPort != 0 {
+		vncPort, err = s.cloneVNCPort(req.VNCPort)
+	} else if vncPort, err = s.cloneVNCPort(manifest.VM.VNCPort); err != nil {
+		vncPort, err = s.cloneVNCPort(0)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switches := make([]networkModels.StandardSwitch, len(manifest.Networks))
+	for i, network := range manifest.Networks {
+		switchName := network.Switch
+		if mapped := req.Switches[network.Switch]; mapped != "" {
+			switchName = mapped
+		}
+
+		if err := s.DB.Where("name = ?", switchName).First(&switches[i]).Error; err != nil {
+			return nil, fmt.Errorf("switch_not_found: %s", switchName)
+		}
+	}
+
+	var created []*zfs.Dataset
+	var macIds []uint
+
+	s.setImportStage(progress, "receiving_disks")
+
+	cleanup := func() {
+		for i := len(created) - 1; i >= 0; i-- {
+			if err := created[i].Destroy(zfs.DestroyRecursive); err != nil {
+				logger.L.Warn().Err(err).Msgf("Failed to destroy imported dataset %s", created[i].Name)
+			}
+		}
+
+		if len(macIds) > 0 {
+			s.DB.Where("object_id IN ?", macIds).Delete(&networkModels.ObjectEntry{})
+			s.DB.Delete(&networkModels.Object{}, macIds)
+		}
+	}
+
+	received := make(map[string]*zfs.Dataset)
+	var vars []byte
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed_to_read_archive: %w", err)
+		}
+
+		if manifest.UEFIVars != "" && hdr.Name == manifest.UEFIVars {
+			if vars, err = io.ReadAll(tr); err != nil {
+				cleanup()
+				return nil, fmt.Errorf("failed_to_read_archive: %w", err)
+			}
+			continue
+		}
+
+		if received[hdr.Name] != nil {
+			continue
+		}
+
+		for i, disk := range manifest.Disks {
+			if disk.File == "" || disk.File != hdr.Name {
+				continue
+			}
+
+			dataset, err := importArchiveDisk(
+				tr,
+				hdr.Size,
+				disk,
+				fmt.Sprintf("%s/vm-%d-disk%d", parent.Name, vmId, i),
+				importedImageName(disk.Name, oldVmId, vmId),
+			)
+			if err != nil {
+				cleanup()
+				return nil, err
+			}
+
+			created = append(created, dataset)
+			received[hdr.Name] = dataset
+			break
+		}
+	}
+
+	var storages []vmModels.Storage
+	for _, disk := range manifest.Disks {
+		if disk.Type == "iso" {
+			if _, err := s.FindISOByUUID(disk.ISO, false); err != nil {
+				logger.L.Warn().Msgf("Dropping ISO %s of imported VM %d, it is not available on this node", disk.ISO, vmId)
+				continue
+			}
+
+			storages = append(storages, vmModels.Storage{
+				Type:      disk.Type,
+				Dataset:   disk.ISO,
+				Emulation: disk.Emulation,
+			})
+			continue
+		}
+
+		dataset := received[disk.File]
+		if dataset == nil {
+			cleanup()
+			return nil, fmt.Errorf("archive_missing_disk: %s", disk.File)
+		}
+
+		storageName := disk.Name
+		if disk.Type == "raw" {
+			storageName = importedImageName(disk.Name, oldVmId, vmId)
+
+			// Streams hold the images under the names they had.
+			if disk.Format == "zfs" && storageName != rawImageName(disk.Name, oldVmId) {
+				oldImage := filepath.Join(dataset.Mountpoint, rawImageName(disk.Name, oldVmId)+".img")
+				newImage := filepath.Join(dataset.Mountpoint, storageName+".img")
+
+				if err := os.Rename(oldImage, newImage); err != nil {
+					cleanup()
+					return nil, fmt.Errorf("failed_to_rename_disk_image: %w", err)
+				}
+			}
+		}
+
+		storages = append(storages, vmModels.Storage{
+			Name:      storageName,
+			Type:      disk.Type,
+			Dataset:   dataset.GUID,
+			Size:      disk.Size,
+			Emulation: disk.Emulation,
+		})
+	}
+
+	var networks []vmModels.Network
+	for i, network := range manifest.Networks {
+		sw := switches[i]
+		macName := fmt.Sprintf("%s-%s", name, sw.Name)
+
+		inUse := true
+		if req.KeepMACs && network.MAC != "" {
+			if inUse, err = s.macInUse(network.MAC); err != nil {
+				cleanup()
+				return nil, err
+			}
+
+			if inUse {
+				logger.L.Warn().Msgf("MAC %s of imported VM %d is in use, generating a new one", network.MAC, vmId)
+			}
+		}
+
+		var macId uint
+		if inUse {
+			macId, err = s.createMacObject(macName)
+		} else {
+			macId, err = s.createMacObjectWithAddress(macName, network.MAC)
+		}
+
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+
+		macIds = append(macIds, macId)
+
+		networks = append(networks, vmModels.Network{
+			MacID:     &macId,
+			SwitchID:  uint(sw.ID),
+			DHCP:      network.DHCP,
+			SLAAC:     network.SLAAC,
+			Emulation: network.Emulation,
+		})
+	}
+
+	iso := manifest.VM.ISO
+	if iso != "" {
+		if _, err := s.FindISOByUUID(iso, false); err != nil {
+			iso = ""
+		}
+	}
+
+	s.setImportStage(progress, "creating_vm")
+
+	vm := portableVM(manifest.VM)
+	vm.Name = name
+	vm.VmID = vmId
+	vm.VNCPort = vncPort
+	vm.ISO = iso
+	vm.Storages = storages
+	vm.Networks = networks
+	vm.StartedAt = nil
+	vm.StoppedAt = nil
+
+	if err := s.DB.
+		Session(&gorm.Session{FullSaveAssociations: true}).
+		Create(&vm).Error; err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed_to_create_vm_with_associations: %w", err)
+	}
+
+	if err := s.createLvVm(int(vm.ID), false); err != nil {
+		s.DB.Select("Storages", "Networks", "CloudInit").Delete(&vm)
+		cleanup()
+		return nil, fmt.Errorf("failed_to_create_lv_vm: %w", err)
+	}
+
+	if len(vars) > 0 {
+		varsPath, err := vmUEFIVarsPath(vm.VmID)
+		if err == nil {
+			err = os.WriteFile(varsPath, vars, 0644)
+		}
+
+		if err != nil {
+			if err := s.RemoveLvVm(vm.VmID); err != nil {
+				logger.L.Warn().Err(err).Msgf("Failed to undefine imported VM %d", vm.VmID)
+			}
+			s.DB.Select("Storages", "Networks", "CloudInit").Delete(&vm)
+			cleanup()
+			return nil, fmt.Errorf("failed_to_restore_uefi_vars: %w", err)
+		}
+	}
+
+	return &vm, nil
+}
+
+// importedImageName renames a raw disk image named after the VM ID it was
+// exported with after the new one.
+func importedImageName(name string, oldVmId int, vmId int) string {
+	if name == "" || name == strconv.Itoa(oldVmId) {
+		return strconv.Itoa(vmId)
+	}
+
+	return name
+}
@@ -0,0 +1,483 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal/config"
+	networkModels "github.com/alchemillahq/sylve/internal/db/models/network"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/utils"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+
+	"gorm.io/gorm"
+)
+
+// cloneVMID returns the requested VM ID after checking it is free, or the
+// next free one after the highest in use.
+func (s *Service) cloneVMID(requested *int) (int, error) {
+	if requested != nil {
+		if *requested <= 0 || *requested > 9999 {
+			return 0, fmt.Errorf("invalid_vm_id")
+		}
+
+		var count int64
+		if err := s.DB.Model(&vmModels.VM{}).Where("vm_id = ?", *requested).Count(&count).Error; err != nil {
+			return 0, fmt.Errorf("failed_to_check_vm_id_usage: %w", err)
+		}
+
+		if count > 0 {
+			return 0, fmt.Errorf("vm_id_already_in_use")
+		}
+
+		return *requested, nil
+	}
+
+	var highest int
+	if err := s.DB.Model(&vmModels.VM{}).Select("COALESCE(MAX(vm_id), 99)").Scan(&highest).Error; err != nil {
+		return 0, fmt.Errorf("failed_to_find_next_vm_id: %w", err)
+	}
+
+	if highest >= 9999 {
+		return 0, fmt.Errorf("no_free_vm_id")
+	}
+
+	return highest + 1, nil
+}
+
+// cloneVNCPort returns the requested VNC port after checking it is free, or
+// the first free one after the highest port used by a VM.
+func (s *Service) cloneVNCPort(requested int) (int, error) {
+	used := func(port int) (bool, error) {
+		var count int64
+		if err := s.DB.Model(&vmModels.VM{}).Where("vnc_port = ?", port).Count(&count).Error; err != nil {
+			return false, fmt.Errorf("failed_to_check_vnc_port_usage: %w", err)
+		}
+
+		return count > 0 || utils.IsPortInUse(port), nil
+	}
+
+	if requested != 0 {
+		if requested < 1 || requested > 65535 {
+			return 0, fmt.Errorf("vnc_port_must_be_between_1_and_65535")
+		}
+
+		inUse, err := used(requested)
+		if err != nil {
+			return 0, err
+		}
+
+		if inUse {
+			return 0, fmt.Errorf("vnc_port_already_in_use")
+		}
+
+		return requested, nil
+	}
+
+	var highest int
+	if err := s.DB.Model(&vmModels.VM{}).Select("COALESCE(MAX(vnc_port), 5899)").Scan(&highest).Error; err != nil {
+		return 0, fmt.Errorf("failed_to_find_next_vnc_port: %w", err)
+	}
+
+	for port := highest + 1; port <= 65535; port++ {
+		inUse, err := used(port)
+		if err != nil {
+			return 0, err
+		}
+
+		if !inUse {
+			return port, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no_free_vnc_port")
+}
+
+// cloneDatasetName names the copy of a VM dataset next to the original. A
+// dataset named after the VM ID is named after the new one, anything else
+// gets the new ID appended.
+func cloneDatasetName(name string, oldVmId int, newVmId int) string {
+	parent, base := path.Split(name)
+	if base == strconv.Itoa(oldVmId) {
+		return parent + strconv.Itoa(newVmId)
+	}
+
+	return fmt.Sprintf("%s%s-%d", parent, base, newVmId)
+}
+
+// cloneDataset copies a VM dataset through a temporary snapshot named after
+// the new VM ID, so clones of the same VM made at once do not collide. A full
+// clone sends and receives it, leaving an independent dataset. A linked clone
+// is a ZFS clone of the snapshot, so the snapshot stays behind as its origin.
+func cloneDataset(source *zfs.Dataset, dest string, vmId int, linked bool) (*zfs.Dataset, error) {
+	if _, err := zfs.GetDataset(dest); err == nil {
+		return nil, fmt.Errorf("dataset_already_exists: %s", dest)
+	}
+
+	snapName := fmt.Sprintf("sylve-clone-%d-%d", vmId, time.Now().Unix())
+	snapshot, err := source.Snapshot(snapName, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_snapshot_dataset: %w", err)
+	}
+
+	if linked {
+		clone, err := snapshot.Clone(dest, nil)
+		if err != nil {
+			snapshot.Destroy(zfs.DestroyDefault)
+			return nil, fmt.Errorf("failed_to_clone_dataset: %w", err)
+		}
+
+		return clone, nil
+	}
+
+	defer snapshot.Destroy(zfs.DestroyDefault)
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(snapshot.Send(zfs.SendOptions{}, pw))
+	}()
+
+	copied, err := zfs.ReceiveSnapshot(pr, dest)
+	pr.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_copy_dataset: %w", err)
+	}
+
+	if received, err := zfs.GetDataset(fmt.Sprintf("%s@%s", dest, snapName)); err == nil {
+		if err := received.Destroy(zfs.DestroyDefault); err != nil {
+			logger.L.Warn().Err(err).Msgf("Failed to destroy snapshot %s", received.Name)
+		}
+	}
+
+	return copied, nil
+}
+
+// cloneOptions describes the VM cloneVM creates from a source VM.
+type cloneOptions struct {
+	name    string
+	vmId    *int
+	vncPort int
+	linked  bool
+
+	// switches maps source network IDs to the switch the copy is attached to.
+	switches map[uint]uint
+	// diskSizes maps source storage IDs to the size the copied disk is grown to.
+	diskSizes map[uint]int64
+	// modify adjusts the new VM before it is saved.
+	modify func(vm *vmModels.VM)
+}
+
+// growClonedDisk grows a copied zvol or raw disk image to size bytes.
+func growClonedDisk(storage vmModels.Storage, dataset *zfs.Dataset, size int64) (int64, error) {
+	if size <= storage.Size {
+		return 0, fmt.Errorf("disk_size_can_only_grow")
+	}
+
+	switch storage.Type {
+	case "zvol":
+		if bs := int64(dataset.VolBlockSize); bs > 0 {
+			size = (size + bs - 1) / bs * bs
+		}
+
+		if err := dataset.SetProperty("volsize", strconv.FormatInt(size, 10)); err != nil {
+			return 0, fmt.Errorf("failed_to_grow_volume: %w", err)
+		}
+	case "raw":
+		image := filepath.Join(dataset.Mountpoint, storage.Name+".img")
+		if err := os.Truncate(image, size); err != nil {
+			return 0, fmt.Errorf("failed_to_grow_disk_image: %w", err)
+		}
+	default:
+		return 0, fmt.Errorf("cannot_grow_storage_type: %s", storage.Type)
+	}
+
+	return size, nil
+}
+
+// CloneVM creates a copy of a shut off VM under a new VM ID. Every disk is
+// copied to a new dataset, and the copy gets its own MAC addresses, VNC port,
+// UEFI variables and TPM state. Passthrough devices and CPU pinning are not
+// carried over since they can only belong to one VM.
+func (s *Service) CloneVM(id uint, req libvirtServiceInterfaces.CloneVMRequest) (*vmModels.VM, error) {
+	source, err := s.findVM(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Template {
+		return nil, fmt.Errorf("vm_is_template")
+	}
+
+	if req.Mode != "full" && req.Mode != "linked" {
+		return nil, fmt.Errorf("invalid_clone_mode: %s", req.Mode)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = source.Name + "-clone"
+	}
+
+	switches := make(map[uint]uint, len(req.Networks))
+	for _, n := range req.Networks {
+		switches[n.NetworkID] = n.SwitchID
+	}
+
+	return s.cloneVM(source, cloneOptions{
+		name:     name,
+		vmId:     req.VMID,
+		vncPort:  req.VNCPort,
+		linked:   req.Mode == "linked",
+		switches: switches,
+	})
+}
+
+func (s *Service) cloneVM(source vmModels.VM, opts cloneOptions) (*vmModels.VM, error) {
+	inactive, err := s.IsDomainInactive(source.VmID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !inactive {
+		return nil, fmt.Errorf("domain_state_not_shutoff: %d", source.VmID)
+	}
+
+	name := opts.name
+	if !utils.IsValidVMName(name) {
+		return nil, fmt.Errorf("invalid_vm_name")
+	}
+
+	vmId, err := s.cloneVMID(opts.vmId)
+	if err != nil {
+		return nil, err
+	}
+
+	vncPort, err := s.cloneVNCPort(opts.vncPort)
+	if err != nil {
+		return nil, err
+	}
+	datasets, err := zfs.Datasets("")
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_datasets: %w", err)
+	}
+
+	var created []*zfs.Dataset
+	var macIds []uint
+
+	cleanup := func() {
+		for i := len(created) - 1; i >= 0; i-- {
+			if err := created[i].Destroy(zfs.DestroyRecursive); err != nil {
+				logger.L.Warn().Err(err).Msgf("Failed to destroy cloned dataset %s", created[i].Name)
+				continue
+			}
+
+			// A linked clone leaves its origin snapshot on the source.
+			if !opts.linked || created[i].Origin == "" || created[i].Origin == "-" {
+				continue
+			}
+
+			if origin, err := zfs.GetDataset(created[i].Origin); err == nil {
+				if err := origin.Destroy(zfs.DestroyDefault); err != nil {
+					logger.L.Warn().Err(err).Msgf("Failed to destroy clone origin %s", origin.Name)
+				}
+			}
+		}
+
+		if len(macIds) > 0 {
+			s.DB.Where("object_id IN ?", macIds).Delete(&networkModels.ObjectEntry{})
+			s.DB.Delete(&networkModels.Object{}, macIds)
+		}
+	}
+
+	var storages []vmModels.Storage
+	for _, storage := range source.Storages {
+		if storage.Type == "iso" {
+			storages = append(storages, vmModels.Storage{
+				Type:      storage.Type,
+				Dataset:   storage.Dataset,
+				Emulation: storage.Emulation,
+			})
+			continue
+		}
+
+		var dataset *zfs.Dataset
+		for _, d := range datasets {
+			if d.GUID == storage.Dataset && d.Type != zfs.DatasetSnapshot && d.Type != zfs.DatasetBookmark {
+				dataset = d
+				break
+			}
+		}
+
+		if dataset == nil {
+			cleanup()
+			return nil, fmt.Errorf("dataset_not_found: %s", storage.Dataset)
+		}
+
+		clone, err := cloneDataset(dataset, cloneDatasetName(dataset.Name, source.VmID, vmId), vmId, opts.linked)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+
+		created = append(created, clone)
+
+		storageName := storage.Name
+		if storage.Type == "raw" && (storageName == "" || storageName == strconv.Itoa(source.VmID)) {
+			storageName = strconv.Itoa(vmId)
+
+			oldImage := filepath.Join(clone.Mountpoint, fmt.Sprintf("%d.img", source.VmID))
+			newImage := filepath.Join(clone.Mountpoint, fmt.Sprintf("%d.img", vmId))
+
+			if err := os.Rename(oldImage, newImage); err != nil {
+				cleanup()
+				return nil, fmt.Errorf("failed_to_rename_disk_image: %w", err)
+			}
+		}
+
+		copied := vmModels.Storage{
+			Name:      storageName,
+			Type:      storage.Type,
+			Dataset:   clone.GUID,
+			Size:      storage.Size,
+			Emulation: storage.Emulation,
+		}
+
+		if size, ok := opts.diskSizes[storage.ID]; ok {
+			if copied.Size, err = growClonedDisk(copied, clone, size); err != nil {
+				cleanup()
+				return nil, err
+			}
+		}
+
+		storages = append(storages, copied)
+	}
+
+	var networks []vmModels.Network
+	for _, network := range source.Networks {
+		switchId := network.SwitchID
+		if override, ok := opts.switches[network.ID]; ok {
+			switchId = override
+		}
+
+		var sw networkModels.StandardSwitch
+		if err := s.DB.First(&sw, switchId).Error; err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed_to_find_switch: %w", err)
+		}
+
+		macId, err := s.createMacObject(fmt.Sprintf("%s-%s", name, sw.Name))
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+
+		macIds = append(macIds, macId)
+
+		// Static addresses belong to the source, the copy keeps only DHCP
+		// and SLAAC.
+		networks = append(networks, vmModels.Network{
+			MacID:     &macId,
+			SwitchID:  switchId,
+			DHCP:      network.DHCP,
+			SLAAC:     network.SLAAC,
+			Emulation: network.Emulation,
+		})
+	}
+
+	var cloudInit *vmModels.CloudInit
+	if source.CloudInit != nil {
+		cloudInit = &vmModels.CloudInit{
+			Users:       source.CloudInit.Users,
+			Packages:    source.CloudInit.Packages,
+			RunCmd:      source.CloudInit.RunCmd,
+			Nameservers: source.CloudInit.Nameservers,
+		}
+	}
+
+	vm := &vmModels.VM{
+		Name:          name,
+		VmID:          vmId,
+		Description:   source.Description,
+		CPUSockets:    source.CPUSockets,
+		CPUCores:      source.CPUCores,
+		CPUsThreads:   source.CPUsThreads,
+		RAM:           source.RAM,
+		VNCPort:       vncPort,
+		VNCPassword:   source.VNCPassword,
+		VNCResolution: source.VNCResolution,
+		VNCWait:       source.VNCWait,
+		StartAtBoot:   source.StartAtBoot,
+		TPMEmulation:  source.TPMEmulation,
+		StartOrder:    source.StartOrder,
+		WoL:           source.WoL,
+		OSType:        source.OSType,
+		SourceImage:   source.SourceImage,
+		TemplateID:    source.TemplateID,
+		ISO:           source.ISO,
+		Storages:      storages,
+		Networks:      networks,
+		CloudInit:     cloudInit,
+	}
+
+	if opts.modify != nil {
+		opts.modify(vm)
+	}
+
+	if err := s.DB.
+		Session(&gorm.Session{FullSaveAssociations: true}).
+		Create(vm).Error; err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed_to_create_vm_with_associations: %w", err)
+	}
+
+	if err := s.createLvVm(int(vm.ID), false); err != nil {
+		s.DB.Select("Storages", "Networks", "CloudInit").Delete(vm)
+		cleanup()
+		return nil, fmt.Errorf("failed_to_create_lv_vm: %w", err)
+	}
+
+	// Keep the boot entries of the original, the TPM state starts out fresh.
+	if err := copyCloneUEFIVars(source.VmID, vmId); err != nil {
+		if err := s.RemoveLvVm(vmId); err != nil {
+			logger.L.Warn().Err(err).Msgf("Failed to undefine cloned VM %d", vmId)
+		}
+		s.DB.Select("Storages", "Networks", "CloudInit").Delete(vm)
+		cleanup()
+		return nil, err
+	}
+
+	return vm, nil
+}
+
+func copyCloneUEFIVars(sourceVmId int, vmId int) error {
+	vmDir, err := config.GetVMsPath()
+	if err != nil {
+		return fmt.Errorf("failed to get VMs path: %w", err)
+	}
+
+	sourceVars := filepath.Join(vmDir, strconv.Itoa(sourceVmId), fmt.Sprintf("%d_vars.fd", sourceVmId))
+	if _, err := os.Stat(sourceVars); err != nil {
+		return nil
+	}
+
+	cloneVars := filepath.Join(vmDir, strconv.Itoa(vmId), fmt.Sprintf("%d_vars.fd", vmId))
+	if err := utils.CopyFile(sourceVars, cloneVars); err != nil {
+		return fmt.Errorf("failed to copy UEFI vars file: %w", err)
+	}
+
+	return nil
+}
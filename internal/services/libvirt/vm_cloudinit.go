@@ -0,0 +1,601 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alchemillahq/sylve/internal/config"
+	networkModels "github.com/alchemillahq/sylve/internal/db/models/network"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/pkg/iso9660"
+	"github.com/alchemillahq/sylve/pkg/utils"
+
+	"github.com/beevik/etree"
+	"gorm.io/gorm"
+)
+
+var (
+	cloudInitHostnameRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	cloudInitUserRe     = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+)
+
+// The seed files are JSON, which cloud-init reads as YAML just as well and
+// saves us from quoting user supplied strings by hand.
+type cloudConfigUser struct {
+	Name              string   `json:"name"`
+	SSHAuthorizedKeys []string `json:"ssh_authorized_keys,omitempty"`
+	Passwd            string   `json:"passwd,omitempty"`
+	LockPasswd        bool     `json:"lock_passwd"`
+	Sudo              string   `json:"sudo,omitempty"`
+	Shell             string   `json:"shell,omitempty"`
+}
+
+type cloudConfig struct {
+	Hostname       string            `json:"hostname"`
+	ManageEtcHosts bool              `json:"manage_etc_hosts"`
+	Users          []cloudConfigUser `json:"users,omitempty"`
+	Packages       []string          `json:"packages,omitempty"`
+	RunCmd         []string          `json:"runcmd,omitempty"`
+}
+
+type cloudNameservers struct {
+	Addresses []string `json:"addresses"`
+}
+
+type cloudEthernet struct {
+	Match       map[string]string `json:"match"`
+	DHCP4       bool              `json:"dhcp4"`
+	DHCP6       bool              `json:"dhcp6"`
+	AcceptRA    bool              `json:"accept-ra,omitempty"`
+	Addresses   []string          `json:"addresses,omitempty"`
+	Gateway4    string            `json:"gateway4,omitempty"`
+	Gateway6    string            `json:"gateway6,omitempty"`
+	Nameservers *cloudNameservers `json:"nameservers,omitempty"`
+}
+
+type cloudNetworkConfig struct {
+	Version   int                      `json:"version"`
+	Ethernets map[string]cloudEthernet `json:"ethernets"`
+}
+
+func cloudInitSeedPath(vmPath string, vmId int) string {
+	return filepath.Join(vmPath, fmt.Sprintf("%d_cloudinit.iso", vmId))
+}
+
+// preloadCloudInit loads everything the seed is generated from.
+func preloadCloudInit(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("CloudInit").
+		Preload("Networks.AddressObj.Entries").
+		Preload("Networks.IPv4Obj.Entries").
+		Preload("Networks.IPv4GwObj.Entries").
+		Preload("Networks.IPv6Obj.Entries").
+		Preload("Networks.IPv6GwObj.Entries").
+		Preload("Networks.Switch.NetworkObj.Entries").
+		Preload("Networks.Switch.Network6Obj.Entries").
+		Preload("Networks.Switch.GatewayAddressObj.Entries").
+		Preload("Networks.Switch.Gateway6AddressObj.Entries")
+}
+
+func objectValue(obj *networkModels.Object) string {
+	if obj == nil || len(obj.Entries) == 0 {
+		return ""
+	}
+
+	return obj.Entries[0].Value
+}
+
+// cloudInitAddress returns an address in CIDR notation, host objects without
+// a prefix length take the one of the switch network they are in.
+func cloudInitAddress(value string, network string) (string, error) {
+	if strings.Contains(value, "/") {
+		if _, _, err := net.ParseCIDR(value); err != nil {
+			return "", fmt.Errorf("invalid_address: %s", value)
+		}
+		return value, nil
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return "", fmt.Errorf("invalid_address: %s", value)
+	}
+
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil || !ipNet.Contains(ip) {
+		return "", fmt.Errorf("address_prefix_unknown: %s", value)
+	}
+
+	ones, _ := ipNet.Mask.Size()
+	return fmt.Sprintf("%s/%d", value, ones), nil
+}
+
+// cloudInitHostname falls back to the VM name, which may contain
+// underscores that are not allowed in host names.
+func cloudInitHostname(vm vmModels.VM) string {
+	if vm.CloudInit.Hostname != "" {
+		return vm.CloudInit.Hostname
+	}
+
+	return strings.Trim(strings.ToLower(strings.ReplaceAll(vm.Name, "_", "-")), "-")
+}
+
+func marshalSeedFile(header string, v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// cloudInitSeedFiles generates the NoCloud meta-data, user-data and
+// network-config of a VM loaded through preloadCloudInit.
+func cloudInitSeedFiles(vm vmModels.VM) ([]iso9660.File, error) {
+	ci := vm.CloudInit
+	hostname := cloudInitHostname(vm)
+
+	userData := cloudConfig{
+		Hostname:       hostname,
+		ManageEtcHosts: true,
+		Packages:       ci.Packages,
+		RunCmd:         ci.RunCmd,
+	}
+
+	for _, u := range ci.Users {
+		user := cloudConfigUser{
+			Name:              u.Name,
+			SSHAuthorizedKeys: u.SSHKeys,
+			Passwd:            u.PasswordHash,
+			LockPasswd:        u.PasswordHash == "",
+			Shell:             u.Shell,
+		}
+
+		if u.Sudo {
+			user.Sudo = "ALL=(ALL) NOPASSWD:ALL"
+		}
+
+		userData.Users = append(userData.Users, user)
+	}
+
+	networkConfig := cloudNetworkConfig{
+		Version:   2,
+		Ethernets: make(map[string]cloudEthernet),
+	}
+
+	for i, network := range vm.Networks {
+		mac := objectValue(network.AddressObj)
+		if mac == "" {
+			return nil, fmt.Errorf("network_mac_address_missing")
+		}
+
+		eth := cloudEthernet{
+			Match:    map[string]string{"macaddress": strings.ToLower(mac)},
+			DHCP4:    network.DHCP,
+			AcceptRA: network.SLAAC,
+		}
+
+		if ipv4 := objectValue(network.IPv4Obj); ipv4 != "" && !network.DHCP {
+			address, err := cloudInitAddress(ipv4, network.Switch.Network(4))
+			if err != nil {
+				return nil, err
+			}
+
+			eth.Addresses = append(eth.Addresses, address)
+			eth.Gateway4 = objectValue(network.IPv4GwObj)
+			if eth.Gateway4 == "" {
+				eth.Gateway4 = network.Switch.Gateway(4)
+			}
+		}
+
+		if ipv6 := objectValue(network.IPv6Obj); ipv6 != "" && !network.SLAAC {
+			address, err := cloudInitAddress(ipv6, network.Switch.Network(6))
+			if err != nil {
+				return nil, err
+			}
+
+			eth.Addresses = append(eth.Addresses, address)
+			eth.Gateway6 = objectValue(network.IPv6GwObj)
+			if eth.Gateway6 == "" {
+				eth.Gateway6 = network.Switch.Gateway(6)
+			}
+		}
+
+		if len(ci.Nameservers) > 0 {
+			eth.Nameservers = &cloudNameservers{Addresses: ci.Nameservers}
+		}
+
+		networkConfig.Ethernets[fmt.Sprintf("net%d", i)] = eth
+	}
+
+	user, err := marshalSeedFile("#cloud-config\n", userData)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_generate_user_data: %w", err)
+	}
+
+	network, err := marshalSeedFile("", networkConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_generate_network_config: %w", err)
+	}
+
+	// A new instance-id makes cloud-init apply the seed again on the next
+	// boot, so it changes whenever the generated configuration does.
+	sum := sha256.Sum256(append(append([]byte{}, user...), network...))
+
+	meta, err := marshalSeedFile("", map[string]string{
+		"instance-id":    fmt.Sprintf("sylve-%d-%s", vm.VmID, hex.EncodeToString(sum[:4])),
+		"local-hostname": hostname,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_generate_meta_data: %w", err)
+	}
+
+	return []iso9660.File{
+		{Name: "meta-data", Data: meta},
+		{Name: "user-data", Data: user},
+		{Name: "network-config", Data: network},
+	}, nil
+}
+
+// writeCloudInitSeed builds the cidata volume next to the VM's other files.
+// It is written to a temporary file first so a running guest never sees a
+// half written seed.
+func writeCloudInitSeed(vm vmModels.VM, vmPath string) error {
+	files, err := cloudInitSeedFiles(vm)
+	if err != nil {
+		return err
+	}
+
+	seed := cloudInitSeedPath(vmPath, vm.VmID)
+	tmp := seed + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed_to_create_cloud_init_seed: %w", err)
+	}
+
+	if err := iso9660.Write(f, "cidata", files); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed_to_write_cloud_init_seed: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed_to_write_cloud_init_seed: %w", err)
+	}
+
+	if err := os.Rename(tmp, seed); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed_to_write_cloud_init_seed: %w", err)
+	}
+
+	return nil
+}
+
+// setCloudInitArg adds or removes the ahci-cd device of the seed in the
+// domain's bhyve command line.
+func (s *Service) setCloudInitArg(vmId int, seed string, attach bool) error {
+	domain, err := s.Conn.DomainLookupByName(strconv.Itoa(vmId))
+	if err != nil {
+		return fmt.Errorf("failed_to_lookup_domain_by_name: %w", err)
+	}
+
+	xml, err := s.Conn.DomainGetXMLDesc(domain, 0)
+	if err != nil {
+		return fmt.Errorf("failed_to_get_domain_xml_desc: %w", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(xml); err != nil {
+		return fmt.Errorf("failed_to_parse_xml: %w", err)
+	}
+
+	bhyveCommandline := doc.FindElement("//commandline")
+	if bhyveCommandline == nil || bhyveCommandline.Space != "bhyve" {
+		if !attach {
+			return nil
+		}
+
+		root := doc.Root()
+		if root.SelectAttr("xmlns:bhyve") == nil {
+			root.CreateAttr("xmlns:bhyve", "http://libvirt.org/schemas/domain/bhyve/1.0")
+		}
+		bhyveCommandline = root.CreateElement("bhyve:commandline")
+	}
+
+	var existing *etree.Element
+	for _, arg := range bhyveCommandline.ChildElements() {
+		if value := arg.SelectAttr("value"); value != nil && strings.HasSuffix(value.Value, ","+seed) {
+			existing = arg
+			break
+		}
+	}
+
+	switch {
+	case attach && existing == nil:
+		index, err := findLowestIndex(xml)
+		if err != nil {
+			return fmt.Errorf("failed_to_find_lowest_index: %w", err)
+		}
+
+		bhyveCommandline.CreateElement("bhyve:arg").CreateAttr("value", fmt.Sprintf("-s %d:0,ahci-cd,%s", index, seed))
+	case !attach && existing != nil:
+		bhyveCommandline.RemoveChild(existing)
+	default:
+		return nil
+	}
+
+	out, err := doc.WriteToString()
+	if err != nil {
+		return fmt.Errorf("failed_to_serialize_xml: %w", err)
+	}
+
+	if err := s.Conn.DomainUndefineFlags(domain, 0); err != nil {
+		return fmt.Errorf("failed_to_undefine_domain: %w", err)
+	}
+
+	if _, err := s.Conn.DomainDefineXML(out); err != nil {
+		return fmt.Errorf("failed_to_define_domain_with_modified_xml: %w", err)
+	}
+
+	return nil
+}
+
+// RefreshCloudInitSeed regenerates the seed of a VM after something it is
+// generated from changed, like the MAC or IP objects of its networks. VMs
+// without cloud-init settings are left alone.
+func (s *Service) RefreshCloudInitSeed(vmId int) error {
+	var vm vmModels.VM
+	if err := preloadCloudInit(s.DB).Where("vm_id = ?", vmId).First(&vm).Error; err != nil {
+		return fmt.Errorf("failed_to_find_vm: %w", err)
+	}
+
+	if vm.CloudInit == nil {
+		return nil
+	}
+
+	vmDir, err := config.GetVMsPath()
+	if err != nil {
+		return fmt.Errorf("failed to get VMs path: %w", err)
+	}
+
+	vmPath := filepath.Join(vmDir, strconv.Itoa(vm.VmID))
+	if err := writeCloudInitSeed(vm, vmPath); err != nil {
+		return err
+	}
+
+	return s.setCloudInitArg(vm.VmID, cloudInitSeedPath(vmPath, vm.VmID), true)
+}
+
+func (s *Service) GetCloudInit(id uint) (*vmModels.CloudInit, error) {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return vm.CloudInit, nil
+}
+
+func validateCloudInit(req libvirtServiceInterfaces.CloudInitRequest) error {
+	if req.Hostname != "" && (len(req.Hostname) > 253 || !cloudInitHostnameRe.MatchString(req.Hostname)) {
+		return fmt.Errorf("invalid_hostname")
+	}
+
+	names := make(map[string]bool, len(req.Users))
+	for _, u := range req.Users {
+		if !cloudInitUserRe.MatchString(u.Name) {
+			return fmt.Errorf("invalid_user_name: %s", u.Name)
+		}
+
+		if names[u.Name] {
+			return fmt.Errorf("duplicate_user: %s", u.Name)
+		}
+		names[u.Name] = true
+
+		// Only crypt(3) hashes are stored, never plain text passwords.
+		if u.PasswordHash != "" && !strings.HasPrefix(u.PasswordHash, "$") {
+			return fmt.Errorf("invalid_password_hash: %s", u.Name)
+		}
+
+		for _, key := range u.SSHKeys {
+			if strings.TrimSpace(key) == "" || strings.ContainsAny(key, "\r\n") {
+				return fmt.Errorf("invalid_ssh_key: %s", u.Name)
+			}
+		}
+	}
+
+	for _, ns := range req.Nameservers {
+		if !utils.IsValidIP(ns) {
+			return fmt.Errorf("invalid_nameserver: %s", ns)
+		}
+	}
+
+	for _, n := range req.Networks {
+		if n.DHCP && n.IPv4 != 0 {
+			return fmt.Errorf("dhcp_and_static_ipv4_are_exclusive")
+		}
+
+		if n.SLAAC && n.IPv6 != 0 {
+			return fmt.Errorf("slaac_and_static_ipv6_are_exclusive")
+		}
+
+		if (n.IPv4Gw != 0 && n.IPv4 == 0) || (n.IPv6Gw != 0 && n.IPv6 == 0) {
+			return fmt.Errorf("gateway_requires_address")
+		}
+	}
+
+	return nil
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
+
+// SetCloudInit saves the cloud-init settings of a shut off VM, attaching the
+// seed to it the first time and regenerating it afterwards.
+func (s *Service) SetCloudInit(id uint, req libvirtServiceInterfaces.CloudInitRequest) error {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return err
+	}
+
+	if vm.Template {
+		return fmt.Errorf("vm_is_template")
+	}
+
+	inactive, err := s.IsDomainInactive(vm.VmID)
+	if err != nil {
+		return err
+	}
+
+	if !inactive {
+		return fmt.Errorf("domain_state_not_shutoff: %d", vm.VmID)
+	}
+
+	if err := validateCloudInit(req); err != nil {
+		return err
+	}
+
+	networks := make(map[uint]bool, len(vm.Networks))
+	for _, n := range vm.Networks {
+		networks[n.ID] = true
+	}
+
+	for _, n := range req.Networks {
+		if !networks[n.NetworkID] {
+			return fmt.Errorf("network_not_found_in_vm: %d", n.NetworkID)
+		}
+
+		for _, objId := range []uint{n.IPv4, n.IPv4Gw, n.IPv6, n.IPv6Gw} {
+			if objId == 0 {
+				continue
+			}
+
+			var obj networkModels.Object
+			if err := s.DB.Preload("Entries").First(&obj, objId).Error; err != nil {
+				return fmt.Errorf("failed_to_find_object: %w", err)
+			}
+
+			if obj.Type != "Host" || len(obj.Entries) != 1 {
+				return fmt.Errorf("invalid_ip_object: %d", objId)
+			}
+		}
+	}
+
+	ci := vm.CloudInit
+	if ci == nil {
+		ci = &vmModels.CloudInit{VMID: vm.ID}
+	}
+
+	ci.Hostname = req.Hostname
+	ci.Users = req.Users
+	ci.Packages = req.Packages
+	ci.RunCmd = req.RunCmd
+	ci.Nameservers = req.Nameservers
+
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(ci).Error; err != nil {
+			return fmt.Errorf("failed_to_save_cloud_init: %w", err)
+		}
+
+		for _, n := range req.Networks {
+			if err := tx.Model(&vmModels.Network{}).Where("id = ?", n.NetworkID).Updates(map[string]any{
+				"ipv4_id":    optionalID(n.IPv4),
+				"ipv4_gw_id": optionalID(n.IPv4Gw),
+				"ipv6_id":    optionalID(n.IPv6),
+				"ipv6_gw_id": optionalID(n.IPv6Gw),
+				"dhcp":       n.DHCP,
+				"slaac":      n.SLAAC,
+			}).Error; err != nil {
+				return fmt.Errorf("failed_to_update_network: %w", err)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return s.RefreshCloudInitSeed(vm.VmID)
+}
+
+func (s *Service) DeleteCloudInit(id uint) error {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return err
+	}
+
+	if vm.CloudInit == nil {
+		return fmt.Errorf("cloud_init_not_configured")
+	}
+
+	inactive, err := s.IsDomainInactive(vm.VmID)
+	if err != nil {
+		return err
+	}
+
+	if !inactive {
+		return fmt.Errorf("domain_state_not_shutoff: %d", vm.VmID)
+	}
+
+	vmDir, err := config.GetVMsPath()
+	if err != nil {
+		return fmt.Errorf("failed to get VMs path: %w", err)
+	}
+
+	seed := cloudInitSeedPath(filepath.Join(vmDir, strconv.Itoa(vm.VmID)), vm.VmID)
+	if err := s.setCloudInitArg(vm.VmID, seed, false); err != nil {
+		return err
+	}
+
+	if err := os.Remove(seed); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed_to_remove_cloud_init_seed: %w", err)
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(vm.CloudInit).Error; err != nil {
+			return fmt.Errorf("failed_to_delete_cloud_init: %w", err)
+		}
+
+		if err := tx.Model(&vmModels.Network{}).Where("vm_id = ?", vm.ID).Updates(map[string]any{
+			"ipv4_id":    nil,
+			"ipv4_gw_id": nil,
+			"ipv6_id":    nil,
+			"ipv6_gw_id": nil,
+			"dhcp":       false,
+			"slaac":      false,
+		}).Error; err != nil {
+			return fmt.Errorf("failed_to_update_network: %w", err)
+		}
+
+		return nil
+	})
+}
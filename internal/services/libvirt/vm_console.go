@@ -0,0 +1,308 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/alchemillahq/sylve/internal/config"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/utils"
+
+	"github.com/beevik/etree"
+	"github.com/digitalocean/go-libvirt"
+)
+
+const (
+	// consoleScrollback is how much recent output a new client is sent.
+	consoleScrollback = 64 * 1024
+	// consoleLogLimit is the size the console log is rotated at.
+	consoleLogLimit = 4 * 1024 * 1024
+)
+
+// vmConsoleDevices returns the nmdm pair COM1 of a VM is wired to, bhyve
+// holds the A side and the host talks to the B side.
+func vmConsoleDevices(vmId int) (string, string) {
+	return fmt.Sprintf("/dev/nmdm%dA", vmId), fmt.Sprintf("/dev/nmdm%dB", vmId)
+}
+
+func vmConsoleLogPath(vmId int) (string, error) {
+	vmDir, err := config.GetVMsPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get VMs path: %w", err)
+	}
+
+	return filepath.Join(vmDir, strconv.Itoa(vmId), "console.log"), nil
+}
+
+// vmConsole reads the host side of a VM's serial console for as long as
+// the VM exists, keeping the recent output for clients that attach later
+// and appending everything to the console log.
+type vmConsole struct {
+	vmId    int
+	tty     *os.File
+	logPath string
+
+	mu         sync.Mutex
+	log        *os.File
+	logSize    int64
+	scrollback []byte
+	clients    map[chan []byte]struct{}
+}
+
+// ensureConsoleDevice adds the serial console to domains defined before
+// VMs had one, it is picked up the next time the VM starts.
+func (s *Service) ensureConsoleDevice(domain libvirt.Domain, vmId int) error {
+	xml, err := s.Conn.DomainGetXMLDesc(domain, 0)
+	if err != nil {
+		return fmt.Errorf("failed_to_get_domain_xml_desc: %w", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(xml); err != nil {
+		return fmt.Errorf("failed_to_parse_xml: %w", err)
+	}
+
+	devices := doc.FindElement("//devices")
+	if devices == nil {
+		return fmt.Errorf("devices_element_not_found")
+	}
+
+	if devices.FindElement("serial") != nil {
+		return nil
+	}
+
+	master, slave := vmConsoleDevices(vmId)
+	serial := devices.CreateElement("serial")
+	serial.CreateAttr("type", "nmdm")
+	source := serial.CreateElement("source")
+	source.CreateAttr("master", master)
+	source.CreateAttr("slave", slave)
+
+	out, err := doc.WriteToString()
+	if err != nil {
+		return fmt.Errorf("failed_to_write_xml: %w", err)
+	}
+
+	if _, err := s.Conn.DomainDefineXML(out); err != nil {
+		return fmt.Errorf("failed_to_define_domain_with_modified_xml: %w", err)
+	}
+
+	return nil
+}
+
+// openConsole starts capturing the console of a VM, or returns the capture
+// that is already running.
+func (s *Service) openConsole(vmId int) (*vmConsole, error) {
+	s.consoleMutex.Lock()
+	defer s.consoleMutex.Unlock()
+
+	if c, ok := s.consoles[vmId]; ok {
+		return c, nil
+	}
+
+	_, slave := vmConsoleDevices(vmId)
+
+	tty, err := os.OpenFile(slave, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_open_console_device: %w", err)
+	}
+
+	// The guest does its own echo and line editing.
+	if out, err := utils.RunCommand("stty", "-f", slave, "raw", "-echo"); err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("failed_to_configure_console_device: %s: %w", out, err)
+	}
+
+	logPath, err := vmConsoleLogPath(vmId)
+	if err != nil {
+		tty.Close()
+		return nil, err
+	}
+
+	c := &vmConsole{
+		vmId:    vmId,
+		tty:     tty,
+		logPath: logPath,
+		clients: make(map[chan []byte]struct{}),
+	}
+
+	if err := c.openLog(); err != nil {
+		tty.Close()
+		return nil, err
+	}
+
+	s.consoles[vmId] = c
+	go s.readConsole(c)
+
+	return c, nil
+}
+
+func (c *vmConsole) openLog() error {
+	log, err := os.OpenFile(c.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed_to_open_console_log: %w", err)
+	}
+
+	info, err := log.Stat()
+	if err != nil {
+		log.Close()
+		return fmt.Errorf("failed_to_open_console_log: %w", err)
+	}
+
+	c.log = log
+	c.logSize = info.Size()
+
+	return nil
+}
+
+// record appends output to the log and scrollback and hands it to every
+// client. Clients that fall behind are dropped rather than stalling the
+// console.
+func (c *vmConsole) record(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.log != nil {
+		if c.logSize+int64(len(data)) > consoleLogLimit {
+			c.log.Close()
+			c.log = nil
+
+			if err := os.Rename(c.logPath, c.logPath+".1"); err != nil {
+				logger.L.Warn().Err(err).Msgf("Failed to rotate console log of VM %d", c.vmId)
+			}
+
+			if err := c.openLog(); err != nil {
+				logger.L.Warn().Err(err).Msgf("Failed to reopen console log of VM %d", c.vmId)
+			}
+		}
+
+		if c.log != nil {
+			n, _ := c.log.Write(data)
+			c.logSize += int64(n)
+		}
+	}
+
+	c.scrollback = append(c.scrollback, data...)
+	if over := len(c.scrollback) - consoleScrollback; over > 0 {
+		c.scrollback = append(c.scrollback[:0], c.scrollback[over:]...)
+	}
+
+	for client := range c.clients {
+		select {
+		case client <- append([]byte(nil), data...):
+		default:
+			delete(c.clients, client)
+			close(client)
+		}
+	}
+}
+
+func (s *Service) readConsole(c *vmConsole) {
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := c.tty.Read(buf)
+		if n > 0 {
+			c.record(buf[:n])
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	s.consoleMutex.Lock()
+	if s.consoles[c.vmId] == c {
+		delete(s.consoles, c.vmId)
+	}
+	s.consoleMutex.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tty.Close()
+	if c.log != nil {
+		c.log.Close()
+		c.log = nil
+	}
+
+	for client := range c.clients {
+		delete(c.clients, client)
+		close(client)
+	}
+}
+
+// closeConsole stops capturing the console of a VM, its clients are
+// disconnected.
+func (s *Service) closeConsole(vmId int) {
+	s.consoleMutex.Lock()
+	c, ok := s.consoles[vmId]
+	delete(s.consoles, vmId)
+	s.consoleMutex.Unlock()
+
+	if ok {
+		c.tty.Close()
+	}
+}
+
+// AttachConsole connects a client to the serial console of a VM. It gets
+// the scrollback and a channel of everything written to the console after
+// it, the channel is closed when the console goes away or the client falls
+// too far behind. detach has to be called once the client is done.
+func (s *Service) AttachConsole(vmId int) ([]byte, <-chan []byte, func(), error) {
+	if _, err := s.GetVmByVmId(vmId); err != nil {
+		return nil, nil, nil, fmt.Errorf("vm_not_found: %d", vmId)
+	}
+
+	c, err := s.openConsole(vmId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	client := make(chan []byte, 256)
+
+	c.mu.Lock()
+	scrollback := append([]byte(nil), c.scrollback...)
+	c.clients[client] = struct{}{}
+	c.mu.Unlock()
+
+	detach := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if _, ok := c.clients[client]; ok {
+			delete(c.clients, client)
+			close(client)
+		}
+	}
+
+	return scrollback, client, detach, nil
+}
+
+// WriteConsole sends input to the serial console of a VM.
+func (s *Service) WriteConsole(vmId int, data []byte) error {
+	s.consoleMutex.Lock()
+	c, ok := s.consoles[vmId]
+	s.consoleMutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("console_not_attached: %d", vmId)
+	}
+
+	if _, err := c.tty.Write(data); err != nil {
+		return fmt.Errorf("failed_to_write_console: %w", err)
+	}
+
+	return nil
+}
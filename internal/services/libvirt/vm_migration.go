@@ -0,0 +1,957 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal"
+	"github.com/alchemillahq/sylve/internal/db/models"
+	clusterModels "github.com/alchemillahq/sylve/internal/db/models/cluster"
+	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
+	networkModels "github.com/alchemillahq/sylve/internal/db/models/network"
+	sambaModels "github.com/alchemillahq/sylve/internal/db/models/samba"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/utils"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+
+	"gorm.io/gorm"
+)
+
+const migrationSnapshotPrefix = "sylve-migrate"
+
+type migrationDataset struct {
+	source *zfs.Dataset
+	target string
+}
+
+// migrationReader counts the bytes of a send stream into the progress of the
+// migration it belongs to.
+type migrationReader struct {
+	r        io.Reader
+	s        *Service
+	progress *libvirtServiceInterfaces.MigrationProgress
+}
+
+func (m *migrationReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+
+	m.s.migrationMutex.Lock()
+	m.progress.BytesSent += int64(n)
+	m.s.migrationMutex.Unlock()
+
+	return n, err
+}
+
+// migrationDatasetName places a dataset in the target pool picked for its
+// pool, datasets of pools without a mapping keep their name.
+func migrationDatasetName(name string, pools map[string]string) string {
+	pool, rest, _ := strings.Cut(name, "/")
+	if target, ok := pools[pool]; ok && target != "" {
+		pool = target
+	}
+
+	return pool + "/" + rest
+}
+
+func isValidMigrationDataset(name string) bool {
+	return name != "" && strings.Contains(name, "/") && !strings.ContainsAny(name, "@# ")
+}
+
+func (s *Service) clusterHeaders() (map[string]string, error) {
+	hostname, err := utils.GetSystemHostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_hostname: %w", err)
+	}
+
+	token, err := s.Auth.CreateClusterJWT(0, hostname, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_create_cluster_token: %w", err)
+	}
+
+	return map[string]string{
+		"Accept":          "application/json",
+		"X-Cluster-Token": fmt.Sprintf("Bearer %s", token),
+	}, nil
+}
+
+func (s *Service) postToMigrationTarget(base string, path string, headers map[string]string, payload any) error {
+	body, _, err := utils.HTTPPostJSONRead(base+path, payload, headers)
+	if err != nil {
+		return fmt.Errorf("target_request_failed: %w", err)
+	}
+
+	var resp internal.APIResponse[any]
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("failed_to_parse_target_response: %w", err)
+	}
+
+	if resp.Status != "success" {
+		return fmt.Errorf("target_request_failed: %s", resp.Error)
+	}
+
+	return nil
+}
+
+// sendMigrationStream pipes a zfs send into the replication endpoint of the
+// target node.
+func (s *Service) sendMigrationStream(
+	base string,
+	headers map[string]string,
+	dataset string,
+	force bool,
+	progress *libvirtServiceInterfaces.MigrationProgress,
+	send func(w io.Writer) error,
+) error {
+	pr, pw := io.Pipe()
+	sendErr := make(chan error, 1)
+
+	go func() {
+		err := send(pw)
+		pw.CloseWithError(err)
+		sendErr <- err
+	}()
+
+	receiveURL := fmt.Sprintf("%s/api/zfs/replication/receive?dataset=%s&force=%t", base, url.QueryEscape(dataset), force)
+	reader := &migrationReader{r: pr, s: s, progress: progress}
+
+	if _, _, err := utils.HTTPPostStream(context.Background(), receiveURL, reader, headers); err != nil {
+		pr.CloseWithError(err)
+
+		select {
+		case sErr := <-sendErr:
+			if sErr != nil {
+				return sErr
+			}
+		default:
+		}
+
+		return err
+	}
+
+	return <-sendErr
+}
+
+func (s *Service) setMigrationStage(progress *libvirtServiceInterfaces.MigrationProgress, stage string, dataset string) {
+	s.migrationMutex.Lock()
+	defer s.migrationMutex.Unlock()
+
+	progress.Stage = stage
+	progress.Dataset = dataset
+}
+
+func (s *Service) finishMigration(progress *libvirtServiceInterfaces.MigrationProgress, err error) {
+	s.migrationMutex.Lock()
+	defer s.migrationMutex.Unlock()
+
+	now := time.Now()
+	progress.Done = true
+	progress.FinishedAt = &now
+	progress.Dataset = ""
+
+	if err != nil {
+		progress.Stage = "failed"
+		progress.Error = err.Error()
+	} else {
+		progress.Stage = "completed"
+	}
+}
+
+func (s *Service) GetMigrationProgress(id uint) (*libvirtServiceInterfaces.MigrationProgress, error) {
+	s.migrationMutex.Lock()
+	defer s.migrationMutex.Unlock()
+
+	progress, ok := s.migrations[id]
+	if !ok {
+		return nil, fmt.Errorf("migration_not_found: %d", id)
+	}
+
+	copied := *progress
+	return &copied, nil
+}
+
+// buildMigration describes a VM the way the target node recreates it and
+// lists the datasets that have to be sent. PCI devices without a mapping to
+// a device of the target are dropped, CPU pinning refers to cores of this
+// node and is not carried over.
+func (s *Service) buildMigration(vm vmModels.VM, req libvirtServiceInterfaces.MigrateVMRequest) (libvirtServiceInterfaces.VMMigration, []migrationDataset, error) {
+	var spec libvirtServiceInterfaces.VMMigration
+	var datasets []migrationDataset
+
+	byGUID, err := datasetsByGUID()
+	if err != nil {
+		return spec, nil, err
+	}
+
+	seen := make(map[string]bool)
+
+	for _, storage := range vm.Storages {
+		if storage.Type == "iso" {
+			spec.Storages = append(spec.Storages, libvirtServiceInterfaces.MigrationStorage{
+				Name:      storage.Name,
+				Type:      storage.Type,
+				Dataset:   storage.Dataset,
+				Emulation: storage.Emulation,
+			})
+			continue
+		}
+
+		dataset, ok := byGUID[storage.Dataset]
+		if !ok {
+			return spec, nil, fmt.Errorf("dataset_not_found: %s", storage.Dataset)
+		}
+
+		if !strings.Contains(dataset.Name, "/") {
+			return spec, nil, fmt.Errorf("cannot_migrate_pool_root_dataset: %s", dataset.Name)
+		}
+
+		// The source dataset is destroyed once the VM runs on the target,
+		// so it has to belong to this VM alone.
+		users, err := s.datasetUsers(dataset, vm.ID)
+		if err != nil {
+			return spec, nil, err
+		}
+
+		if len(users) > 0 {
+			return spec, nil, fmt.Errorf("dataset_shared: %s (%s)", dataset.Name, strings.Join(users, ", "))
+		}
+
+		target := migrationDatasetName(dataset.Name, req.Pools)
+
+		spec.Storages = append(spec.Storages, libvirtServiceInterfaces.MigrationStorage{
+			Name:      storage.Name,
+			Type:      storage.Type,
+			Dataset:   target,
+			Encrypted: dataset.IsEncrypted(),
+			Size:      storage.Size,
+			Emulation: storage.Emulation,
+		})
+
+		if !seen[storage.Dataset] {
+			seen[storage.Dataset] = true
+			datasets = append(datasets, migrationDataset{source: dataset, target: target})
+		}
+	}
+
+	spec.Networks = portableNetworks(vm)
+
+	for _, pciId := range vm.PCIDevices {
+		var device models.PassedThroughIDs
+		if err := s.DB.First(&device, "id = ?", pciId).Error; err != nil {
+			continue
+		}
+
+		if target := req.PCIDevices[device.DeviceID]; target != "" {
+			spec.PCIDevices = append(spec.PCIDevices, target)
+		}
+	}
+
+	spec.VM = portableVM(vm)
+
+	return spec, datasets, nil
+}
+
+// datasetUsers lists what besides the VM with the given id depends on a
+// dataset: child datasets, other VMs, jails and Samba shares.
+func (s *Service) datasetUsers(dataset *zfs.Dataset, id uint) ([]string, error) {
+	var users []string
+
+	children, err := dataset.Children(1)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_list_child_datasets: %w", err)
+	}
+
+	for _, child := range children {
+		if child.Type != zfs.DatasetSnapshot {
+			users = append(users, child.Name)
+		}
+	}
+
+	var vms []vmModels.VM
+	if err := s.DB.Where("id <> ? AND id IN (?)", id,
+		s.DB.Model(&vmModels.Storage{}).Select("vm_id").Where("dataset = ?", dataset.GUID),
+	).Find(&vms).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_find_vms_using_dataset: %w", err)
+	}
+
+	for _, vm := range vms {
+		users = append(users, fmt.Sprintf("vm_%d", vm.VmID))
+	}
+
+	var jails []jailModels.Jail
+	if err := s.DB.Where("dataset = ?", dataset.GUID).Find(&jails).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_find_jails_using_dataset: %w", err)
+	}
+
+	for _, jail := range jails {
+		users = append(users, fmt.Sprintf("jail_%d", jail.CTID))
+	}
+
+	var shares []sambaModels.SambaShare
+	if err := s.DB.Where("dataset = ?", dataset.GUID).Find(&shares).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_find_shares_using_dataset: %w", err)
+	}
+
+	for _, share := range shares {
+		users = append(users, fmt.Sprintf("share_%s", share.Name))
+	}
+
+	return users, nil
+}
+
+// portableVM copies the settings of a VM that mean the same on any node,
+// leaving out its rows, devices and CPU pinning.
+func portableVM(vm vmModels.VM) vmModels.VM {
+	copied := vm
+	copied.ID = 0
+	copied.Storages = nil
+	copied.Networks = nil
+	copied.PCIDevices = nil
+	copied.CPUPinning = nil
+	copied.TemplateID = nil
+	copied.CurrentSnapshotID = nil
+
+	if vm.CloudInit != nil {
+		cloudInit := *vm.CloudInit
+		cloudInit.ID = 0
+		cloudInit.VMID = 0
+		copied.CloudInit = &cloudInit
+	}
+
+	return copied
+}
+
+// portableNetworks describes the networks of a VM by switch name and MAC
+// address. Networks and Switch have to be preloaded.
+func portableNetworks(vm vmModels.VM) []libvirtServiceInterfaces.MigrationNetwork {
+	var networks []libvirtServiceInterfaces.MigrationNetwork
+
+	for _, network := range vm.Networks {
+		mac := network.MAC
+		macName := ""
+
+		if network.AddressObj != nil {
+			macName = network.AddressObj.Name
+			if len(network.AddressObj.Entries) > 0 {
+				mac = network.AddressObj.Entries[0].Value
+			}
+		}
+
+		networks = append(networks, libvirtServiceInterfaces.MigrationNetwork{
+			Switch:    network.Switch.Name,
+			MAC:       mac,
+			MACName:   macName,
+			Emulation: network.Emulation,
+			DHCP:      network.DHCP,
+			SLAAC:     network.SLAAC,
+		})
+	}
+
+	return networks
+}
+
+func snapshotMigrationDatasets(datasets []migrationDataset, name string) error {
+	pools := make(map[string][]string)
+	for _, d := range datasets {
+		pool := strings.SplitN(d.source.Name, "/", 2)[0]
+		pools[pool] = append(pools[pool], d.source.Name)
+	}
+
+	for _, names := range pools {
+		if err := zfs.SnapshotDatasets(names, name); err != nil {
+			return fmt.Errorf("failed_to_snapshot_datasets: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func destroyMigrationSnapshots(datasets []migrationDataset, names ...string) {
+	for _, d := range datasets {
+		for _, name := range names {
+			snap, err := zfs.GetDataset(d.source.Name + "@" + name)
+			if err != nil {
+				continue
+			}
+
+			if err := snap.Destroy(zfs.DestroyDefault); err != nil {
+				logger.L.Warn().Err(err).Msgf("Failed to destroy migration snapshot %s", snap.Name)
+			}
+		}
+	}
+}
+
+// MigrateVM moves a VM to another node of the cluster. The target checks
+// that it can take the VM before anything is sent, the migration itself runs
+// in the background and reports through GetMigrationProgress.
+func (s *Service) MigrateVM(id uint, req libvirtServiceInterfaces.MigrateVMRequest) error {
+	var vm vmModels.VM
+	if err := preloadCloudInit(s.DB).Preload("Storages").First(&vm, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("vm_not_found: %d", id)
+		}
+		return fmt.Errorf("failed_to_find_vm: %w", err)
+	}
+
+	if vm.Template {
+		return fmt.Errorf("vm_is_template")
+	}
+
+	// VM snapshots are zfs snapshots of this node's datasets, only the state
+	// the VM is in gets sent.
+	var snapshots int64
+	if err := s.DB.Model(&vmModels.Snapshot{}).Where("vm_id = ?", vm.ID).Count(&snapshots).Error; err != nil {
+		return fmt.Errorf("failed_to_count_snapshots: %w", err)
+	}
+
+	if snapshots > 0 {
+		return fmt.Errorf("vm_has_snapshots")
+	}
+
+	if local, err := utils.GetSystemUUID(); err == nil && local == req.TargetNode {
+		return fmt.Errorf("cannot_migrate_to_same_node")
+	}
+
+	var node clusterModels.ClusterNode
+	if err := s.DB.Where("node_uuid = ?", req.TargetNode).First(&node).Error; err != nil {
+		return fmt.Errorf("target_node_not_found")
+	}
+
+	base := "https://" + node.API
+
+	headers, err := s.clusterHeaders()
+	if err != nil {
+		return err
+	}
+
+	spec, datasets, err := s.buildMigration(vm, req)
+	if err != nil {
+		return err
+	}
+
+	s.migrationMutex.Lock()
+	if running, ok := s.migrations[vm.ID]; ok && !running.Done {
+		s.migrationMutex.Unlock()
+		return fmt.Errorf("migration_already_running")
+	}
+
+	progress := &libvirtServiceInterfaces.MigrationProgress{
+		VMID:       vm.VmID,
+		TargetNode: req.TargetNode,
+		Stage:      "preparing",
+		StartedAt:  time.Now(),
+	}
+
+	s.migrations[vm.ID] = progress
+	s.migrationMutex.Unlock()
+
+	if err := s.postToMigrationTarget(base, "/api/vm/migration/prepare", headers, spec); err != nil {
+		s.finishMigration(progress, err)
+		return err
+	}
+
+	go func() {
+		err := s.migrateVM(vm, spec, datasets, base, headers, progress)
+		if err != nil {
+			logger.L.Error().Err(err).Msgf("Failed to migrate VM %d to %s", vm.VmID, req.TargetNode)
+		}
+
+		s.finishMigration(progress, err)
+	}()
+
+	return nil
+}
+
+// migrateVM sends the disks once while the VM keeps running and once more,
+// incrementally, after it is stopped, so the downtime only covers what
+// changed in between. Nothing on this node is removed before the target has
+// defined the VM, a failure before that restarts the VM if it was running
+// and asks the target to drop what it received.
+func (s *Service) migrateVM(
+	vm vmModels.VM,
+	spec libvirtServiceInterfaces.VMMigration,
+	datasets []migrationDataset,
+	base string,
+	headers map[string]string,
+	progress *libvirtServiceInterfaces.MigrationProgress,
+) error {
+	stamp := time.Now().Unix()
+	initial := fmt.Sprintf("%s-%d-1", migrationSnapshotPrefix, stamp)
+	final := fmt.Sprintf("%s-%d-2", migrationSnapshotPrefix, stamp)
+
+	inactive, err := s.IsDomainInactive(vm.VmID)
+	if err != nil {
+		return err
+	}
+
+	stopped := false
+
+	fail := func(err error) error {
+		s.setMigrationStage(progress, "reverting", "")
+
+		targets := make([]string, 0, len(datasets))
+		for _, d := range datasets {
+			targets = append(targets, d.target)
+		}
+
+		if err := s.postToMigrationTarget(base, "/api/vm/migration/abort", headers, libvirtServiceInterfaces.AbortMigrationRequest{
+			Datasets: targets,
+		}); err != nil {
+			logger.L.Warn().Err(err).Msgf("Failed to clean up migration of VM %d on target", vm.VmID)
+		}
+
+		destroyMigrationSnapshots(datasets, initial, final)
+
+		if stopped {
+			if err := s.LvVMAction(vm, "start"); err != nil {
+				logger.L.Warn().Err(err).Msgf("Failed to restart VM %d after failed migration", vm.VmID)
+			}
+		}
+
+		return err
+	}
+
+	s.setMigrationStage(progress, "snapshotting", "")
+	if err := snapshotMigrationDatasets(datasets, initial); err != nil {
+		return fail(err)
+	}
+
+	for _, d := range datasets {
+		s.setMigrationStage(progress, "sending", d.target)
+
+		snap, err := zfs.GetDataset(d.source.Name + "@" + initial)
+		if err != nil {
+			return fail(fmt.Errorf("migration_snapshot_not_found: %w", err))
+		}
+
+		if err := s.sendMigrationStream(base, headers, d.target, false, progress, func(w io.Writer) error {
+			return snap.Send(zfs.SendOptions{}, w)
+		}); err != nil {
+			return fail(fmt.Errorf("failed_to_send_dataset: %w", err))
+		}
+	}
+
+	if !inactive {
+		s.setMigrationStage(progress, "stopping", "")
+		if err := s.LvVMAction(vm, "stop"); err != nil {
+			return fail(fmt.Errorf("failed_to_stop_vm: %w", err))
+		}
+		stopped = true
+	}
+
+	s.setMigrationStage(progress, "snapshotting", "")
+	if err := snapshotMigrationDatasets(datasets, final); err != nil {
+		return fail(err)
+	}
+
+	for _, d := range datasets {
+		s.setMigrationStage(progress, "syncing", d.target)
+
+		snap, err := zfs.GetDataset(d.source.Name + "@" + final)
+		if err != nil {
+			return fail(fmt.Errorf("migration_snapshot_not_found: %w", err))
+		}
+
+		opts := zfs.SendOptions{Base: d.source.Name + "@" + initial}
+		if err := s.sendMigrationStream(base, headers, d.target, true, progress, func(w io.Writer) error {
+			return snap.Send(opts, w)
+		}); err != nil {
+			return fail(fmt.Errorf("failed_to_send_dataset: %w", err))
+		}
+	}
+
+	varsPath, err := vmUEFIVarsPath(vm.VmID)
+	if err != nil {
+		return fail(err)
+	}
+
+	vars, err := os.ReadFile(varsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fail(fmt.Errorf("failed_to_read_uefi_vars: %w", err))
+	}
+
+	spec.UEFIVars = vars
+	spec.Start = !inactive
+
+	s.setMigrationStage(progress, "defining", "")
+	if err := s.postToMigrationTarget(base, "/api/vm/migration/receive", headers, spec); err != nil {
+		return fail(err)
+	}
+
+	// The VM lives on the target now, anything failing from here on only
+	// leaves leftovers behind on this node.
+	s.setMigrationStage(progress, "cleaning_up", "")
+
+	if err := s.RemoveVM(vm.ID, true); err != nil {
+		logger.L.Warn().Err(err).Msgf("Failed to remove migrated VM %d", vm.VmID)
+	}
+
+	for _, d := range datasets {
+		users, err := s.datasetUsers(d.source, vm.ID)
+		if err == nil && len(users) > 0 {
+			err = fmt.Errorf("dataset_shared: %s", strings.Join(users, ", "))
+		}
+
+		if err != nil {
+			logger.L.Warn().Err(err).Msgf("Keeping migrated dataset %s", d.source.Name)
+			continue
+		}
+
+		if err := d.source.Destroy(zfs.DestroyRecursive); err != nil {
+			logger.L.Warn().Err(err).Msgf("Failed to destroy migrated dataset %s", d.source.Name)
+		}
+	}
+
+	return nil
+}
+
+// macInUse reports whether a network of a VM on this node has mac.
+func (s *Service) macInUse(mac string) (bool, error) {
+	var count int64
+	if err := s.DB.Model(&vmModels.Network{}).
+		Joins("LEFT JOIN objects ON networks.mac_id = objects.id").
+		Joins("LEFT JOIN object_entries ON object_entries.object_id = objects.id").
+		Where("LOWER(object_entries.value) = ?", strings.ToLower(mac)).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed_to_check_mac_usage: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// validateMigration checks that a VM fits on this node. Before the datasets
+// are sent they must not exist yet, once they are received they must.
+func (s *Service) validateMigration(m libvirtServiceInterfaces.VMMigration, received bool) error {
+	if !utils.IsValidVMName(m.VM.Name) {
+		return fmt.Errorf("invalid_vm_name")
+	}
+
+	if _, err := s.cloneVMID(&m.VM.VmID); err != nil {
+		return err
+	}
+
+	for _, network := range m.Networks {
+		var count int64
+		if err := s.DB.Model(&networkModels.StandardSwitch{}).Where("name = ?", network.Switch).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed_to_check_switch: %w", err)
+		}
+
+		if count == 0 {
+			return fmt.Errorf("switch_not_found: %s", network.Switch)
+		}
+
+		if network.MAC == "" {
+			continue
+		}
+
+		inUse, err := s.macInUse(network.MAC)
+		if err != nil {
+			return err
+		}
+
+		if inUse {
+			return fmt.Errorf("mac_already_in_use: %s", network.MAC)
+		}
+	}
+
+	for _, deviceId := range m.PCIDevices {
+		var count int64
+		if err := s.DB.Model(&models.PassedThroughIDs{}).Where("device_id = ?", deviceId).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed_to_check_pci_device: %w", err)
+		}
+
+		if count == 0 {
+			return fmt.Errorf("pci_device_not_passed_through: %s", deviceId)
+		}
+	}
+
+	for _, storage := range m.Storages {
+		if storage.Type == "iso" {
+			continue
+		}
+
+		if !isValidMigrationDataset(storage.Dataset) {
+			return fmt.Errorf("invalid_target_dataset: %s", storage.Dataset)
+		}
+
+		dataset, err := zfs.GetDataset(storage.Dataset)
+
+		if received {
+			if err != nil {
+				return fmt.Errorf("target_dataset_not_found: %s", storage.Dataset)
+			}
+
+			if (storage.Type == "zvol" && dataset.Type != zfs.DatasetVolume) ||
+				(storage.Type == "raw" && dataset.Type != zfs.DatasetFilesystem) {
+				return fmt.Errorf("invalid_dataset_type: %s", storage.Dataset)
+			}
+
+			var count int64
+			if err := s.DB.Model(&vmModels.Storage{}).Where("dataset = ?", dataset.GUID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed_to_check_dataset_usage: %w", err)
+			}
+
+			if count > 0 {
+				return fmt.Errorf("target_dataset_in_use: %s", storage.Dataset)
+			}
+
+			continue
+		}
+
+		if err == nil {
+			return fmt.Errorf("target_dataset_exists: %s", storage.Dataset)
+		}
+
+		parent, err := zfs.GetDataset(storage.Dataset[:strings.LastIndex(storage.Dataset, "/")])
+		if err != nil {
+			return fmt.Errorf("target_parent_not_found: %s", storage.Dataset)
+		}
+
+		// Streams are sent decrypted, they only end up encrypted again
+		// below an encrypted parent.
+		if storage.Encrypted && !parent.IsEncrypted() {
+			return fmt.Errorf("target_parent_not_encrypted: %s", storage.Dataset)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) PrepareMigration(m libvirtServiceInterfaces.VMMigration) error {
+	return s.validateMigration(m, false)
+}
+
+// ReceiveMigration recreates a VM whose datasets have been sent to this node.
+// Networks get their MAC addresses back on switches of the same name, static
+// IP objects stay behind and only DHCP and SLAAC carry over. ISOs this node
+// does not have are dropped and the VNC port moves if it is taken here.
+func (s *Service) ReceiveMigration(m libvirtServiceInterfaces.VMMigration) error {
+	if err := s.validateMigration(m, true); err != nil {
+		return err
+	}
+
+	vncPort, err := s.cloneVNCPort(m.VM.VNCPort)
+	if err != nil {
+		if vncPort, err = s.cloneVNCPort(0); err != nil {
+			return err
+		}
+	}
+
+	var storages []vmModels.Storage
+	for _, storage := range m.Storages {
+		guid := storage.Dataset
+
+		if storage.Type == "iso" {
+			if _, err := s.FindISOByUUID(storage.Dataset, false); err != nil {
+				logger.L.Warn().Msgf("Dropping ISO %s of migrated VM %d, it is not available on this node", storage.Dataset, m.VM.VmID)
+				continue
+			}
+		} else {
+			dataset, err := zfs.GetDataset(storage.Dataset)
+			if err != nil {
+				return fmt.Errorf("target_dataset_not_found: %s", storage.Dataset)
+			}
+
+			// Replication streams are received unmounted.
+			if dataset.Type == zfs.DatasetFilesystem && dataset.Mounted != "yes" {
+				if _, err := dataset.Mount(false, nil); err != nil {
+					return fmt.Errorf("failed_to_mount_dataset: %w", err)
+				}
+			}
+
+			guid = dataset.GUID
+		}
+
+		storages = append(storages, vmModels.Storage{
+			Name:      storage.Name,
+			Type:      storage.Type,
+			Dataset:   guid,
+			Size:      storage.Size,
+			Emulation: storage.Emulation,
+		})
+	}
+
+	iso := m.VM.ISO
+	if iso != "" {
+		if _, err := s.FindISOByUUID(iso, false); err != nil {
+			iso = ""
+		}
+	}
+
+	var pciIds []int
+	for _, deviceId := range m.PCIDevices {
+		var device models.PassedThroughIDs
+		if err := s.DB.First(&device, "device_id = ?", deviceId).Error; err != nil {
+			return fmt.Errorf("pci_device_not_passed_through: %s", deviceId)
+		}
+
+		pciIds = append(pciIds, device.ID)
+	}
+
+	var macIds []uint
+
+	cleanup := func() {
+		if len(macIds) > 0 {
+			s.DB.Where("object_id IN ?", macIds).Delete(&networkModels.ObjectEntry{})
+			s.DB.Delete(&networkModels.Object{}, macIds)
+		}
+	}
+
+	var networks []vmModels.Network
+	for _, network := range m.Networks {
+		var sw networkModels.StandardSwitch
+		if err := s.DB.Where("name = ?", network.Switch).First(&sw).Error; err != nil {
+			cleanup()
+			return fmt.Errorf("switch_not_found: %s", network.Switch)
+		}
+
+		name := network.MACName
+		if name == "" {
+			name = fmt.Sprintf("%s-%s", m.VM.Name, sw.Name)
+		}
+
+		var macId uint
+		if network.MAC != "" {
+			macId, err = s.createMacObjectWithAddress(name, network.MAC)
+		} else {
+			macId, err = s.createMacObject(name)
+		}
+
+		if err != nil {
+			cleanup()
+			return err
+		}
+
+		macIds = append(macIds, macId)
+
+		networks = append(networks, vmModels.Network{
+			MacID:     &macId,
+			SwitchID:  uint(sw.ID),
+			DHCP:      network.DHCP,
+			SLAAC:     network.SLAAC,
+			Emulation: network.Emulation,
+		})
+	}
+
+	vm := m.VM
+	vm.ID = 0
+	vm.VNCPort = vncPort
+	vm.ISO = iso
+	vm.Template = false
+	vm.TemplateID = nil
+	vm.CurrentSnapshotID = nil
+	vm.CPUPinning = nil
+	vm.PCIDevices = pciIds
+	vm.Storages = storages
+	vm.Networks = networks
+	vm.StartedAt = nil
+	vm.StoppedAt = nil
+
+	if vm.CloudInit != nil {
+		vm.CloudInit.ID = 0
+		vm.CloudInit.VMID = 0
+	}
+
+	if err := s.DB.
+		Session(&gorm.Session{FullSaveAssociations: true}).
+		Create(&vm).Error; err != nil {
+		cleanup()
+		return fmt.Errorf("failed_to_create_vm_with_associations: %w", err)
+	}
+
+	if err := s.createLvVm(int(vm.ID), false); err != nil {
+		s.DB.Select("Storages", "Networks", "CloudInit").Delete(&vm)
+		cleanup()
+		return fmt.Errorf("failed_to_create_lv_vm: %w", err)
+	}
+
+	if len(m.UEFIVars) > 0 {
+		varsPath, err := vmUEFIVarsPath(vm.VmID)
+		if err == nil {
+			err = os.WriteFile(varsPath, m.UEFIVars, 0644)
+		}
+
+		if err != nil {
+			if err := s.RemoveLvVm(vm.VmID); err != nil {
+				logger.L.Warn().Err(err).Msgf("Failed to undefine migrated VM %d", vm.VmID)
+			}
+			s.DB.Select("Storages", "Networks", "CloudInit").Delete(&vm)
+			cleanup()
+			return fmt.Errorf("failed_to_restore_uefi_vars: %w", err)
+		}
+	}
+
+	if m.Start {
+		if err := s.LvVMAction(vm, "start"); err != nil {
+			logger.L.Warn().Err(err).Msgf("Failed to start migrated VM %d", vm.VmID)
+		}
+	}
+
+	return nil
+}
+
+// AbortMigration destroys what was received for a migration that failed.
+// Only datasets that hold a migration snapshot or a partial receive are
+// touched, and never one a VM on this node uses.
+func (s *Service) AbortMigration(datasets []string) error {
+	for _, name := range datasets {
+		if !isValidMigrationDataset(name) {
+			return fmt.Errorf("invalid_target_dataset: %s", name)
+		}
+
+		dataset, err := zfs.GetDataset(name)
+		if err != nil {
+			continue
+		}
+
+		var count int64
+		if err := s.DB.Model(&vmModels.Storage{}).Where("dataset = ?", dataset.GUID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed_to_check_dataset_usage: %w", err)
+		}
+
+		if count > 0 {
+			return fmt.Errorf("dataset_in_use: %s", name)
+		}
+
+		received := dataset.ResumeToken() != ""
+
+		if !received {
+			snapshots, err := zfs.Snapshots(name)
+			if err != nil {
+				return fmt.Errorf("failed_to_list_snapshots: %w", err)
+			}
+
+			for _, snap := range snapshots {
+				if strings.HasPrefix(snap.Name, name+"@"+migrationSnapshotPrefix+"-") {
+					received = true
+					break
+				}
+			}
+		}
+
+		if !received {
+			return fmt.Errorf("not_a_migration_dataset: %s", name)
+		}
+
+		if err := dataset.Destroy(zfs.DestroyRecursive); err != nil {
+			return fmt.Errorf("failed_to_destroy_dataset: %w", err)
+		}
+	}
+
+	return nil
+}
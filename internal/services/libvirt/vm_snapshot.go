@@ -0,0 +1,555 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal/config"
+	networkModels "github.com/alchemillahq/sylve/internal/db/models/network"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// datasetsByGUID maps the GUIDs of all filesystems and volumes to their
+// datasets, storages refer to datasets by GUID so renames do not break them.
+func datasetsByGUID() (map[string]*zfs.Dataset, error) {
+	datasets, err := zfs.Datasets("")
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_datasets: %w", err)
+	}
+
+	byGUID := make(map[string]*zfs.Dataset, len(datasets))
+	for _, d := range datasets {
+		if d.Type == zfs.DatasetFilesystem || d.Type == zfs.DatasetVolume {
+			byGUID[d.GUID] = d
+		}
+	}
+
+	return byGUID, nil
+}
+
+func vmUEFIVarsPath(vmId int) (string, error) {
+	vmDir, err := config.GetVMsPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get VMs path: %w", err)
+	}
+
+	return filepath.Join(vmDir, strconv.Itoa(vmId), fmt.Sprintf("%d_vars.fd", vmId)), nil
+}
+
+// newerSnapshots returns the snapshots of a dataset taken after snapName,
+// zfs lists the snapshots of a dataset in the order they were taken.
+func newerSnapshots(dataset string, snapName string) ([]*zfs.Dataset, error) {
+	snapshots, err := zfs.Snapshots(dataset)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_list_snapshots: %w", err)
+	}
+
+	var newer []*zfs.Dataset
+	found := false
+
+	for _, snap := range snapshots {
+		if !strings.HasPrefix(snap.Name, dataset+"@") {
+			continue
+		}
+
+		if found {
+			newer = append(newer, snap)
+		} else if snap.Name == dataset+"@"+snapName {
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("snapshot_not_found_on_dataset: %s", dataset)
+	}
+
+	return newer, nil
+}
+
+func (s *Service) findVMSnapshot(vmId uint, snapshotId uint) (vmModels.Snapshot, error) {
+	var snapshot vmModels.Snapshot
+	if err := s.DB.Where("id = ? AND vm_id = ?", snapshotId, vmId).First(&snapshot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return snapshot, fmt.Errorf("snapshot_not_found: %d", snapshotId)
+		}
+		return snapshot, fmt.Errorf("failed_to_find_snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// ListVMSnapshots returns the snapshots of a VM as a tree, each snapshot is
+// a child of the one the VM was at when it was taken.
+func (s *Service) ListVMSnapshots(id uint) ([]libvirtServiceInterfaces.VMSnapshotNode, error) {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []vmModels.Snapshot
+	if err := s.DB.Where("vm_id = ?", vm.ID).Order("id ASC").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_snapshots: %w", err)
+	}
+
+	children := make(map[uint][]vmModels.Snapshot)
+	var roots []vmModels.Snapshot
+
+	for _, snapshot := range snapshots {
+		if snapshot.ParentID == nil {
+			roots = append(roots, snapshot)
+		} else {
+			children[*snapshot.ParentID] = append(children[*snapshot.ParentID], snapshot)
+		}
+	}
+
+	var build func(list []vmModels.Snapshot) []libvirtServiceInterfaces.VMSnapshotNode
+	build = func(list []vmModels.Snapshot) []libvirtServiceInterfaces.VMSnapshotNode {
+		nodes := make([]libvirtServiceInterfaces.VMSnapshotNode, 0, len(list))
+		for _, snapshot := range list {
+			nodes = append(nodes, libvirtServiceInterfaces.VMSnapshotNode{
+				Snapshot: snapshot,
+				Current:  vm.CurrentSnapshotID != nil && *vm.CurrentSnapshotID == snapshot.ID,
+				Children: build(children[snapshot.ID]),
+			})
+		}
+		return nodes
+	}
+
+	return build(roots), nil
+}
+
+// CreateVMSnapshot snapshots all disks of a VM at once, one zfs snapshot
+// command per pool, and keeps the VM's configuration alongside them. Running
+// VMs get a crash consistent snapshot.
+func (s *Service) CreateVMSnapshot(id uint, req libvirtServiceInterfaces.CreateVMSnapshotRequest) (*vmModels.Snapshot, error) {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.Template {
+		return nil, fmt.Errorf("vm_is_template")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("snapshot_name_required")
+	}
+
+	var count int64
+	if err := s.DB.Model(&vmModels.Snapshot{}).Where("vm_id = ? AND name = ?", vm.ID, name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_check_snapshot_name: %w", err)
+	}
+
+	if count > 0 {
+		return nil, fmt.Errorf("snapshot_name_already_exists: %s", name)
+	}
+
+	byGUID, err := datasetsByGUID()
+	if err != nil {
+		return nil, err
+	}
+
+	// Several raw disks can live on one filesystem, it is snapshotted once.
+	var guids []string
+	pools := make(map[string][]string)
+	seen := make(map[string]bool)
+
+	for _, storage := range vm.Storages {
+		if storage.Type == "iso" || seen[storage.Dataset] {
+			continue
+		}
+
+		dataset, ok := byGUID[storage.Dataset]
+		if !ok {
+			return nil, fmt.Errorf("dataset_not_found: %s", storage.Dataset)
+		}
+
+		seen[storage.Dataset] = true
+		guids = append(guids, storage.Dataset)
+
+		pool := strings.SplitN(dataset.Name, "/", 2)[0]
+		pools[pool] = append(pools[pool], dataset.Name)
+	}
+
+	xml, err := s.GetVMXML(vm.VmID)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_vm_xml: %w", err)
+	}
+
+	vmConfig, err := json.Marshal(vm)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_marshal_vm_config: %w", err)
+	}
+
+	varsPath, err := vmUEFIVarsPath(vm.VmID)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := os.ReadFile(varsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed_to_read_uefi_vars: %w", err)
+	}
+
+	snapName := fmt.Sprintf("sylve-%d-%d", vm.VmID, time.Now().UnixMilli())
+
+	var taken []string
+	for _, datasets := range pools {
+		if err := zfs.SnapshotDatasets(datasets, snapName); err != nil {
+			for _, name := range taken {
+				if snap, err := zfs.GetDataset(name + "@" + snapName); err == nil {
+					if err := snap.Destroy(zfs.DestroyDefault); err != nil {
+						logger.L.Warn().Err(err).Msgf("Failed to destroy snapshot %s", snap.Name)
+					}
+				}
+			}
+			return nil, fmt.Errorf("failed_to_snapshot_datasets: %w", err)
+		}
+		taken = append(taken, datasets...)
+	}
+
+	snapshot := &vmModels.Snapshot{
+		VMID:         vm.ID,
+		ParentID:     vm.CurrentSnapshotID,
+		Name:         name,
+		Description:  req.Description,
+		SnapshotName: snapName,
+		Datasets:     guids,
+		Config:       string(vmConfig),
+		DomainXML:    xml,
+		UEFIVars:     vars,
+	}
+
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(snapshot).Error; err != nil {
+			return fmt.Errorf("failed_to_create_snapshot: %w", err)
+		}
+
+		if err := tx.Model(&vmModels.VM{}).Where("id = ?", vm.ID).Update("current_snapshot_id", snapshot.ID).Error; err != nil {
+			return fmt.Errorf("failed_to_update_current_snapshot: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// destroyVMSnapshot removes the zfs snapshots of a VM snapshot and takes it
+// out of the tree, its children move up to its parent.
+func (s *Service) destroyVMSnapshot(vm vmModels.VM, snapshot vmModels.Snapshot, byGUID map[string]*zfs.Dataset) error {
+	for _, guid := range snapshot.Datasets {
+		dataset, ok := byGUID[guid]
+		if !ok {
+			continue
+		}
+
+		snap, err := zfs.GetDataset(dataset.Name + "@" + snapshot.SnapshotName)
+		if err != nil {
+			continue
+		}
+
+		if err := snap.Destroy(zfs.DestroyDefault); err != nil {
+			return fmt.Errorf("failed_to_destroy_snapshot: %w", err)
+		}
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&vmModels.Snapshot{}).Where("parent_id = ?", snapshot.ID).Update("parent_id", snapshot.ParentID).Error; err != nil {
+			return fmt.Errorf("failed_to_update_child_snapshots: %w", err)
+		}
+
+		if err := tx.Model(&vmModels.VM{}).
+			Where("id = ? AND current_snapshot_id = ?", vm.ID, snapshot.ID).
+			Update("current_snapshot_id", snapshot.ParentID).Error; err != nil {
+			return fmt.Errorf("failed_to_update_current_snapshot: %w", err)
+		}
+
+		if err := tx.Delete(&snapshot).Error; err != nil {
+			return fmt.Errorf("failed_to_delete_snapshot: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// destroyVMSnapshotDatasets destroys the zfs snapshots of all snapshots of a
+// VM that is being removed, the rows go with the VM.
+func (s *Service) destroyVMSnapshotDatasets(vmId uint) error {
+	var snapshots []vmModels.Snapshot
+	if err := s.DB.Where("vm_id = ?", vmId).Find(&snapshots).Error; err != nil {
+		return fmt.Errorf("failed_to_list_snapshots: %w", err)
+	}
+
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	byGUID, err := datasetsByGUID()
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		for _, guid := range snapshot.Datasets {
+			dataset, ok := byGUID[guid]
+			if !ok {
+				continue
+			}
+
+			snap, err := zfs.GetDataset(dataset.Name + "@" + snapshot.SnapshotName)
+			if err != nil {
+				continue
+			}
+
+			if err := snap.Destroy(zfs.DestroyDefault); err != nil {
+				return fmt.Errorf("failed_to_destroy_snapshot: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteVMSnapshot(id uint, snapshotId uint) error {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := s.findVMSnapshot(vm.ID, snapshotId)
+	if err != nil {
+		return err
+	}
+
+	byGUID, err := datasetsByGUID()
+	if err != nil {
+		return err
+	}
+
+	return s.destroyVMSnapshot(vm, snapshot, byGUID)
+}
+
+// RollbackVMSnapshot returns the disks, settings and domain of a VM to a
+// snapshot. ZFS can only roll back to the latest snapshot of a dataset, so
+// newer VM snapshots are destroyed when destroyNewer is set and block the
+// rollback otherwise. Newer snapshots that are not VM snapshots, such as
+// periodic or replication snapshots, always block it.
+func (s *Service) RollbackVMSnapshot(id uint, snapshotId uint, req libvirtServiceInterfaces.RollbackVMSnapshotRequest) error {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return err
+	}
+
+	if vm.Template {
+		return fmt.Errorf("vm_is_template")
+	}
+
+	snapshot, err := s.findVMSnapshot(vm.ID, snapshotId)
+	if err != nil {
+		return err
+	}
+
+	var newer []vmModels.Snapshot
+	if err := s.DB.Where("vm_id = ? AND id > ?", vm.ID, snapshot.ID).Order("id DESC").Find(&newer).Error; err != nil {
+		return fmt.Errorf("failed_to_list_snapshots: %w", err)
+	}
+
+	if len(newer) > 0 && !req.DestroyNewer {
+		return fmt.Errorf("newer_snapshots_exist")
+	}
+
+	var saved vmModels.VM
+	if err := json.Unmarshal([]byte(snapshot.Config), &saved); err != nil {
+		return fmt.Errorf("failed_to_parse_snapshot_config: %w", err)
+	}
+
+	byGUID, err := datasetsByGUID()
+	if err != nil {
+		return err
+	}
+
+	for _, guid := range snapshot.Datasets {
+		if _, ok := byGUID[guid]; !ok {
+			return fmt.Errorf("snapshot_dataset_missing: %s", guid)
+		}
+	}
+
+	// zfs rollback -r would take every newer snapshot of a dataset with
+	// it, so only newer snapshots of this VM are destroyed and any other
+	// newer snapshot blocks the rollback before anything is touched.
+	vmSnapshots := make(map[string]bool, len(newer))
+	for _, n := range newer {
+		vmSnapshots[n.SnapshotName] = true
+	}
+
+	for _, guid := range snapshot.Datasets {
+		later, err := newerSnapshots(byGUID[guid].Name, snapshot.SnapshotName)
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range later {
+			if !vmSnapshots[snap.Name[strings.Index(snap.Name, "@")+1:]] {
+				return fmt.Errorf("newer_foreign_snapshot_exists: %s", snap.Name)
+			}
+
+			holds, err := snap.Holds(false)
+			if err != nil {
+				return fmt.Errorf("failed_to_check_snapshot_holds: %w", err)
+			}
+
+			if len(holds) > 0 {
+				return fmt.Errorf("newer_snapshot_is_held: %s", snap.Name)
+			}
+		}
+	}
+
+	for _, network := range saved.Networks {
+		var count int64
+		if err := s.DB.Model(&networkModels.StandardSwitch{}).Where("id = ?", network.SwitchID).Count(&count).Error; err != nil || count == 0 {
+			return fmt.Errorf("snapshot_switch_missing: %d", network.SwitchID)
+		}
+
+		if network.MacID != nil {
+			if err := s.DB.Model(&networkModels.Object{}).Where("id = ?", *network.MacID).Count(&count).Error; err != nil || count == 0 {
+				return fmt.Errorf("snapshot_mac_object_missing: %d", *network.MacID)
+			}
+		}
+	}
+
+	inactive, err := s.IsDomainInactive(vm.VmID)
+	if err != nil {
+		return err
+	}
+
+	if !inactive {
+		if !req.Force {
+			return fmt.Errorf("domain_state_not_shutoff: %d", vm.VmID)
+		}
+
+		if err := s.LvVMAction(vm, "stop"); err != nil {
+			return fmt.Errorf("failed_to_stop_vm: %w", err)
+		}
+	}
+
+	for _, n := range newer {
+		if err := s.destroyVMSnapshot(vm, n, byGUID); err != nil {
+			return err
+		}
+	}
+
+	for _, guid := range snapshot.Datasets {
+		snap, err := zfs.GetDataset(byGUID[guid].Name + "@" + snapshot.SnapshotName)
+		if err != nil {
+			return fmt.Errorf("snapshot_not_found_on_dataset: %s", byGUID[guid].Name)
+		}
+
+		if err := snap.Rollback(false); err != nil {
+			return fmt.Errorf("failed_to_rollback_dataset: %w", err)
+		}
+	}
+
+	storages, networks, cloudInit := saved.Storages, saved.Networks, saved.CloudInit
+
+	saved.ID = vm.ID
+	saved.VmID = vm.VmID
+	saved.Template = false
+	saved.CurrentSnapshotID = &snapshot.ID
+	saved.StartedAt = vm.StartedAt
+	saved.StoppedAt = vm.StoppedAt
+
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(&saved).Error; err != nil {
+			return fmt.Errorf("failed_to_restore_vm: %w", err)
+		}
+
+		if err := tx.Where("vm_id = ?", vm.ID).Delete(&vmModels.Storage{}).Error; err != nil {
+			return fmt.Errorf("failed_to_delete_storages: %w", err)
+		}
+
+		for _, storage := range storages {
+			storage.ID = 0
+			storage.VMID = vm.ID
+			if err := tx.Create(&storage).Error; err != nil {
+				return fmt.Errorf("failed_to_restore_storage: %w", err)
+			}
+		}
+
+		if err := tx.Where("vm_id = ?", vm.ID).Delete(&vmModels.Network{}).Error; err != nil {
+			return fmt.Errorf("failed_to_delete_networks: %w", err)
+		}
+
+		for _, network := range networks {
+			network.ID = 0
+			network.VMID = vm.ID
+			if err := tx.Omit(clause.Associations).Create(&network).Error; err != nil {
+				return fmt.Errorf("failed_to_restore_network: %w", err)
+			}
+		}
+
+		if err := tx.Where("vm_id = ?", vm.ID).Delete(&vmModels.CloudInit{}).Error; err != nil {
+			return fmt.Errorf("failed_to_delete_cloud_init: %w", err)
+		}
+
+		if cloudInit != nil {
+			cloudInit.ID = 0
+			cloudInit.VMID = vm.ID
+			if err := tx.Create(cloudInit).Error; err != nil {
+				return fmt.Errorf("failed_to_restore_cloud_init: %w", err)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	domain, err := s.Conn.DomainLookupByName(strconv.Itoa(vm.VmID))
+	if err != nil {
+		return fmt.Errorf("failed_to_lookup_domain_by_name: %w", err)
+	}
+
+	if err := s.Conn.DomainUndefineFlags(domain, 0); err != nil {
+		return fmt.Errorf("failed_to_undefine_domain: %w", err)
+	}
+
+	if _, err := s.Conn.DomainDefineXML(snapshot.DomainXML); err != nil {
+		return fmt.Errorf("failed_to_define_domain: %w", err)
+	}
+
+	if len(snapshot.UEFIVars) > 0 {
+		varsPath, err := vmUEFIVarsPath(vm.VmID)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(varsPath, snapshot.UEFIVars, 0644); err != nil {
+			return fmt.Errorf("failed_to_restore_uefi_vars: %w", err)
+		}
+	}
+
+	return s.RefreshCloudInitSeed(vm.VmID)
+}
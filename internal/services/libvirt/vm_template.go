@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package libvirt
+
+import (
+	"fmt"
+
+	utilitiesModels "github.com/alchemillahq/sylve/internal/db/models/utilities"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+)
+
+// ensureNotTemplate refuses changes to templates, they stay as they were
+// when converted so every VM created from them starts out the same.
+func (s *Service) ensureNotTemplate(vmId int) error {
+	var count int64
+	if err := s.DB.Model(&vmModels.VM{}).Where("vm_id = ? AND template = ?", vmId, true).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed_to_find_vm: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("vm_is_template")
+	}
+
+	return nil
+}
+
+func (s *Service) ListTemplates() ([]vmModels.VM, error) {
+	var templates []vmModels.VM
+	if err := s.DB.Preload("Networks").Preload("Storages").Where("template = ?", true).Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_templates: %w", err)
+	}
+
+	for i := range templates {
+		templates[i].State = "INACTIVE"
+	}
+
+	return templates, nil
+}
+
+// ConvertToTemplate turns a shut off VM into a template. It keeps the image
+// the VM was installed from, its ISO unless another one is given.
+func (s *Service) ConvertToTemplate(id uint, req libvirtServiceInterfaces.ConvertToTemplateRequest) error {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return err
+	}
+
+	if vm.Template {
+		return fmt.Errorf("vm_already_template")
+	}
+
+	inactive, err := s.IsDomainInactive(vm.VmID)
+	if err != nil {
+		return err
+	}
+
+	if !inactive {
+		return fmt.Errorf("domain_state_not_shutoff: %d", vm.VmID)
+	}
+
+	sourceImage := req.SourceImage
+	if sourceImage == "" {
+		sourceImage = vm.ISO
+	}
+
+	if sourceImage != "" {
+		var count int64
+		if err := s.DB.Model(&utilitiesModels.Downloads{}).Where("uuid = ?", sourceImage).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed_to_check_source_image: %w", err)
+		}
+
+		if count == 0 {
+			return fmt.Errorf("source_image_not_found: %s", sourceImage)
+		}
+	}
+
+	return s.DB.Model(&vm).Updates(map[string]any{
+		"template":      true,
+		"os_type":       req.OSType,
+		"source_image":  sourceImage,
+		"start_at_boot": false,
+	}).Error
+}
+
+func (s *Service) ConvertFromTemplate(id uint) error {
+	vm, err := s.findVM(id)
+	if err != nil {
+		return err
+	}
+
+	if !vm.Template {
+		return fmt.Errorf("vm_not_template")
+	}
+
+	return s.DB.Model(&vm).Update("template", false).Error
+}
+
+// CreateVMFromTemplate creates a VM from a template through a linked clone,
+// or a full one when asked for, applying the requested overrides.
+func (s *Service) CreateVMFromTemplate(id uint, req libvirtServiceInterfaces.CreateFromTemplateRequest) (*vmModels.VM, error) {
+	template, err := s.findVM(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !template.Template {
+		return nil, fmt.Errorf("vm_not_template")
+	}
+
+	if req.CPUSockets < 0 || req.CPUCores < 0 || req.CPUThreads < 0 {
+		return nil, fmt.Errorf("cpu_topology_must_be_greater_than_0")
+	}
+
+	if req.RAM != 0 && req.RAM < 1024*1024*128 {
+		return nil, fmt.Errorf("memory_must_be_greater_than_128mb")
+	}
+
+	diskSizes := make(map[uint]int64, len(req.Disks))
+	for _, disk := range req.Disks {
+		found := false
+		for _, storage := range template.Storages {
+			if storage.ID == disk.StorageID && storage.Type != "iso" {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("storage_not_found: %d", disk.StorageID)
+		}
+
+		diskSizes[disk.StorageID] = disk.Size
+	}
+
+	switches := make(map[uint]uint, len(req.Networks))
+	for _, n := range req.Networks {
+		switches[n.NetworkID] = n.SwitchID
+	}
+
+	return s.cloneVM(template, cloneOptions{
+		name:      req.Name,
+		vmId:      req.VMID,
+		vncPort:   req.VNCPort,
+		linked:    req.Mode != "full",
+		switches:  switches,
+		diskSizes: diskSizes,
+		modify: func(vm *vmModels.VM) {
+			templateId := template.ID
+			vm.TemplateID = &templateId
+
+			if req.CPUSockets > 0 {
+				vm.CPUSockets = req.CPUSockets
+			}
+			if req.CPUCores > 0 {
+				vm.CPUCores = req.CPUCores
+			}
+			if req.CPUThreads > 0 {
+				vm.CPUsThreads = req.CPUThreads
+			}
+			if req.RAM > 0 {
+				vm.RAM = req.RAM
+			}
+		},
+	})
+}
@@ -0,0 +1,498 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal/db/models"
+	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	lifecycleServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/lifecycle"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/utils"
+)
+
+const (
+	defaultWaitTimeout  = 300 * time.Second
+	reachableRetryDelay = 2 * time.Second
+)
+
+var errBootCancelled = errors.New("boot_cancelled")
+
+func guestKey(kind string, id int) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
+
+func parseGuestKey(key string) (string, int, error) {
+	kind, rawId, ok := strings.Cut(key, ":")
+	if !ok || (kind != "vm" && kind != "jail") {
+		return "", 0, fmt.Errorf("invalid_guest_reference: %s", key)
+	}
+
+	id, err := strconv.Atoi(rawId)
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("invalid_guest_reference: %s", key)
+	}
+
+	return kind, id, nil
+}
+
+type bootGuest struct {
+	guest
+	vm     vmModels.VM
+	config models.GuestBootConfig
+
+	// after is the start order raised to that of the guests it depends on.
+	after int
+	index int
+
+	done chan struct{}
+	ok   bool
+}
+
+func (g *bootGuest) key() string {
+	return guestKey(g.kind, g.id)
+}
+
+// bootGuests lists the VMs and jails that are marked to start at boot along
+// with their boot settings.
+func (s *Service) bootGuests() ([]*bootGuest, error) {
+	var configs []models.GuestBootConfig
+	if err := s.DB.Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_boot_configs: %w", err)
+	}
+
+	byKey := make(map[string]models.GuestBootConfig, len(configs))
+	for _, config := range configs {
+		byKey[guestKey(config.GuestType, config.GuestID)] = config
+	}
+
+	var guests []*bootGuest
+
+	var vms []vmModels.VM
+	if err := s.DB.Where("start_at_boot = ? AND template = ?", true, false).Find(&vms).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_vms: %w", err)
+	}
+
+	for _, vm := range vms {
+		g := &bootGuest{
+			guest: guest{kind: "vm", id: vm.VmID, name: vm.Name, order: vm.StartOrder},
+			vm:    vm,
+			done:  make(chan struct{}),
+		}
+		g.config = byKey[g.key()]
+		guests = append(guests, g)
+	}
+
+	var jails []jailModels.Jail
+	if err := s.DB.Where("start_at_boot = ?", true).Find(&jails).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_jails: %w", err)
+	}
+
+	for _, jail := range jails {
+		g := &bootGuest{
+			guest: guest{kind: "jail", id: jail.CTID, name: jail.Name, order: jail.StartOrder},
+			done:  make(chan struct{}),
+		}
+		g.config = byKey[g.key()]
+		guests = append(guests, g)
+	}
+
+	return guests, nil
+}
+
+// orderGuests works out when each guest may start. A guest never starts
+// before the guests it depends on, so its order is raised to theirs where
+// it is lower. Guests that are part of, or depend on, a dependency cycle
+// are returned.
+func orderGuests(guests []*bootGuest) []*bootGuest {
+	byKey := make(map[string]*bootGuest, len(guests))
+	for _, g := range guests {
+		g.after = g.order
+		byKey[g.key()] = g
+	}
+
+	pending := make(map[*bootGuest]int, len(guests))
+	dependents := make(map[*bootGuest][]*bootGuest)
+
+	for _, g := range guests {
+		for _, dep := range g.config.DependsOn {
+			if d, ok := byKey[dep]; ok && d != g {
+				pending[g]++
+				dependents[d] = append(dependents[d], g)
+			}
+		}
+	}
+
+	var queue []*bootGuest
+	for _, g := range guests {
+		if pending[g] == 0 {
+			queue = append(queue, g)
+		}
+	}
+
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+
+		for _, d := range dependents[g] {
+			if g.after > d.after {
+				d.after = g.after
+			}
+
+			pending[d]--
+			if pending[d] == 0 {
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	var cyclic []*bootGuest
+	for _, g := range guests {
+		if pending[g] > 0 {
+			cyclic = append(cyclic, g)
+		}
+	}
+
+	return cyclic
+}
+
+func (s *Service) guestRunning(kind string, id int) bool {
+	switch kind {
+	case "vm":
+		inactive, err := s.Libvirt.IsDomainInactive(id)
+		return err == nil && !inactive
+	case "jail":
+		return s.Jail.GetJidByCtId(id) >= 0
+	}
+
+	return false
+}
+
+func reachable(config models.GuestBootConfig) bool {
+	switch config.WaitType {
+	case "tcp":
+		address := net.JoinHostPort(config.WaitHost, strconv.Itoa(config.WaitPort))
+		conn, err := net.DialTimeout("tcp", address, reachableRetryDelay)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	case "ping":
+		_, err := utils.RunCommand("ping", "-c", "1", "-t", "2", config.WaitHost)
+		return err == nil
+	}
+
+	return true
+}
+
+func waitReachable(ctx context.Context, config models.GuestBootConfig) error {
+	timeout := time.Duration(config.WaitTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	for !reachable(config) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not_reachable_within_%s", timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errBootCancelled
+		case <-time.After(reachableRetryDelay):
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) setGuestState(g *bootGuest, state string, blockedOn []string) {
+	s.bootMutex.Lock()
+	defer s.bootMutex.Unlock()
+
+	status := &s.boot.Guests[g.index]
+	status.State = state
+	status.BlockedOn = append([]string{}, blockedOn...)
+
+	now := time.Now()
+	switch state {
+	case lifecycleServiceInterfaces.GuestBootStarting:
+		status.StartedAt = &now
+	case lifecycleServiceInterfaces.GuestBootReady, lifecycleServiceInterfaces.GuestBootAlreadyRunning:
+		status.ReadyAt = &now
+	}
+}
+
+func (s *Service) finishGuest(g *bootGuest, state string, blockedOn []string, err error) {
+	s.setGuestState(g, state, blockedOn)
+
+	if errors.Is(err, errBootCancelled) {
+		s.bootMutex.Lock()
+		s.boot.Guests[g.index].Error = err.Error()
+		s.bootMutex.Unlock()
+
+		logger.L.Warn().Msgf("Cancelled starting %s at boot", g)
+	} else if err != nil {
+		s.bootMutex.Lock()
+		s.boot.Guests[g.index].Error = err.Error()
+		s.bootMutex.Unlock()
+
+		logger.L.Error().Err(err).Msgf("Failed to start %s at boot", g)
+	} else {
+		g.ok = true
+		logger.L.Info().Msgf("Started %s at boot (%s)", g, state)
+	}
+
+	close(g.done)
+}
+
+func (s *Service) startBootGuest(ctx context.Context, g *bootGuest, byKey map[string]*bootGuest) {
+	pending := append([]string{}, g.config.DependsOn...)
+
+	for len(pending) > 0 {
+		dep := pending[0]
+		s.setGuestState(g, lifecycleServiceInterfaces.GuestBootWaiting, pending)
+
+		if d, ok := byKey[dep]; ok {
+			select {
+			case <-d.done:
+			case <-ctx.Done():
+				s.finishGuest(g, lifecycleServiceInterfaces.GuestBootCancelled, pending, errBootCancelled)
+				return
+			}
+
+			if !d.ok {
+				s.finishGuest(g, lifecycleServiceInterfaces.GuestBootBlocked, []string{dep},
+					fmt.Errorf("dependency_failed: %s", dep))
+				return
+			}
+		} else {
+			kind, id, err := parseGuestKey(dep)
+			if err != nil || !s.guestRunning(kind, id) {
+				s.finishGuest(g, lifecycleServiceInterfaces.GuestBootBlocked, []string{dep},
+					fmt.Errorf("dependency_not_running: %s", dep))
+				return
+			}
+		}
+
+		pending = pending[1:]
+	}
+
+	if s.guestRunning(g.kind, g.id) {
+		s.finishGuest(g, lifecycleServiceInterfaces.GuestBootAlreadyRunning, nil, nil)
+		return
+	}
+
+	if ctx.Err() != nil {
+		s.finishGuest(g, lifecycleServiceInterfaces.GuestBootCancelled, nil, errBootCancelled)
+		return
+	}
+
+	s.setGuestState(g, lifecycleServiceInterfaces.GuestBootStarting, nil)
+
+	var err error
+	switch g.kind {
+	case "vm":
+		err = s.Libvirt.LvVMAction(g.vm, "start")
+	case "jail":
+		err = s.Jail.JailAction(g.id, "start")
+	}
+
+	if err != nil {
+		s.finishGuest(g, lifecycleServiceInterfaces.GuestBootFailed, nil, fmt.Errorf("failed_to_start: %w", err))
+		return
+	}
+
+	if g.config.StartDelay > 0 {
+		s.setGuestState(g, lifecycleServiceInterfaces.GuestBootDelaying, nil)
+
+		select {
+		case <-time.After(time.Duration(g.config.StartDelay) * time.Second):
+		case <-ctx.Done():
+			s.finishGuest(g, lifecycleServiceInterfaces.GuestBootCancelled, nil, errBootCancelled)
+			return
+		}
+	}
+
+	if g.config.WaitType != "" {
+		s.setGuestState(g, lifecycleServiceInterfaces.GuestBootWaitReachable, nil)
+		if err := waitReachable(ctx, g.config); err != nil {
+			state := lifecycleServiceInterfaces.GuestBootFailed
+			if errors.Is(err, errBootCancelled) {
+				state = lifecycleServiceInterfaces.GuestBootCancelled
+			}
+
+			s.finishGuest(g, state, nil, err)
+			return
+		}
+	}
+
+	s.finishGuest(g, lifecycleServiceInterfaces.GuestBootReady, nil, nil)
+}
+
+// StartGuests starts the VMs and jails marked to start at boot. Guests are
+// started in groups by start order, the guests of a group in parallel as
+// soon as the guests they depend on are ready. A group only starts once the
+// previous one is done. Stopping guests cancels the sequence, guests that
+// were not started by then are left alone.
+func (s *Service) StartGuests() {
+	s.bootMutex.Lock()
+	if s.boot.State == lifecycleServiceInterfaces.BootStateRunning {
+		s.bootMutex.Unlock()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	started := time.Now()
+	s.boot = lifecycleServiceInterfaces.BootStatus{
+		State:     lifecycleServiceInterfaces.BootStateRunning,
+		StartedAt: &started,
+		Guests:    []lifecycleServiceInterfaces.GuestBootStatus{},
+	}
+	s.bootCancel = cancel
+	s.bootDone = done
+	s.bootMutex.Unlock()
+
+	defer func() {
+		finished := time.Now()
+
+		s.bootMutex.Lock()
+		s.boot.State = lifecycleServiceInterfaces.BootStateDone
+		if ctx.Err() != nil {
+			s.boot.State = lifecycleServiceInterfaces.BootStateCancelled
+		}
+		s.boot.FinishedAt = &finished
+		s.bootCancel = nil
+		s.bootDone = nil
+		s.bootMutex.Unlock()
+
+		cancel()
+		close(done)
+	}()
+
+	guests, err := s.bootGuests()
+	if err != nil {
+		logger.L.Error().Err(err).Msg("Failed to list guests to start at boot")
+		return
+	}
+
+	if len(guests) == 0 {
+		return
+	}
+
+	cyclic := orderGuests(guests)
+
+	sort.SliceStable(guests, func(i, j int) bool {
+		if guests[i].after != guests[j].after {
+			return guests[i].after < guests[j].after
+		}
+		return guests[i].order < guests[j].order
+	})
+
+	byKey := make(map[string]*bootGuest, len(guests))
+
+	s.bootMutex.Lock()
+	for i, g := range guests {
+		g.index = i
+		byKey[g.key()] = g
+
+		dependsOn := append([]string{}, g.config.DependsOn...)
+		s.boot.Guests = append(s.boot.Guests, lifecycleServiceInterfaces.GuestBootStatus{
+			Type:       g.kind,
+			ID:         g.id,
+			Name:       g.name,
+			StartOrder: g.order,
+			DependsOn:  dependsOn,
+			State:      lifecycleServiceInterfaces.GuestBootPending,
+			BlockedOn:  []string{},
+		})
+	}
+	s.bootMutex.Unlock()
+
+	skip := make(map[*bootGuest]bool, len(cyclic))
+	for _, g := range cyclic {
+		skip[g] = true
+		s.finishGuest(g, lifecycleServiceInterfaces.GuestBootBlocked, g.config.DependsOn, fmt.Errorf("dependency_cycle"))
+	}
+
+	logger.L.Info().Msgf("Starting %d guests at boot", len(guests))
+
+	for start := 0; start < len(guests); {
+		end := start
+		for end < len(guests) && guests[end].after == guests[start].after {
+			end++
+		}
+
+		if ctx.Err() != nil {
+			for _, g := range guests[start:] {
+				if !skip[g] {
+					s.setGuestState(g, lifecycleServiceInterfaces.GuestBootCancelled, nil)
+				}
+			}
+
+			logger.L.Warn().Msg("Cancelled starting guests at boot")
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, g := range guests[start:end] {
+			if skip[g] {
+				continue
+			}
+
+			wg.Add(1)
+			go func(g *bootGuest) {
+				defer wg.Done()
+				s.startBootGuest(ctx, g, byKey)
+			}(g)
+		}
+
+		wg.Wait()
+		start = end
+	}
+}
+
+// cancelBoot cancels a running boot sequence. The returned channel, nil if
+// nothing was running, is closed once the sequence stopped; a guest that is
+// being started at that moment still comes up.
+func (s *Service) cancelBoot() <-chan struct{} {
+	s.bootMutex.Lock()
+	defer s.bootMutex.Unlock()
+
+	if s.bootCancel == nil {
+		return nil
+	}
+
+	s.bootCancel()
+	return s.bootDone
+}
+
+func (s *Service) GetBootStatus() lifecycleServiceInterfaces.BootStatus {
+	s.bootMutex.Lock()
+	defer s.bootMutex.Unlock()
+
+	status := s.boot
+	status.Guests = append([]lifecycleServiceInterfaces.GuestBootStatus{}, s.boot.Guests...)
+
+	return status
+}
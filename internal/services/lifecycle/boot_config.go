@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package lifecycle
+
+import (
+	"fmt"
+
+	"github.com/alchemillahq/sylve/internal/db/models"
+	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	lifecycleServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/lifecycle"
+)
+
+func (s *Service) GetBootConfigs() ([]models.GuestBootConfig, error) {
+	var configs []models.GuestBootConfig
+	if err := s.DB.Order("guest_type, guest_id").Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_boot_configs: %w", err)
+	}
+
+	return configs, nil
+}
+
+func (s *Service) guestExists(kind string, id int) (bool, error) {
+	var count int64
+	var err error
+
+	switch kind {
+	case "vm":
+		err = s.DB.Model(&vmModels.VM{}).Where("vm_id = ?", id).Count(&count).Error
+	case "jail":
+		err = s.DB.Model(&jailModels.Jail{}).Where("ct_id = ?", id).Count(&count).Error
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed_to_find_guest: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// reaches reports whether target can be reached from any of the guests in
+// from by following dependencies.
+func reaches(graph map[string][]string, from []string, target string, seen map[string]bool) bool {
+	for _, key := range from {
+		if key == target {
+			return true
+		}
+
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if reaches(graph, graph[key], target, seen) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) SetBootConfig(req lifecycleServiceInterfaces.GuestBootConfigRequest) error {
+	exists, err := s.guestExists(req.Type, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("guest_not_found")
+	}
+
+	switch req.WaitType {
+	case "tcp":
+		if req.WaitHost == "" || req.WaitPort == 0 {
+			return fmt.Errorf("tcp_wait_requires_host_and_port")
+		}
+	case "ping":
+		if req.WaitHost == "" {
+			return fmt.Errorf("ping_wait_requires_host")
+		}
+	}
+
+	key := guestKey(req.Type, req.ID)
+	dependsOn := []string{}
+	seen := make(map[string]bool)
+
+	for _, dep := range req.DependsOn {
+		kind, id, err := parseGuestKey(dep)
+		if err != nil {
+			return err
+		}
+
+		ref := guestKey(kind, id)
+		if ref == key {
+			return fmt.Errorf("guest_cannot_depend_on_itself")
+		}
+
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		exists, err := s.guestExists(kind, id)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("dependency_not_found: %s", ref)
+		}
+
+		dependsOn = append(dependsOn, ref)
+	}
+
+	configs, err := s.GetBootConfigs()
+	if err != nil {
+		return err
+	}
+
+	graph := make(map[string][]string, len(configs)+1)
+	for _, config := range configs {
+		graph[guestKey(config.GuestType, config.GuestID)] = config.DependsOn
+	}
+	graph[key] = dependsOn
+
+	if reaches(graph, dependsOn, key, make(map[string]bool)) {
+		return fmt.Errorf("dependency_cycle")
+	}
+
+	var config models.GuestBootConfig
+	if err := s.DB.Where("guest_type = ? AND guest_id = ?", req.Type, req.ID).
+		FirstOrInit(&config).Error; err != nil {
+		return fmt.Errorf("failed_to_find_boot_config: %w", err)
+	}
+
+	config.GuestType = req.Type
+	config.GuestID = req.ID
+	config.StartDelay = req.StartDelay
+	config.WaitType = req.WaitType
+	config.WaitHost = req.WaitHost
+	config.WaitPort = req.WaitPort
+	config.WaitTimeout = req.WaitTimeout
+	config.DependsOn = dependsOn
+
+	if config.WaitType == "" {
+		config.WaitHost = ""
+		config.WaitPort = 0
+		config.WaitTimeout = 0
+	}
+
+	if err := s.DB.Save(&config).Error; err != nil {
+		return fmt.Errorf("failed_to_save_boot_config: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteBootConfig(guestType string, guestId int) error {
+	result := s.DB.Where("guest_type = ? AND guest_id = ?", guestType, guestId).
+		Delete(&models.GuestBootConfig{})
+	if result.Error != nil {
+		return fmt.Errorf("failed_to_delete_boot_config: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("boot_config_not_found")
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package lifecycle
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/alchemillahq/sylve/internal/db/models"
+)
+
+func testGuest(kind string, id int, order int, dependsOn ...string) *bootGuest {
+	return &bootGuest{
+		guest:  guest{kind: kind, id: id, name: guestKey(kind, id), order: order},
+		config: models.GuestBootConfig{GuestType: kind, GuestID: id, DependsOn: dependsOn},
+	}
+}
+
+func TestOrderGuests(t *testing.T) {
+	tests := []struct {
+		name   string
+		guests []*bootGuest
+		after  map[string]int
+		cyclic []string
+	}{
+		{
+			name: "no dependencies keep their order",
+			guests: []*bootGuest{
+				testGuest("vm", 1, 3),
+				testGuest("jail", 1, 1),
+			},
+			after: map[string]int{"vm:1": 3, "jail:1": 1},
+		},
+		{
+			name: "dependent is raised to its dependency",
+			guests: []*bootGuest{
+				testGuest("vm", 1, 1, "jail:2"),
+				testGuest("jail", 2, 5),
+			},
+			after: map[string]int{"vm:1": 5, "jail:2": 5},
+		},
+		{
+			name: "dependent already later is left alone",
+			guests: []*bootGuest{
+				testGuest("vm", 1, 9, "vm:2"),
+				testGuest("vm", 2, 2),
+			},
+			after: map[string]int{"vm:1": 9, "vm:2": 2},
+		},
+		{
+			name: "raise is carried along a chain",
+			guests: []*bootGuest{
+				testGuest("vm", 3, 0, "vm:2"),
+				testGuest("vm", 2, 0, "vm:1"),
+				testGuest("vm", 1, 4),
+			},
+			after: map[string]int{"vm:1": 4, "vm:2": 4, "vm:3": 4},
+		},
+		{
+			name: "highest of several dependencies wins",
+			guests: []*bootGuest{
+				testGuest("vm", 1, 0, "vm:2", "jail:1"),
+				testGuest("vm", 2, 2),
+				testGuest("jail", 1, 7),
+			},
+			after: map[string]int{"vm:1": 7, "vm:2": 2, "jail:1": 7},
+		},
+		{
+			name: "dependencies outside the boot set and on itself are ignored",
+			guests: []*bootGuest{
+				testGuest("vm", 1, 2, "vm:99", "vm:1"),
+			},
+			after: map[string]int{"vm:1": 2},
+		},
+		{
+			name: "cycle and its dependents are returned",
+			guests: []*bootGuest{
+				testGuest("vm", 1, 0, "vm:2"),
+				testGuest("vm", 2, 0, "vm:1"),
+				testGuest("jail", 1, 0, "vm:1"),
+				testGuest("jail", 2, 1),
+			},
+			after:  map[string]int{"jail:2": 1},
+			cyclic: []string{"jail:1", "vm:1", "vm:2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cyclic []string
+			for _, g := range orderGuests(tt.guests) {
+				cyclic = append(cyclic, g.key())
+			}
+			sort.Strings(cyclic)
+
+			if !reflect.DeepEqual(cyclic, tt.cyclic) {
+				t.Errorf("cyclic = %v, want %v", cyclic, tt.cyclic)
+			}
+
+			for _, g := range tt.guests {
+				want, ok := tt.after[g.key()]
+				if ok && g.after != want {
+					t.Errorf("%s starts after %d, want %d", g.key(), g.after, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReaches(t *testing.T) {
+	graph := map[string][]string{
+		"vm:1":   {"vm:2"},
+		"vm:2":   {"jail:1"},
+		"jail:1": {},
+		"vm:3":   {"vm:4"},
+		"vm:4":   {"vm:3"},
+	}
+
+	tests := []struct {
+		name   string
+		from   []string
+		target string
+		want   bool
+	}{
+		{"direct", []string{"vm:2"}, "vm:2", true},
+		{"transitive", []string{"vm:1"}, "jail:1", true},
+		{"any of several", []string{"jail:1", "vm:1"}, "vm:2", true},
+		{"not reachable", []string{"jail:1"}, "vm:1", false},
+		{"unknown guest", []string{"vm:9"}, "vm:1", false},
+		{"empty", nil, "vm:1", false},
+		{"existing cycle terminates", []string{"vm:3"}, "vm:1", false},
+		{"inside a cycle", []string{"vm:3"}, "vm:4", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reaches(graph, tt.from, tt.target, map[string]bool{}); got != tt.want {
+				t.Errorf("reaches(%v, %s) = %v, want %v", tt.from, tt.target, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package lifecycle
+
+import (
+	"context"
+	"sync"
+
+	jailServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/jail"
+	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	lifecycleServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/lifecycle"
+
+	"gorm.io/gorm"
+)
+
+var _ lifecycleServiceInterfaces.LifecycleServiceInterface = (*Service)(nil)
+
+// Service starts and stops guests, VMs and jails alike, in the order they
+// are configured in.
+type Service struct {
+	DB      *gorm.DB
+	Libvirt libvirtServiceInterfaces.LibvirtServiceInterface
+	Jail    jailServiceInterfaces.JailServiceInterface
+
+	shutdownMutex sync.Mutex
+
+	powerMutex sync.Mutex
+	powering   bool
+
+	bootMutex  sync.Mutex
+	boot       lifecycleServiceInterfaces.BootStatus
+	bootCancel context.CancelFunc
+	bootDone   chan struct{}
+}
+
+func NewLifecycleService(db *gorm.DB,
+	libvirt libvirtServiceInterfaces.LibvirtServiceInterface,
+	jail jailServiceInterfaces.JailServiceInterface,
+) lifecycleServiceInterfaces.LifecycleServiceInterface {
+	return &Service{
+		DB:      db,
+		Libvirt: libvirt,
+		Jail:    jail,
+		boot: lifecycleServiceInterfaces.BootStatus{
+			State:  lifecycleServiceInterfaces.BootStateIdle,
+			Guests: []lifecycleServiceInterfaces.GuestBootStatus{},
+		},
+	}
+}
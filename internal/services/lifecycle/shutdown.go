@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package lifecycle
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal/config"
+	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	lifecycleServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/lifecycle"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/utils"
+)
+
+const (
+	// defaultShutdownTimeout leaves Sylve enough of rc's default 90 second
+	// rcshutdown_timeout to shut its server down once guests are stopped.
+	defaultShutdownTimeout   = 80 * time.Second
+	defaultVMShutdownTimeout = 60 * time.Second
+	defaultParallelism       = 4
+)
+
+type guest struct {
+	kind  string
+	id    int
+	name  string
+	order int
+}
+
+func (g guest) String() string {
+	return fmt.Sprintf("%s %d (%s)", g.kind, g.id, g.name)
+}
+
+func shutdownOptions(opts lifecycleServiceInterfaces.ShutdownOptions) lifecycleServiceInterfaces.ShutdownOptions {
+	if config.ParsedConfig != nil {
+		cfg := config.ParsedConfig.Shutdown
+		if opts.Timeout <= 0 && cfg.TimeoutSeconds > 0 {
+			opts.Timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+		}
+		if opts.VMTimeout <= 0 && cfg.VMTimeoutSeconds > 0 {
+			opts.VMTimeout = time.Duration(cfg.VMTimeoutSeconds) * time.Second
+		}
+		if opts.Parallelism <= 0 && cfg.Parallelism > 0 {
+			opts.Parallelism = cfg.Parallelism
+		}
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultShutdownTimeout
+	}
+	if opts.VMTimeout <= 0 {
+		opts.VMTimeout = defaultVMShutdownTimeout
+	}
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = defaultParallelism
+	}
+
+	return opts
+}
+
+// runningGuests lists the VMs and jails that are running. Templates never
+// run.
+func (s *Service) runningGuests() ([]guest, error) {
+	var guests []guest
+
+	var vms []vmModels.VM
+	if err := s.DB.Where("template = ?", false).Find(&vms).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_vms: %w", err)
+	}
+
+	for _, vm := range vms {
+		inactive, err := s.Libvirt.IsDomainInactive(vm.VmID)
+		if err != nil || inactive {
+			continue
+		}
+
+		guests = append(guests, guest{kind: "vm", id: vm.VmID, name: vm.Name, order: vm.StartOrder})
+	}
+
+	var jails []jailModels.Jail
+	if err := s.DB.Find(&jails).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_list_jails: %w", err)
+	}
+
+	for _, jail := range jails {
+		if s.Jail.GetJidByCtId(jail.CTID) < 0 {
+			continue
+		}
+
+		guests = append(guests, guest{kind: "jail", id: jail.CTID, name: jail.Name, order: jail.StartOrder})
+	}
+
+	return guests, nil
+}
+
+// stopGuest stops a guest, a VM is powered off after opts.VMTimeout or once
+// deadline passes, whichever comes first.
+func (s *Service) stopGuest(g guest, opts lifecycleServiceInterfaces.ShutdownOptions, deadline time.Time) lifecycleServiceInterfaces.GuestShutdownResult {
+	started := time.Now()
+	result := lifecycleServiceInterfaces.GuestShutdownResult{
+		Type:       g.kind,
+		ID:         g.id,
+		Name:       g.name,
+		StartOrder: g.order,
+	}
+
+	timeout := min(opts.VMTimeout, max(time.Until(deadline), 0))
+
+	var err error
+	switch g.kind {
+	case "vm":
+		var poweredOff bool
+		poweredOff, err = s.Libvirt.ShutdownVM(g.id, timeout)
+		result.Outcome = "shutdown"
+		if poweredOff {
+			result.Outcome = "powered_off"
+		}
+	case "jail":
+		err = s.Jail.JailAction(g.id, "stop")
+		result.Outcome = "stopped"
+	}
+
+	result.DurationMs = time.Since(started).Milliseconds()
+
+	if err != nil {
+		result.Outcome = "failed"
+		result.Error = err.Error()
+		logger.L.Error().Err(err).Msgf("Failed to stop %s", g)
+		return result
+	}
+
+	switch result.Outcome {
+	case "powered_off":
+		logger.L.Warn().Msgf("Powered off %s, it did not shut down within %s", g, timeout)
+	default:
+		logger.L.Info().Msgf("Stopped %s in %dms", g, result.DurationMs)
+	}
+
+	return result
+}
+
+// ShutdownGuests cancels a running boot sequence and stops every running VM
+// and jail in reverse start order.
+// Guests sharing a start order are stopped together, at most
+// opts.Parallelism at a time, and the next group only starts once the
+// previous one is down. VMs get an ACPI shutdown and are powered off after
+// opts.VMTimeout, or right away once opts.Timeout has passed since the
+// shutdown began.
+func (s *Service) ShutdownGuests(opts lifecycleServiceInterfaces.ShutdownOptions) []lifecycleServiceInterfaces.GuestShutdownResult {
+	s.shutdownMutex.Lock()
+	defer s.shutdownMutex.Unlock()
+
+	if done := s.cancelBoot(); done != nil {
+		<-done
+	}
+
+	opts = shutdownOptions(opts)
+
+	guests, err := s.runningGuests()
+	if err != nil {
+		logger.L.Error().Err(err).Msg("Failed to list running guests")
+		return nil
+	}
+
+	if len(guests) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(guests, func(i, j int) bool {
+		return guests[i].order > guests[j].order
+	})
+
+	deadline := time.Now().Add(opts.Timeout)
+	logger.L.Info().Msgf("Stopping %d guests within %s", len(guests), opts.Timeout)
+
+	var results []lifecycleServiceInterfaces.GuestShutdownResult
+	var resultsMutex sync.Mutex
+
+	for start := 0; start < len(guests); {
+		end := start
+		for end < len(guests) && guests[end].order == guests[start].order {
+			end++
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, opts.Parallelism)
+
+		for _, g := range guests[start:end] {
+			wg.Add(1)
+			sem <- struct{}{}
+
+			go func(g guest) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				result := s.stopGuest(g, opts, deadline)
+
+				resultsMutex.Lock()
+				results = append(results, result)
+				resultsMutex.Unlock()
+			}(g)
+		}
+
+		wg.Wait()
+		start = end
+	}
+
+	return results
+}
+
+// HostPower stops all guests and then reboots or powers off the host. It
+// returns once the shutdown is under way.
+func (s *Service) HostPower(req lifecycleServiceInterfaces.HostPowerRequest) error {
+	var flag string
+	switch req.Action {
+	case "reboot":
+		flag = "-r"
+	case "poweroff":
+		flag = "-p"
+	default:
+		return fmt.Errorf("invalid_action: %s", req.Action)
+	}
+
+	s.powerMutex.Lock()
+	defer s.powerMutex.Unlock()
+
+	if s.powering {
+		return fmt.Errorf("host_power_action_in_progress")
+	}
+
+	s.powering = true
+
+	// Nothing may come up any more while the host goes down.
+	s.cancelBoot()
+
+	opts := lifecycleServiceInterfaces.ShutdownOptions{
+		Timeout:     time.Duration(req.Timeout) * time.Second,
+		VMTimeout:   time.Duration(req.VMTimeout) * time.Second,
+		Parallelism: req.Parallelism,
+	}
+
+	go func() {
+		logger.L.Info().Msgf("Stopping guests for host %s", req.Action)
+		s.ShutdownGuests(opts)
+
+		if out, err := utils.RunCommand("shutdown", flag, "now"); err != nil {
+			logger.L.Error().Err(err).Msgf("Failed to %s host: %s", req.Action, out)
+
+			s.powerMutex.Lock()
+			s.powering = false
+			s.powerMutex.Unlock()
+		}
+	}()
+
+	return nil
+}
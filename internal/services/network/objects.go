@@ -195,6 +195,17 @@ func (s *Service) IsObjectUsed(id uint) (bool, error) {
 				}
 			}
 		}
+
+		var vmNetworks int64
+		if err := s.DB.Model(&vmModels.Network{}).
+			Where("ipv4_id = ? OR ipv4_gw_id = ? OR ipv6_id = ? OR ipv6_gw_id = ?", id, id, id, id).
+			Count(&vmNetworks).Error; err != nil {
+			return true, fmt.Errorf("failed to find VM networks using object %d: %w", id, err)
+		}
+
+		if vmNetworks > 0 {
+			return true, nil
+		}
 	}
 
 	if object.Type == "Mac" {
@@ -470,6 +481,10 @@ func (s *Service) EditObject(id uint, name string, oType string, values []string
 				if err != nil {
 					return fmt.Errorf("failed to change MAC address in VM %d: %w", vm.VmID, err)
 				}
+
+				if err := s.LibVirt.RefreshCloudInitSeed(int(vm.VmID)); err != nil {
+					return fmt.Errorf("failed to refresh cloud-init seed of VM %d: %w", vm.VmID, err)
+				}
 			}
 
 			/* Object was used in a Jail, but now we're changing it to something else, we can't do that */
@@ -548,6 +563,18 @@ func (s *Service) EditObject(id uint, name string, oType string, values []string
 				if err != nil {
 					return fmt.Errorf("failed to sync standard switches after editing object %d: %w", id, err)
 				}
+
+				var switchIDs []int
+				for _, sw := range switches {
+					if (sw.GatewayAddressID != nil && *sw.GatewayAddressID == id) ||
+						(sw.Gateway6AddressID != nil && *sw.Gateway6AddressID == id) {
+						switchIDs = append(switchIDs, sw.ID)
+					}
+				}
+
+				if err := s.refreshSwitchCloudInitSeeds(switchIDs); err != nil {
+					return err
+				}
 			}
 
 			/* IP used by jails */
@@ -566,6 +593,58 @@ func (s *Service) EditObject(id uint, name string, oType string, values []string
 					return fmt.Errorf("failed to add network object edit jail trigger for object %d: %w", id, err)
 				}
 			}
+
+			/* IP used by VM cloud-init network configs */
+			var vmNetworks []vmModels.Network
+			if err := s.DB.Where("ipv4_id = ? OR ipv4_gw_id = ? OR ipv6_id = ? OR ipv6_gw_id = ?", id, id, id, id).Find(&vmNetworks).Error; err != nil {
+				return fmt.Errorf("failed to find VM networks using object %d: %w", id, err)
+			}
+
+			if len(vmNetworks) > 0 && oType != "Host" {
+				return fmt.Errorf("cannot_change_object_type_vm")
+			}
+
+			if len(vmNetworks) > 0 && oType == "Host" {
+				if len(values) != 1 {
+					return fmt.Errorf("at_most_1_entry_allowed")
+				}
+
+				object.Name = name
+
+				if err := s.DB.Save(&object).Error; err != nil {
+					return fmt.Errorf("failed to update object %d: %w", id, err)
+				}
+
+				if err := s.DB.Where("object_id = ?", id).Delete(&networkModels.ObjectEntry{}).Error; err != nil {
+					return fmt.Errorf("failed to delete existing entries for object %d: %w", id, err)
+				}
+
+				entry := networkModels.ObjectEntry{
+					ObjectID: id,
+					Value:    values[0],
+				}
+
+				if err := s.DB.Create(&entry).Error; err != nil {
+					return fmt.Errorf("failed to create entry for object %d: %w", id, err)
+				}
+
+				refreshed := make(map[uint]bool)
+				for _, vn := range vmNetworks {
+					if refreshed[vn.VMID] {
+						continue
+					}
+					refreshed[vn.VMID] = true
+
+					var vm vmModels.VM
+					if err := s.DB.First(&vm, vn.VMID).Error; err != nil {
+						return fmt.Errorf("failed to find VM for network %d: %w", vn.ID, err)
+					}
+
+					if err := s.LibVirt.RefreshCloudInitSeed(vm.VmID); err != nil {
+						return fmt.Errorf("failed to refresh cloud-init seed of VM %d: %w", vm.VmID, err)
+					}
+				}
+			}
 		}
 
 		if object.Type == "Network" {
@@ -630,6 +709,18 @@ func (s *Service) EditObject(id uint, name string, oType string, values []string
 				if err != nil {
 					return fmt.Errorf("failed to sync standard switches after editing object %d: %w", id, err)
 				}
+
+				var switchIDs []int
+				for _, sw := range switches {
+					if (sw.NetworkID != nil && *sw.NetworkID == id) ||
+						(sw.Network6ID != nil && *sw.Network6ID == id) {
+						switchIDs = append(switchIDs, sw.ID)
+					}
+				}
+
+				if err := s.refreshSwitchCloudInitSeeds(switchIDs); err != nil {
+					return err
+				}
 			}
 
 			/* Network used by jails */
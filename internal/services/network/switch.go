@@ -12,6 +12,7 @@ import (
 	"fmt"
 
 	networkModels "github.com/alchemillahq/sylve/internal/db/models/network"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
 )
 
 func (s *Service) GetBridgeNameByID(id uint) (string, error) {
@@ -29,3 +30,27 @@ func (s *Service) GetBridgeNameByID(id uint) (string, error) {
 
 	return "", fmt.Errorf("switch/bridge with ID %d not found", id)
 }
+
+// refreshSwitchCloudInitSeeds regenerates the cloud-init seeds of the VMs
+// attached to the switches, the network config in them carries the subnets
+// and gateways of the switches.
+func (s *Service) refreshSwitchCloudInitSeeds(switchIDs []int) error {
+	if len(switchIDs) == 0 {
+		return nil
+	}
+
+	var vmIds []int
+	if err := s.DB.Model(&vmModels.VM{}).
+		Where("id IN (?)", s.DB.Model(&vmModels.Network{}).Select("vm_id").Where("switch_id IN ?", switchIDs)).
+		Pluck("vm_id", &vmIds).Error; err != nil {
+		return fmt.Errorf("failed to find VMs on switches: %w", err)
+	}
+
+	for _, vmId := range vmIds {
+		if err := s.LibVirt.RefreshCloudInitSeed(vmId); err != nil {
+			return fmt.Errorf("failed to refresh cloud-init seed of VM %d: %w", vmId, err)
+		}
+	}
+
+	return nil
+}
@@ -489,7 +489,11 @@ func (s *Service) EditStandardSwitch(
 		}
 	}
 
-	return s.SyncStandardSwitches(&before, "edit")
+	if err := s.SyncStandardSwitches(&before, "edit"); err != nil {
+		return err
+	}
+
+	return s.refreshSwitchCloudInitSeeds([]int{id})
 }
 
 func (s *Service) SyncStandardSwitches(sw *networkModels.StandardSwitch, action string) error {
@@ -15,6 +15,7 @@ import (
 	infoServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/info"
 	jailServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/jail"
 	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	lifecycleServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/lifecycle"
 	networkServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/network"
 	sambaServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/samba"
 	systemServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/system"
@@ -26,6 +27,7 @@ import (
 	"github.com/alchemillahq/sylve/internal/services/info"
 	"github.com/alchemillahq/sylve/internal/services/jail"
 	"github.com/alchemillahq/sylve/internal/services/libvirt"
+	"github.com/alchemillahq/sylve/internal/services/lifecycle"
 	"github.com/alchemillahq/sylve/internal/services/network"
 	"github.com/alchemillahq/sylve/internal/services/samba"
 	"github.com/alchemillahq/sylve/internal/services/startup"
@@ -49,6 +51,7 @@ type ServiceRegistry struct {
 	SambaService     sambaServiceInterfaces.SambaServiceInterface
 	JailService      jailServiceInterfaces.JailServiceInterface
 	ClusterService   clusterServiceInterfaces.ClusterServiceInterface
+	LifecycleService lifecycleServiceInterfaces.LifecycleServiceInterface
 }
 
 func NewService[T any](db *gorm.DB, dependencies ...interface{}) interface{} {
@@ -81,13 +84,17 @@ func NewService[T any](db *gorm.DB, dependencies ...interface{}) interface{} {
 	case *info.Service:
 		return info.NewInfoService(db)
 	case *zfs.Service:
-		return zfs.NewZfsService(db, dependencies[0].(libvirtServiceInterfaces.LibvirtServiceInterface))
+		libvirtService := dependencies[0].(libvirtServiceInterfaces.LibvirtServiceInterface)
+		authService := dependencies[1].(serviceInterfaces.AuthServiceInterface)
+		systemService := dependencies[2].(systemServiceInterfaces.SystemServiceInterface)
+		return zfs.NewZfsService(db, libvirtService, authService, systemService)
 	case *disk.Service:
 		return disk.NewDiskService(db, dependencies[0].(zfsServiceInterfaces.ZfsServiceInterface))
 	case *network.Service:
 		return network.NewNetworkService(db, dependencies[0].(libvirtServiceInterfaces.LibvirtServiceInterface))
 	case *libvirt.Service:
-		return libvirt.NewLibvirtService(db)
+		authService := dependencies[0].(serviceInterfaces.AuthServiceInterface)
+		return libvirt.NewLibvirtService(db, authService)
 	case *utilities.Service:
 		return utilities.NewUtilitiesService(db)
 	case *samba.Service:
@@ -99,6 +106,10 @@ func NewService[T any](db *gorm.DB, dependencies ...interface{}) interface{} {
 	case *cluster.Service:
 		authService := dependencies[0].(serviceInterfaces.AuthServiceInterface)
 		return cluster.NewClusterService(db, authService)
+	case *lifecycle.Service:
+		libvirtService := dependencies[0].(libvirtServiceInterfaces.LibvirtServiceInterface)
+		jailService := dependencies[1].(jailServiceInterfaces.JailServiceInterface)
+		return lifecycle.NewLifecycleService(db, libvirtService, jailService)
 	default:
 		return nil
 	}
@@ -107,10 +118,10 @@ func NewService[T any](db *gorm.DB, dependencies ...interface{}) interface{} {
 func NewServiceRegistry(db *gorm.DB) *ServiceRegistry {
 	authService := NewService[auth.Service](db)
 	infoService := NewService[info.Service](db)
-	libvirtService := NewService[libvirt.Service](db)
-	zfsService := NewService[zfs.Service](db, libvirtService)
-	utilitiesService := NewService[utilities.Service](db)
+	libvirtService := NewService[libvirt.Service](db, authService)
 	systemService := NewService[system.Service](db)
+	zfsService := NewService[zfs.Service](db, libvirtService, authService, systemService)
+	utilitiesService := NewService[utilities.Service](db)
 	sambaService := NewService[samba.Service](db, zfsService)
 	networkService := NewService[network.Service](db, libvirtService)
 	jailService := NewService[jail.Service](db, networkService)
@@ -129,5 +140,6 @@ func NewServiceRegistry(db *gorm.DB) *ServiceRegistry {
 		SambaService:     sambaService.(sambaServiceInterfaces.SambaServiceInterface),
 		JailService:      jailService.(jailServiceInterfaces.JailServiceInterface),
 		ClusterService:   clusterService.(clusterServiceInterfaces.ClusterServiceInterface),
+		LifecycleService: NewService[lifecycle.Service](db, libvirtService, jailService).(lifecycleServiceInterfaces.LifecycleServiceInterface),
 	}
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/alchemillahq/sylve/internal/db/models"
 	sambaModels "github.com/alchemillahq/sylve/internal/db/models/samba"
+	sambaServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/samba"
 	"github.com/alchemillahq/sylve/pkg/utils"
 	"github.com/alchemillahq/sylve/pkg/zfs"
 )
@@ -265,3 +266,62 @@ func (s *Service) DeleteShare(id uint) error {
 
 	return s.WriteConfig(true)
 }
+
+// GetShareQuotas lists every user with access to the share together with
+// the space they use on its dataset and how much of their quota is left.
+func (s *Service) GetShareQuotas(id uint) ([]sambaServiceInterfaces.ShareUserQuota, error) {
+	var share sambaModels.SambaShare
+	if err := s.DB.Preload("ReadOnlyGroups.Users").Preload("WriteableGroups.Users").First(&share, id).Error; err != nil {
+		return nil, fmt.Errorf("share_not_found: %w", err)
+	}
+
+	datasets, err := zfs.Filesystems("")
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_fetch_datasets: %v", err)
+	}
+
+	var fDataset *zfs.Dataset
+	for _, ds := range datasets {
+		if ds.GUID == share.Dataset {
+			fDataset = ds
+			break
+		}
+	}
+
+	if fDataset == nil {
+		return nil, fmt.Errorf("dataset_not_found")
+	}
+
+	usage, err := fDataset.SpaceUsage(zfs.QuotaUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_user_space: %w", err)
+	}
+
+	byName := make(map[string]zfs.SpaceUsage, len(usage))
+	for _, u := range usage {
+		byName[u.Name] = u
+	}
+
+	quotas := []sambaServiceInterfaces.ShareUserQuota{}
+	seen := make(map[uint]bool)
+
+	for _, group := range append(share.ReadOnlyGroups, share.WriteableGroups...) {
+		for _, user := range group.Users {
+			if seen[user.ID] {
+				continue
+			}
+			seen[user.ID] = true
+
+			u := byName[user.Username]
+			quotas = append(quotas, sambaServiceInterfaces.ShareUserQuota{
+				UserID:    user.ID,
+				Username:  user.Username,
+				Used:      u.Used,
+				Quota:     u.Quota,
+				Remaining: u.Available(fDataset.Avail),
+			})
+		}
+	}
+
+	return quotas, nil
+}
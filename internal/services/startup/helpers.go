@@ -14,6 +14,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/alchemillahq/sylve/internal/db/models"
 	"github.com/alchemillahq/sylve/internal/logger"
 	"github.com/alchemillahq/sylve/pkg/pkg"
 	"github.com/alchemillahq/sylve/pkg/rcconf"
@@ -42,6 +43,17 @@ func (s *Service) SysctlSync() error {
 		}
 	}
 
+	var tunables []models.Sysctl
+	if err := s.DB.Find(&tunables).Error; err != nil {
+		return fmt.Errorf("failed_to_get_sysctls: %w", err)
+	}
+
+	for _, t := range tunables {
+		if err := sysctl.SetInt64(t.Name, t.Value); err != nil {
+			logger.L.Error().Msgf("Error setting sysctl %s: %v", t.Name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -125,6 +125,12 @@ func (s *Service) Initialize(authService serviceInterfaces.AuthServiceInterface)
 		return err
 	}
 
+	// Encrypted datasets have to be unlocked before anything that lives on
+	// them, VMs and jails included, is started.
+	if err := s.ZFS.UnlockEncryptedDatasets(); err != nil {
+		logger.L.Error().Msgf("error unlocking encrypted datasets: %v", err)
+	}
+
 	if err := s.ZFS.SyncLibvirtPools(); err != nil {
 		return err
 	}
@@ -136,6 +142,9 @@ func (s *Service) Initialize(authService serviceInterfaces.AuthServiceInterface)
 	go s.Info.Cron()
 	go s.ZFS.Cron()
 	go s.ZFS.StartSnapshotScheduler(context.Background())
+	go s.ZFS.StartBackupScheduler(context.Background())
+	go s.ZFS.StartScrubScheduler(context.Background())
+	go s.ZFS.StartEventWatcher(context.Background())
 	go s.Libvirt.StoreVMUsage()
 	go s.Jail.StoreJailUsage()
 	go s.Jail.WatchNetworkObjectChanges()
@@ -1 +1,817 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
 package zfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal"
+	clusterModels "github.com/alchemillahq/sylve/internal/db/models/cluster"
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/utils"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	maxBackupJobRuns = 100
+
+	// replicationSnapshotLayout is the timestamp backup jobs append to their
+	// prefix when naming snapshots.
+	replicationSnapshotLayout = "2006-01-02-15-04-05"
+)
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func validateBackupJob(req zfsServiceInterfaces.BackupJobRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("invalid_name")
+	}
+
+	if req.SourceGUID == "" {
+		return fmt.Errorf("invalid_source_dataset")
+	}
+
+	switch req.Type {
+	case "", "sylve":
+		if req.TargetNode == "" {
+			return fmt.Errorf("invalid_target_node")
+		}
+
+		if !isValidTargetDataset(req.TargetDataset) {
+			return fmt.Errorf("invalid_target_dataset")
+		}
+	case "s3":
+		if req.S3ConfigID == 0 {
+			return fmt.Errorf("invalid_s3_config")
+		}
+
+		if req.S3Prefix != "" && !isValidS3Prefix(req.S3Prefix) {
+			return fmt.Errorf("invalid_s3_prefix")
+		}
+	default:
+		return fmt.Errorf("invalid_backup_type")
+	}
+
+	if req.Prefix != "" && strings.ContainsAny(req.Prefix, "@#/ ") {
+		return fmt.Errorf("invalid_prefix")
+	}
+
+	if req.CronExpr != "" {
+		if _, err := cron.ParseStandard(req.CronExpr); err != nil {
+			return fmt.Errorf("invalid_cron_expression")
+		}
+	} else if req.Interval <= 0 {
+		return fmt.Errorf("interval_or_cron_expression_required")
+	}
+
+	return nil
+}
+
+func isValidTargetDataset(name string) bool {
+	return name != "" && strings.Contains(name, "/") && !strings.ContainsAny(name, "@# ")
+}
+
+func isValidS3Prefix(prefix string) bool {
+	return !strings.HasPrefix(prefix, "/") && !strings.HasSuffix(prefix, "/") &&
+		!strings.Contains(prefix, "..") && !strings.ContainsAny(prefix, " \\")
+}
+
+func backupJobDue(job zfsModels.BackupJob, now time.Time) bool {
+	if job.CronExpr != "" {
+		sched, err := cron.ParseStandard(job.CronExpr)
+		if err != nil {
+			logger.L.Debug().Err(err).Msgf("Invalid cron expression for backup job %d", job.ID)
+			return false
+		}
+
+		return job.LastRunAt.IsZero() || now.After(sched.Next(job.LastRunAt))
+	}
+
+	if job.Interval > 0 {
+		return job.LastRunAt.IsZero() || now.Sub(job.LastRunAt).Seconds() >= float64(job.Interval)
+	}
+
+	return false
+}
+
+func (s *Service) GetBackupJobs() ([]zfsModels.BackupJob, error) {
+	var jobs []zfsModels.BackupJob
+
+	if err := s.DB.Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_backup_jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (s *Service) GetBackupJobRuns(id uint, limit int) ([]zfsModels.BackupJobRun, error) {
+	var runs []zfsModels.BackupJobRun
+
+	if limit <= 0 || limit > maxBackupJobRuns {
+		limit = maxBackupJobRuns
+	}
+
+	if err := s.DB.Where("job_id = ?", id).
+		Order("id DESC").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_backup_job_runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (s *Service) CreateBackupJob(req zfsServiceInterfaces.BackupJobRequest) error {
+	if err := validateBackupJob(req); err != nil {
+		return err
+	}
+
+	if err := s.checkBackupTarget(req); err != nil {
+		return err
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	if err := s.checkBackupPrefix(0, req.SourceGUID, req.Prefix); err != nil {
+		return err
+	}
+
+	job := zfsModels.BackupJob{
+		Name:          req.Name,
+		Type:          backupType(req.Type),
+		SourceGUID:    req.SourceGUID,
+		TargetNode:    req.TargetNode,
+		TargetDataset: req.TargetDataset,
+		S3ConfigID:    req.S3ConfigID,
+		S3Prefix:      s3Prefix(req),
+		Prefix:        req.Prefix,
+		Interval:      req.Interval,
+		CronExpr:      req.CronExpr,
+		Enabled:       enabled,
+	}
+
+	// Without a prefix of its own the job gets one with its ID, so jobs on
+	// the same source never prune each other's snapshots.
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+
+		if job.Prefix != "" {
+			return nil
+		}
+
+		return tx.Model(&job).Update("prefix", fmt.Sprintf("sylve-backup-%d", job.ID)).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed_to_create_backup_job: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) EditBackupJob(id uint, req zfsServiceInterfaces.BackupJobRequest) error {
+	if err := validateBackupJob(req); err != nil {
+		return err
+	}
+
+	var job zfsModels.BackupJob
+	if err := s.DB.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("backup_job_not_found")
+		}
+		return fmt.Errorf("failed_to_get_backup_job: %w", err)
+	}
+
+	if s.isBackupRunning(id) {
+		return fmt.Errorf("backup_job_running")
+	}
+
+	if err := s.checkBackupTarget(req); err != nil {
+		return err
+	}
+
+	prefix := job.Prefix
+	if req.Prefix != "" {
+		prefix = req.Prefix
+	}
+
+	if err := s.checkBackupPrefix(job.ID, req.SourceGUID, prefix); err != nil {
+		return err
+	}
+
+	if job.Type != backupType(req.Type) ||
+		job.SourceGUID != req.SourceGUID ||
+		job.TargetNode != req.TargetNode ||
+		job.TargetDataset != req.TargetDataset ||
+		job.S3ConfigID != req.S3ConfigID ||
+		job.S3Prefix != s3Prefix(req) {
+		job.LastSnapshot = ""
+		job.LastSnapshotGUID = ""
+	}
+
+	job.Name = req.Name
+	job.Type = backupType(req.Type)
+	job.SourceGUID = req.SourceGUID
+	job.TargetNode = req.TargetNode
+	job.TargetDataset = req.TargetDataset
+	job.S3ConfigID = req.S3ConfigID
+	job.S3Prefix = s3Prefix(req)
+	job.Interval = req.Interval
+	job.CronExpr = req.CronExpr
+	job.Prefix = prefix
+
+	if req.Enabled != nil {
+		job.Enabled = *req.Enabled
+	}
+
+	if err := s.DB.Save(&job).Error; err != nil {
+		return fmt.Errorf("failed_to_update_backup_job: %w", err)
+	}
+
+	return nil
+}
+
+func backupType(t string) string {
+	if t == "" {
+		return "sylve"
+	}
+	return t
+}
+
+func s3Prefix(req zfsServiceInterfaces.BackupJobRequest) string {
+	if backupType(req.Type) != "s3" {
+		return ""
+	}
+
+	if req.S3Prefix == "" {
+		return "sylve/" + req.Name
+	}
+
+	return req.S3Prefix
+}
+
+func (s *Service) checkBackupTarget(req zfsServiceInterfaces.BackupJobRequest) error {
+	if _, err := s.GetDatasetByGUID(req.SourceGUID); err != nil {
+		return fmt.Errorf("source_dataset_not_found")
+	}
+
+	if backupType(req.Type) == "s3" {
+		if _, err := s.getS3Config(req.S3ConfigID); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	var node clusterModels.ClusterNode
+	if err := s.DB.Where("node_uuid = ?", req.TargetNode).First(&node).Error; err != nil {
+		return fmt.Errorf("target_node_not_found")
+	}
+
+	return nil
+}
+
+// checkBackupPrefix rejects a prefix another job already uses on the same
+// source, the jobs would otherwise prune each other's snapshots.
+func (s *Service) checkBackupPrefix(id uint, sourceGUID string, prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+
+	var count int64
+	if err := s.DB.Model(&zfsModels.BackupJob{}).
+		Where("source_guid = ? AND prefix = ? AND id <> ?", sourceGUID, prefix, id).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed_to_check_backup_prefix: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("prefix_in_use_by_another_job")
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteBackupJob(id uint) error {
+	if s.isBackupRunning(id) {
+		return fmt.Errorf("backup_job_running")
+	}
+
+	var job zfsModels.BackupJob
+	if err := s.DB.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("backup_job_not_found")
+		}
+		return fmt.Errorf("failed_to_get_backup_job: %w", err)
+	}
+
+	if err := s.DB.Where("job_id = ?", job.ID).Delete(&zfsModels.BackupJobRun{}).Error; err != nil {
+		return fmt.Errorf("failed_to_delete_backup_job_runs: %w", err)
+	}
+
+	if err := s.DB.Delete(&job).Error; err != nil {
+		return fmt.Errorf("failed_to_delete_backup_job: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) RunBackupJob(id uint) error {
+	var job zfsModels.BackupJob
+	if err := s.DB.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("backup_job_not_found")
+		}
+		return fmt.Errorf("failed_to_get_backup_job: %w", err)
+	}
+
+	if !s.startBackup(job) {
+		return fmt.Errorf("backup_job_already_running")
+	}
+
+	return nil
+}
+
+func (s *Service) StartBackupScheduler(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				var jobs []zfsModels.BackupJob
+				if err := s.DB.Where("enabled = ?", true).Find(&jobs).Error; err != nil {
+					logger.L.Debug().Err(err).Msg("Failed to load backup jobs")
+					continue
+				}
+
+				now := time.Now()
+
+				for _, job := range jobs {
+					if !backupJobDue(job, now) {
+						continue
+					}
+
+					s.startBackup(job)
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (s *Service) isBackupRunning(id uint) bool {
+	s.backupMutex.Lock()
+	defer s.backupMutex.Unlock()
+
+	return s.runningBackups[id]
+}
+
+func (s *Service) startBackup(job zfsModels.BackupJob) bool {
+	s.backupMutex.Lock()
+	if s.runningBackups[job.ID] {
+		s.backupMutex.Unlock()
+		return false
+	}
+	s.runningBackups[job.ID] = true
+	s.backupMutex.Unlock()
+
+	go func() {
+		defer func() {
+			s.backupMutex.Lock()
+			delete(s.runningBackups, job.ID)
+			s.backupMutex.Unlock()
+		}()
+
+		if err := s.runBackupJob(job); err != nil {
+			logger.L.Error().Err(err).Msgf("Backup job %s failed", job.Name)
+		} else {
+			logger.L.Info().Msgf("Backup job %s completed", job.Name)
+		}
+	}()
+
+	return true
+}
+
+func (s *Service) runBackupJob(job zfsModels.BackupJob) error {
+	run := zfsModels.BackupJobRun{
+		JobID:     job.ID,
+		Status:    "running",
+		StartedAt: time.Now(),
+	}
+
+	if err := s.DB.Create(&run).Error; err != nil {
+		return fmt.Errorf("failed_to_create_backup_job_run: %w", err)
+	}
+
+	var runErr error
+	if job.Type == "s3" {
+		runErr = s.backupToS3(&job, &run)
+	} else {
+		runErr = s.replicate(&job, &run)
+	}
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+
+	updates := map[string]any{
+		"last_run_at": run.StartedAt,
+	}
+
+	if runErr != nil {
+		run.Status = "failed"
+		run.Error = runErr.Error()
+		updates["last_status"] = "failed"
+		updates["last_error"] = runErr.Error()
+	} else {
+		run.Status = "success"
+		updates["last_status"] = "success"
+		updates["last_error"] = ""
+		updates["last_snapshot"] = job.LastSnapshot
+		updates["last_snapshot_guid"] = job.LastSnapshotGUID
+	}
+
+	if err := s.DB.Save(&run).Error; err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to save run for backup job %d", job.ID)
+	}
+
+	if err := s.DB.Model(&zfsModels.BackupJob{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to update backup job %d", job.ID)
+	}
+
+	if err := s.DB.Where("job_id = ? AND id NOT IN (?)", job.ID,
+		s.DB.Model(&zfsModels.BackupJobRun{}).
+			Select("id").
+			Where("job_id = ?", job.ID).
+			Order("id DESC").
+			Limit(maxBackupJobRuns),
+	).Delete(&zfsModels.BackupJobRun{}).Error; err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to trim runs for backup job %d", job.ID)
+	}
+
+	return runErr
+}
+
+func (s *Service) replicate(job *zfsModels.BackupJob, run *zfsModels.BackupJobRun) error {
+	var node clusterModels.ClusterNode
+	if err := s.DB.Where("node_uuid = ?", job.TargetNode).First(&node).Error; err != nil {
+		return fmt.Errorf("target_node_not_found")
+	}
+
+	base := "https://" + node.API
+
+	headers, err := s.clusterHeaders()
+	if err != nil {
+		return err
+	}
+
+	source, err := s.GetDatasetByGUID(job.SourceGUID)
+	if err != nil {
+		return fmt.Errorf("source_dataset_not_found")
+	}
+
+	state, err := s.remoteReplicationState(base, headers, job.TargetDataset)
+	if err != nil {
+		return err
+	}
+
+	if state.ResumeToken != "" {
+		run.Mode = "resume"
+
+		n, err := s.streamToTarget(base, headers, job.TargetDataset, false, func(w io.Writer) error {
+			return zfs.ResumeSend(state.ResumeToken, w)
+		})
+
+		run.BytesSent += n
+
+		if err != nil {
+			return fmt.Errorf("failed_to_resume_send: %w", err)
+		}
+
+		state, err = s.remoteReplicationState(base, headers, job.TargetDataset)
+		if err != nil {
+			return err
+		}
+	}
+
+	snapName := fmt.Sprintf("%s-%s", job.Prefix, time.Now().Format(replicationSnapshotLayout))
+	snapshot, err := source.Snapshot(snapName, false)
+	if err != nil {
+		return fmt.Errorf("failed_to_create_snapshot: %w", err)
+	}
+
+	run.Snapshot = snapshot.Name
+
+	remote := make(map[string]bool, len(state.Snapshots))
+	for _, snap := range state.Snapshots {
+		remote[snap.GUID] = true
+	}
+
+	common, err := findCommonSnapshot(source, snapshot, remote)
+	if err != nil {
+		return err
+	}
+
+	// Encrypted datasets are replicated raw so the target never sees the key.
+	opts := zfs.SendOptions{Raw: source.IsEncrypted()}
+
+	if common != nil {
+		opts.Base = common.Name
+		run.BaseSnapshot = common.Name
+		run.Mode = strings.TrimPrefix(run.Mode+",incremental", ",")
+	} else {
+		if state.Exists {
+			return fmt.Errorf("no_common_snapshot_with_target")
+		}
+		run.Mode = strings.TrimPrefix(run.Mode+",full", ",")
+	}
+
+	n, err := s.streamToTarget(base, headers, job.TargetDataset, common != nil, func(w io.Writer) error {
+		return snapshot.Send(opts, w)
+	})
+
+	run.BytesSent += n
+
+	if err != nil {
+		return fmt.Errorf("failed_to_send_snapshot: %w", err)
+	}
+
+	job.LastSnapshot = snapshot.Name
+	job.LastSnapshotGUID = snapshot.GUID
+
+	bookmarkReplicationSnapshot(source, job.Prefix, snapshot)
+	pruneReplicationSnapshots(source, job.Prefix, snapshot)
+
+	return nil
+}
+
+func findCommonSnapshot(source *zfs.Dataset, exclude *zfs.Dataset, remote map[string]bool) (*zfs.Dataset, error) {
+	if len(remote) == 0 {
+		return nil, nil
+	}
+
+	snapshots, err := zfs.Snapshots(source.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_list_source_snapshots: %w", err)
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		snap := snapshots[i]
+
+		if !strings.HasPrefix(snap.Name, source.Name+"@") || snap.GUID == exclude.GUID {
+			continue
+		}
+
+		if remote[snap.GUID] {
+			return snap, nil
+		}
+	}
+
+	// A bookmark keeps the GUID of its snapshot, so it can stand in as the
+	// incremental base once the snapshot itself has been destroyed.
+	bookmarks, err := source.Bookmarks()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_list_source_bookmarks: %w", err)
+	}
+
+	for i := len(bookmarks) - 1; i >= 0; i-- {
+		if remote[bookmarks[i].GUID] && bookmarks[i].GUID != exclude.GUID {
+			return bookmarks[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// bookmarkReplicationSnapshot bookmarks the snapshot that was just sent, so
+// the next incremental can start from it even after the snapshot is pruned,
+// and drops older bookmarks of the same job. Bookmarks of other jobs on the
+// source are their fallback bases and are left alone.
+func bookmarkReplicationSnapshot(source *zfs.Dataset, prefix string, snapshot *zfs.Dataset) {
+	bookmark, err := snapshot.Bookmark(snapshotShortName(snapshot.Name))
+	if err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to bookmark replication snapshot %s", snapshot.Name)
+		return
+	}
+
+	bookmarks, err := source.Bookmarks()
+	if err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to list bookmarks of %s for pruning", source.Name)
+		return
+	}
+
+	for _, b := range bookmarks {
+		if b.Name == bookmark.Name || !isReplicationSnapshot(source, b.Name, prefix) {
+			continue
+		}
+
+		if err := b.Destroy(zfs.DestroyDefault); err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to prune replication bookmark %s", b.Name)
+		}
+	}
+}
+
+// isReplicationSnapshot reports whether name, a snapshot or bookmark of
+// source, was created by the backup job with prefix. Only the exact
+// <prefix>-<timestamp> form matches, so a job with prefix "a" leaves the
+// snapshots of a job with prefix "a-2" alone.
+func isReplicationSnapshot(source *zfs.Dataset, name string, prefix string) bool {
+	if !strings.HasPrefix(name, source.Name+"@") && !strings.HasPrefix(name, source.Name+"#") {
+		return false
+	}
+
+	stamp, ok := strings.CutPrefix(snapshotShortName(name), prefix+"-")
+	if !ok {
+		return false
+	}
+
+	_, err := time.Parse(replicationSnapshotLayout, stamp)
+	return err == nil
+}
+
+func pruneReplicationSnapshots(source *zfs.Dataset, prefix string, keep *zfs.Dataset) {
+	snapshots, err := zfs.Snapshots(source.Name)
+	if err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to list snapshots of %s for pruning", source.Name)
+		return
+	}
+
+	for _, snap := range snapshots {
+		if snap.GUID == keep.GUID || !isReplicationSnapshot(source, snap.Name, prefix) {
+			continue
+		}
+
+		if isSnapshotHeld(snap) {
+			continue
+		}
+
+		if err := snap.Destroy(zfs.DestroyDefault); err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to prune replication snapshot %s", snap.Name)
+		}
+	}
+}
+
+func (s *Service) clusterHeaders() (map[string]string, error) {
+	hostname, err := utils.GetSystemHostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_hostname: %w", err)
+	}
+
+	token, err := s.Auth.CreateClusterJWT(0, hostname, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_create_cluster_token: %w", err)
+	}
+
+	return map[string]string{
+		"Accept":          "application/json",
+		"X-Cluster-Token": fmt.Sprintf("Bearer %s", token),
+	}, nil
+}
+
+func (s *Service) remoteReplicationState(base string, headers map[string]string, dataset string) (*zfsServiceInterfaces.ReplicationState, error) {
+	stateURL := fmt.Sprintf("%s/api/zfs/replication/state?dataset=%s", base, url.QueryEscape(dataset))
+
+	body, _, err := utils.HTTPGetJSONRead(stateURL, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_target_state: %w", err)
+	}
+
+	var resp internal.APIResponse[zfsServiceInterfaces.ReplicationState]
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed_to_parse_target_state: %w", err)
+	}
+
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("failed_to_get_target_state: %s", resp.Error)
+	}
+
+	return &resp.Data, nil
+}
+
+func (s *Service) streamToTarget(base string, headers map[string]string, dataset string, force bool, send func(w io.Writer) error) (int64, error) {
+	pr, pw := io.Pipe()
+	counter := &countingReader{r: pr}
+	sendErr := make(chan error, 1)
+
+	go func() {
+		err := send(pw)
+		pw.CloseWithError(err)
+		sendErr <- err
+	}()
+
+	receiveURL := fmt.Sprintf("%s/api/zfs/replication/receive?dataset=%s&force=%t", base, url.QueryEscape(dataset), force)
+
+	if _, _, err := utils.HTTPPostStream(context.Background(), receiveURL, counter, headers); err != nil {
+		pr.CloseWithError(err)
+
+		select {
+		case sErr := <-sendErr:
+			if sErr != nil {
+				return counter.n, sErr
+			}
+		default:
+		}
+
+		return counter.n, err
+	}
+
+	if err := <-sendErr; err != nil {
+		return counter.n, err
+	}
+
+	return counter.n, nil
+}
+
+func (s *Service) GetReplicationState(dataset string) (*zfsServiceInterfaces.ReplicationState, error) {
+	state := &zfsServiceInterfaces.ReplicationState{
+		Dataset:   dataset,
+		Snapshots: []zfsServiceInterfaces.ReplicationSnapshot{},
+	}
+
+	ds, err := zfs.GetDataset(dataset)
+	if err != nil {
+		var zErr *zfs.Error
+		if errors.As(err, &zErr) && strings.Contains(zErr.Stderr, "does not exist") {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed_to_get_dataset: %w", err)
+	}
+
+	state.Exists = true
+
+	state.ResumeToken = ds.ResumeToken()
+
+	snapshots, err := zfs.Snapshots(dataset)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_list_snapshots: %w", err)
+	}
+
+	for _, snap := range snapshots {
+		if !strings.HasPrefix(snap.Name, dataset+"@") {
+			continue
+		}
+
+		state.Snapshots = append(state.Snapshots, zfsServiceInterfaces.ReplicationSnapshot{
+			Name: snap.Name,
+			GUID: snap.GUID,
+		})
+	}
+
+	return state, nil
+}
+
+func (s *Service) ReceiveReplicationStream(dataset string, input io.Reader, force bool) error {
+	if dataset == "" || !strings.Contains(dataset, "/") || strings.ContainsAny(dataset, "@# ") {
+		return fmt.Errorf("invalid_target_dataset")
+	}
+
+	parent := dataset[:strings.LastIndex(dataset, "/")]
+	if _, err := zfs.GetDataset(parent); err != nil {
+		return fmt.Errorf("target_parent_not_found")
+	}
+
+	if existing, err := zfs.GetDataset(dataset); err == nil {
+		if s.IsDatasetInUse(existing.GUID, true) {
+			return fmt.Errorf("target_dataset_in_use")
+		}
+	}
+
+	if _, err := zfs.ReceiveResumable(input, dataset, force); err != nil {
+		return fmt.Errorf("failed_to_receive_stream: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,366 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	clusterModels "github.com/alchemillahq/sylve/internal/db/models/cluster"
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/s3"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+
+	"gorm.io/gorm"
+)
+
+const s3ManifestVersion = 1
+
+func snapshotShortName(name string) string {
+	if idx := strings.LastIndexAny(name, "@#"); idx != -1 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+func (s *Service) getS3Config(id uint) (*clusterModels.ClusterS3Config, error) {
+	var cfg clusterModels.ClusterS3Config
+	if err := s.DB.First(&cfg, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("s3_config_not_found")
+		}
+		return nil, fmt.Errorf("failed_to_get_s3_config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+func readS3Manifest(cfg *clusterModels.ClusterS3Config, prefix string) (*zfsServiceInterfaces.S3BackupManifest, error) {
+	body, err := s3.Get(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKey, cfg.SecretKey, prefix+"/manifest.json")
+	if err != nil {
+		if s3.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed_to_read_manifest: %w", err)
+	}
+	defer body.Close()
+
+	var manifest zfsServiceInterfaces.S3BackupManifest
+	if err := json.NewDecoder(body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed_to_parse_manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+func writeS3Manifest(cfg *clusterModels.ClusterS3Config, prefix string, manifest *zfsServiceInterfaces.S3BackupManifest) error {
+	manifest.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed_to_marshal_manifest: %w", err)
+	}
+
+	if _, _, err := s3.Put(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKey, cfg.SecretKey, prefix+"/manifest.json", bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed_to_write_manifest: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) backupToS3(job *zfsModels.BackupJob, run *zfsModels.BackupJobRun) error {
+	cfg, err := s.getS3Config(job.S3ConfigID)
+	if err != nil {
+		return err
+	}
+
+	source, err := s.GetDatasetByGUID(job.SourceGUID)
+	if err != nil {
+		return fmt.Errorf("source_dataset_not_found")
+	}
+
+	manifest, err := readS3Manifest(cfg, job.S3Prefix)
+	if err != nil {
+		return err
+	}
+
+	if manifest == nil {
+		manifest = &zfsServiceInterfaces.S3BackupManifest{
+			Version:    s3ManifestVersion,
+			Dataset:    source.Name,
+			SourceGUID: source.GUID,
+			Type:       source.Type,
+			Snapshots:  []zfsServiceInterfaces.S3BackupEntry{},
+		}
+	} else if manifest.SourceGUID != source.GUID {
+		return fmt.Errorf("s3_prefix_belongs_to_another_dataset")
+	}
+
+	// A stream is at most the logical size of the data, or its referenced
+	// size when sent raw, so the parts are sized from the larger of the two.
+	partSize, err := s3.PartSize(int64(max(source.Referenced, source.Logicalused)))
+	if err != nil {
+		return err
+	}
+
+	snapName := fmt.Sprintf("%s-%s", job.Prefix, time.Now().Format(replicationSnapshotLayout))
+	snapshot, err := source.Snapshot(snapName, false)
+	if err != nil {
+		return fmt.Errorf("failed_to_create_snapshot: %w", err)
+	}
+
+	run.Snapshot = snapshot.Name
+
+	uploaded := make(map[string]bool, len(manifest.Snapshots))
+	for _, entry := range manifest.Snapshots {
+		uploaded[entry.GUID] = true
+	}
+
+	common, err := findCommonSnapshot(source, snapshot, uploaded)
+	if err != nil {
+		return err
+	}
+
+	entry := zfsServiceInterfaces.S3BackupEntry{
+		Snapshot:  snapshotShortName(snapshot.Name),
+		GUID:      snapshot.GUID,
+		Key:       fmt.Sprintf("%s/%s.zfs", job.S3Prefix, snapshotShortName(snapshot.Name)),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	opts := zfs.SendOptions{Raw: source.IsEncrypted()}
+
+	if common != nil {
+		opts.Base = common.Name
+		entry.Base = snapshotShortName(common.Name)
+		entry.BaseGUID = common.GUID
+		run.BaseSnapshot = common.Name
+		run.Mode = "incremental"
+	} else {
+		run.Mode = "full"
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(snapshot.Send(opts, pw))
+	}()
+
+	size, err := s3.Upload(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKey, cfg.SecretKey, entry.Key, pr, partSize)
+	run.BytesSent = size
+
+	if err != nil {
+		pr.CloseWithError(err)
+		return fmt.Errorf("failed_to_upload_stream: %w", err)
+	}
+
+	entry.Size = size
+	manifest.Snapshots = append(manifest.Snapshots, entry)
+
+	if err := writeS3Manifest(cfg, job.S3Prefix, manifest); err != nil {
+		return err
+	}
+
+	job.LastSnapshot = snapshot.Name
+	job.LastSnapshotGUID = snapshot.GUID
+
+	bookmarkReplicationSnapshot(source, job.Prefix, snapshot)
+	pruneReplicationSnapshots(source, job.Prefix, snapshot)
+
+	return nil
+}
+
+func (s *Service) GetS3BackupManifest(s3ConfigID uint, prefix string) (*zfsServiceInterfaces.S3BackupManifest, error) {
+	if prefix == "" || !isValidS3Prefix(prefix) {
+		return nil, fmt.Errorf("invalid_s3_prefix")
+	}
+
+	cfg, err := s.getS3Config(s3ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := readS3Manifest(cfg, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("manifest_not_found")
+	}
+
+	return manifest, nil
+}
+
+func restoreChain(manifest *zfsServiceInterfaces.S3BackupManifest, snapshot string) ([]zfsServiceInterfaces.S3BackupEntry, error) {
+	if len(manifest.Snapshots) == 0 {
+		return nil, fmt.Errorf("manifest_has_no_snapshots")
+	}
+
+	byGUID := make(map[string]zfsServiceInterfaces.S3BackupEntry, len(manifest.Snapshots))
+	for _, entry := range manifest.Snapshots {
+		byGUID[entry.GUID] = entry
+	}
+
+	var current *zfsServiceInterfaces.S3BackupEntry
+
+	if snapshot == "" {
+		current = &manifest.Snapshots[len(manifest.Snapshots)-1]
+	} else {
+		for i := range manifest.Snapshots {
+			entry := &manifest.Snapshots[i]
+			if entry.Snapshot == snapshot || entry.GUID == snapshot {
+				current = entry
+				break
+			}
+		}
+	}
+
+	if current == nil {
+		return nil, fmt.Errorf("snapshot_not_found_in_manifest")
+	}
+
+	chain := []zfsServiceInterfaces.S3BackupEntry{*current}
+
+	for current.BaseGUID != "" {
+		base, ok := byGUID[current.BaseGUID]
+		if !ok || len(chain) > len(manifest.Snapshots) {
+			return nil, fmt.Errorf("broken_snapshot_chain")
+		}
+
+		chain = append([]zfsServiceInterfaces.S3BackupEntry{base}, chain...)
+		current = &base
+	}
+
+	return chain, nil
+}
+
+// RestoreS3Backup checks a restore of an S3 backup chain into a new dataset
+// and receives the chain in the background, its progress is available from
+// GetS3RestoreProgress under the target dataset.
+func (s *Service) RestoreS3Backup(req zfsServiceInterfaces.S3RestoreRequest) error {
+	if !isValidS3Prefix(req.S3Prefix) {
+		return fmt.Errorf("invalid_s3_prefix")
+	}
+
+	if !isValidTargetDataset(req.TargetDataset) {
+		return fmt.Errorf("invalid_target_dataset")
+	}
+
+	if _, err := zfs.GetDataset(req.TargetDataset); err == nil {
+		return fmt.Errorf("target_dataset_exists")
+	}
+
+	parent := req.TargetDataset[:strings.LastIndex(req.TargetDataset, "/")]
+	if _, err := zfs.GetDataset(parent); err != nil {
+		return fmt.Errorf("target_parent_not_found")
+	}
+
+	manifest, err := s.GetS3BackupManifest(req.S3ConfigID, req.S3Prefix)
+	if err != nil {
+		return err
+	}
+
+	chain, err := restoreChain(manifest, req.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := s.getS3Config(req.S3ConfigID)
+	if err != nil {
+		return err
+	}
+
+	s.restoreMutex.Lock()
+	if p, ok := s.restores[req.TargetDataset]; ok && !p.Done {
+		s.restoreMutex.Unlock()
+		return fmt.Errorf("restore_already_running")
+	}
+
+	progress := &zfsServiceInterfaces.S3RestoreProgress{
+		Target:    req.TargetDataset,
+		Snapshot:  chain[len(chain)-1].Snapshot,
+		Steps:     len(chain),
+		StartedAt: time.Now(),
+	}
+	s.restores[req.TargetDataset] = progress
+	s.restoreMutex.Unlock()
+
+	go func() {
+		err := s.receiveS3Chain(cfg, chain, req.TargetDataset, progress)
+		if err != nil {
+			logger.L.Error().Err(err).Msgf("Failed to restore S3 backup %s into %s", req.S3Prefix, req.TargetDataset)
+		}
+
+		s.finishS3Restore(progress, err)
+	}()
+
+	return nil
+}
+
+func (s *Service) receiveS3Chain(
+	cfg *clusterModels.ClusterS3Config,
+	chain []zfsServiceInterfaces.S3BackupEntry,
+	target string,
+	progress *zfsServiceInterfaces.S3RestoreProgress,
+) error {
+	for i, entry := range chain {
+		s.restoreMutex.Lock()
+		progress.Step = i + 1
+		progress.Current = entry.Snapshot
+		s.restoreMutex.Unlock()
+
+		body, err := s3.Get(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKey, cfg.SecretKey, entry.Key)
+		if err != nil {
+			return fmt.Errorf("failed_to_download_%s: %w", entry.Snapshot, err)
+		}
+
+		_, err = zfs.ReceiveSnapshot(body, target, i > 0)
+		body.Close()
+
+		if err != nil {
+			return fmt.Errorf("failed_to_receive_%s: %w", entry.Snapshot, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) finishS3Restore(progress *zfsServiceInterfaces.S3RestoreProgress, err error) {
+	s.restoreMutex.Lock()
+	defer s.restoreMutex.Unlock()
+
+	now := time.Now()
+	progress.Done = true
+	progress.FinishedAt = &now
+
+	if err != nil {
+		progress.Error = err.Error()
+	}
+}
+
+func (s *Service) GetS3RestoreProgress(target string) (*zfsServiceInterfaces.S3RestoreProgress, error) {
+	s.restoreMutex.Lock()
+	defer s.restoreMutex.Unlock()
+
+	progress, ok := s.restores[target]
+	if !ok {
+		return nil, fmt.Errorf("restore_not_found")
+	}
+
+	copied := *progress
+	return &copied, nil
+}
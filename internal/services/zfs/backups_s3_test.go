@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"reflect"
+	"testing"
+
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+)
+
+func testEntry(snapshot string, base string) zfsServiceInterfaces.S3BackupEntry {
+	entry := zfsServiceInterfaces.S3BackupEntry{Snapshot: snapshot, GUID: "guid-" + snapshot}
+	if base != "" {
+		entry.Base = base
+		entry.BaseGUID = "guid-" + base
+	}
+	return entry
+}
+
+func TestRestoreChain(t *testing.T) {
+	// s1 full, s2 and s3 incremental on it, s4 a new full after the chain
+	// broke, s5 incremental on s4.
+	manifest := &zfsServiceInterfaces.S3BackupManifest{
+		Snapshots: []zfsServiceInterfaces.S3BackupEntry{
+			testEntry("s1", ""),
+			testEntry("s2", "s1"),
+			testEntry("s3", "s2"),
+			testEntry("s4", ""),
+			testEntry("s5", "s4"),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		snapshot string
+		chain    []string
+		err      string
+	}{
+		{name: "latest by default", chain: []string{"s4", "s5"}},
+		{name: "full snapshot alone", snapshot: "s1", chain: []string{"s1"}},
+		{name: "incremental walks back to its full", snapshot: "s3", chain: []string{"s1", "s2", "s3"}},
+		{name: "lookup by guid", snapshot: "guid-s2", chain: []string{"s1", "s2"}},
+		{name: "latest full", snapshot: "s4", chain: []string{"s4"}},
+		{name: "unknown snapshot", snapshot: "s9", err: "snapshot_not_found_in_manifest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := restoreChain(manifest, tt.snapshot)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("restoreChain(%q) error = %v, want %s", tt.snapshot, err, tt.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("restoreChain(%q): %v", tt.snapshot, err)
+			}
+
+			var got []string
+			for _, entry := range chain {
+				got = append(got, entry.Snapshot)
+			}
+
+			if !reflect.DeepEqual(got, tt.chain) {
+				t.Errorf("restoreChain(%q) = %v, want %v", tt.snapshot, got, tt.chain)
+			}
+		})
+	}
+}
+
+func TestRestoreChainBroken(t *testing.T) {
+	tests := []struct {
+		name      string
+		snapshots []zfsServiceInterfaces.S3BackupEntry
+		err       string
+	}{
+		{
+			name: "missing base",
+			snapshots: []zfsServiceInterfaces.S3BackupEntry{
+				testEntry("s2", "s1"),
+				testEntry("s3", "s2"),
+			},
+			err: "broken_snapshot_chain",
+		},
+		{
+			name: "cycle",
+			snapshots: []zfsServiceInterfaces.S3BackupEntry{
+				testEntry("s1", "s2"),
+				testEntry("s2", "s1"),
+			},
+			err: "broken_snapshot_chain",
+		},
+		{
+			name: "empty manifest",
+			err:  "manifest_has_no_snapshots",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := &zfsServiceInterfaces.S3BackupManifest{Snapshots: tt.snapshots}
+			if _, err := restoreChain(manifest, ""); err == nil || err.Error() != tt.err {
+				t.Fatalf("restoreChain() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
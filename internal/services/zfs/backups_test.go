@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"testing"
+
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+func TestIsReplicationSnapshot(t *testing.T) {
+	source := &zfs.Dataset{Name: "tank/data"}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   bool
+	}{
+		{"tank/data@sylve-backup-1-2026-01-02-03-04-05", "sylve-backup-1", true},
+		{"tank/data#sylve-backup-1-2026-01-02-03-04-05", "sylve-backup-1", true},
+		{"tank/data@sylve-backup-12-2026-01-02-03-04-05", "sylve-backup-1", false},
+		{"tank/data@nightly-2-2026-01-02-03-04-05", "nightly", false},
+		{"tank/data@nightly-manual", "nightly", false},
+		{"tank/data/child@nightly-2026-01-02-03-04-05", "nightly", false},
+		{"tank/data@nightly-2026-01-02-03-04-05", "nightly", true},
+	}
+
+	for _, tt := range tests {
+		if got := isReplicationSnapshot(source, tt.name, tt.prefix); got != tt.want {
+			t.Errorf("isReplicationSnapshot(%q, %q) = %v, want %v", tt.name, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -9,19 +9,21 @@
 package zfs
 
 import (
-	"encoding/json"
 	"time"
 
 	"github.com/alchemillahq/sylve/internal/db"
-	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
 	"github.com/alchemillahq/sylve/internal/logger"
 	"github.com/alchemillahq/sylve/pkg/zfs"
 )
 
 func (s *Service) StoreStats(interval int) {
+	now := time.Now()
+
 	if interval == 10 || interval == 0 {
 		d := zfs.GetTotalIODelay()
-		db.StoreAndTrimRecords(s.DB, &infoModels.IODelay{Delay: d}, 128)
+		if err := db.RecordMetrics(s.DB, "iodelay", "", now, map[string]float64{"delay": d}); err != nil {
+			logger.L.Debug().Err(err).Msg("zfs_cron: Failed to store IO delay")
+		}
 	}
 
 	if interval == 60 || interval == 0 {
@@ -32,17 +34,17 @@ func (s *Service) StoreStats(interval int) {
 		}
 
 		for _, pool := range pools {
-			newStat := infoModels.ZPoolHistorical{
-				Pools: infoModels.ZpoolJSON(*pool),
-			}
-			if err := s.DB.Create(&newStat).Error; err != nil {
+			if err := db.RecordMetrics(s.DB, "pool", pool.Name, now, map[string]float64{
+				"allocated":  float64(pool.Allocated),
+				"free":       float64(pool.Free),
+				"size":       float64(pool.Size),
+				"dedupRatio": pool.DedupRatio,
+			}); err != nil {
 				logger.L.Debug().Err(err).Msg("zfs_cron: Failed to insert zpool data")
 			}
 		}
 
-		if time.Now().Minute()%10 == 0 {
-			s.trimZPoolHistoricalData()
-		}
+		s.storeVdevIOStats()
 	}
 }
 
@@ -53,101 +55,17 @@ func (s *Service) RemoveNonExistentPools() {
 		return
 	}
 
-	existingPools := make(map[string]struct{}, len(pools))
+	names := make([]string, 0, len(pools))
 	for _, pool := range pools {
-		existingPools[pool.Name] = struct{}{}
-	}
-
-	rows, err := s.DB.Model(&infoModels.ZPoolHistorical{}).Select("id, pools").Rows()
-	if err != nil {
-		logger.L.Error().Err(err).Msg("zfs_cron: Failed to stream zpool records from DB")
-		return
-	}
-	defer rows.Close()
-
-	var idsToDelete []int64
-	for rows.Next() {
-		var id int64
-		var raw json.RawMessage
-
-		if err := rows.Scan(&id, &raw); err != nil {
-			logger.L.Warn().Err(err).Msg("zfs_cron: Failed to scan row")
-			continue
-		}
-
-		var pool zfs.Zpool
-		if err := json.Unmarshal(raw, &pool); err != nil {
-			logger.L.Warn().Err(err).Int64("id", id).Msg("zfs_cron: Failed to unmarshal pool")
-			continue
-		}
-
-		if _, exists := existingPools[pool.Name]; !exists {
-			idsToDelete = append(idsToDelete, id)
-		}
-	}
-
-	if len(idsToDelete) > 0 {
-		if err := s.DB.Where("id IN ?", idsToDelete).Delete(&infoModels.ZPoolHistorical{}).Error; err != nil {
-			logger.L.Error().Err(err).Msg("zfs_cron: Failed to delete old pool entries")
-		} else {
-			logger.L.Info().Int("count", len(idsToDelete)).Msg("zfs_cron: Deleted non-existent pool entries")
-		}
-	}
-}
-
-func (s *Service) trimZPoolHistoricalData() {
-	now := time.Now()
-	cutoff24h := now.Add(-24 * time.Hour).UnixMilli()
-
-	var oldRecords []infoModels.ZPoolHistorical
-	err := s.DB.Where("created_at < ?", cutoff24h).
-		Order("created_at ASC").
-		Find(&oldRecords).Error
-	if err != nil {
-		logger.L.Debug().Err(err).Msg("zfs_cron: Failed to fetch old zpool records")
-		return
-	}
-
-	if len(oldRecords) == 0 {
-		return
-	}
-
-	hourlyRecords := make(map[int64]infoModels.ZPoolHistorical)
-	recordsToDelete := make([]int64, 0)
-
-	for _, record := range oldRecords {
-		recordTime := time.UnixMilli(record.CreatedAt)
-		hourKey := recordTime.Truncate(time.Hour).UnixMilli()
-
-		if _, exists := hourlyRecords[hourKey]; !exists {
-			hourlyRecords[hourKey] = record
-		} else {
-			recordsToDelete = append(recordsToDelete, record.ID)
-		}
-	}
-
-	if len(recordsToDelete) > 0 {
-		err = s.DB.Where("id IN ?", recordsToDelete).Delete(&infoModels.ZPoolHistorical{}).Error
-		if err != nil {
-			logger.L.Debug().Err(err).Msg("ZFS Cron: Failed to delete old zpool records")
-		} else {
-			logger.L.Debug().
-				Int("deleted_count", len(recordsToDelete)).
-				Msg("ZFS Cron: Trimmed old zpool historical data")
-		}
+		names = append(names, pool.Name)
 	}
 
-	maxAge := now.Add(-365 * 24 * time.Hour).UnixMilli()
-	result := s.DB.Where("created_at < ?", maxAge).Delete(&infoModels.ZPoolHistorical{})
-	deletedCount := result.RowsAffected
-	if result.Error == nil && deletedCount > 0 {
-		logger.L.Debug().
-			Int64("deleted_count", deletedCount).
-			Msg("ZFS Cron: Deleted very old zpool records (>1 year)")
+	if err := db.DeleteMetricsExcept(s.DB, "pool", names); err != nil {
+		logger.L.Error().Err(err).Msg("zfs_cron: Failed to delete old pool entries")
 	}
 
-	if err := s.DB.Exec("VACUUM").Error; err != nil {
-		logger.L.Warn().Msgf("ZFS Cron: VACUUM failed: %v", err)
+	if err := s.removeStaleVdevIOStats(names); err != nil {
+		logger.L.Error().Err(err).Msg("zfs_cron: Failed to delete old vdev iostat entries")
 	}
 }
 
@@ -61,6 +61,11 @@ func (s *Service) GetDatasets(t string) ([]*zfsServiceInterfaces.Dataset, error)
 			ACLMode:       dataset.ACLMode,
 			PrimaryCache:  dataset.PrimaryCache,
 			VolMode:       dataset.VolMode,
+
+			Encryption:     dataset.Encryption,
+			EncryptionRoot: dataset.EncryptionRoot,
+			KeyStatus:      dataset.KeyStatus,
+			KeyFormat:      dataset.KeyFormat,
 		})
 	}
 
@@ -74,7 +79,8 @@ func (s *Service) GetDatasetByGUID(guid string) (*zfs.Dataset, error) {
 	}
 
 	for _, dataset := range datasets {
-		if dataset.GUID == guid {
+		// Bookmarks share the GUID of the snapshot they were created from.
+		if dataset.GUID == guid && dataset.Type != zfs.DatasetBookmark {
 			return dataset, nil
 		}
 	}
@@ -102,22 +108,37 @@ func (s *Service) BulkDeleteDataset(guids []string) error {
 
 	available := make(map[string]*zfs.Dataset)
 	for _, ds := range datasets {
-		available[ds.GUID] = ds
+		if ds.Type != zfs.DatasetBookmark {
+			available[ds.GUID] = ds
+		}
 	}
 
 	for _, guid := range guids {
 		if _, ok := available[guid]; !ok {
 			return fmt.Errorf("dataset with guid %s not found", guid)
 		}
+
+		if available[guid].Type == zfs.DatasetSnapshot {
+			if err := ensureNotHeld(available[guid], false); err != nil {
+				return err
+			}
+		}
 	}
 
 	for _, guid := range guids {
+		var keylocation string
+		if available[guid].IsEncryptionRoot() {
+			keylocation, _ = available[guid].GetProperty("keylocation")
+		}
+
 		if err := available[guid].Destroy(zfs.DestroyDefault); err != nil {
 			return fmt.Errorf("failed to delete dataset with guid %s: %w", guid, err)
 		}
+
+		removeKeyFile(keylocation)
 	}
 
-	return nil
+	return s.deleteDatasetKeys(guids)
 }
 
 func (s *Service) IsDatasetInUse(guid string, failEarly bool) bool {
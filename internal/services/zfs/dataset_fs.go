@@ -10,7 +10,6 @@ package zfs
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/alchemillahq/sylve/pkg/zfs"
 
@@ -37,9 +36,15 @@ func (s *Service) CreateFilesystem(name string, props map[string]string) error {
 	name = fmt.Sprintf("%s/%s", parent, name)
 	delete(props, "parent")
 
-	_, err := zfs.CreateFilesystem(name, props)
+	pendingKey, err := prepareDatasetEncryption(props)
+	if err != nil {
+		return err
+	}
+
+	_, err = zfs.CreateFilesystem(name, props)
 
 	if err != nil {
+		removeKeyFile(props["keylocation"])
 		return err
 	}
 
@@ -50,6 +55,9 @@ func (s *Service) CreateFilesystem(name string, props map[string]string) error {
 
 	for _, dataset := range datasets {
 		if dataset.Name == name {
+			if pendingKey != nil {
+				return s.storeDatasetKey(dataset, pendingKey)
+			}
 			return nil
 		}
 	}
@@ -103,27 +111,35 @@ func (s *Service) DeleteFilesystem(guid string) error {
 			continue
 		}
 
-		keylocation, err := filesystem.GetProperty("keylocation")
+		children, err := zfs.Datasets(filesystem.Name)
 		if err != nil {
 			return err
 		}
 
+		var guids, keylocations []string
+		for _, child := range children {
+			guids = append(guids, child.GUID)
+
+			if child.IsEncryptionRoot() {
+				keylocation, err := child.GetProperty("keylocation")
+				if err != nil {
+					return err
+				}
+				keylocations = append(keylocations, keylocation)
+			}
+		}
+
 		if err := filesystem.Destroy(zfs.DestroyRecursive); err != nil {
 			return err
 		}
 
-		if keylocation != "" && keylocation != "none" {
-			keylocation = keylocation[7:]
-			if _, err := os.Stat(keylocation); err == nil {
-				if err := os.Remove(keylocation); err != nil {
-					return err
-				}
-			} else {
-				return fmt.Errorf("keylocation_file_not_found: %s", keylocation)
+		for _, keylocation := range keylocations {
+			if err := removeKeyFile(keylocation); err != nil {
+				return err
 			}
 		}
 
-		return nil
+		return s.deleteDatasetKeys(guids)
 	}
 
 	return fmt.Errorf("filesystem with guid %s not found", guid)
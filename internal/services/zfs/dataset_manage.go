@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alchemillahq/sylve/internal/config"
+	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+func datasetPool(name string) string {
+	return strings.SplitN(name, "/", 2)[0]
+}
+
+func (s *Service) CloneSnapshot(guid string, name string, props map[string]string) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	snapshot, err := s.getSnapshotByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	if !isValidTargetDataset(name) || strings.Contains(name, "@") {
+		return fmt.Errorf("invalid_clone_name")
+	}
+
+	if datasetPool(name) != datasetPool(snapshot.Name) {
+		return fmt.Errorf("cannot_clone_across_pools")
+	}
+
+	if _, err := zfs.GetDataset(name); err == nil {
+		return fmt.Errorf("dataset_already_exists")
+	}
+
+	if _, err := snapshot.Clone(name, props); err != nil {
+		return fmt.Errorf("failed_to_clone_snapshot: %w", err)
+	}
+
+	return s.Libvirt.RescanStoragePools()
+}
+
+func (s *Service) PromoteDataset(guid string) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	dataset, err := s.GetDatasetByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("dataset_not_found")
+	}
+
+	if dataset.Origin == "" || dataset.Origin == "-" {
+		return fmt.Errorf("dataset_is_not_a_clone")
+	}
+
+	if err := dataset.Promote(); err != nil {
+		return fmt.Errorf("failed_to_promote_dataset: %w", err)
+	}
+
+	return nil
+}
+
+// datasetTree returns a dataset and all of its descendants, keyed by GUID.
+func datasetTree(name string) (map[string]*zfs.Dataset, error) {
+	datasets, err := zfs.Datasets(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]*zfs.Dataset, len(datasets))
+	for _, d := range datasets {
+		if d.Type != zfs.DatasetSnapshot {
+			tree[d.GUID] = d
+		}
+	}
+
+	return tree, nil
+}
+
+func (s *Service) RenameDataset(guid string, newName string, createParent bool, recursive bool) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	dataset, err := s.GetDatasetByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("dataset_not_found")
+	}
+
+	if newName == dataset.Name {
+		return fmt.Errorf("no_changes_detected")
+	}
+
+	if dataset.Type == zfs.DatasetSnapshot {
+		oldFS, _, _ := strings.Cut(dataset.Name, "@")
+		newFS, short, ok := strings.Cut(newName, "@")
+		if !ok || newFS != oldFS || short == "" || strings.ContainsAny(short, "@/# ") {
+			return fmt.Errorf("invalid_snapshot_name")
+		}
+
+		if _, err := dataset.Rename(newName, false, recursive); err != nil {
+			return fmt.Errorf("failed_to_rename_snapshot: %w", err)
+		}
+
+		return nil
+	}
+
+	if recursive {
+		return fmt.Errorf("recursive_rename_only_for_snapshots")
+	}
+
+	if !isValidTargetDataset(newName) || strings.Contains(newName, "@") {
+		return fmt.Errorf("invalid_dataset_name")
+	}
+
+	if datasetPool(newName) != datasetPool(dataset.Name) {
+		return fmt.Errorf("cannot_move_across_pools")
+	}
+
+	if strings.HasPrefix(newName, dataset.Name+"/") {
+		return fmt.Errorf("cannot_move_into_itself")
+	}
+
+	before, err := datasetTree(dataset.Name)
+	if err != nil {
+		return fmt.Errorf("failed_to_list_datasets: %w", err)
+	}
+
+	guids := make([]string, 0, len(before))
+	for g := range before {
+		if s.IsDatasetInUse(g, false) {
+			return fmt.Errorf("dataset_in_use_by_running_vm")
+		}
+		guids = append(guids, g)
+	}
+
+	renamed, err := dataset.Rename(newName, createParent, false)
+	if err != nil {
+		return fmt.Errorf("failed_to_rename_dataset: %w", err)
+	}
+
+	after, err := datasetTree(renamed.Name)
+	if err != nil {
+		return fmt.Errorf("failed_to_list_datasets: %w", err)
+	}
+
+	paths := make(map[string]string)
+	for g, old := range before {
+		current, ok := after[g]
+		if !ok {
+			continue
+		}
+
+		if old.Type == zfs.DatasetVolume {
+			paths[filepath.Join("/dev/zvol", old.Name)] = filepath.Join("/dev/zvol", current.Name)
+		} else if filepath.IsAbs(old.Mountpoint) && old.Mountpoint != current.Mountpoint {
+			paths[old.Mountpoint] = current.Mountpoint
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	return errors.Join(
+		s.updateVMStoragePaths(guids, paths),
+		s.updateJailPaths(guids, paths),
+		s.Libvirt.RescanStoragePools(),
+	)
+}
+
+func (s *Service) updateVMStoragePaths(guids []string, paths map[string]string) error {
+	var vms []vmModels.VM
+
+	if err := s.DB.Where("id IN (?)",
+		s.DB.Model(&vmModels.Storage{}).Select("vm_id").Where("dataset IN ?", guids),
+	).Find(&vms).Error; err != nil {
+		return fmt.Errorf("failed_to_find_vms_using_dataset: %w", err)
+	}
+
+	var errs []error
+	for _, vm := range vms {
+		if err := s.Libvirt.RewriteStoragePaths(vm.VmID, paths); err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to update storage paths of VM %d", vm.VmID)
+			errs = append(errs, fmt.Errorf("failed_to_update_vm_%d: %w", vm.VmID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Service) updateJailPaths(guids []string, paths map[string]string) error {
+	var jails []jailModels.Jail
+
+	if err := s.DB.Where("dataset IN ?", guids).Find(&jails).Error; err != nil {
+		return fmt.Errorf("failed_to_find_jails_using_dataset: %w", err)
+	}
+
+	if len(jails) == 0 {
+		return nil
+	}
+
+	jailsPath, err := config.GetJailsPath()
+	if err != nil {
+		return fmt.Errorf("failed_to_get_jails_path: %w", err)
+	}
+
+	var errs []error
+	for _, jail := range jails {
+		cfgPath := filepath.Join(jailsPath, fmt.Sprintf("%d", jail.CTID), fmt.Sprintf("%d.conf", jail.CTID))
+
+		data, err := os.ReadFile(cfgPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed_to_read_jail_config_%d: %w", jail.CTID, err))
+			continue
+		}
+
+		cfg := string(data)
+		for oldPath, newPath := range paths {
+			cfg = strings.ReplaceAll(cfg, "\""+oldPath+"\"", "\""+newPath+"\"")
+			cfg = strings.ReplaceAll(cfg, oldPath+"/", newPath+"/")
+		}
+
+		if err := os.WriteFile(cfgPath, []byte(cfg), 0644); err != nil {
+			errs = append(errs, fmt.Errorf("failed_to_write_jail_config_%d: %w", jail.CTID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -11,8 +11,11 @@ package zfs
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/diskimage"
 	"github.com/alchemillahq/sylve/pkg/utils"
 	"github.com/alchemillahq/sylve/pkg/zfs"
 )
@@ -41,9 +44,22 @@ func (s *Service) CreateVolume(name string, parent string, props map[string]stri
 
 	pSize := utils.HumanFormatToSize(props["size"])
 
-	_, err = zfs.CreateVolume(name, pSize, props)
+	pendingKey, err := prepareDatasetEncryption(props)
+	if err != nil {
+		return err
+	}
+
+	volume, err := zfs.CreateVolume(name, pSize, props)
+	if err != nil {
+		removeKeyFile(props["keylocation"])
+		return err
+	}
+
+	if pendingKey != nil {
+		return s.storeDatasetKey(volume, pendingKey)
+	}
 
-	return err
+	return nil
 }
 
 func (s *Service) EditVolume(name string, props map[string]string) error {
@@ -91,75 +107,96 @@ func (s *Service) DeleteVolume(guid string) error {
 		}
 
 		if g == guid {
+			var keylocation string
+			if volume.IsEncryptionRoot() {
+				if keylocation, err = volume.GetProperty("keylocation"); err != nil {
+					return err
+				}
+			}
+
 			err := volume.Destroy(zfs.DestroyRecursive)
 			if err != nil {
 				return err
 			}
-			return nil
+
+			if err := removeKeyFile(keylocation); err != nil {
+				return err
+			}
+
+			return s.deleteDatasetKeys([]string{guid})
 		}
 	}
 
 	return fmt.Errorf("volume with guid %s not found", guid)
 }
 
-func (s *Service) FlashVolume(guid string, uuid string) error {
+// FlashVolume writes a downloaded disk image onto a volume, converting it
+// from qcow2, VMDK or VHDX and decompressing it on the way. With grow set, a
+// volume smaller than the image is resized to fit it. The image is written in
+// the background, its progress is tracked under the volume's GUID.
+func (s *Service) FlashVolume(guid string, uuid string, grow bool) error {
+	img, device, size, err := s.prepareVolumeFlash(guid, uuid, grow)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.writeFlashImage(guid, img, device, diskimage.WriteOptions{Limit: size}, nil); err != nil {
+			logger.L.Error().Err(err).Msgf("Failed to flash volume %s", guid)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Service) prepareVolumeFlash(guid string, uuid string, grow bool) (diskimage.Image, string, int64, error) {
 	s.syncMutex.Lock()
 	defer s.syncMutex.Unlock()
 
-	datasets, err := zfs.Datasets("")
+	dataset, err := s.GetDatasetByGUID(guid)
+	if err != nil || dataset.Type != "volume" {
+		return nil, "", 0, fmt.Errorf("volume with guid %s not found", guid)
+	}
+
+	if s.IsDatasetInUse(guid, false) {
+		return nil, "", 0, fmt.Errorf("dataset_in_use_by_vm")
+	}
+
+	if dataset.Volsize == 0 {
+		return nil, "", 0, fmt.Errorf("invalid_volume_size")
+	}
+
+	device := fmt.Sprintf("/dev/zvol/%s", dataset.Name)
+	if _, err := os.Stat(device); err != nil {
+		return nil, "", 0, fmt.Errorf("zvol_not_found: %w", err)
+	}
+
+	img, err := s.openFlashImage(guid, uuid)
 	if err != nil {
-		return err
+		return nil, "", 0, err
 	}
 
-	for _, dataset := range datasets {
-		properties, err := dataset.GetAllProperties()
-		if err != nil {
-			return err
+	size, err := fitImage(img, int64(dataset.Volsize), grow, func(size int64) (int64, error) {
+		if bs := int64(dataset.VolBlockSize); bs > 0 {
+			size = (size + bs - 1) / bs * bs
 		}
 
-		for _, v := range properties {
-			if v == guid {
-				if s.IsDatasetInUse(guid, false) {
-					return fmt.Errorf("dataset_in_use_by_vm")
-				}
+		if err := zfs.EditVolume(dataset.Name, map[string]string{
+			"volsize": strconv.FormatInt(size, 10),
+		}); err != nil {
+			return 0, fmt.Errorf("failed_to_grow_volume: %w", err)
+		}
 
-				if _, ok := properties["volsize"]; !ok {
-					return fmt.Errorf("invalid_dataset")
-				}
+		return size, nil
+	})
+	if err != nil {
+		img.Close()
+		return nil, "", 0, s.finishFlash(guid, err)
+	}
 
-				pSize := utils.HumanFormatToSize(properties["volsize"])
-
-				if pSize > 0 {
-					file, err := s.Libvirt.FindISOByUUID(uuid, true)
-					if file == "" || err != nil {
-						return fmt.Errorf("iso_not_found")
-					}
-
-					fileInfo, err := os.Stat(file)
-					if err != nil {
-						return fmt.Errorf("failed_to_get_iso_file_info: %w", err)
-					}
-
-					if fileInfo.Size() > 0 && pSize >= uint64(fileInfo.Size()) {
-						if _, err := os.Stat(fmt.Sprintf("/dev/zvol/%s", dataset.Name)); err != nil {
-							return fmt.Errorf("zvol_not_found: %w", err)
-						} else {
-							output, err := utils.RunCommand("dd", "if="+file, "of=/dev/zvol/"+dataset.Name, "bs=4M")
-							if err != nil {
-								return fmt.Errorf("failed_to_flash_volume: %w, output: %s", err, output)
-							}
-
-							return nil
-						}
-					} else {
-						return fmt.Errorf("iso_size_exceeds_volume_size")
-					}
-				} else {
-					return fmt.Errorf("invalid_volume_size")
-				}
-			}
-		}
+	if size != int64(dataset.Volsize) {
+		s.Libvirt.RescanStoragePools()
 	}
 
-	return fmt.Errorf("volume with guid %s not found", guid)
+	return img, device, size, nil
 }
@@ -0,0 +1,537 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alchemillahq/sylve/internal/db/models"
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/crypto"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	zfsKeysDir  = "/etc/zfs/keys"
+	keySealPath = zfsKeysDir + "/sylve.seal"
+)
+
+type pendingDatasetKey struct {
+	format     string
+	key        []byte
+	autoUnlock bool
+}
+
+// decodeDatasetKey turns a key given through the API into the bytes zfs
+// expects for the format. Keys for the raw format are given hex encoded,
+// and hex and raw keys are generated when none is given.
+func decodeDatasetKey(format string, key string) ([]byte, error) {
+	switch format {
+	case "passphrase":
+		if len(key) < 32 || len(key) > 512 {
+			return nil, fmt.Errorf("invalid_encryption_key_length")
+		}
+		return []byte(key), nil
+	case "hex", "raw":
+		var raw []byte
+		if key == "" {
+			raw = make([]byte, 32)
+			if _, err := rand.Read(raw); err != nil {
+				return nil, fmt.Errorf("failed_to_generate_key: %w", err)
+			}
+		} else {
+			decoded, err := hex.DecodeString(key)
+			if err != nil || len(decoded) != 32 {
+				return nil, fmt.Errorf("invalid_encryption_key")
+			}
+			raw = decoded
+		}
+
+		if format == "hex" {
+			return []byte(hex.EncodeToString(raw)), nil
+		}
+		return raw, nil
+	default:
+		return nil, fmt.Errorf("invalid_key_format")
+	}
+}
+
+func writeKeyFile(key []byte) (string, error) {
+	if err := os.MkdirAll(zfsKeysDir, 0700); err != nil {
+		return "", fmt.Errorf("failed_to_create_keys_dir: %w", err)
+	}
+
+	path := filepath.Join(zfsKeysDir, uuid.NewString())
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return "", fmt.Errorf("failed_to_write_encryption_key: %w", err)
+	}
+
+	return path, nil
+}
+
+// removeKeyFile deletes a key file Sylve wrote for the dataset, keylocations
+// outside the keys directory belong to the admin and are left alone.
+func removeKeyFile(keylocation string) error {
+	path, ok := strings.CutPrefix(keylocation, "file://")
+	if !ok || filepath.Dir(path) != zfsKeysDir {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// prepareDatasetEncryption takes the encryption pseudo properties
+// (encryptionKey, autoUnlock) out of props and sets the real keyformat and
+// keylocation for zfs create. Passphrases are handed to zfs on stdin, hex
+// and raw keys are written to a key file.
+func prepareDatasetEncryption(props map[string]string) (*pendingDatasetKey, error) {
+	autoUnlock := props["autoUnlock"] == "true"
+	delete(props, "autoUnlock")
+
+	if props["encryption"] == "" || props["encryption"] == "off" {
+		delete(props, "encryptionKey")
+		delete(props, "keyformat")
+		return nil, nil
+	}
+
+	format := props["keyformat"]
+	if format == "" {
+		format = "passphrase"
+	}
+
+	if format == "passphrase" && props["encryptionKey"] == "" {
+		return nil, fmt.Errorf("encryption_key_required")
+	}
+
+	key, err := decodeDatasetKey(format, props["encryptionKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	props["keyformat"] = format
+
+	if format != "passphrase" {
+		delete(props, "encryptionKey")
+
+		path, err := writeKeyFile(key)
+		if err != nil {
+			return nil, err
+		}
+
+		props["keylocation"] = "file://" + path
+	}
+
+	return &pendingDatasetKey{format: format, key: key, autoUnlock: autoUnlock}, nil
+}
+
+func datasetKeySecretName(guid string) string {
+	return "zfs_key_" + guid
+}
+
+// keySecret returns the key stored dataset keys are sealed with, creating
+// it on first use. It is kept in a file next to the key files instead of the
+// database, so a copy of the database alone does not give the keys away.
+// Losing the file loses the stored keys, the datasets then have to be
+// unlocked with their key again.
+func keySecret() ([]byte, error) {
+	secret, err := os.ReadFile(keySealPath)
+	if err == nil {
+		return secret, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed_to_read_seal_key: %w", err)
+	}
+
+	if err := os.MkdirAll(zfsKeysDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed_to_create_keys_dir: %w", err)
+	}
+
+	secret = make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed_to_generate_seal_key: %w", err)
+	}
+
+	f, err := os.OpenFile(keySealPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return os.ReadFile(keySealPath)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed_to_write_seal_key: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(secret); err != nil {
+		return nil, fmt.Errorf("failed_to_write_seal_key: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return nil, fmt.Errorf("failed_to_write_seal_key: %w", err)
+	}
+
+	return secret, nil
+}
+
+func (s *Service) storeDatasetKey(dataset *zfs.Dataset, pending *pendingDatasetKey) error {
+	secret, err := keySecret()
+	if err != nil {
+		return err
+	}
+
+	sealed, err := crypto.EncryptSecret(pending.key, secret)
+	if err != nil {
+		return fmt.Errorf("failed_to_encrypt_key: %w", err)
+	}
+
+	secretName := datasetKeySecretName(dataset.GUID)
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var record models.SystemSecrets
+		err := tx.Where("name = ?", secretName).First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			record = models.SystemSecrets{Name: secretName, Data: sealed}
+			if err := tx.Create(&record).Error; err != nil {
+				return fmt.Errorf("failed_to_store_key: %w", err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed_to_store_key: %w", err)
+		} else if err := tx.Model(&record).Update("data", sealed).Error; err != nil {
+			return fmt.Errorf("failed_to_store_key: %w", err)
+		}
+
+		var key zfsModels.DatasetKey
+		if err := tx.Where("guid = ?", dataset.GUID).First(&key).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed_to_store_key: %w", err)
+		}
+
+		key.GUID = dataset.GUID
+		key.Name = dataset.Name
+		key.KeyFormat = pending.format
+		key.SecretName = secretName
+		key.AutoUnlock = pending.autoUnlock
+
+		if err := tx.Save(&key).Error; err != nil {
+			return fmt.Errorf("failed_to_store_key: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Service) storedDatasetKey(guid string) (*zfsModels.DatasetKey, []byte, error) {
+	var key zfsModels.DatasetKey
+	if err := s.DB.Where("guid = ?", guid).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed_to_get_dataset_key: %w", err)
+	}
+
+	var record models.SystemSecrets
+	if err := s.DB.Where("name = ?", key.SecretName).First(&record).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed_to_get_dataset_key: %w", err)
+	}
+
+	secret, err := keySecret()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plain, err := crypto.DecryptSecret(record.Data, secret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed_to_decrypt_key: %w", err)
+	}
+
+	return &key, plain, nil
+}
+
+// deleteDatasetKeys forgets the stored keys of the given datasets, used
+// after they were destroyed.
+func (s *Service) deleteDatasetKeys(guids []string) error {
+	if len(guids) == 0 {
+		return nil
+	}
+
+	secretNames := make([]string, 0, len(guids))
+	for _, guid := range guids {
+		secretNames = append(secretNames, datasetKeySecretName(guid))
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("guid IN ?", guids).Delete(&zfsModels.DatasetKey{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("name IN ?", secretNames).Delete(&models.SystemSecrets{}).Error
+	})
+}
+
+func (s *Service) encryptionRootByGUID(guid string) (*zfs.Dataset, error) {
+	dataset, err := s.GetDatasetByGUID(guid)
+	if err != nil {
+		return nil, fmt.Errorf("dataset_not_found")
+	}
+
+	if !dataset.IsEncrypted() {
+		return nil, fmt.Errorf("dataset_not_encrypted")
+	}
+
+	if !dataset.IsEncryptionRoot() {
+		return nil, fmt.Errorf("not_encryption_root: %s", dataset.EncryptionRoot)
+	}
+
+	return dataset, nil
+}
+
+// mountEncryptionRoot mounts the filesystems that share the root's key once
+// it is loaded, children with their own encryption root stay untouched.
+func mountEncryptionRoot(root *zfs.Dataset) {
+	datasets, err := zfs.Filesystems(root.Name)
+	if err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to list filesystems of %s", root.Name)
+		return
+	}
+
+	for _, ds := range datasets {
+		if ds.EncryptionRoot != root.Name || ds.Mounted == "yes" {
+			continue
+		}
+
+		canmount, _ := ds.GetProperty("canmount")
+		if canmount != "on" || ds.Mountpoint == "none" || ds.Mountpoint == "legacy" {
+			continue
+		}
+
+		if _, err := ds.Mount(false, nil); err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to mount %s", ds.Name)
+		}
+	}
+}
+
+// LoadDatasetKey loads the key of an encryption root. Without a key the one
+// Sylve stored is used, and failing that zfs falls back to the keylocation.
+// With remember set a given key replaces the stored one.
+func (s *Service) LoadDatasetKey(guid string, req zfsServiceInterfaces.LoadKeyRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	dataset, err := s.encryptionRootByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	if dataset.KeyStatus == zfs.KeyStatusAvailable {
+		return fmt.Errorf("key_already_loaded")
+	}
+
+	var key []byte
+	if req.Key != "" {
+		key, err = decodeDatasetKey(dataset.KeyFormat, req.Key)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, key, err = s.storedDatasetKey(guid)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := dataset.LoadKey(key); err != nil {
+		return fmt.Errorf("failed_to_load_key: %w", err)
+	}
+
+	if req.Key != "" && req.Remember {
+		stored, _, _ := s.storedDatasetKey(guid)
+		pending := &pendingDatasetKey{format: dataset.KeyFormat, key: key}
+		if stored != nil {
+			pending.autoUnlock = stored.AutoUnlock
+		}
+
+		if err := s.storeDatasetKey(dataset, pending); err != nil {
+			return err
+		}
+	}
+
+	mountEncryptionRoot(dataset)
+
+	return s.Libvirt.RescanStoragePools()
+}
+
+func (s *Service) UnloadDatasetKey(guid string) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	dataset, err := s.encryptionRootByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	if dataset.KeyStatus != zfs.KeyStatusAvailable {
+		return fmt.Errorf("key_not_loaded")
+	}
+
+	datasets, err := zfs.Datasets(dataset.Name)
+	if err != nil {
+		return err
+	}
+
+	// Everything mounted below the root has to go before the root itself can
+	// be unmounted, children with their own key included.
+	var mounted []*zfs.Dataset
+	for _, ds := range datasets {
+		isMounted := ds.Type == zfs.DatasetFilesystem && ds.Mounted == "yes"
+		if (ds.EncryptionRoot == dataset.Name || isMounted) && s.IsDatasetInUse(ds.GUID, false) {
+			return fmt.Errorf("dataset_in_use_by_vm: %s", ds.Name)
+		}
+
+		if isMounted {
+			mounted = append(mounted, ds)
+		}
+	}
+
+	sort.Slice(mounted, func(i, j int) bool {
+		return strings.Count(mounted[i].Name, "/") > strings.Count(mounted[j].Name, "/")
+	})
+
+	for _, ds := range mounted {
+		if _, err := ds.Unmount(false); err != nil {
+			return fmt.Errorf("failed_to_unmount_dataset: %s: %w", ds.Name, err)
+		}
+	}
+
+	if err := dataset.UnloadKey(); err != nil {
+		return fmt.Errorf("failed_to_unload_key: %w", err)
+	}
+
+	return s.Libvirt.RescanStoragePools()
+}
+
+// ChangeDatasetKey rewraps an encryption root with a new key. Passphrases
+// are set through stdin and stay keylocation=prompt, hex and raw keys get a
+// fresh key file. The stored key is replaced either way.
+func (s *Service) ChangeDatasetKey(guid string, req zfsServiceInterfaces.ChangeKeyRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	dataset, err := s.encryptionRootByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	if dataset.KeyStatus != zfs.KeyStatusAvailable {
+		return fmt.Errorf("key_not_loaded")
+	}
+
+	if req.KeyFormat == "passphrase" && req.Key == "" {
+		return fmt.Errorf("encryption_key_required")
+	}
+
+	key, err := decodeDatasetKey(req.KeyFormat, req.Key)
+	if err != nil {
+		return err
+	}
+
+	oldLocation, err := dataset.GetProperty("keylocation")
+	if err != nil {
+		return err
+	}
+
+	if req.KeyFormat == "passphrase" {
+		err = dataset.ChangeKey(req.KeyFormat, "prompt", key)
+	} else {
+		var path string
+		path, err = writeKeyFile(key)
+		if err != nil {
+			return err
+		}
+
+		err = dataset.ChangeKey(req.KeyFormat, "file://"+path, nil)
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed_to_change_key: %w", err)
+	}
+
+	if err := removeKeyFile(oldLocation); err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to remove old key file of %s", dataset.Name)
+	}
+
+	pending := &pendingDatasetKey{format: req.KeyFormat, key: key}
+	if req.AutoUnlock != nil {
+		pending.autoUnlock = *req.AutoUnlock
+	} else if stored, _, _ := s.storedDatasetKey(guid); stored != nil {
+		pending.autoUnlock = stored.AutoUnlock
+	}
+
+	return s.storeDatasetKey(dataset, pending)
+}
+
+// UnlockEncryptedDatasets loads the keys of every encryption root marked for
+// auto-unlock and mounts its filesystems. It runs at startup before guests
+// are started, failures are logged so a single locked dataset does not keep
+// the rest of the system down.
+func (s *Service) UnlockEncryptedDatasets() error {
+	var keys []zfsModels.DatasetKey
+	if err := s.DB.Where("auto_unlock = ?", true).Find(&keys).Error; err != nil {
+		return fmt.Errorf("failed_to_get_dataset_keys: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	for _, k := range keys {
+		dataset, err := s.GetDatasetByGUID(k.GUID)
+		if err != nil {
+			logger.L.Warn().Msgf("Encrypted dataset %s (%s) not found, skipping auto-unlock", k.Name, k.GUID)
+			continue
+		}
+
+		if dataset.KeyStatus == zfs.KeyStatusAvailable {
+			mountEncryptionRoot(dataset)
+			continue
+		}
+
+		_, key, err := s.storedDatasetKey(k.GUID)
+		if err != nil {
+			logger.L.Error().Err(err).Msgf("Failed to get key of %s", dataset.Name)
+			continue
+		}
+
+		if err := dataset.LoadKey(key); err != nil {
+			logger.L.Error().Err(err).Msgf("Failed to unlock %s", dataset.Name)
+			continue
+		}
+
+		mountEncryptionRoot(dataset)
+		logger.L.Info().Msgf("Unlocked encrypted dataset %s", dataset.Name)
+	}
+
+	return s.Libvirt.RescanStoragePools()
+}
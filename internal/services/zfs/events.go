@@ -0,0 +1,345 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+const (
+	maxPoolEvents = 5000
+	maxPoolAlerts = 1000
+
+	// Checksum and I/O errors tend to come in bursts, only one alert per
+	// device and class is raised in this interval.
+	poolErrorAlertInterval = 10 * time.Minute
+)
+
+type poolEventWatcher struct {
+	s              *Service
+	since          time.Time
+	stored         int
+	poolHealth     map[string]string
+	lastErrorAlert map[string]time.Time
+}
+
+func (s *Service) newPoolEventWatcher() *poolEventWatcher {
+	w := &poolEventWatcher{
+		s:              s,
+		poolHealth:     make(map[string]string),
+		lastErrorAlert: make(map[string]time.Time),
+	}
+
+	// zpool events replays the kernel's queue on every start, skip what is
+	// already stored.
+	var last zfsModels.PoolEvent
+	if err := s.DB.Order("time DESC").Limit(1).Find(&last).Error; err == nil {
+		w.since = last.Time
+	}
+
+	pools, err := zfs.ListZpools()
+	if err != nil {
+		logger.L.Debug().Err(err).Msg("Failed to list pools for event watcher")
+	}
+
+	for _, pool := range pools {
+		w.poolHealth[pool.Name] = pool.Health
+	}
+
+	return w
+}
+
+func vdevStateSeverity(state string) string {
+	switch state {
+	case "ONLINE":
+		return "info"
+	case "DEGRADED", "OFFLINE":
+		return "warning"
+	default:
+		return "critical"
+	}
+}
+
+func eventDevice(e zfs.Event) string {
+	if e.VdevPath != "" {
+		return e.VdevPath
+	}
+	return e.VdevGUID
+}
+
+func (w *poolEventWatcher) errorAlert(e zfs.Event, kind string) *zfsModels.PoolAlert {
+	key := e.Class + "/" + e.Pool + "/" + e.VdevGUID
+	if last, ok := w.lastErrorAlert[key]; ok && e.Time.Sub(last) < poolErrorAlertInterval {
+		return nil
+	}
+	w.lastErrorAlert[key] = e.Time
+
+	device := eventDevice(e)
+	message := fmt.Sprintf("%s on pool %s", kind, e.Pool)
+	if device != "" {
+		message = fmt.Sprintf("%s on %s in pool %s", kind, device, e.Pool)
+	}
+
+	return &zfsModels.PoolAlert{
+		Kind:     kind,
+		Severity: "warning",
+		Device:   device,
+		State:    e.VdevState,
+		Message:  message,
+	}
+}
+
+// alertsFor turns an event into the health changes it represents. Pool
+// transitions are found by comparing the pool's health before and after.
+func (w *poolEventWatcher) alertsFor(e zfs.Event) []zfsModels.PoolAlert {
+	var alerts []zfsModels.PoolAlert
+
+	switch e.Class {
+	case zfs.EventClassStateChange:
+		if e.VdevState != "" && e.VdevState != e.VdevLastState {
+			device := eventDevice(e)
+			alerts = append(alerts, zfsModels.PoolAlert{
+				Kind:      "vdev_state",
+				Severity:  vdevStateSeverity(e.VdevState),
+				Device:    device,
+				PrevState: e.VdevLastState,
+				State:     e.VdevState,
+				Message:   fmt.Sprintf("%s in pool %s changed from %s to %s", device, e.Pool, e.VdevLastState, e.VdevState),
+			})
+		}
+	case zfs.EventClassRemoved:
+		device := eventDevice(e)
+		alerts = append(alerts, zfsModels.PoolAlert{
+			Kind:     "device_removed",
+			Severity: "critical",
+			Device:   device,
+			State:    "REMOVED",
+			Message:  fmt.Sprintf("%s was removed from pool %s", device, e.Pool),
+		})
+	case zfs.EventClassChecksum:
+		if alert := w.errorAlert(e, "checksum_error"); alert != nil {
+			alerts = append(alerts, *alert)
+		}
+	case zfs.EventClassIO:
+		if alert := w.errorAlert(e, "io_error"); alert != nil {
+			alerts = append(alerts, *alert)
+		}
+	case zfs.EventClassData:
+		if alert := w.errorAlert(e, "data_error"); alert != nil {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	if e.Pool == "" {
+		return alerts
+	}
+
+	pool, err := zfs.GetZpool(e.Pool)
+	if err != nil {
+		return alerts
+	}
+
+	prev, known := w.poolHealth[pool.Name]
+	w.poolHealth[pool.Name] = pool.Health
+
+	if known && prev != pool.Health {
+		alerts = append(alerts, zfsModels.PoolAlert{
+			Kind:      "pool_state",
+			Severity:  vdevStateSeverity(pool.Health),
+			Device:    pool.Name,
+			PrevState: prev,
+			State:     pool.Health,
+			Message:   fmt.Sprintf("Pool %s changed from %s to %s", pool.Name, prev, pool.Health),
+		})
+	}
+
+	return alerts
+}
+
+func (w *poolEventWatcher) handle(e zfs.Event) {
+	if !e.Time.After(w.since) {
+		return
+	}
+	w.since = e.Time
+
+	event := zfsModels.PoolEvent{
+		EID:       e.EID,
+		Time:      e.Time,
+		Class:     e.Class,
+		Pool:      e.Pool,
+		PoolGUID:  e.PoolGUID,
+		VdevPath:  e.VdevPath,
+		VdevGUID:  e.VdevGUID,
+		VdevState: e.VdevState,
+		Attrs:     e.Attrs,
+	}
+
+	if err := w.s.DB.Create(&event).Error; err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to store pool event %s", e.Class)
+		return
+	}
+
+	w.stored++
+	if w.stored%100 == 0 {
+		w.s.trimPoolEvents()
+	}
+
+	for _, alert := range w.alertsFor(e) {
+		alert.EventID = event.ID
+		alert.Pool = e.Pool
+		alert.PoolGUID = e.PoolGUID
+
+		if err := w.s.DB.Create(&alert).Error; err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to store pool alert for %s", e.Pool)
+			continue
+		}
+
+		if alert.Severity == "info" {
+			logger.L.Info().Msg(alert.Message)
+		} else {
+			logger.L.Warn().Msg(alert.Message)
+		}
+
+		w.s.publishPoolAlert(alert)
+	}
+}
+
+func (s *Service) trimPoolEvents() {
+	if err := s.DB.Where("id NOT IN (?)",
+		s.DB.Model(&zfsModels.PoolEvent{}).
+			Select("id").
+			Order("id DESC").
+			Limit(maxPoolEvents),
+	).Delete(&zfsModels.PoolEvent{}).Error; err != nil {
+		logger.L.Debug().Err(err).Msg("Failed to trim pool events")
+	}
+
+	if err := s.DB.Where("id NOT IN (?)",
+		s.DB.Model(&zfsModels.PoolAlert{}).
+			Select("id").
+			Order("id DESC").
+			Limit(maxPoolAlerts),
+	).Delete(&zfsModels.PoolAlert{}).Error; err != nil {
+		logger.L.Debug().Err(err).Msg("Failed to trim pool alerts")
+	}
+}
+
+// SubscribePoolAlerts returns a channel that receives every new pool alert
+// and a function to unsubscribe. Alerts are dropped for subscribers that
+// do not keep up.
+func (s *Service) SubscribePoolAlerts() (<-chan zfsModels.PoolAlert, func()) {
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	id := s.nextAlertSubscriber
+	s.nextAlertSubscriber++
+
+	ch := make(chan zfsModels.PoolAlert, 16)
+	s.alertSubscribers[id] = ch
+
+	return ch, func() {
+		s.alertMutex.Lock()
+		defer s.alertMutex.Unlock()
+
+		if ch, ok := s.alertSubscribers[id]; ok {
+			delete(s.alertSubscribers, id)
+			close(ch)
+		}
+	}
+}
+
+func (s *Service) publishPoolAlert(alert zfsModels.PoolAlert) {
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	for _, ch := range s.alertSubscribers {
+		select {
+		case ch <- alert:
+		default:
+		}
+	}
+}
+
+func (s *Service) GetPoolEvents(pool string, limit int) ([]zfsModels.PoolEvent, error) {
+	if limit <= 0 || limit > maxPoolEvents {
+		limit = 100
+	}
+
+	query := s.DB.Order("time DESC").Limit(limit)
+	if pool != "" {
+		query = query.Where("pool = ?", pool)
+	}
+
+	var events []zfsModels.PoolEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_pool_events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *Service) GetPoolAlerts(unacknowledged bool, limit int) ([]zfsModels.PoolAlert, error) {
+	if limit <= 0 || limit > maxPoolAlerts {
+		limit = 100
+	}
+
+	query := s.DB.Order("id DESC").Limit(limit)
+	if unacknowledged {
+		query = query.Where("acknowledged = ?", false)
+	}
+
+	var alerts []zfsModels.PoolAlert
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_pool_alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+func (s *Service) AcknowledgePoolAlert(id uint) error {
+	result := s.DB.Model(&zfsModels.PoolAlert{}).Where("id = ?", id).Update("acknowledged", true)
+	if result.Error != nil {
+		return fmt.Errorf("failed_to_acknowledge_alert: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("alert_not_found")
+	}
+
+	return nil
+}
+
+// StartEventWatcher follows `zpool events` and stores every event, raising
+// alerts for health changes. zpool is restarted if it exits.
+func (s *Service) StartEventWatcher(ctx context.Context) {
+	go func() {
+		w := s.newPoolEventWatcher()
+
+		for {
+			err := zfs.FollowEvents(ctx, w.handle)
+			if ctx.Err() != nil {
+				return
+			}
+
+			logger.L.Warn().Err(err).Msg("Pool event watcher stopped, restarting")
+
+			select {
+			case <-time.After(10 * time.Second):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
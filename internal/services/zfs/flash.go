@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/diskimage"
+)
+
+func rawDiskFlashTarget(storageId int) string {
+	return fmt.Sprintf("storage-%d", storageId)
+}
+
+// openFlashImage resolves a download to a disk image and registers a flash of
+// it onto target, so only one image is written to a target at a time.
+func (s *Service) openFlashImage(target string, uuid string) (diskimage.Image, error) {
+	file, err := s.Libvirt.FindISOByUUID(uuid, true)
+	if file == "" || err != nil {
+		return nil, fmt.Errorf("iso_not_found")
+	}
+
+	img, err := diskimage.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_open_image: %w", err)
+	}
+
+	s.flashMutex.Lock()
+	defer s.flashMutex.Unlock()
+
+	if p, ok := s.flashes[target]; ok && !p.Done {
+		img.Close()
+		return nil, fmt.Errorf("flash_already_running")
+	}
+
+	s.flashes[target] = &zfsServiceInterfaces.FlashProgress{
+		Target:      target,
+		Format:      img.Format(),
+		Compression: img.Compression(),
+		VirtualSize: img.VirtualSize(),
+		StartedAt:   time.Now(),
+	}
+
+	return img, nil
+}
+
+func (s *Service) finishFlash(target string, err error) error {
+	s.flashMutex.Lock()
+	defer s.flashMutex.Unlock()
+
+	if p, ok := s.flashes[target]; ok {
+		now := time.Now()
+		p.Done = true
+		p.FinishedAt = &now
+		if err != nil {
+			p.Error = err.Error()
+		} else {
+			p.Percent = 100
+		}
+	}
+
+	return err
+}
+
+// fitImage checks that img fits into a target of capacity bytes, calling
+// resize to grow the target when allowed. Images of unknown size are only
+// checked while they are written.
+func fitImage(img diskimage.Image, capacity int64, grow bool, resize func(size int64) (int64, error)) (int64, error) {
+	size := img.VirtualSize()
+	if size <= capacity {
+		return capacity, nil
+	}
+
+	if !grow {
+		return 0, diskimage.ErrImageTooLarge
+	}
+
+	return resize(size)
+}
+
+// writeFlashImage writes img to device and closes it, tracking progress
+// under target. commit, if given, runs once the image is written and synced.
+func (s *Service) writeFlashImage(target string, img diskimage.Image, device string, opts diskimage.WriteOptions, commit func() error) error {
+	defer img.Close()
+
+	dst, err := os.OpenFile(device, os.O_WRONLY, 0)
+	if err != nil {
+		return s.finishFlash(target, fmt.Errorf("failed_to_open_target: %w", err))
+	}
+
+	opts.OnProgress = func(p diskimage.Progress) {
+		s.flashMutex.Lock()
+		defer s.flashMutex.Unlock()
+
+		if f, ok := s.flashes[target]; ok {
+			f.Written = p.Written
+			f.Percent = p.Percent
+		}
+	}
+
+	if _, err := diskimage.WriteTo(img, dst, opts); err != nil {
+		dst.Close()
+		if errors.Is(err, diskimage.ErrImageTooLarge) {
+			return s.finishFlash(target, err)
+		}
+		return s.finishFlash(target, fmt.Errorf("failed_to_flash_image: %w", err))
+	}
+
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return s.finishFlash(target, fmt.Errorf("failed_to_sync_target: %w", err))
+	}
+
+	if err := dst.Close(); err != nil {
+		return s.finishFlash(target, fmt.Errorf("failed_to_close_target: %w", err))
+	}
+
+	if commit != nil {
+		if err := commit(); err != nil {
+			return s.finishFlash(target, err)
+		}
+	}
+
+	return s.finishFlash(target, nil)
+}
+
+func (s *Service) getFlashProgress(target string) (*zfsServiceInterfaces.FlashProgress, error) {
+	s.flashMutex.Lock()
+	defer s.flashMutex.Unlock()
+
+	p, ok := s.flashes[target]
+	if !ok {
+		return nil, fmt.Errorf("flash_not_found")
+	}
+
+	copied := *p
+	return &copied, nil
+}
+
+func (s *Service) GetVolumeFlashProgress(guid string) (*zfsServiceInterfaces.FlashProgress, error) {
+	return s.getFlashProgress(guid)
+}
+
+func (s *Service) GetRawDiskFlashProgress(storageId int) (*zfsServiceInterfaces.FlashProgress, error) {
+	return s.getFlashProgress(rawDiskFlashTarget(storageId))
+}
+
+type rawDiskFlash struct {
+	img     diskimage.Image
+	path    string
+	tmp     string
+	size    int64
+	storage vmModels.Storage
+}
+
+// FlashRawDisk replaces the contents of a raw VM disk image with a downloaded
+// disk image, converting it from qcow2, VMDK or VHDX on the way. The image is
+// written to a file next to the disk in the background, which only replaces
+// the disk once it was written completely.
+func (s *Service) FlashRawDisk(storageId int, uuid string, grow bool) error {
+	target := rawDiskFlashTarget(storageId)
+
+	flash, err := s.prepareRawDiskFlash(storageId, target, uuid, grow)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		// The new file starts out empty, skipping zeroes keeps it sparse.
+		err := s.writeFlashImage(target, flash.img, flash.tmp, diskimage.WriteOptions{
+			Limit:      flash.size,
+			SkipZeroes: true,
+		}, func() error {
+			return s.commitRawDiskFlash(flash)
+		})
+
+		if err != nil {
+			os.Remove(flash.tmp)
+			logger.L.Error().Err(err).Msgf("Failed to flash raw disk %s", flash.path)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Service) commitRawDiskFlash(flash *rawDiskFlash) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	if err := os.Rename(flash.tmp, flash.path); err != nil {
+		return fmt.Errorf("failed_to_replace_image: %w", err)
+	}
+
+	if flash.size != flash.storage.Size {
+		if err := s.DB.Model(&flash.storage).Update("size", flash.size).Error; err != nil {
+			return fmt.Errorf("failed_to_update_storage_size: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) prepareRawDiskFlash(storageId int, target string, uuid string, grow bool) (*rawDiskFlash, error) {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	var storage vmModels.Storage
+	if err := s.DB.First(&storage, storageId).Error; err != nil {
+		return nil, fmt.Errorf("storage_not_found")
+	}
+
+	if storage.Type != "raw" {
+		return nil, fmt.Errorf("storage_not_raw_disk")
+	}
+
+	var vm vmModels.VM
+	if err := s.DB.First(&vm, storage.VMID).Error; err != nil {
+		return nil, fmt.Errorf("vm_not_found")
+	}
+
+	if vm.Template {
+		return nil, fmt.Errorf("vm_is_template")
+	}
+
+	inactive, err := s.Libvirt.IsDomainInactive(vm.VmID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !inactive {
+		return nil, fmt.Errorf("vm_must_be_shut_off")
+	}
+
+	dataset, err := s.GetDatasetByGUID(storage.Dataset)
+	if err != nil || dataset.Type != "filesystem" {
+		return nil, fmt.Errorf("dataset_not_found: %s", storage.Dataset)
+	}
+
+	name := storage.Name
+	if name == "" {
+		name = strconv.Itoa(vm.VmID)
+	}
+
+	path := filepath.Join(dataset.Mountpoint, name+".img")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("image_file_not_found: %s", path)
+	}
+
+	img, err := s.openFlashImage(target, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Growing a file is left to the truncate below.
+	size, err := fitImage(img, info.Size(), grow, func(size int64) (int64, error) {
+		return size, nil
+	})
+	if err != nil {
+		img.Close()
+		return nil, s.finishFlash(target, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".flash-*")
+	if err != nil {
+		img.Close()
+		return nil, s.finishFlash(target, fmt.Errorf("failed_to_create_image: %w", err))
+	}
+	tmp.Close()
+
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err == nil {
+		err = os.Truncate(tmp.Name(), size)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		img.Close()
+		return nil, s.finishFlash(target, fmt.Errorf("failed_to_create_image: %w", err))
+	}
+
+	return &rawDiskFlash{
+		img:     img,
+		path:    path,
+		tmp:     tmp.Name(),
+		size:    size,
+		storage: storage,
+	}, nil
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+// Hold tags and bookmark names end up as zfs arguments, keep them to
+// characters that are valid in a snapshot name.
+var holdNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,254}$`)
+
+// ensureNotHeld refuses to go on when any hold is left on the snapshot, or on
+// the snapshots of the same name below it when they are destroyed with it.
+func ensureNotHeld(snapshot *zfs.Dataset, recursive bool) error {
+	holds, err := snapshot.Holds(recursive)
+	if err != nil {
+		return fmt.Errorf("failed_to_get_snapshot_holds: %w", err)
+	}
+
+	if len(holds) == 0 {
+		return nil
+	}
+
+	held := make([]string, 0, len(holds))
+	for _, hold := range holds {
+		held = append(held, fmt.Sprintf("%s (%s)", hold.Snapshot, hold.Tag))
+	}
+
+	return fmt.Errorf("snapshot_is_held: %s", strings.Join(held, ", "))
+}
+
+func (s *Service) GetSnapshotHolds(guid string) ([]zfs.Hold, error) {
+	snapshot, err := s.getSnapshotByGUID(guid)
+	if err != nil {
+		return nil, err
+	}
+
+	holds, err := snapshot.Holds(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_snapshot_holds: %w", err)
+	}
+
+	return holds, nil
+}
+
+func (s *Service) HoldSnapshot(guid string, req zfsServiceInterfaces.SnapshotHoldRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	if !holdNamePattern.MatchString(req.Tag) {
+		return fmt.Errorf("invalid_hold_tag")
+	}
+
+	snapshot, err := s.getSnapshotByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	if err := snapshot.Hold(req.Tag, req.Recursive); err != nil {
+		return fmt.Errorf("failed_to_hold_snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) ReleaseSnapshot(guid string, req zfsServiceInterfaces.SnapshotHoldRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	snapshot, err := s.getSnapshotByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	if err := snapshot.Release(req.Tag, req.Recursive); err != nil {
+		return fmt.Errorf("failed_to_release_snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetBookmarks(guid string) ([]*zfs.Dataset, error) {
+	dataset, err := s.GetDatasetByGUID(guid)
+	if err != nil {
+		return nil, fmt.Errorf("dataset_not_found")
+	}
+
+	if dataset.Type == zfs.DatasetSnapshot {
+		return nil, fmt.Errorf("snapshots_have_no_bookmarks")
+	}
+
+	bookmarks, err := dataset.Bookmarks()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_list_bookmarks: %w", err)
+	}
+
+	return bookmarks, nil
+}
+
+// CreateBookmark bookmarks the snapshot with the given GUID on its dataset.
+func (s *Service) CreateBookmark(guid string, name string) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	if !holdNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid_bookmark_name")
+	}
+
+	snapshot, err := s.getSnapshotByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	if _, err := snapshot.Bookmark(name); err != nil {
+		return fmt.Errorf("failed_to_create_bookmark: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteBookmark(guid string, name string) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	dataset, err := s.GetDatasetByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("dataset_not_found")
+	}
+
+	bookmarks, err := dataset.Bookmarks()
+	if err != nil {
+		return fmt.Errorf("failed_to_list_bookmarks: %w", err)
+	}
+
+	for _, bookmark := range bookmarks {
+		if bookmark.Name == dataset.Name+"#"+name {
+			if err := bookmark.Destroy(zfs.DestroyDefault); err != nil {
+				return fmt.Errorf("failed_to_delete_bookmark: %w", err)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("bookmark_not_found")
+}
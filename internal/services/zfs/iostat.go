@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alchemillahq/sylve/internal/db"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+// vdevLabel identifies a pool, vdev or device in the "vdev" metrics series.
+// Pool names and types never contain a "|" and the name goes last, so device
+// paths such as gpt/disk0 survive the round trip.
+func vdevLabel(st zfs.VdevIOStat) string {
+	return strings.Join([]string{st.Pool, st.Type, st.Parent, st.Name}, "|")
+}
+
+func parseVdevLabel(label string) (pool, typ, parent, name string, ok bool) {
+	parts := strings.SplitN(label, "|", 4)
+	if len(parts) != 4 {
+		return "", "", "", "", false
+	}
+
+	return parts[0], parts[1], parts[2], parts[3], true
+}
+
+func (s *Service) storeVdevIOStats() {
+	stats, err := zfs.VdevIOStats(1)
+	if err != nil {
+		logger.L.Debug().Err(err).Msg("zfs_cron: Failed to sample vdev iostat")
+		return
+	}
+
+	now := time.Now()
+	for _, st := range stats {
+		if err := db.RecordMetrics(s.DB, "vdev", vdevLabel(st), now, map[string]float64{
+			"readOps":        float64(st.ReadOps),
+			"writeOps":       float64(st.WriteOps),
+			"readBytes":      float64(st.ReadBytes),
+			"writeBytes":     float64(st.WriteBytes),
+			"totalReadWait":  float64(st.TotalReadWait),
+			"totalWriteWait": float64(st.TotalWriteWait),
+			"diskReadWait":   float64(st.DiskReadWait),
+			"diskWriteWait":  float64(st.DiskWriteWait),
+		}); err != nil {
+			logger.L.Debug().Err(err).Msg("zfs_cron: Failed to insert vdev iostat data")
+		}
+	}
+}
+
+// removeStaleVdevIOStats drops the iostat history of pools that are gone.
+func (s *Service) removeStaleVdevIOStats(pools []string) error {
+	labels, err := db.MetricLabels(s.DB, "vdev")
+	if err != nil {
+		return err
+	}
+
+	live := make(map[string]bool, len(pools))
+	for _, pool := range pools {
+		live[pool] = true
+	}
+
+	var stale []string
+	for _, label := range labels {
+		if pool, _, _, _, ok := parseVdevLabel(label); !ok || !live[pool] {
+			stale = append(stale, label)
+		}
+	}
+
+	return db.DeleteMetrics(s.DB, "vdev", stale)
+}
+
+// GetVdevIOStats returns the iostat points of every pool, vdev and device in
+// the range, optionally limited to one pool and one vdev or device. Each
+// point carries its pool, name, parent and type.
+func (s *Service) GetVdevIOStats(pool string, name string, r db.MetricRange) ([]db.MetricPoint, error) {
+	labels, err := db.MetricLabels(s.DB, "vdev")
+	if err != nil {
+		return nil, err
+	}
+
+	stats := []db.MetricPoint{}
+	for _, label := range labels {
+		lPool, lType, lParent, lName, ok := parseVdevLabel(label)
+		if !ok || (pool != "" && lPool != pool) || (name != "" && lName != name) {
+			continue
+		}
+
+		points, err := db.QueryMetrics(s.DB, "vdev", label, r)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range points {
+			p.Labels = map[string]any{
+				"pool":   lPool,
+				"name":   lName,
+				"parent": lParent,
+				"type":   lType,
+			}
+			stats = append(stats, p)
+		}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Time.Before(stats[j].Time)
+	})
+
+	return stats, nil
+}
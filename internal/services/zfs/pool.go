@@ -11,25 +11,25 @@ package zfs
 import (
 	"fmt"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/alchemillahq/sylve/internal/db"
-	infoModels "github.com/alchemillahq/sylve/internal/db/models/info"
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
 	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
 	"github.com/alchemillahq/sylve/pkg/disk"
 	"github.com/alchemillahq/sylve/pkg/zfs"
 )
 
-func (s *Service) GetTotalIODelayHisorical() ([]infoModels.IODelay, error) {
-	historicalData, err := db.GetHistorical[infoModels.IODelay](s.DB, 128)
-
-	if err != nil {
-		return nil, err
-	}
+var raidMinDevices = map[string]int{
+	"mirror": 2,
+	"raidz":  3,
+	"raidz2": 4,
+	"raidz3": 5,
+}
 
-	return historicalData, nil
+func (s *Service) GetTotalIODelayHisorical(r db.MetricRange) ([]db.MetricPoint, error) {
+	return db.QueryMetrics(s.DB, "iodelay", "", r)
 }
 
 func (s *Service) CreatePool(pool zfsServiceInterfaces.Zpool) error {
@@ -43,13 +43,7 @@ func (s *Service) CreatePool(pool zfsServiceInterfaces.Zpool) error {
 	}
 
 	if pool.RaidType != "" {
-		validRaidTypes := map[string]int{
-			"mirror": 2,
-			"raidz":  3,
-			"raidz2": 4,
-			"raidz3": 5,
-		}
-		minDevices, ok := validRaidTypes[pool.RaidType]
+		minDevices, ok := raidMinDevices[pool.RaidType]
 		if !ok {
 			return fmt.Errorf("invalid_raidz_type")
 		}
@@ -129,11 +123,12 @@ func (s *Service) DeletePool(guid string) error {
 		return err
 	}
 
-	result := s.DB.Where("json_extract(pools, '$.guid') = ?", guid).
-		Delete(&infoModels.ZPoolHistorical{})
+	if err := db.DeleteMetrics(s.DB, "pool", []string{pool.Name}); err != nil {
+		return fmt.Errorf("failed_to_delete_historical_data: %v", err)
+	}
 
-	if result.Error != nil {
-		return fmt.Errorf("failed_to_delete_historical_data: %v", result.Error)
+	if err := s.DB.Where("pool_guid = ?", guid).Delete(&zfsModels.ScrubSchedule{}).Error; err != nil {
+		return fmt.Errorf("failed_to_delete_scrub_schedule: %v", err)
 	}
 
 	if err := s.Libvirt.DeleteStoragePool(pool.Name); err != nil {
@@ -278,51 +273,71 @@ func (s *Service) SyncToLibvirt() error {
 	return nil
 }
 
-func (s *Service) GetZpoolHistoricalStats(intervalMinutes int, limit int) (map[string][]zfsServiceInterfaces.PoolStatPoint, int, error) {
+// GetZpoolHistoricalStats returns up to limit points per pool in r, one every
+// intervalMinutes. Without a start the range covers limit intervals, and with
+// the auto resolution the coarsest rollup that still has that detail is read.
+// The count is how many minutes of history there are.
+func (s *Service) GetZpoolHistoricalStats(intervalMinutes int, limit int, r db.MetricRange) (map[string][]zfsServiceInterfaces.PoolStatPoint, int, error) {
 	if intervalMinutes <= 0 {
 		return nil, 0, fmt.Errorf("invalid interval: must be > 0")
 	}
 
-	var records []infoModels.ZPoolHistorical
-	if err := s.DB.
-		Order("created_at ASC").
-		Find(&records).Error; err != nil {
+	interval := time.Duration(intervalMinutes) * time.Minute
+
+	if r.Resolution == db.ResolutionAuto {
+		switch {
+		case interval < time.Hour:
+			r.Resolution = db.Resolution1m
+		case interval < 24*time.Hour:
+			r.Resolution = db.Resolution1h
+		default:
+			r.Resolution = db.Resolution1d
+		}
+	}
+
+	if r.From.IsZero() && limit > 0 {
+		r.From = r.To.Add(-interval * time.Duration(limit))
+	}
+
+	oldest, err := db.OldestMetric(s.DB, "pool")
+	if err != nil {
 		return nil, 0, err
 	}
 
-	count := len(records)
-	intervalMs := int64(intervalMinutes) * 60 * 1000
+	count := 0
+	if !oldest.IsZero() {
+		count = int(time.Since(oldest) / time.Minute)
+	}
+
+	labels, err := db.MetricLabels(s.DB, "pool")
+	if err != nil {
+		return nil, 0, err
+	}
 
-	buckets := make(map[string]map[int64]zfsServiceInterfaces.PoolStatPoint)
-	for _, rec := range records {
-		bucketTime := (rec.CreatedAt / intervalMs) * intervalMs
-		name := zfs.Zpool(rec.Pools).Name
+	intervalMs := interval.Milliseconds()
+	result := make(map[string][]zfsServiceInterfaces.PoolStatPoint, len(labels))
 
-		if buckets[name] == nil {
-			buckets[name] = make(map[int64]zfsServiceInterfaces.PoolStatPoint)
+	for _, name := range labels {
+		points, err := db.QueryMetrics(s.DB, "pool", name, r)
+		if err != nil {
+			return nil, 0, err
 		}
 
-		if _, seen := buckets[name][bucketTime]; !seen {
-			p := zfs.Zpool(rec.Pools)
-			buckets[name][bucketTime] = zfsServiceInterfaces.PoolStatPoint{
-				Time:       bucketTime,
-				Allocated:  p.Allocated,
-				Free:       p.Free,
-				Size:       p.Size,
-				DedupRatio: p.DedupRatio,
+		pts := make([]zfsServiceInterfaces.PoolStatPoint, 0, len(points))
+		for _, p := range points {
+			bucketTime := (p.Time.UnixMilli() / intervalMs) * intervalMs
+			if len(pts) > 0 && pts[len(pts)-1].Time == bucketTime {
+				continue
 			}
-		}
-	}
 
-	result := make(map[string][]zfsServiceInterfaces.PoolStatPoint, len(buckets))
-	for name, mp := range buckets {
-		pts := make([]zfsServiceInterfaces.PoolStatPoint, 0, len(mp))
-		for _, pt := range mp {
-			pts = append(pts, pt)
+			pts = append(pts, zfsServiceInterfaces.PoolStatPoint{
+				Time:       bucketTime,
+				Allocated:  uint64(p.Values["allocated"]),
+				Free:       uint64(p.Values["free"]),
+				Size:       uint64(p.Values["size"]),
+				DedupRatio: p.Values["dedupRatio"],
+			})
 		}
-		sort.Slice(pts, func(i, j int) bool {
-			return pts[i].Time < pts[j].Time
-		})
 
 		if limit > 0 && len(pts) > limit {
 			pts = pts[len(pts)-limit:]
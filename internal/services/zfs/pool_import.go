@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alchemillahq/sylve/internal/config"
+	jailModels "github.com/alchemillahq/sylve/internal/db/models/jail"
+	sambaModels "github.com/alchemillahq/sylve/internal/db/models/samba"
+	vmModels "github.com/alchemillahq/sylve/internal/db/models/vm"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+func (s *Service) GetImportablePools() ([]zfs.ImportablePool, error) {
+	pools, err := zfs.ImportablePools()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_list_importable_pools: %w", err)
+	}
+
+	return pools, nil
+}
+
+func (s *Service) ImportPool(req zfsServiceInterfaces.ImportPoolRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	if req.NewName != "" && !zfs.IsValidPoolName(req.NewName) {
+		return fmt.Errorf("invalid_pool_name")
+	}
+
+	if req.AltRoot != "" && !filepath.IsAbs(req.AltRoot) {
+		return fmt.Errorf("altroot_must_be_absolute")
+	}
+
+	importable, err := zfs.ImportablePools()
+	if err != nil {
+		return fmt.Errorf("failed_to_list_importable_pools: %w", err)
+	}
+
+	var match *zfs.ImportablePool
+	for i := range importable {
+		if importable[i].GUID == req.Pool || importable[i].Name == req.Pool {
+			if match != nil {
+				return fmt.Errorf("multiple_pools_match_name_use_guid")
+			}
+			match = &importable[i]
+		}
+	}
+
+	if match == nil {
+		return fmt.Errorf("importable_pool_not_found")
+	}
+
+	name := match.Name
+	if req.NewName != "" {
+		name = req.NewName
+	}
+
+	if _, err := zfs.GetZpool(name); err == nil {
+		return fmt.Errorf("pool_name_taken")
+	}
+
+	if _, err := zfs.ImportZpool(match.GUID, zfs.ImportOptions{
+		NewName:  req.NewName,
+		ReadOnly: req.ReadOnly,
+		AltRoot:  req.AltRoot,
+		Force:    req.Force,
+	}); err != nil {
+		return fmt.Errorf("failed_to_import_pool: %w", err)
+	}
+
+	return s.SyncToLibvirt()
+}
+
+// poolUsers lists everything that still references a dataset of the pool:
+// VM storages, jails, Samba shares and Sylve's own data directory.
+func (s *Service) poolUsers(pool *zfs.Zpool) ([]string, error) {
+	datasets, err := pool.Datasets()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_datasets: %w", err)
+	}
+
+	var users []string
+
+	dataPath, _ := config.GetDataPath()
+	hostsData := false
+
+	guids := make([]string, 0, len(datasets))
+	for _, ds := range datasets {
+		guids = append(guids, ds.GUID)
+
+		if dataPath == "" || !filepath.IsAbs(ds.Mountpoint) || ds.Mountpoint == "/" {
+			continue
+		}
+
+		if dataPath == ds.Mountpoint || strings.HasPrefix(dataPath, ds.Mountpoint+"/") {
+			hostsData = true
+		}
+	}
+
+	if hostsData {
+		users = append(users, "sylve_data")
+	}
+
+	if len(guids) == 0 {
+		return users, nil
+	}
+
+	var vms []vmModels.VM
+	if err := s.DB.Where("id IN (?)",
+		s.DB.Model(&vmModels.Storage{}).Select("vm_id").Where("dataset IN ?", guids),
+	).Find(&vms).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_find_vms_using_pool: %w", err)
+	}
+
+	for _, vm := range vms {
+		users = append(users, fmt.Sprintf("vm_%d", vm.VmID))
+	}
+
+	var jails []jailModels.Jail
+	if err := s.DB.Where("dataset IN ?", guids).Find(&jails).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_find_jails_using_pool: %w", err)
+	}
+
+	for _, jail := range jails {
+		users = append(users, fmt.Sprintf("jail_%d", jail.CTID))
+	}
+
+	var shares []sambaModels.SambaShare
+	if err := s.DB.Where("dataset IN ?", guids).Find(&shares).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_find_shares_using_pool: %w", err)
+	}
+
+	for _, share := range shares {
+		users = append(users, fmt.Sprintf("share_%s", share.Name))
+	}
+
+	return users, nil
+}
+
+func (s *Service) ExportPool(guid string, force bool) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("pool_not_found")
+	}
+
+	users, err := s.poolUsers(pool)
+	if err != nil {
+		return err
+	}
+
+	if len(users) > 0 {
+		return fmt.Errorf("pool_in_use: %s", strings.Join(users, ", "))
+	}
+
+	if err := pool.Export(force); err != nil {
+		return fmt.Errorf("failed_to_export_pool: %w", err)
+	}
+
+	if err := s.Libvirt.DeleteStoragePool(pool.Name); err != nil {
+		if !strings.Contains(err.Error(), "failed to lookup storage pool") &&
+			!strings.Contains(err.Error(), "Storage pool not found") {
+			return err
+		}
+	}
+
+	return s.SyncToLibvirt()
+}
@@ -0,0 +1,326 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"fmt"
+	"path/filepath"
+
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/pkg/disk"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+// vdevRedundancy is the number of device failures a vdev survives.
+func vdevRedundancy(vdevType string, width int) int {
+	switch vdevType {
+	case "mirror":
+		return width - 1
+	case "raidz", "raidz1":
+		return 1
+	case "raidz2":
+		return 2
+	case "raidz3":
+		return 3
+	default:
+		return 0
+	}
+}
+
+func sameDevice(a, b string) bool {
+	return filepath.Base(a) == filepath.Base(b)
+}
+
+func findTopologyVdev(topology *zfs.ZpoolTopology, device string) *zfs.TopologyVdev {
+	for _, class := range [][]zfs.TopologyVdev{topology.Data, topology.Log, topology.Special, topology.Dedup} {
+		for i := range class {
+			for _, d := range class[i].Devices {
+				if sameDevice(d, device) {
+					return &class[i]
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func topologyHasDevice(topology *zfs.ZpoolTopology, device string) bool {
+	if findTopologyVdev(topology, device) != nil {
+		return true
+	}
+
+	for _, d := range append(append([]string{}, topology.Cache...), topology.Spares...) {
+		if sameDevice(d, device) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) GetPoolTopology(guid string) (*zfs.ZpoolTopology, error) {
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return nil, fmt.Errorf("pool_not_found")
+	}
+
+	topology, err := pool.Topology()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_pool_topology: %w", err)
+	}
+
+	return topology, nil
+}
+
+func validateAddVdevs(req zfsServiceInterfaces.AddVdevsRequest, topology *zfs.ZpoolTopology) error {
+	switch req.Class {
+	case "cache":
+		if req.RaidType != "" {
+			return fmt.Errorf("cache_devices_cannot_be_redundant")
+		}
+	case "log":
+		if req.RaidType != "" && req.RaidType != "mirror" {
+			return fmt.Errorf("log_devices_cannot_be_raidz")
+		}
+	}
+
+	minDevices := 1
+	if req.RaidType != "" {
+		minDevices = raidMinDevices[req.RaidType]
+	}
+
+	for _, vdev := range req.Vdevs {
+		if len(vdev.VdevDevices) < minDevices {
+			return fmt.Errorf("vdev %s has insufficient devices (minimum %d)", vdev.Name, minDevices)
+		}
+
+		for _, device := range vdev.VdevDevices {
+			if topologyHasDevice(topology, device) {
+				return fmt.Errorf("device_already_in_pool: %s", device)
+			}
+		}
+	}
+
+	if req.Class == "cache" {
+		return nil
+	}
+
+	var existing []zfs.TopologyVdev
+	switch req.Class {
+	case "data":
+		existing = topology.Data
+	case "log":
+		existing = topology.Log
+	case "special":
+		existing = topology.Special
+	case "dedup":
+		existing = topology.Dedup
+	}
+
+	// Special and dedup vdevs hold pool metadata, so the first one added has
+	// to be as redundant as the data vdevs.
+	if len(existing) == 0 && (req.Class == "special" || req.Class == "dedup") {
+		existing = topology.Data
+	}
+
+	if len(existing) == 0 {
+		return nil
+	}
+
+	want := vdevRedundancy(existing[0].Type, existing[0].Width())
+
+	for _, vdev := range req.Vdevs {
+		width := 1
+		vdevType := "disk"
+		if req.RaidType != "" {
+			width = len(vdev.VdevDevices)
+			vdevType = req.RaidType
+		}
+
+		if vdevRedundancy(vdevType, width) != want {
+			return fmt.Errorf("mismatched_redundancy")
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) AddPoolVdevs(guid string, req zfsServiceInterfaces.AddVdevsRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("pool_not_found")
+	}
+
+	topology, err := pool.Topology()
+	if err != nil {
+		return fmt.Errorf("failed_to_get_pool_topology: %w", err)
+	}
+
+	if err := validateAddVdevs(req, topology); err != nil {
+		return err
+	}
+
+	var args []string
+	if req.Class != "data" {
+		args = append(args, req.Class)
+	}
+
+	for _, vdev := range req.Vdevs {
+		if req.RaidType != "" {
+			args = append(args, req.RaidType)
+		}
+		args = append(args, vdev.VdevDevices...)
+	}
+
+	if err := pool.Add(req.Force, true, args...); err != nil {
+		return fmt.Errorf("dry_run_failed: %w", err)
+	}
+
+	if err := pool.Add(req.Force, false, args...); err != nil {
+		return fmt.Errorf("failed_to_add_vdevs: %w", err)
+	}
+
+	return s.Libvirt.RescanStoragePools()
+}
+
+// AttachPoolDevice mirrors a single disk vdev or widens an existing mirror.
+// zpool attach has no dry-run mode, so the checks it would do are done here
+// before touching the pool.
+func (s *Service) AttachPoolDevice(guid string, req zfsServiceInterfaces.AttachDeviceRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("pool_not_found")
+	}
+
+	topology, err := pool.Topology()
+	if err != nil {
+		return fmt.Errorf("failed_to_get_pool_topology: %w", err)
+	}
+
+	vdev := findTopologyVdev(topology, req.Device)
+	if vdev == nil {
+		return fmt.Errorf("device_not_found_in_pool")
+	}
+
+	if vdev.Type != "disk" && vdev.Type != "mirror" {
+		return fmt.Errorf("can_only_attach_to_disk_or_mirror")
+	}
+
+	if topologyHasDevice(topology, req.NewDevice) {
+		return fmt.Errorf("device_already_in_pool: %s", req.NewDevice)
+	}
+
+	oldSize, err := disk.GetDiskSize(req.Device)
+	if err != nil {
+		return fmt.Errorf("failed_to_get_device_size %s: %v", req.Device, err)
+	}
+
+	newSize, err := disk.GetDiskSize(req.NewDevice)
+	if err != nil {
+		return fmt.Errorf("invalid_device %s: %v", req.NewDevice, err)
+	}
+
+	if newSize == 0 || newSize < oldSize {
+		return fmt.Errorf("device %s is too small, minimum size is %d bytes", req.NewDevice, oldSize)
+	}
+
+	if err := pool.Attach(req.Device, req.NewDevice, req.Force); err != nil {
+		return fmt.Errorf("failed_to_attach_device: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) DetachPoolDevice(guid string, req zfsServiceInterfaces.DetachDeviceRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("pool_not_found")
+	}
+
+	topology, err := pool.Topology()
+	if err != nil {
+		return fmt.Errorf("failed_to_get_pool_topology: %w", err)
+	}
+
+	vdev := findTopologyVdev(topology, req.Device)
+	if vdev == nil {
+		return fmt.Errorf("device_not_found_in_pool")
+	}
+
+	if vdev.Type != "mirror" {
+		return fmt.Errorf("can_only_detach_mirror_members")
+	}
+
+	if err := pool.Detach(req.Device); err != nil {
+		return fmt.Errorf("failed_to_detach_device: %w", err)
+	}
+
+	return nil
+}
+
+// RemovePoolVdev removes a top-level vdev or a log, cache or special device.
+// Data vdevs can only be removed from pools without raidz vdevs, zpool
+// refuses that during the dry run.
+func (s *Service) RemovePoolVdev(guid string, req zfsServiceInterfaces.RemoveVdevRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("pool_not_found")
+	}
+
+	topology, err := pool.Topology()
+	if err != nil {
+		return fmt.Errorf("failed_to_get_pool_topology: %w", err)
+	}
+
+	found := false
+	for _, class := range [][]zfs.TopologyVdev{topology.Data, topology.Log, topology.Special, topology.Dedup} {
+		for _, vdev := range class {
+			if vdev.Name == req.Name || (vdev.Type == "disk" && sameDevice(vdev.Name, req.Name)) {
+				found = true
+			}
+		}
+	}
+
+	for _, d := range topology.Cache {
+		if sameDevice(d, req.Name) {
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("vdev_not_found_in_pool")
+	}
+
+	if len(topology.Data) == 1 && (topology.Data[0].Name == req.Name || sameDevice(topology.Data[0].Name, req.Name)) {
+		return fmt.Errorf("cannot_remove_last_data_vdev")
+	}
+
+	if err := pool.RemoveVdev(req.Name, true); err != nil {
+		return fmt.Errorf("dry_run_failed: %w", err)
+	}
+
+	if err := pool.RemoveVdev(req.Name, false); err != nil {
+		return fmt.Errorf("failed_to_remove_vdev: %w", err)
+	}
+
+	return s.Libvirt.RescanStoragePools()
+}
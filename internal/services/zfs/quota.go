@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alchemillahq/sylve/internal/db/models"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/pkg/system"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+func isNumericID(name string) bool {
+	_, err := strconv.ParseUint(name, 10, 32)
+	return err == nil
+}
+
+func (s *Service) filesystemByGUID(guid string) (*zfs.Dataset, error) {
+	dataset, err := s.GetDatasetByGUID(guid)
+	if err != nil {
+		return nil, fmt.Errorf("dataset_not_found")
+	}
+
+	if dataset.Type != zfs.DatasetFilesystem {
+		return nil, fmt.Errorf("dataset_not_a_filesystem")
+	}
+
+	return dataset, nil
+}
+
+// GetDatasetSpaceUsage lists per-user, per-group and per-project usage and
+// quotas of a filesystem. Users and groups are linked to Sylve's own users
+// and groups by name, and flagged when a Unix account of that name exists.
+func (s *Service) GetDatasetSpaceUsage(guid string) (*zfsServiceInterfaces.DatasetSpaceUsage, error) {
+	dataset, err := s.filesystemByGUID(guid)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &zfsServiceInterfaces.DatasetSpaceUsage{
+		Dataset:  dataset.Name,
+		Users:    []zfsServiceInterfaces.SpaceUsageEntry{},
+		Groups:   []zfsServiceInterfaces.SpaceUsageEntry{},
+		Projects: []zfsServiceInterfaces.SpaceUsageEntry{},
+	}
+
+	var users []models.User
+	if err := s.DB.Select("id", "username").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_users: %w", err)
+	}
+
+	userIDs := make(map[string]uint, len(users))
+	for _, u := range users {
+		userIDs[u.Username] = u.ID
+	}
+
+	var groups []models.Group
+	if err := s.DB.Select("id", "name").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_groups: %w", err)
+	}
+
+	groupIDs := make(map[string]uint, len(groups))
+	for _, g := range groups {
+		groupIDs[g.Name] = g.ID
+	}
+
+	for _, kind := range []string{zfs.QuotaUser, zfs.QuotaGroup, zfs.QuotaProject} {
+		usage, err := dataset.SpaceUsage(kind)
+		if err != nil {
+			return nil, fmt.Errorf("failed_to_get_%s_space: %w", kind, err)
+		}
+
+		for _, u := range usage {
+			entry := zfsServiceInterfaces.SpaceUsageEntry{
+				Type:      u.Type,
+				Name:      u.Name,
+				Used:      u.Used,
+				Quota:     u.Quota,
+				ObjUsed:   u.ObjUsed,
+				ObjQuota:  u.ObjQuota,
+				Available: u.Available(dataset.Avail),
+			}
+
+			switch kind {
+			case zfs.QuotaUser:
+				if id, ok := userIDs[u.Name]; ok {
+					entry.UserID = &id
+				}
+				entry.UnixAccount, _ = system.UnixUserExists(u.Name)
+				result.Users = append(result.Users, entry)
+			case zfs.QuotaGroup:
+				if id, ok := groupIDs[u.Name]; ok {
+					entry.GroupID = &id
+				}
+				entry.UnixAccount = system.UnixGroupExists(u.Name)
+				result.Groups = append(result.Groups, entry)
+			case zfs.QuotaProject:
+				result.Projects = append(result.Projects, entry)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// SetDatasetQuota sets or clears the quota of a user, group or project on a
+// filesystem. Users and groups have to exist as Unix accounts unless they
+// are given as numeric IDs, projects are always numeric.
+func (s *Service) SetDatasetQuota(guid string, req zfsServiceInterfaces.SetQuotaRequest) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	dataset, err := s.filesystemByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	if req.Quota == nil && req.ObjQuota == nil {
+		return fmt.Errorf("no_quota_specified")
+	}
+
+	switch req.Type {
+	case zfs.QuotaUser:
+		if !isNumericID(req.Name) {
+			exists, err := system.UnixUserExists(req.Name)
+			if err != nil {
+				return fmt.Errorf("failed_to_check_user: %w", err)
+			}
+			if !exists {
+				return fmt.Errorf("user_not_found")
+			}
+		}
+	case zfs.QuotaGroup:
+		if !isNumericID(req.Name) && !system.UnixGroupExists(req.Name) {
+			return fmt.Errorf("group_not_found")
+		}
+	case zfs.QuotaProject:
+		if !isNumericID(req.Name) {
+			return fmt.Errorf("invalid_project_id")
+		}
+	default:
+		return fmt.Errorf("invalid_quota_type")
+	}
+
+	if err := dataset.SetSpaceQuota(req.Type, req.Name, req.Quota, req.ObjQuota); err != nil {
+		return fmt.Errorf("failed_to_set_quota: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+
+	"gorm.io/gorm"
+)
+
+const (
+	periodicSnapshotLayout = "2006-01-02-15-04"
+	maxSnapshotPruneRuns   = 100
+)
+
+type policySnapshot struct {
+	dataset *zfs.Dataset
+	taken   time.Time
+}
+
+type retentionPeriod struct {
+	keep   int
+	bucket func(time.Time) string
+}
+
+func validateRetention(r zfsServiceInterfaces.SnapshotRetention) error {
+	if r.KeepHourly < 0 || r.KeepDaily < 0 || r.KeepWeekly < 0 || r.KeepMonthly < 0 || r.KeepYearly < 0 {
+		return fmt.Errorf("invalid_retention")
+	}
+
+	return nil
+}
+
+func retentionPeriods(p zfsModels.PeriodicSnapshot) []retentionPeriod {
+	return []retentionPeriod{
+		{p.KeepHourly, func(t time.Time) string { return t.Format("2006-01-02-15") }},
+		{p.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{p.KeepWeekly, func(t time.Time) string {
+			y, w := t.ISOWeek()
+			return fmt.Sprintf("%d-%02d", y, w)
+		}},
+		{p.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+		{p.KeepYearly, func(t time.Time) string { return t.Format("2006") }},
+	}
+}
+
+// gfsRetain expects snapshots ordered newest first and keeps the newest
+// snapshot of each of the last N hours, days, weeks, months and years.
+func gfsRetain(snapshots []policySnapshot, policy zfsModels.PeriodicSnapshot) []bool {
+	keep := make([]bool, len(snapshots))
+
+	for _, period := range retentionPeriods(policy) {
+		if period.keep <= 0 {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for i, snap := range snapshots {
+			if len(seen) >= period.keep {
+				break
+			}
+
+			key := period.bucket(snap.taken)
+			if !seen[key] {
+				seen[key] = true
+				keep[i] = true
+			}
+		}
+	}
+
+	return keep
+}
+
+// policySnapshots returns the snapshots created by the scheduler for a
+// policy, newest first. Snapshots that merely share the prefix but were not
+// named by the scheduler are left out so they are never pruned.
+func policySnapshots(dataset *zfs.Dataset, prefix string) ([]policySnapshot, error) {
+	snapshots, err := zfs.Snapshots(dataset.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_list_snapshots: %w", err)
+	}
+
+	namePrefix := dataset.Name + "@" + prefix + "-"
+
+	var matched []policySnapshot
+	for _, snap := range snapshots {
+		if !strings.HasPrefix(snap.Name, namePrefix) {
+			continue
+		}
+
+		taken, err := time.ParseInLocation(periodicSnapshotLayout, strings.TrimPrefix(snap.Name, namePrefix), time.Local)
+		if err != nil {
+			continue
+		}
+
+		matched = append(matched, policySnapshot{dataset: snap, taken: taken})
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].taken.After(matched[j].taken)
+	})
+
+	return matched, nil
+}
+
+func isSnapshotHeld(snap *zfs.Dataset) bool {
+	refs, err := snap.GetProperty("userrefs")
+	if err != nil {
+		return true
+	}
+
+	return refs != "0" && refs != "-" && refs != ""
+}
+
+func (s *Service) pruneSnapshots(policy zfsModels.PeriodicSnapshot, dryRun bool) (*zfsModels.SnapshotPruneRun, error) {
+	run := zfsModels.SnapshotPruneRun{
+		PolicyID: policy.ID,
+		DryRun:   dryRun,
+		Kept:     []string{},
+		Deleted:  []string{},
+		Skipped:  []string{},
+	}
+
+	err := s.pruneSnapshotsRun(policy, &run)
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	if dbErr := s.DB.Create(&run).Error; dbErr != nil {
+		logger.L.Debug().Err(dbErr).Msgf("Failed to record prune run for policy %d", policy.ID)
+	}
+
+	if dbErr := s.DB.Where("policy_id = ? AND id NOT IN (?)", policy.ID,
+		s.DB.Model(&zfsModels.SnapshotPruneRun{}).
+			Select("id").
+			Where("policy_id = ?", policy.ID).
+			Order("id DESC").
+			Limit(maxSnapshotPruneRuns),
+	).Delete(&zfsModels.SnapshotPruneRun{}).Error; dbErr != nil {
+		logger.L.Debug().Err(dbErr).Msgf("Failed to trim prune runs for policy %d", policy.ID)
+	}
+
+	return &run, err
+}
+
+func (s *Service) pruneSnapshotsRun(policy zfsModels.PeriodicSnapshot, run *zfsModels.SnapshotPruneRun) error {
+	if !policy.HasRetention() {
+		return fmt.Errorf("retention_not_configured")
+	}
+
+	dataset, err := s.GetDatasetByGUID(policy.GUID)
+	if err != nil {
+		return fmt.Errorf("dataset_not_found")
+	}
+
+	snapshots, err := policySnapshots(dataset, policy.Prefix)
+	if err != nil {
+		return err
+	}
+
+	keep := gfsRetain(snapshots, policy)
+
+	if !run.DryRun {
+		s.syncMutex.Lock()
+		defer s.syncMutex.Unlock()
+	}
+
+	var failed []string
+
+	for i, snap := range snapshots {
+		name := snap.dataset.Name
+
+		if keep[i] {
+			run.Kept = append(run.Kept, name)
+			continue
+		}
+
+		if isSnapshotHeld(snap.dataset) {
+			run.Skipped = append(run.Skipped, name)
+			continue
+		}
+
+		if run.DryRun {
+			run.Deleted = append(run.Deleted, name)
+			continue
+		}
+
+		flags := zfs.DestroyDefault
+		if policy.Recursive {
+			flags = zfs.DestroyRecursive
+		}
+
+		if err := snap.dataset.Destroy(flags); err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to prune snapshot %s", name)
+			run.Skipped = append(run.Skipped, name)
+			failed = append(failed, name)
+			continue
+		}
+
+		run.Deleted = append(run.Deleted, name)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed_to_destroy_snapshots: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func (s *Service) getPeriodicSnapshot(id uint) (*zfsModels.PeriodicSnapshot, error) {
+	var policy zfsModels.PeriodicSnapshot
+	if err := s.DB.First(&policy, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("periodic_snapshot_not_found")
+		}
+		return nil, fmt.Errorf("failed_to_get_periodic_snapshot: %w", err)
+	}
+
+	return &policy, nil
+}
+
+func (s *Service) EditPeriodicSnapshotRetention(id uint, retention zfsServiceInterfaces.SnapshotRetention) error {
+	if err := validateRetention(retention); err != nil {
+		return err
+	}
+
+	policy, err := s.getPeriodicSnapshot(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.DB.Model(policy).Updates(map[string]any{
+		"keep_hourly":  retention.KeepHourly,
+		"keep_daily":   retention.KeepDaily,
+		"keep_weekly":  retention.KeepWeekly,
+		"keep_monthly": retention.KeepMonthly,
+		"keep_yearly":  retention.KeepYearly,
+	}).Error; err != nil {
+		return fmt.Errorf("failed_to_update_retention: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) PrunePeriodicSnapshot(id uint, dryRun bool) (*zfsModels.SnapshotPruneRun, error) {
+	policy, err := s.getPeriodicSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pruneSnapshots(*policy, dryRun)
+}
+
+func (s *Service) GetSnapshotPruneRuns(id uint, limit int) ([]zfsModels.SnapshotPruneRun, error) {
+	var runs []zfsModels.SnapshotPruneRun
+
+	if limit <= 0 || limit > maxSnapshotPruneRuns {
+		limit = maxSnapshotPruneRuns
+	}
+
+	if err := s.DB.Where("policy_id = ?", id).
+		Order("id DESC").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_prune_runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,476 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/internal/logger"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const maxScrubRuns = 100
+
+func validateScrubSchedule(req zfsServiceInterfaces.ScrubScheduleRequest) error {
+	if req.PoolGUID == "" {
+		return fmt.Errorf("invalid_pool_guid")
+	}
+
+	if req.CronExpr != "" {
+		if _, err := cron.ParseStandard(req.CronExpr); err != nil {
+			return fmt.Errorf("invalid_cron_expression")
+		}
+	} else if req.Interval <= 0 {
+		return fmt.Errorf("interval_or_cron_expression_required")
+	}
+
+	return nil
+}
+
+func scrubScheduleDue(schedule zfsModels.ScrubSchedule, now time.Time) bool {
+	if schedule.CronExpr != "" {
+		sched, err := cron.ParseStandard(schedule.CronExpr)
+		if err != nil {
+			logger.L.Debug().Err(err).Msgf("Invalid cron expression for scrub schedule %d", schedule.ID)
+			return false
+		}
+
+		return now.After(sched.Next(schedule.LastRunAt))
+	}
+
+	if schedule.Interval > 0 {
+		return now.Sub(schedule.LastRunAt).Seconds() >= float64(schedule.Interval)
+	}
+
+	return false
+}
+
+func (s *Service) GetScrubSchedules() ([]zfsModels.ScrubSchedule, error) {
+	var schedules []zfsModels.ScrubSchedule
+
+	if err := s.DB.Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_scrub_schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (s *Service) CreateScrubSchedule(req zfsServiceInterfaces.ScrubScheduleRequest) error {
+	if err := validateScrubSchedule(req); err != nil {
+		return err
+	}
+
+	pool, err := zfs.GetZpoolByGUID(req.PoolGUID)
+	if err != nil {
+		return fmt.Errorf("pool_not_found")
+	}
+
+	var count int64
+	if err := s.DB.Model(&zfsModels.ScrubSchedule{}).Where("pool_guid = ?", req.PoolGUID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed_to_check_scrub_schedules: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("scrub_schedule_exists")
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	// The first scrub waits for the schedule instead of starting right away.
+	schedule := zfsModels.ScrubSchedule{
+		PoolGUID:  req.PoolGUID,
+		PoolName:  pool.Name,
+		Interval:  req.Interval,
+		CronExpr:  req.CronExpr,
+		Enabled:   enabled,
+		LastRunAt: time.Now(),
+	}
+
+	if err := s.DB.Create(&schedule).Error; err != nil {
+		return fmt.Errorf("failed_to_create_scrub_schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) getScrubSchedule(id uint) (*zfsModels.ScrubSchedule, error) {
+	var schedule zfsModels.ScrubSchedule
+	if err := s.DB.First(&schedule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("scrub_schedule_not_found")
+		}
+		return nil, fmt.Errorf("failed_to_get_scrub_schedule: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+func (s *Service) EditScrubSchedule(id uint, req zfsServiceInterfaces.ScrubScheduleRequest) error {
+	if err := validateScrubSchedule(req); err != nil {
+		return err
+	}
+
+	schedule, err := s.getScrubSchedule(id)
+	if err != nil {
+		return err
+	}
+
+	if schedule.PoolGUID != req.PoolGUID {
+		return fmt.Errorf("cannot_change_schedule_pool")
+	}
+
+	schedule.Interval = req.Interval
+	schedule.CronExpr = req.CronExpr
+
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+
+	if err := s.DB.Save(schedule).Error; err != nil {
+		return fmt.Errorf("failed_to_update_scrub_schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteScrubSchedule(id uint) error {
+	schedule, err := s.getScrubSchedule(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.DB.Delete(schedule).Error; err != nil {
+		return fmt.Errorf("failed_to_delete_scrub_schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetScrubStatus(guid string) (*zfs.ScanStatus, error) {
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return nil, fmt.Errorf("pool_not_found")
+	}
+
+	status, err := pool.ScanStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_get_scan_status: %w", err)
+	}
+
+	return &status, nil
+}
+
+func (s *Service) StartScrub(guid string, trigger string) error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return fmt.Errorf("pool_not_found")
+	}
+
+	status, err := pool.ScanStatus()
+	if err != nil {
+		return fmt.Errorf("failed_to_get_scan_status: %w", err)
+	}
+
+	switch status.State {
+	case zfs.ScanStateScanning:
+		return fmt.Errorf("%s_in_progress", status.Function)
+	case zfs.ScanStatePaused:
+		return fmt.Errorf("scrub_paused")
+	}
+
+	if err := pool.Scrub(); err != nil {
+		return fmt.Errorf("failed_to_start_scrub: %w", err)
+	}
+
+	run := zfsModels.ScrubRun{
+		PoolGUID:  guid,
+		PoolName:  pool.Name,
+		Trigger:   trigger,
+		Status:    "running",
+		StartedAt: time.Now(),
+	}
+
+	if err := s.DB.Create(&run).Error; err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to record scrub of pool %s", pool.Name)
+	}
+
+	s.trimScrubRuns(guid)
+
+	return nil
+}
+
+func (s *Service) activeScrub(guid string) (*zfs.Zpool, *zfs.ScanStatus, error) {
+	pool, err := zfs.GetZpoolByGUID(guid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("pool_not_found")
+	}
+
+	status, err := pool.ScanStatus()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed_to_get_scan_status: %w", err)
+	}
+
+	if status.Function != "scrub" || (status.State != zfs.ScanStateScanning && status.State != zfs.ScanStatePaused) {
+		return nil, nil, fmt.Errorf("no_scrub_in_progress")
+	}
+
+	return pool, &status, nil
+}
+
+func (s *Service) PauseScrub(guid string) error {
+	pool, status, err := s.activeScrub(guid)
+	if err != nil {
+		return err
+	}
+
+	if status.State == zfs.ScanStatePaused {
+		return fmt.Errorf("scrub_already_paused")
+	}
+
+	if err := pool.PauseScrub(); err != nil {
+		return fmt.Errorf("failed_to_pause_scrub: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) ResumeScrub(guid string) error {
+	pool, status, err := s.activeScrub(guid)
+	if err != nil {
+		return err
+	}
+
+	if status.State != zfs.ScanStatePaused {
+		return fmt.Errorf("scrub_not_paused")
+	}
+
+	if err := pool.Scrub(); err != nil {
+		return fmt.Errorf("failed_to_resume_scrub: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) CancelScrub(guid string) error {
+	pool, _, err := s.activeScrub(guid)
+	if err != nil {
+		return err
+	}
+
+	if err := pool.CancelScrub(); err != nil {
+		return fmt.Errorf("failed_to_cancel_scrub: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetScrubRuns(guid string, limit int) ([]zfsModels.ScrubRun, error) {
+	var runs []zfsModels.ScrubRun
+
+	if limit <= 0 || limit > maxScrubRuns {
+		limit = maxScrubRuns
+	}
+
+	if err := s.DB.Where("pool_guid = ?", guid).
+		Order("id DESC").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed_to_get_scrub_runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (s *Service) trimScrubRuns(guid string) {
+	if err := s.DB.Where("pool_guid = ? AND id NOT IN (?)", guid,
+		s.DB.Model(&zfsModels.ScrubRun{}).
+			Select("id").
+			Where("pool_guid = ?", guid).
+			Order("id DESC").
+			Limit(maxScrubRuns),
+	).Delete(&zfsModels.ScrubRun{}).Error; err != nil {
+		logger.L.Debug().Err(err).Msgf("Failed to trim scrub runs for pool %s", guid)
+	}
+}
+
+// recordScrubRuns completes running scrub records once zpool reports the
+// outcome, and records scrubs that were started outside of Sylve so the
+// history covers every scrub of a pool.
+func (s *Service) recordScrubRuns() {
+	var running []zfsModels.ScrubRun
+	if err := s.DB.Where("status = ?", "running").Find(&running).Error; err != nil {
+		logger.L.Debug().Err(err).Msg("Failed to load running scrubs")
+		return
+	}
+
+	runningByPool := make(map[string]*zfsModels.ScrubRun, len(running))
+	for i := range running {
+		runningByPool[running[i].PoolGUID] = &running[i]
+	}
+
+	pools, err := zfs.ListZpools()
+	if err != nil {
+		logger.L.Debug().Err(err).Msg("Failed to list pools for scrub history")
+		return
+	}
+
+	for _, pool := range pools {
+		run := runningByPool[pool.GUID]
+		delete(runningByPool, pool.GUID)
+
+		status, err := pool.ScanStatus()
+		if err != nil || status.Function != "scrub" || status.EndedAt == nil {
+			continue
+		}
+
+		if status.State != zfs.ScanStateFinished && status.State != zfs.ScanStateCanceled {
+			continue
+		}
+
+		if run != nil {
+			if status.EndedAt.Before(run.StartedAt.Truncate(time.Second)) {
+				continue
+			}
+
+			run.Status = status.State
+			run.Repaired = status.Repaired
+			run.Errors = status.Errors
+			run.Duration = status.Duration
+			run.FinishedAt = status.EndedAt
+
+			if err := s.DB.Save(run).Error; err != nil {
+				logger.L.Debug().Err(err).Msgf("Failed to update scrub run %d", run.ID)
+			}
+			continue
+		}
+
+		if status.State != zfs.ScanStateFinished {
+			continue
+		}
+
+		var last zfsModels.ScrubRun
+		err = s.DB.Where("pool_guid = ? AND finished_at IS NOT NULL", pool.GUID).Order("id DESC").First(&last).Error
+		if err == nil && last.FinishedAt.Equal(*status.EndedAt) {
+			continue
+		}
+
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+
+		startedAt := *status.EndedAt
+		if d, err := parseScrubDuration(status.Duration); err == nil {
+			startedAt = startedAt.Add(-d)
+		}
+
+		external := zfsModels.ScrubRun{
+			PoolGUID:   pool.GUID,
+			PoolName:   pool.Name,
+			Trigger:    "external",
+			Status:     status.State,
+			Repaired:   status.Repaired,
+			Errors:     status.Errors,
+			Duration:   status.Duration,
+			StartedAt:  startedAt,
+			FinishedAt: status.EndedAt,
+		}
+
+		if err := s.DB.Create(&external).Error; err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to record scrub of pool %s", pool.Name)
+			continue
+		}
+
+		s.trimScrubRuns(pool.GUID)
+	}
+
+	// Whatever is left belongs to pools that are gone.
+	now := time.Now()
+	for _, run := range runningByPool {
+		run.Status = "failed"
+		run.Error = "pool_not_found"
+		run.FinishedAt = &now
+
+		if err := s.DB.Save(run).Error; err != nil {
+			logger.L.Debug().Err(err).Msgf("Failed to update scrub run %d", run.ID)
+		}
+	}
+}
+
+// parseScrubDuration parses durations as printed by zpool, either
+// "HH:MM:SS" or "N days HH:MM:SS".
+func parseScrubDuration(value string) (time.Duration, error) {
+	var days, h, m, sec int
+
+	if _, err := fmt.Sscanf(value, "%d days %d:%d:%d", &days, &h, &m, &sec); err != nil {
+		days = 0
+		if _, err := fmt.Sscanf(value, "%d:%d:%d", &h, &m, &sec); err != nil {
+			return 0, err
+		}
+	}
+
+	return time.Duration(days)*24*time.Hour +
+		time.Duration(h)*time.Hour +
+		time.Duration(m)*time.Minute +
+		time.Duration(sec)*time.Second, nil
+}
+
+func (s *Service) StartScrubScheduler(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.recordScrubRuns()
+
+				var schedules []zfsModels.ScrubSchedule
+				if err := s.DB.Where("enabled = ?", true).Find(&schedules).Error; err != nil {
+					logger.L.Debug().Err(err).Msg("Failed to load scrub schedules")
+					continue
+				}
+
+				now := time.Now()
+
+				for _, schedule := range schedules {
+					if !scrubScheduleDue(schedule, now) {
+						continue
+					}
+
+					if err := s.DB.Model(&schedule).Update("LastRunAt", now).Error; err != nil {
+						logger.L.Debug().Err(err).Msgf("Failed to update LastRunAt for scrub schedule %d", schedule.ID)
+					}
+
+					if err := s.StartScrub(schedule.PoolGUID, "scheduled"); err != nil {
+						logger.L.Warn().Err(err).Msgf("Scheduled scrub of pool %s did not start", schedule.PoolName)
+						continue
+					}
+
+					logger.L.Info().Msgf("Started scheduled scrub of pool %s", schedule.PoolName)
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
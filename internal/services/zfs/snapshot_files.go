@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package zfs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	systemServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/system"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
+	"github.com/alchemillahq/sylve/pkg/zfs"
+)
+
+func (s *Service) getSnapshotByGUID(guid string) (*zfs.Dataset, error) {
+	snapshot, err := s.GetDatasetByGUID(guid)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot_not_found")
+	}
+
+	if snapshot.Type != zfs.DatasetSnapshot {
+		return nil, fmt.Errorf("dataset_is_not_a_snapshot")
+	}
+
+	return snapshot, nil
+}
+
+// snapshotRoots returns the mountpoint of the filesystem a snapshot belongs
+// to and the directory the snapshot is exposed at under .zfs/snapshot.
+func snapshotRoots(snapshot *zfs.Dataset) (string, string, error) {
+	fsName, short, ok := strings.Cut(snapshot.Name, "@")
+	if !ok {
+		return "", "", fmt.Errorf("invalid_snapshot_name")
+	}
+
+	parent, err := zfs.GetDataset(fsName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed_to_get_parent_dataset: %w", err)
+	}
+
+	if parent.Type != zfs.DatasetFilesystem {
+		return "", "", fmt.Errorf("snapshot_is_not_of_a_filesystem")
+	}
+
+	if parent.Mounted != "yes" || !filepath.IsAbs(parent.Mountpoint) {
+		return "", "", fmt.Errorf("filesystem_not_mounted")
+	}
+
+	return parent.Mountpoint, filepath.Join(parent.Mountpoint, ".zfs", "snapshot", short), nil
+}
+
+// snapshotPath joins a path relative to the snapshot root, never escaping it.
+func snapshotPath(root, rel string) string {
+	return filepath.Join(root, filepath.Clean("/"+rel))
+}
+
+func (s *Service) ListSnapshotFiles(guid string, path string) ([]systemServiceInterfaces.FileNode, error) {
+	snapshot, err := s.getSnapshotByGUID(guid)
+	if err != nil {
+		return nil, err
+	}
+
+	_, root, err := snapshotRoots(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.System.Traverse(snapshotPath(root, strings.TrimPrefix(path, root)))
+}
+
+func (s *Service) DiffSnapshot(guid string, compareGUID string) ([]zfsServiceInterfaces.SnapshotDiffEntry, error) {
+	snapshot, err := s.getSnapshotByGUID(guid)
+	if err != nil {
+		return nil, err
+	}
+
+	fsName, _, _ := strings.Cut(snapshot.Name, "@")
+
+	var target *zfs.Dataset
+
+	if compareGUID == "" {
+		target, err = zfs.GetDataset(fsName)
+		if err != nil {
+			return nil, fmt.Errorf("failed_to_get_parent_dataset: %w", err)
+		}
+	} else {
+		target, err = s.getSnapshotByGUID(compareGUID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.HasPrefix(target.Name, fsName+"@") {
+			return nil, fmt.Errorf("snapshots_of_different_datasets")
+		}
+	}
+
+	changes, err := target.Diff(snapshot.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_diff_snapshot: %w", err)
+	}
+
+	entries := make([]zfsServiceInterfaces.SnapshotDiffEntry, 0, len(changes))
+	for _, change := range changes {
+		entries = append(entries, zfsServiceInterfaces.SnapshotDiffEntry{
+			Change:               change.Change.String(),
+			Type:                 change.Type.String(),
+			Path:                 change.Path,
+			NewPath:              change.NewPath,
+			ReferenceCountChange: change.ReferenceCountChange,
+		})
+	}
+
+	return entries, nil
+}
+
+func (s *Service) RestoreSnapshotFiles(guid string, req zfsServiceInterfaces.RestoreSnapshotFilesRequest) error {
+	if len(req.Paths) == 0 {
+		return fmt.Errorf("no_paths_provided")
+	}
+
+	if req.Destination != "" && !filepath.IsAbs(req.Destination) {
+		return fmt.Errorf("destination_must_be_absolute")
+	}
+
+	snapshot, err := s.getSnapshotByGUID(guid)
+	if err != nil {
+		return err
+	}
+
+	mountpoint, root, err := snapshotRoots(snapshot)
+	if err != nil {
+		return err
+	}
+
+	base := mountpoint
+	if req.Destination != "" {
+		base = filepath.Clean(req.Destination)
+	}
+
+	if strings.HasPrefix(base+"/", filepath.Join(mountpoint, ".zfs")+"/") {
+		return fmt.Errorf("invalid_destination")
+	}
+
+	pairs := make([][2]string, 0, len(req.Paths))
+
+	for _, p := range req.Paths {
+		p = strings.TrimPrefix(p, root)
+
+		rel := filepath.Clean("/" + p)
+		if rel == "/" {
+			return fmt.Errorf("invalid_path: %s", p)
+		}
+
+		source := filepath.Join(root, rel)
+		if _, err := os.Lstat(source); err != nil {
+			return fmt.Errorf("path_not_in_snapshot: %s", rel)
+		}
+
+		parent := filepath.Join(base, filepath.Dir(rel))
+		if err := os.MkdirAll(parent, 0755); err != nil {
+			return fmt.Errorf("failed_to_create_destination: %w", err)
+		}
+
+		pairs = append(pairs, [2]string{source, parent})
+	}
+
+	if err := s.System.CopyOrMoveFilesOrFolders(pairs, false); err != nil {
+		return fmt.Errorf("failed_to_restore_files: %w", err)
+	}
+
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
 	"github.com/alchemillahq/sylve/internal/logger"
 	"github.com/alchemillahq/sylve/pkg/zfs"
 
@@ -38,6 +39,10 @@ func (s *Service) DeleteSnapshot(guid string, recursive bool) error {
 
 		for _, v := range properties {
 			if v == guid {
+				if err := ensureNotHeld(dataset, recursive); err != nil {
+					return err
+				}
+
 				var err error
 
 				if recursive {
@@ -106,7 +111,11 @@ func (s *Service) GetPeriodicSnapshots() ([]zfsModels.PeriodicSnapshot, error) {
 	return snapshots, nil
 }
 
-func (s *Service) AddPeriodicSnapshot(guid string, prefix string, recursive bool, interval int, cronExpr string) error {
+func (s *Service) AddPeriodicSnapshot(guid string, prefix string, recursive bool, interval int, cronExpr string, retention zfsServiceInterfaces.SnapshotRetention) error {
+	if err := validateRetention(retention); err != nil {
+		return err
+	}
+
 	dataset, err := s.GetDatasetByGUID(guid)
 	if err != nil {
 		return err
@@ -125,6 +134,12 @@ func (s *Service) AddPeriodicSnapshot(guid string, prefix string, recursive bool
 				Recursive: recursive,
 				Interval:  interval,
 				CronExpr:  cronExpr,
+
+				KeepHourly:  retention.KeepHourly,
+				KeepDaily:   retention.KeepDaily,
+				KeepWeekly:  retention.KeepWeekly,
+				KeepMonthly: retention.KeepMonthly,
+				KeepYearly:  retention.KeepYearly,
 			}
 
 			if err := s.DB.Create(&snapshot).Error; err != nil {
@@ -145,6 +160,10 @@ func (s *Service) DeletePeriodicSnapshot(guid string) error {
 		return err
 	}
 
+	if err := s.DB.Where("policy_id = ?", snapshot.ID).Delete(&zfsModels.SnapshotPruneRun{}).Error; err != nil {
+		return err
+	}
+
 	if err := s.DB.Delete(&snapshot).Error; err != nil {
 		return err
 	}
@@ -200,7 +219,7 @@ func (s *Service) StartSnapshotScheduler(ctx context.Context) {
 						continue
 					}
 
-					name := job.Prefix + "-" + now.Format("2006-01-02-15-04")
+					name := job.Prefix + "-" + now.Format(periodicSnapshotLayout)
 					dataset, err := s.GetDatasetByGUID(job.GUID)
 					if err != nil {
 						logger.L.Debug().Err(err).Msgf("Failed to get dataset for %s", job.GUID)
@@ -235,6 +254,12 @@ func (s *Service) StartSnapshotScheduler(ctx context.Context) {
 					}
 
 					logger.L.Debug().Msgf("Snapshot %s created successfully", name)
+
+					if job.HasRetention() {
+						if _, err := s.pruneSnapshots(job, false); err != nil {
+							logger.L.Debug().Err(err).Msgf("Failed to prune snapshots for policy %d", job.ID)
+						}
+					}
 				}
 			case <-ctx.Done():
 				ticker.Stop()
@@ -11,7 +11,10 @@ package zfs
 import (
 	"sync"
 
+	zfsModels "github.com/alchemillahq/sylve/internal/db/models/zfs"
+	serviceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services"
 	libvirtServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/libvirt"
+	systemServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/system"
 	zfsServiceInterfaces "github.com/alchemillahq/sylve/internal/interfaces/services/zfs"
 	"github.com/alchemillahq/sylve/internal/logger"
 	"github.com/alchemillahq/sylve/pkg/zfs"
@@ -24,14 +27,36 @@ var _ zfsServiceInterfaces.ZfsServiceInterface = (*Service)(nil)
 type Service struct {
 	DB        *gorm.DB
 	Libvirt   libvirtServiceInterfaces.LibvirtServiceInterface
+	Auth      serviceInterfaces.AuthServiceInterface
+	System    systemServiceInterfaces.SystemServiceInterface
 	syncMutex *sync.Mutex
+
+	backupMutex    sync.Mutex
+	runningBackups map[uint]bool
+
+	alertMutex          sync.Mutex
+	alertSubscribers    map[int]chan zfsModels.PoolAlert
+	nextAlertSubscriber int
+
+	flashMutex sync.Mutex
+	flashes    map[string]*zfsServiceInterfaces.FlashProgress
+
+	restoreMutex sync.Mutex
+	restores     map[string]*zfsServiceInterfaces.S3RestoreProgress
 }
 
-func NewZfsService(db *gorm.DB, libvirt libvirtServiceInterfaces.LibvirtServiceInterface) zfsServiceInterfaces.ZfsServiceInterface {
+func NewZfsService(db *gorm.DB, libvirt libvirtServiceInterfaces.LibvirtServiceInterface, auth serviceInterfaces.AuthServiceInterface, system systemServiceInterfaces.SystemServiceInterface) zfsServiceInterfaces.ZfsServiceInterface {
 	return &Service{
-		DB:        db,
-		Libvirt:   libvirt,
-		syncMutex: &sync.Mutex{},
+		DB:             db,
+		Libvirt:        libvirt,
+		Auth:           auth,
+		System:         system,
+		syncMutex:      &sync.Mutex{},
+		runningBackups: make(map[uint]bool),
+
+		alertSubscribers: make(map[int]chan zfsModels.PoolAlert),
+		flashes:          make(map[string]*zfsServiceInterfaces.FlashProgress),
+		restores:         make(map[string]*zfsServiceInterfaces.S3RestoreProgress),
 	}
 }
 
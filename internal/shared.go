@@ -22,6 +22,26 @@ type Raft struct {
 	Reset bool `json:"reset"`
 }
 
+// MetricsConfig sets how long raw samples and each rollup resolution are
+// kept, zero values fall back to the defaults.
+type MetricsConfig struct {
+	RawRetentionHours   int `json:"rawRetentionHours"`
+	MinuteRetentionDays int `json:"minuteRetentionDays"`
+	HourRetentionDays   int `json:"hourRetentionDays"`
+	DayRetentionDays    int `json:"dayRetentionDays"`
+}
+
+// ShutdownConfig controls how guests are stopped when Sylve exits or the
+// host is rebooted, zero values fall back to the defaults. TimeoutSeconds
+// bounds stopping all guests, VMs still running by then are powered off.
+// rc(8) kills Sylve once rcshutdown_timeout (90 seconds by default) passes
+// on host shutdown, so keep it below that or raise rcshutdown_timeout.
+type ShutdownConfig struct {
+	TimeoutSeconds   int `json:"timeoutSeconds"`
+	VMTimeoutSeconds int `json:"vmTimeoutSeconds"`
+	Parallelism      int `json:"parallelism"`
+}
+
 type SylveConfig struct {
 	Environment   string          `json:"environment"`
 	ProxyToVite   bool            `json:"proxyToVite"`
@@ -33,6 +53,8 @@ type SylveConfig struct {
 	DataPath      string          `json:"dataPath"`
 	TLS           TLSConfig       `json:"tlsConfig"`
 	Raft          Raft            `json:"raft"`
+	Metrics       MetricsConfig   `json:"metrics"`
+	Shutdown      ShutdownConfig  `json:"shutdown"`
 }
 
 type APIResponse[T any] struct {
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package crypto_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alchemillahq/sylve/pkg/crypto"
+)
+
+func TestEncryptSecretRoundTrip(t *testing.T) {
+	secretKey := []byte("supersecretkey")
+	plain := []byte("correct horse battery staple")
+
+	encoded, err := crypto.EncryptSecret(plain, secretKey)
+	if err != nil {
+		t.Fatalf("EncryptSecret failed: %v", err)
+	}
+
+	decoded, err := crypto.DecryptSecret(encoded, secretKey)
+	if err != nil {
+		t.Fatalf("DecryptSecret failed: %v", err)
+	}
+
+	if !bytes.Equal(plain, decoded) {
+		t.Errorf("Expected %q, got %q", plain, decoded)
+	}
+}
+
+func TestDecryptSecretWrongKey(t *testing.T) {
+	encoded, err := crypto.EncryptSecret([]byte("freebsd"), []byte("key1"))
+	if err != nil {
+		t.Fatalf("EncryptSecret failed: %v", err)
+	}
+
+	if _, err := crypto.DecryptSecret(encoded, []byte("key2")); err == nil {
+		t.Errorf("Expected an error when decrypting with the wrong key")
+	}
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package diskimage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	CompressionGzip  = "gzip"
+	CompressionBzip2 = "bzip2"
+	CompressionXz    = "xz"
+)
+
+func detectCompression(magic []byte) string {
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return CompressionGzip
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return CompressionBzip2
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return CompressionXz
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return "zstd"
+	default:
+		return ""
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// decompressor streams the decompressed contents of a file. xz has no
+// decoder in the standard library, so it goes through xz(1) from base.
+type decompressor struct {
+	f     *os.File
+	in    *countingReader
+	total int64
+	cmd   *exec.Cmd
+	r     io.Reader
+}
+
+func newDecompressor(path, compression string) (*decompressor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	d := &decompressor{f: f, in: &countingReader{r: f}, total: info.Size()}
+
+	switch compression {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(d.in)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("invalid_gzip_stream: %w", err)
+		}
+		d.r = zr
+	case CompressionBzip2:
+		d.r = bzip2.NewReader(d.in)
+	case CompressionXz:
+		d.cmd = exec.Command("xz", "-dc")
+		d.cmd.Stdin = d.in
+		out, err := d.cmd.StdoutPipe()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := d.cmd.Start(); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed_to_start_xz: %w", err)
+		}
+		d.r = out
+	default:
+		f.Close()
+		return nil, fmt.Errorf("%w: %s compression", ErrUnsupported, compression)
+	}
+
+	return d, nil
+}
+
+func (d *decompressor) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err == io.EOF && d.cmd != nil {
+		if werr := d.cmd.Wait(); werr != nil {
+			return n, fmt.Errorf("xz_failed: %w", werr)
+		}
+		d.cmd = nil
+	}
+	return n, err
+}
+
+func (d *decompressor) progress() float64 {
+	if d.total == 0 {
+		return 0
+	}
+	return float64(d.in.n.Load()) / float64(d.total) * 100
+}
+
+func (d *decompressor) Close() error {
+	if d.cmd != nil && d.cmd.Process != nil {
+		d.cmd.Process.Kill()
+		d.cmd.Wait()
+	}
+	return d.f.Close()
+}
+
+// xzUncompressedSize asks xz for the size recorded in the stream index.
+func xzUncompressedSize(path string) int64 {
+	out, err := exec.Command("xz", "--robot", "--list", path).Output()
+	if err != nil {
+		return -1
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) > 4 && fields[0] == "totals" {
+			if size, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+				return size
+			}
+		}
+	}
+
+	return -1
+}
+
+// compressedRawImage is a raw disk read straight out of a compressed file,
+// its size is only known upfront for xz.
+type compressedRawImage struct {
+	d           *decompressor
+	r           *bufio.Reader
+	compression string
+	size        int64
+}
+
+func (c *compressedRawImage) Format() string         { return FormatRaw }
+func (c *compressedRawImage) Compression() string    { return c.compression }
+func (c *compressedRawImage) VirtualSize() int64     { return c.size }
+func (c *compressedRawImage) Reader() io.Reader      { return c.r }
+func (c *compressedRawImage) Close() error           { return c.d.Close() }
+func (c *compressedRawImage) inputProgress() float64 { return c.d.progress() }
+
+// decompressedImage is a container format that came compressed and was
+// unpacked next to the original, since it needs random access.
+type decompressedImage struct {
+	Image
+	compression string
+	path        string
+}
+
+func (d *decompressedImage) Compression() string { return d.compression }
+
+func (d *decompressedImage) Close() error {
+	err := d.Image.Close()
+	os.Remove(d.path)
+	return err
+}
+
+func openCompressed(path, compression string) (Image, error) {
+	d, err := newDecompressor(path, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReaderSize(d, 1<<20)
+	magic, err := r.Peek(512)
+	if err != nil && err != io.EOF {
+		d.Close()
+		return nil, fmt.Errorf("failed_to_read_compressed_image: %w", err)
+	}
+
+	if detectFormat(magic) == FormatRaw {
+		size := int64(-1)
+		if compression == CompressionXz {
+			size = xzUncompressedSize(path)
+		}
+		return &compressedRawImage{d: d, r: r, compression: compression, size: size}, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed_to_create_temp_file: %w", err)
+	}
+
+	_, err = io.Copy(tmp, r)
+	d.Close()
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed_to_decompress_image: %w", err)
+	}
+
+	header := make([]byte, 512)
+	n, _ := tmp.ReadAt(header, 0)
+
+	img, err := openFile(tmp, header[:n])
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return &decompressedImage{Image: img, compression: compression, path: tmp.Name()}, nil
+}
@@ -0,0 +1,259 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package diskimage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	FormatRaw   = "raw"
+	FormatQcow2 = "qcow2"
+	FormatVMDK  = "vmdk"
+	FormatVHDX  = "vhdx"
+)
+
+var (
+	ErrUnsupported   = errors.New("unsupported_image")
+	ErrImageTooLarge = errors.New("image_size_exceeds_target_size")
+)
+
+// Image is a disk image decoded into the raw disk it describes.
+type Image interface {
+	io.Closer
+
+	// Format is the container format, compression aside.
+	Format() string
+
+	// Compression is the compression the file was wrapped in, if any.
+	Compression() string
+
+	// VirtualSize is the size of the raw disk in bytes, -1 when it is only
+	// known once a compressed raw stream has been read to the end.
+	VirtualSize() int64
+
+	// Reader returns the raw disk from the start.
+	Reader() io.Reader
+}
+
+// sparseImage is implemented by images that can tell unallocated ranges
+// apart, so copies can skip them instead of writing zeroes.
+type sparseImage interface {
+	io.ReaderAt
+	allocated(off, length int64) bool
+}
+
+// streamImage is implemented by images read from a compressed stream whose
+// size is not known upfront, progress is then taken from the input.
+type streamImage interface {
+	inputProgress() float64
+}
+
+// Open detects the format of a disk image, looking through gzip, bzip2 and
+// xz compression, and returns it ready to be read as a raw disk.
+func Open(path string) (Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	magic := make([]byte, 512)
+	n, err := f.ReadAt(magic, 0)
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, err
+	}
+	magic = magic[:n]
+
+	if compression := detectCompression(magic); compression != "" {
+		f.Close()
+		return openCompressed(path, compression)
+	}
+
+	img, err := openFile(f, magic)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return img, nil
+}
+
+func openFile(f *os.File, magic []byte) (Image, error) {
+	switch detectFormat(magic) {
+	case FormatQcow2:
+		return openQcow2(f)
+	case FormatVMDK:
+		return openVMDK(f)
+	case FormatVHDX:
+		return openVHDX(f)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rawImage{f: f, size: info.Size()}, nil
+}
+
+func detectFormat(magic []byte) string {
+	switch {
+	case bytes.HasPrefix(magic, []byte(qcow2Magic)):
+		return FormatQcow2
+	case bytes.HasPrefix(magic, []byte(vmdkSparseMagic)),
+		bytes.HasPrefix(magic, []byte(vmdkDescriptorMagic)):
+		return FormatVMDK
+	case bytes.HasPrefix(magic, []byte(vhdxMagic)):
+		return FormatVHDX
+	default:
+		return FormatRaw
+	}
+}
+
+type rawImage struct {
+	f    *os.File
+	size int64
+}
+
+func (r *rawImage) Format() string      { return FormatRaw }
+func (r *rawImage) Compression() string { return "" }
+func (r *rawImage) VirtualSize() int64  { return r.size }
+func (r *rawImage) Close() error        { return r.f.Close() }
+
+func (r *rawImage) Reader() io.Reader {
+	return io.NewSectionReader(r.f, 0, r.size)
+}
+
+func (r *rawImage) ReadAt(p []byte, off int64) (int, error) {
+	return r.f.ReadAt(p, off)
+}
+
+func (r *rawImage) allocated(off, length int64) bool {
+	return true
+}
+
+// Progress is reported while an image is being written out.
+type Progress struct {
+	Written int64   `json:"written"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// WriteOptions controls how an image is written to its target.
+type WriteOptions struct {
+	// Limit is the size of the target, writing past it fails with
+	// ErrImageTooLarge. Zero means no limit.
+	Limit int64
+
+	// SkipZeroes leaves unallocated and all-zero blocks unwritten. Only
+	// safe when the target reads back as zeroes, like a fresh file.
+	SkipZeroes bool
+
+	// OnProgress is called after every block with the progress so far.
+	OnProgress func(Progress)
+}
+
+const copyBlockSize = 1 << 20
+
+// WriteTo writes the raw disk of img to dst.
+func WriteTo(img Image, dst io.WriterAt, opts WriteOptions) (int64, error) {
+	size := img.VirtualSize()
+	if opts.Limit > 0 && size > opts.Limit {
+		return 0, ErrImageTooLarge
+	}
+
+	sparse, _ := img.(sparseImage)
+	stream, _ := img.(streamImage)
+
+	buf := make([]byte, copyBlockSize)
+	var off int64
+
+	report := func() {
+		if opts.OnProgress == nil {
+			return
+		}
+
+		p := Progress{Written: off, Total: size}
+		switch {
+		case size > 0:
+			p.Percent = float64(off) / float64(size) * 100
+		case stream != nil:
+			p.Percent = stream.inputProgress()
+		}
+
+		opts.OnProgress(p)
+	}
+
+	write := func(b []byte) error {
+		if opts.SkipZeroes && isZero(b) {
+			return nil
+		}
+		if _, err := dst.WriteAt(b, off); err != nil {
+			return fmt.Errorf("write_failed: %w", err)
+		}
+		return nil
+	}
+
+	if sparse != nil && size >= 0 {
+		for off < size {
+			b := buf[:min(int64(len(buf)), size-off)]
+
+			if !opts.SkipZeroes || sparse.allocated(off, int64(len(b))) {
+				if _, err := sparse.ReadAt(b, off); err != nil && err != io.EOF {
+					return off, err
+				}
+				if err := write(b); err != nil {
+					return off, err
+				}
+			}
+
+			off += int64(len(b))
+			report()
+		}
+
+		return off, nil
+	}
+
+	r := img.Reader()
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if opts.Limit > 0 && off+int64(n) > opts.Limit {
+				return off, ErrImageTooLarge
+			}
+			if werr := write(buf[:n]); werr != nil {
+				return off, werr
+			}
+
+			off += int64(n)
+			report()
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return off, nil
+		}
+		if err != nil {
+			return off, err
+		}
+	}
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
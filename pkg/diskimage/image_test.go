@@ -0,0 +1,331 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package diskimage
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type memWriter struct {
+	buf []byte
+}
+
+func (m *memWriter) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.buf) {
+		m.buf = append(m.buf, make([]byte, end-len(m.buf))...)
+	}
+	copy(m.buf[off:], p)
+	return len(p), nil
+}
+
+func pattern(size int, seed byte) []byte {
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = seed + byte(i%251)
+	}
+	return b
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func decode(t *testing.T, path string, opts WriteOptions) (Image, []byte) {
+	t.Helper()
+	img, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { img.Close() })
+
+	out := &memWriter{}
+	n, err := WriteTo(img, out, opts)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	if img.VirtualSize() >= 0 && n != img.VirtualSize() {
+		t.Fatalf("wrote %d bytes, want %d", n, img.VirtualSize())
+	}
+
+	if int64(len(out.buf)) < n {
+		out.buf = append(out.buf, make([]byte, n-int64(len(out.buf)))...)
+	}
+
+	return img, out.buf
+}
+
+// qcow2 with 64 KiB clusters: cluster 0 plain, cluster 1 compressed,
+// cluster 2 unallocated, cluster 3 flagged as zero.
+func buildQcow2(plain, compressed []byte) []byte {
+	const clusterBits = 16
+	const cluster = 1 << clusterBits
+
+	img := make([]byte, 5*cluster)
+	be := binary.BigEndian
+
+	copy(img, qcow2Magic)
+	be.PutUint32(img[4:], 3)
+	be.PutUint32(img[20:], clusterBits)
+	be.PutUint64(img[24:], 4*cluster)
+	be.PutUint32(img[36:], 1)
+	be.PutUint64(img[40:], cluster)
+	be.PutUint32(img[100:], 104)
+
+	be.PutUint64(img[cluster:], 2*cluster|1<<63)
+
+	copy(img[3*cluster:], plain)
+	be.PutUint64(img[2*cluster:], 3*cluster|1<<63)
+
+	var z bytes.Buffer
+	zw, _ := flate.NewWriter(&z, flate.BestCompression)
+	zw.Write(compressed)
+	zw.Close()
+
+	offset := uint64(4 * cluster)
+	copy(img[offset:], z.Bytes())
+	sectors := uint64((z.Len()+511)/512 - 1)
+	shift := 62 - (clusterBits - 8)
+	be.PutUint64(img[2*cluster+8:], qcow2CompressedFlag|sectors<<shift|offset)
+
+	be.PutUint64(img[2*cluster+24:], qcow2ZeroFlag)
+
+	return img
+}
+
+func TestQcow2(t *testing.T) {
+	const cluster = 1 << 16
+	plain, compressed := pattern(cluster, 1), bytes.Repeat([]byte("sylve"), cluster/5+1)[:cluster]
+
+	path := writeFile(t, "disk.qcow2", buildQcow2(plain, compressed))
+	img, raw := decode(t, path, WriteOptions{})
+
+	if img.Format() != FormatQcow2 || img.VirtualSize() != 4*cluster {
+		t.Fatalf("got %s of %d bytes", img.Format(), img.VirtualSize())
+	}
+
+	want := append(append(append([]byte{}, plain...), compressed...), make([]byte, 2*cluster)...)
+	if !bytes.Equal(raw, want) {
+		t.Fatal("decoded qcow2 does not match")
+	}
+
+	if _, err := WriteTo(img, &memWriter{}, WriteOptions{Limit: cluster}); err != ErrImageTooLarge {
+		t.Fatalf("expected ErrImageTooLarge, got %v", err)
+	}
+}
+
+func buildVMDK(grains [][]byte, compressed bool) []byte {
+	const grainSectors = 8
+
+	le := binary.LittleEndian
+	header := make([]byte, vmdkSector)
+	copy(header, vmdkSparseMagic)
+	le.PutUint32(header[4:], 1)
+	if compressed {
+		le.PutUint32(header[4:], 3)
+		le.PutUint32(header[8:], vmdkFlagCompressed)
+		le.PutUint16(header[77:], 1)
+	}
+	le.PutUint64(header[12:], uint64(len(grains)*grainSectors))
+	le.PutUint64(header[20:], grainSectors)
+	le.PutUint32(header[44:], 512)
+	le.PutUint64(header[56:], 1)
+
+	// sector 1 grain directory, sector 2-5 grain table, data from sector 8
+	img := make([]byte, 8*vmdkSector)
+	copy(img, header)
+	le.PutUint32(img[vmdkSector:], 2)
+
+	for i, g := range grains {
+		if g == nil {
+			continue
+		}
+
+		sector := uint32(len(img) / vmdkSector)
+		le.PutUint32(img[2*vmdkSector+4*i:], sector)
+
+		if !compressed {
+			img = append(img, g...)
+			continue
+		}
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(g)
+		zw.Close()
+
+		marker := make([]byte, 12)
+		le.PutUint64(marker, uint64(i*grainSectors))
+		le.PutUint32(marker[8:], uint32(z.Len()))
+		data := append(marker, z.Bytes()...)
+		data = append(data, make([]byte, (vmdkSector-len(data)%vmdkSector)%vmdkSector)...)
+		img = append(img, data...)
+	}
+
+	return img
+}
+
+func TestVMDKSparse(t *testing.T) {
+	const grain = 8 * vmdkSector
+	grains := [][]byte{pattern(grain, 3), nil, pattern(grain, 7)}
+	want := append(append(append([]byte{}, grains[0]...), make([]byte, grain)...), grains[2]...)
+
+	for _, compressed := range []bool{false, true} {
+		path := writeFile(t, "disk.vmdk", buildVMDK(grains, compressed))
+		img, raw := decode(t, path, WriteOptions{SkipZeroes: true})
+
+		if img.Format() != FormatVMDK {
+			t.Fatalf("got format %s", img.Format())
+		}
+		if !bytes.Equal(raw, want) {
+			t.Fatalf("decoded vmdk (compressed=%v) does not match", compressed)
+		}
+	}
+}
+
+func TestVMDKDescriptor(t *testing.T) {
+	dir := t.TempDir()
+	flat := pattern(4*vmdkSector, 9)
+	os.WriteFile(filepath.Join(dir, "disk-flat.vmdk"), flat, 0644)
+
+	descriptor := "# Disk DescriptorFile\nversion=1\ncreateType=\"monolithicFlat\"\n\n" +
+		"# Extent description\nRW 4 FLAT \"disk-flat.vmdk\" 0\nRW 2 ZERO\n"
+	path := filepath.Join(dir, "disk.vmdk")
+	os.WriteFile(path, []byte(descriptor), 0644)
+
+	img, raw := decode(t, path, WriteOptions{})
+	if img.VirtualSize() != 6*vmdkSector {
+		t.Fatalf("got size %d", img.VirtualSize())
+	}
+	if !bytes.Equal(raw, append(append([]byte{}, flat...), make([]byte, 2*vmdkSector)...)) {
+		t.Fatal("decoded flat vmdk does not match")
+	}
+}
+
+func vhdxSign(buf []byte) {
+	binary.LittleEndian.PutUint32(buf[4:], 0)
+	binary.LittleEndian.PutUint32(buf[4:], crc32.Checksum(buf, castagnoli))
+}
+
+// Dynamic VHDX with 1 MiB blocks, block 0 present and block 1 not.
+func buildVHDX(block []byte) []byte {
+	const mb = 1 << 20
+	le := binary.LittleEndian
+
+	img := make([]byte, 4*mb)
+	copy(img, vhdxMagic)
+
+	header := img[vhdxHeader1Offset : vhdxHeader1Offset+vhdxHeaderSize]
+	copy(header, "head")
+	le.PutUint64(header[8:], 1)
+	le.PutUint16(header[66:], 1)
+	vhdxSign(header)
+
+	region := img[vhdxRegion1Offset : vhdxRegion1Offset+vhdxRegionSize]
+	copy(region, "regi")
+	le.PutUint32(region[8:], 2)
+	bat, meta := vhdxBATRegion, vhdxMetadataRegion
+	copy(region[16:], bat[:])
+	le.PutUint64(region[32:], mb)
+	le.PutUint32(region[40:], mb)
+	copy(region[48:], meta[:])
+	le.PutUint64(region[64:], 2*mb)
+	le.PutUint32(region[72:], mb)
+	vhdxSign(region)
+
+	le.PutUint64(img[mb:], uint64(3)<<20|vhdxBlockFullyPresent)
+
+	table := img[2*mb:]
+	copy(table, "metadata")
+	le.PutUint16(table[10:], 3)
+	items := []struct {
+		id   [16]byte
+		data []byte
+	}{
+		{vhdxFileParameters, le.AppendUint32(le.AppendUint32(nil, mb), 0)},
+		{vhdxVirtualDiskSize, le.AppendUint64(nil, 2*mb)},
+		{vhdxLogicalSectorSize, le.AppendUint32(nil, 512)},
+	}
+	for i, item := range items {
+		entry := table[32+32*i:]
+		offset := 64<<10 + 8*i
+		copy(entry, item.id[:])
+		le.PutUint32(entry[16:], uint32(offset))
+		le.PutUint32(entry[20:], uint32(len(item.data)))
+		copy(table[offset:], item.data)
+	}
+
+	copy(img[3*mb:], block)
+	return img
+}
+
+func TestVHDX(t *testing.T) {
+	block := pattern(1<<20, 5)
+	path := writeFile(t, "disk.vhdx", buildVHDX(block))
+	img, raw := decode(t, path, WriteOptions{})
+
+	if img.Format() != FormatVHDX || img.VirtualSize() != 2<<20 {
+		t.Fatalf("got %s of %d bytes", img.Format(), img.VirtualSize())
+	}
+	if !bytes.Equal(raw, append(append([]byte{}, block...), make([]byte, 1<<20)...)) {
+		t.Fatal("decoded vhdx does not match")
+	}
+}
+
+func TestCompressed(t *testing.T) {
+	data := pattern(3<<20+17, 11)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(data)
+	zw.Close()
+
+	var last Progress
+	img, raw := decode(t, writeFile(t, "disk.img.gz", gz.Bytes()), WriteOptions{
+		OnProgress: func(p Progress) { last = p },
+	})
+
+	if img.Format() != FormatRaw || img.Compression() != CompressionGzip || img.VirtualSize() != -1 {
+		t.Fatalf("got %s/%s of %d bytes", img.Format(), img.Compression(), img.VirtualSize())
+	}
+	if !bytes.Equal(raw, data) {
+		t.Fatal("decoded gzip image does not match")
+	}
+	if last.Written != int64(len(data)) || last.Percent != 100 {
+		t.Fatalf("unexpected final progress %+v", last)
+	}
+
+	cluster := 1 << 16
+	qcow := buildQcow2(pattern(cluster, 1), pattern(cluster, 2))
+	gz.Reset()
+	zw = gzip.NewWriter(&gz)
+	zw.Write(qcow)
+	zw.Close()
+
+	img, raw = decode(t, writeFile(t, "disk.qcow2.gz", gz.Bytes()), WriteOptions{})
+	if img.Format() != FormatQcow2 || img.Compression() != CompressionGzip {
+		t.Fatalf("got %s/%s", img.Format(), img.Compression())
+	}
+	if !bytes.Equal(raw[:2*cluster], append(pattern(cluster, 1), pattern(cluster, 2)...)) {
+		t.Fatal("decoded compressed qcow2 does not match")
+	}
+}
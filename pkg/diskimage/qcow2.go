@@ -0,0 +1,262 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package diskimage
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const qcow2Magic = "QFI\xfb"
+
+const (
+	qcow2OffsetMask     = 0x00fffffffffffe00
+	qcow2CompressedFlag = 1 << 62
+	qcow2ZeroFlag       = 1
+
+	qcow2IncompatDirty       = 1 << 0
+	qcow2IncompatCorrupt     = 1 << 1
+	qcow2IncompatDataFile    = 1 << 2
+	qcow2IncompatCompression = 1 << 3
+	qcow2IncompatExtendedL2  = 1 << 4
+)
+
+type qcow2Header struct {
+	Magic                 [4]byte
+	Version               uint32
+	BackingFileOffset     uint64
+	BackingFileSize       uint32
+	ClusterBits           uint32
+	Size                  uint64
+	CryptMethod           uint32
+	L1Size                uint32
+	L1TableOffset         uint64
+	RefcountTableOffset   uint64
+	RefcountTableClusters uint32
+	NbSnapshots           uint32
+	SnapshotsOffset       uint64
+}
+
+// qcow2Image reads qcow2 version 2 and 3 images without a backing file.
+// Clusters are mapped through the two level L1/L2 tables, compressed
+// clusters are raw deflate streams.
+type qcow2Image struct {
+	f           *os.File
+	size        int64
+	clusterBits uint32
+	clusterSize int64
+	l1          []uint64
+
+	mu       sync.Mutex
+	l2Offset uint64
+	l2       []uint64
+	cBuf     []byte
+	cOffset  uint64
+}
+
+func openQcow2(f *os.File) (*qcow2Image, error) {
+	var h qcow2Header
+	if err := binary.Read(io.NewSectionReader(f, 0, 72), binary.BigEndian, &h); err != nil {
+		return nil, fmt.Errorf("invalid_qcow2_header: %w", err)
+	}
+
+	if h.Version != 2 && h.Version != 3 {
+		return nil, fmt.Errorf("%w: qcow2 version %d", ErrUnsupported, h.Version)
+	}
+
+	if h.BackingFileOffset != 0 {
+		return nil, fmt.Errorf("%w: qcow2 image has a backing file", ErrUnsupported)
+	}
+
+	if h.CryptMethod != 0 {
+		return nil, fmt.Errorf("%w: qcow2 image is encrypted", ErrUnsupported)
+	}
+
+	if h.ClusterBits < 9 || h.ClusterBits > 21 {
+		return nil, fmt.Errorf("invalid_qcow2_cluster_bits: %d", h.ClusterBits)
+	}
+
+	if h.Version == 3 {
+		var incompat uint64
+		if err := binary.Read(io.NewSectionReader(f, 72, 8), binary.BigEndian, &incompat); err != nil {
+			return nil, fmt.Errorf("invalid_qcow2_header: %w", err)
+		}
+
+		switch {
+		case incompat&qcow2IncompatCorrupt != 0:
+			return nil, fmt.Errorf("qcow2_image_is_corrupt")
+		case incompat&qcow2IncompatDataFile != 0:
+			return nil, fmt.Errorf("%w: qcow2 image uses an external data file", ErrUnsupported)
+		case incompat&qcow2IncompatCompression != 0:
+			return nil, fmt.Errorf("%w: qcow2 image is not deflate compressed", ErrUnsupported)
+		case incompat&qcow2IncompatExtendedL2 != 0:
+			return nil, fmt.Errorf("%w: qcow2 image uses extended L2 entries", ErrUnsupported)
+		case incompat&^uint64(qcow2IncompatDirty) != 0:
+			return nil, fmt.Errorf("%w: qcow2 incompatible features %#x", ErrUnsupported, incompat)
+		}
+	}
+
+	img := &qcow2Image{
+		f:           f,
+		size:        int64(h.Size),
+		clusterBits: h.ClusterBits,
+		clusterSize: 1 << h.ClusterBits,
+		l1:          make([]uint64, h.L1Size),
+	}
+
+	l2Entries := img.clusterSize / 8
+	if need := (img.size + img.clusterSize*l2Entries - 1) / (img.clusterSize * l2Entries); int64(h.L1Size) < need {
+		return nil, fmt.Errorf("invalid_qcow2_l1_size: %d", h.L1Size)
+	}
+
+	if err := binary.Read(io.NewSectionReader(f, int64(h.L1TableOffset), int64(h.L1Size)*8), binary.BigEndian, img.l1); err != nil {
+		return nil, fmt.Errorf("failed_to_read_qcow2_l1_table: %w", err)
+	}
+
+	return img, nil
+}
+
+func (q *qcow2Image) Format() string      { return FormatQcow2 }
+func (q *qcow2Image) Compression() string { return "" }
+func (q *qcow2Image) VirtualSize() int64  { return q.size }
+func (q *qcow2Image) Close() error        { return q.f.Close() }
+
+func (q *qcow2Image) Reader() io.Reader {
+	return io.NewSectionReader(q, 0, q.size)
+}
+
+// l2Entry returns the L2 entry of the cluster at the guest offset, 0 when
+// the cluster is not allocated.
+func (q *qcow2Image) l2Entry(off int64) (uint64, error) {
+	l2Entries := q.clusterSize / 8
+	cluster := off >> q.clusterBits
+	l1Index := cluster / l2Entries
+
+	if l1Index >= int64(len(q.l1)) {
+		return 0, nil
+	}
+
+	l2Offset := q.l1[l1Index] & qcow2OffsetMask
+	if l2Offset == 0 {
+		return 0, nil
+	}
+
+	if q.l2 == nil || q.l2Offset != l2Offset {
+		table := make([]uint64, l2Entries)
+		if err := binary.Read(io.NewSectionReader(q.f, int64(l2Offset), q.clusterSize), binary.BigEndian, table); err != nil {
+			return 0, fmt.Errorf("failed_to_read_qcow2_l2_table: %w", err)
+		}
+		q.l2, q.l2Offset = table, l2Offset
+	}
+
+	return q.l2[cluster%l2Entries], nil
+}
+
+// compressedCluster inflates the compressed cluster an L2 entry points to.
+func (q *qcow2Image) compressedCluster(entry uint64) ([]byte, error) {
+	shift := 62 - (q.clusterBits - 8)
+	offset := entry & (1<<shift - 1)
+	sectors := (entry >> shift) & (1<<(q.clusterBits-8) - 1)
+
+	if q.cBuf != nil && q.cOffset == offset {
+		return q.cBuf, nil
+	}
+
+	length := int64(sectors+1)*512 - int64(offset&511)
+	compressed := make([]byte, length)
+	n, err := q.f.ReadAt(compressed, int64(offset))
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed_to_read_qcow2_compressed_cluster: %w", err)
+	}
+
+	cluster := make([]byte, q.clusterSize)
+	zr := flate.NewReader(bytes.NewReader(compressed[:n]))
+	defer zr.Close()
+
+	if _, err := io.ReadFull(zr, cluster); err != nil {
+		return nil, fmt.Errorf("failed_to_inflate_qcow2_cluster: %w", err)
+	}
+
+	q.cBuf, q.cOffset = cluster, offset
+	return cluster, nil
+}
+
+func (q *qcow2Image) ReadAt(p []byte, off int64) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if off >= q.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(p) && off < q.size {
+		inCluster := off & (q.clusterSize - 1)
+		chunk := min(int64(len(p)-n), q.clusterSize-inCluster, q.size-off)
+		dst := p[n : n+int(chunk)]
+
+		entry, err := q.l2Entry(off)
+		if err != nil {
+			return n, err
+		}
+
+		switch {
+		case entry&qcow2CompressedFlag != 0:
+			cluster, err := q.compressedCluster(entry)
+			if err != nil {
+				return n, err
+			}
+			copy(dst, cluster[inCluster:])
+
+		case entry&qcow2ZeroFlag != 0 || entry&qcow2OffsetMask == 0:
+			clear(dst)
+
+		default:
+			if _, err := q.f.ReadAt(dst, int64(entry&qcow2OffsetMask)+inCluster); err != nil {
+				return n, fmt.Errorf("failed_to_read_qcow2_cluster: %w", err)
+			}
+		}
+
+		n += int(chunk)
+		off += chunk
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (q *qcow2Image) allocated(off, length int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for end := off + length; off < end; off = (off | (q.clusterSize - 1)) + 1 {
+		entry, err := q.l2Entry(off)
+		if err != nil {
+			return true
+		}
+
+		if entry&qcow2CompressedFlag != 0 {
+			return true
+		}
+
+		if entry&qcow2ZeroFlag == 0 && entry&qcow2OffsetMask != 0 {
+			return true
+		}
+	}
+
+	return false
+}
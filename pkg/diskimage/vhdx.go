@@ -0,0 +1,330 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package diskimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	vhdxMagic = "vhdxfile"
+
+	vhdxHeader1Offset = 64 << 10
+	vhdxHeader2Offset = 128 << 10
+	vhdxRegion1Offset = 192 << 10
+	vhdxRegion2Offset = 256 << 10
+	vhdxHeaderSize    = 4 << 10
+	vhdxRegionSize    = 64 << 10
+
+	vhdxBlockNotPresent       = 0
+	vhdxBlockUndefined        = 1
+	vhdxBlockZero             = 2
+	vhdxBlockUnmapped         = 3
+	vhdxBlockFullyPresent     = 6
+	vhdxBlockPartiallyPresent = 7
+
+	vhdxHasParent = 1 << 1
+)
+
+var (
+	vhdxBATRegion      = vhdxGUID("2DC27766-F623-4200-9D64-115E9BFD4A08")
+	vhdxMetadataRegion = vhdxGUID("8B7CA206-4790-4B9A-B8FE-575F050F886E")
+
+	vhdxFileParameters    = vhdxGUID("CAA16737-FA36-4D43-B3B6-33F0AA44E76B")
+	vhdxVirtualDiskSize   = vhdxGUID("2FA54224-CD1B-4876-B211-5DBED83BF4B8")
+	vhdxLogicalSectorSize = vhdxGUID("8141BF1D-A96F-4709-BA47-F233A8FAAB5F")
+
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// vhdxGUID converts a GUID string into its on-disk form, where the first
+// three groups are little endian.
+func vhdxGUID(s string) [16]byte {
+	raw, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(raw) != 16 {
+		panic("invalid guid " + s)
+	}
+
+	var g [16]byte
+	g[0], g[1], g[2], g[3] = raw[3], raw[2], raw[1], raw[0]
+	g[4], g[5] = raw[5], raw[4]
+	g[6], g[7] = raw[7], raw[6]
+	copy(g[8:], raw[8:])
+	return g
+}
+
+type vhdxHeader struct {
+	Signature      [4]byte
+	Checksum       uint32
+	SequenceNumber uint64
+	FileWriteGUID  [16]byte
+	DataWriteGUID  [16]byte
+	LogGUID        [16]byte
+	LogVersion     uint16
+	Version        uint16
+	LogLength      uint32
+	LogOffset      uint64
+}
+
+type vhdxRegionEntry struct {
+	GUID       [16]byte
+	FileOffset uint64
+	Length     uint32
+	Required   uint32
+}
+
+type vhdxMetadataEntry struct {
+	ItemID   [16]byte
+	Offset   uint32
+	Length   uint32
+	Flags    uint32
+	Reserved uint32
+}
+
+// vhdxImage reads fixed and dynamic VHDX images. Differencing images and
+// images with a log that still has to be replayed are refused.
+type vhdxImage struct {
+	f          *os.File
+	size       int64
+	blockSize  int64
+	chunkRatio int64
+	bat        []uint64
+}
+
+// vhdxChecksumValid checks the CRC-32C of a header or region table, which
+// is computed with its own checksum field zeroed.
+func vhdxChecksumValid(buf []byte) bool {
+	want := binary.LittleEndian.Uint32(buf[4:8])
+	tmp := append([]byte(nil), buf...)
+	clear(tmp[4:8])
+	return crc32.Checksum(tmp, castagnoli) == want
+}
+
+func readVHDXHeader(f *os.File) (*vhdxHeader, error) {
+	var current *vhdxHeader
+
+	for _, offset := range []int64{vhdxHeader1Offset, vhdxHeader2Offset} {
+		buf := make([]byte, vhdxHeaderSize)
+		if _, err := f.ReadAt(buf, offset); err != nil {
+			continue
+		}
+
+		if string(buf[:4]) != "head" || !vhdxChecksumValid(buf) {
+			continue
+		}
+
+		var h vhdxHeader
+		if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &h); err != nil {
+			continue
+		}
+
+		if current == nil || h.SequenceNumber > current.SequenceNumber {
+			current = &h
+		}
+	}
+
+	if current == nil {
+		return nil, fmt.Errorf("invalid_vhdx_header")
+	}
+
+	return current, nil
+}
+
+func readVHDXRegions(f *os.File) (map[[16]byte]vhdxRegionEntry, error) {
+	for _, offset := range []int64{vhdxRegion1Offset, vhdxRegion2Offset} {
+		buf := make([]byte, vhdxRegionSize)
+		if _, err := f.ReadAt(buf, offset); err != nil {
+			continue
+		}
+
+		if string(buf[:4]) != "regi" || !vhdxChecksumValid(buf) {
+			continue
+		}
+
+		count := binary.LittleEndian.Uint32(buf[8:12])
+		if count > 2047 {
+			continue
+		}
+
+		entries := make([]vhdxRegionEntry, count)
+		if err := binary.Read(bytes.NewReader(buf[16:]), binary.LittleEndian, entries); err != nil {
+			continue
+		}
+
+		regions := make(map[[16]byte]vhdxRegionEntry, count)
+		for _, e := range entries {
+			regions[e.GUID] = e
+		}
+		return regions, nil
+	}
+
+	return nil, fmt.Errorf("invalid_vhdx_region_table")
+}
+
+func readVHDXMetadata(f *os.File, region vhdxRegionEntry) (map[[16]byte][]byte, error) {
+	buf := make([]byte, region.Length)
+	if _, err := f.ReadAt(buf, int64(region.FileOffset)); err != nil {
+		return nil, fmt.Errorf("failed_to_read_vhdx_metadata: %w", err)
+	}
+
+	if string(buf[:8]) != "metadata" {
+		return nil, fmt.Errorf("invalid_vhdx_metadata_table")
+	}
+
+	count := binary.LittleEndian.Uint16(buf[10:12])
+	entries := make([]vhdxMetadataEntry, count)
+	if err := binary.Read(bytes.NewReader(buf[32:]), binary.LittleEndian, entries); err != nil {
+		return nil, fmt.Errorf("invalid_vhdx_metadata_table: %w", err)
+	}
+
+	items := make(map[[16]byte][]byte, count)
+	for _, e := range entries {
+		end := int64(e.Offset) + int64(e.Length)
+		if end > int64(len(buf)) {
+			return nil, fmt.Errorf("invalid_vhdx_metadata_item")
+		}
+		items[e.ItemID] = buf[e.Offset:end]
+	}
+
+	return items, nil
+}
+
+func openVHDX(f *os.File) (*vhdxImage, error) {
+	header, err := readVHDXHeader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.LogGUID != [16]byte{} {
+		return nil, fmt.Errorf("%w: vhdx log has to be replayed first", ErrUnsupported)
+	}
+
+	regions, err := readVHDXRegions(f)
+	if err != nil {
+		return nil, err
+	}
+
+	batRegion, ok := regions[vhdxBATRegion]
+	if !ok {
+		return nil, fmt.Errorf("vhdx_bat_region_not_found")
+	}
+
+	metaRegion, ok := regions[vhdxMetadataRegion]
+	if !ok {
+		return nil, fmt.Errorf("vhdx_metadata_region_not_found")
+	}
+
+	meta, err := readVHDXMetadata(f, metaRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	params, size, sector := meta[vhdxFileParameters], meta[vhdxVirtualDiskSize], meta[vhdxLogicalSectorSize]
+	if len(params) < 8 || len(size) < 8 || len(sector) < 4 {
+		return nil, fmt.Errorf("vhdx_metadata_incomplete")
+	}
+
+	if binary.LittleEndian.Uint32(params[4:8])&vhdxHasParent != 0 {
+		return nil, fmt.Errorf("%w: vhdx differencing images", ErrUnsupported)
+	}
+
+	img := &vhdxImage{
+		f:         f,
+		size:      int64(binary.LittleEndian.Uint64(size)),
+		blockSize: int64(binary.LittleEndian.Uint32(params[0:4])),
+	}
+
+	sectorSize := int64(binary.LittleEndian.Uint32(sector))
+	if img.blockSize == 0 || (sectorSize != 512 && sectorSize != 4096) {
+		return nil, fmt.Errorf("invalid_vhdx_metadata")
+	}
+
+	img.chunkRatio = (1 << 23) * sectorSize / img.blockSize
+
+	img.bat = make([]uint64, batRegion.Length/8)
+	if err := binary.Read(io.NewSectionReader(f, int64(batRegion.FileOffset), int64(batRegion.Length)), binary.LittleEndian, img.bat); err != nil {
+		return nil, fmt.Errorf("failed_to_read_vhdx_bat: %w", err)
+	}
+
+	return img, nil
+}
+
+func (v *vhdxImage) Format() string      { return FormatVHDX }
+func (v *vhdxImage) Compression() string { return "" }
+func (v *vhdxImage) VirtualSize() int64  { return v.size }
+func (v *vhdxImage) Close() error        { return v.f.Close() }
+
+func (v *vhdxImage) Reader() io.Reader {
+	return io.NewSectionReader(v, 0, v.size)
+}
+
+// batEntry returns the BAT entry of the payload block at off. The BAT has
+// a sector bitmap entry after every chunkRatio payload entries.
+func (v *vhdxImage) batEntry(off int64) uint64 {
+	block := off / v.blockSize
+	index := block + block/v.chunkRatio
+
+	if index >= int64(len(v.bat)) {
+		return vhdxBlockNotPresent
+	}
+
+	return v.bat[index]
+}
+
+func (v *vhdxImage) ReadAt(p []byte, off int64) (int, error) {
+	if off >= v.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(p) && off < v.size {
+		inBlock := off % v.blockSize
+		chunk := min(int64(len(p)-n), v.blockSize-inBlock, v.size-off)
+		dst := p[n : n+int(chunk)]
+
+		entry := v.batEntry(off)
+		switch entry & 7 {
+		case vhdxBlockFullyPresent:
+			fileOffset := int64(entry>>20) << 20
+			if _, err := v.f.ReadAt(dst, fileOffset+inBlock); err != nil {
+				return n, fmt.Errorf("failed_to_read_vhdx_block: %w", err)
+			}
+		case vhdxBlockPartiallyPresent:
+			return n, fmt.Errorf("%w: vhdx partially present block", ErrUnsupported)
+		default:
+			clear(dst)
+		}
+
+		n += int(chunk)
+		off += chunk
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (v *vhdxImage) allocated(off, length int64) bool {
+	for end := off + length; off < end; off = (off/v.blockSize + 1) * v.blockSize {
+		if v.batEntry(off)&7 == vhdxBlockFullyPresent {
+			return true
+		}
+	}
+
+	return false
+}
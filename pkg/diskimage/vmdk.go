@@ -0,0 +1,471 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package diskimage
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	vmdkSparseMagic     = "KDMV"
+	vmdkDescriptorMagic = "# Disk DescriptorFile"
+
+	vmdkSector = 512
+
+	vmdkFlagZeroGrainGTE = 1 << 2
+	vmdkFlagCompressed   = 1 << 16
+
+	vmdkGDAtEnd = 0xffffffffffffffff
+)
+
+type vmdkSparseHeader struct {
+	Magic              [4]byte
+	Version            uint32
+	Flags              uint32
+	Capacity           uint64
+	GrainSize          uint64
+	DescriptorOffset   uint64
+	DescriptorSize     uint64
+	NumGTEsPerGT       uint32
+	RGDOffset          uint64
+	GDOffset           uint64
+	OverHead           uint64
+	UncleanShutdown    uint8
+	SingleEndLineChar  byte
+	NonEndLineChar     byte
+	DoubleEndLineChar1 byte
+	DoubleEndLineChar2 byte
+	CompressAlgorithm  uint16
+}
+
+// vmdkExtent is one extent of a VMDK, in the order the descriptor lists
+// them. Sparse extents have their own grain directory, flat extents map
+// directly onto a file and zero extents have no backing at all.
+type vmdkExtent struct {
+	start  int64
+	size   int64
+	flat   *os.File
+	offset int64
+	sparse *vmdkSparseExtent
+}
+
+type vmdkSparseExtent struct {
+	f          *os.File
+	grainSize  int64
+	gtEntries  int64
+	gd         []uint32
+	size       int64
+	compressed bool
+	zeroGrain  bool
+
+	mu      sync.Mutex
+	gtIndex int64
+	gt      []uint32
+	cGrain  uint32
+	cBuf    []byte
+}
+
+// vmdkImage reads monolithic and split sparse, stream optimized and flat
+// VMDKs. Extents are opened relative to the descriptor.
+type vmdkImage struct {
+	files   []*os.File
+	extents []vmdkExtent
+	size    int64
+}
+
+func openVMDK(f *os.File) (*vmdkImage, error) {
+	img := &vmdkImage{files: []*os.File{f}}
+
+	magic := make([]byte, 4)
+	if _, err := f.ReadAt(magic, 0); err != nil {
+		return nil, fmt.Errorf("invalid_vmdk_header: %w", err)
+	}
+
+	if string(magic) == vmdkSparseMagic {
+		extent, err := openVMDKSparse(f)
+		if err != nil {
+			return nil, err
+		}
+
+		img.extents = []vmdkExtent{{size: extent.capacity(), sparse: extent}}
+		img.size = extent.capacity()
+		return img, nil
+	}
+
+	descriptor, err := io.ReadAll(io.LimitReader(f, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_read_vmdk_descriptor: %w", err)
+	}
+
+	if err := img.openExtents(filepath.Dir(f.Name()), string(descriptor)); err != nil {
+		img.Close()
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// parseVMDKExtents parses the extent lines of a descriptor, e.g.
+// `RW 4192256 SPARSE "disk-s001.vmdk"` or `RW 2048 FLAT "disk-flat.vmdk" 0`.
+func parseVMDKExtents(descriptor string) ([][]string, error) {
+	var extents [][]string
+
+	sc := bufio.NewScanner(strings.NewReader(descriptor))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !strings.HasPrefix(line, "RW ") && !strings.HasPrefix(line, "RDONLY ") && !strings.HasPrefix(line, "NOACCESS ") {
+			continue
+		}
+
+		before, rest, quoted := strings.Cut(line, `"`)
+		fields := strings.Fields(before)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid_vmdk_extent: %s", line)
+		}
+
+		extent := fields[1:3]
+		if quoted {
+			name, tail, ok := strings.Cut(rest, `"`)
+			if !ok {
+				return nil, fmt.Errorf("invalid_vmdk_extent: %s", line)
+			}
+			extent = append(extent, name)
+			extent = append(extent, strings.Fields(tail)...)
+		}
+
+		extents = append(extents, extent)
+	}
+
+	if len(extents) == 0 {
+		return nil, fmt.Errorf("vmdk_descriptor_has_no_extents")
+	}
+
+	return extents, nil
+}
+
+func (v *vmdkImage) openExtents(dir, descriptor string) error {
+	lines, err := parseVMDKExtents(descriptor)
+	if err != nil {
+		return err
+	}
+
+	for _, fields := range lines {
+		sectors, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid_vmdk_extent_size: %s", fields[0])
+		}
+
+		extent := vmdkExtent{start: v.size, size: sectors * vmdkSector}
+
+		switch fields[1] {
+		case "ZERO":
+		case "FLAT", "SPARSE":
+			if len(fields) < 3 {
+				return fmt.Errorf("vmdk_extent_has_no_file")
+			}
+
+			name := filepath.Base(fields[2])
+			ef, err := os.Open(filepath.Join(dir, name))
+			if err != nil {
+				return fmt.Errorf("failed_to_open_vmdk_extent: %w", err)
+			}
+			v.files = append(v.files, ef)
+
+			if fields[1] == "FLAT" {
+				extent.flat = ef
+				if len(fields) > 3 {
+					offset, err := strconv.ParseInt(fields[3], 10, 64)
+					if err != nil {
+						return fmt.Errorf("invalid_vmdk_extent_offset: %s", fields[3])
+					}
+					extent.offset = offset * vmdkSector
+				}
+			} else {
+				if extent.sparse, err = openVMDKSparse(ef); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%w: vmdk extent type %s", ErrUnsupported, fields[1])
+		}
+
+		v.extents = append(v.extents, extent)
+		v.size += extent.size
+	}
+
+	return nil
+}
+
+func openVMDKSparse(f *os.File) (*vmdkSparseExtent, error) {
+	var h vmdkSparseHeader
+	if err := binary.Read(io.NewSectionReader(f, 0, vmdkSector), binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("invalid_vmdk_header: %w", err)
+	}
+
+	// Stream optimized images are written front to back, the real header
+	// is a footer just before the end of stream marker.
+	if h.GDOffset == vmdkGDAtEnd {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := binary.Read(io.NewSectionReader(f, info.Size()-2*vmdkSector, vmdkSector), binary.LittleEndian, &h); err != nil {
+			return nil, fmt.Errorf("invalid_vmdk_footer: %w", err)
+		}
+	}
+
+	if string(h.Magic[:]) != vmdkSparseMagic {
+		return nil, fmt.Errorf("invalid_vmdk_magic")
+	}
+
+	if h.GrainSize == 0 || h.NumGTEsPerGT == 0 || h.GDOffset == vmdkGDAtEnd {
+		return nil, fmt.Errorf("invalid_vmdk_header")
+	}
+
+	if h.Flags&vmdkFlagCompressed != 0 && h.CompressAlgorithm != 1 {
+		return nil, fmt.Errorf("%w: vmdk compression %d", ErrUnsupported, h.CompressAlgorithm)
+	}
+
+	e := &vmdkSparseExtent{
+		f:          f,
+		grainSize:  int64(h.GrainSize) * vmdkSector,
+		gtEntries:  int64(h.NumGTEsPerGT),
+		size:       int64(h.Capacity) * vmdkSector,
+		compressed: h.Flags&vmdkFlagCompressed != 0,
+		zeroGrain:  h.Flags&vmdkFlagZeroGrainGTE != 0,
+		gtIndex:    -1,
+	}
+
+	grains := (e.size + e.grainSize - 1) / e.grainSize
+	e.gd = make([]uint32, (grains+e.gtEntries-1)/e.gtEntries)
+
+	if err := binary.Read(io.NewSectionReader(f, int64(h.GDOffset)*vmdkSector, int64(len(e.gd))*4), binary.LittleEndian, e.gd); err != nil {
+		return nil, fmt.Errorf("failed_to_read_vmdk_grain_directory: %w", err)
+	}
+
+	e.gt = make([]uint32, e.gtEntries)
+
+	return e, nil
+}
+
+func (e *vmdkSparseExtent) capacity() int64 {
+	return e.size
+}
+
+// grainEntry returns the grain table entry of the grain at off, the sector
+// the grain starts at, 0 when unallocated or 1 for a zeroed grain.
+func (e *vmdkSparseExtent) grainEntry(off int64) (uint32, error) {
+	grain := off / e.grainSize
+	gdIndex := grain / e.gtEntries
+
+	if gdIndex >= int64(len(e.gd)) || e.gd[gdIndex] == 0 {
+		return 0, nil
+	}
+
+	if e.gtIndex != gdIndex {
+		if err := binary.Read(io.NewSectionReader(e.f, int64(e.gd[gdIndex])*vmdkSector, e.gtEntries*4), binary.LittleEndian, e.gt); err != nil {
+			e.gtIndex = -1
+			return 0, fmt.Errorf("failed_to_read_vmdk_grain_table: %w", err)
+		}
+		e.gtIndex = gdIndex
+	}
+
+	return e.gt[grain%e.gtEntries], nil
+}
+
+// compressedGrain inflates a compressed grain, which starts with its LBA
+// and the length of the deflate stream that follows.
+func (e *vmdkSparseExtent) compressedGrain(sector uint32) ([]byte, error) {
+	if e.cBuf != nil && e.cGrain == sector {
+		return e.cBuf, nil
+	}
+
+	header := make([]byte, 12)
+	if _, err := e.f.ReadAt(header, int64(sector)*vmdkSector); err != nil {
+		return nil, fmt.Errorf("failed_to_read_vmdk_grain: %w", err)
+	}
+
+	size := binary.LittleEndian.Uint32(header[8:])
+
+	zr, err := zlib.NewReader(io.NewSectionReader(e.f, int64(sector)*vmdkSector+12, int64(size)))
+	if err != nil {
+		return nil, fmt.Errorf("failed_to_inflate_vmdk_grain: %w", err)
+	}
+	defer zr.Close()
+
+	grain := make([]byte, e.grainSize)
+	if _, err := io.ReadFull(zr, grain); err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed_to_inflate_vmdk_grain: %w", err)
+	}
+
+	e.cBuf, e.cGrain = grain, sector
+	return grain, nil
+}
+
+func (e *vmdkSparseExtent) ReadAt(p []byte, off int64) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		inGrain := off % e.grainSize
+		chunk := min(int64(len(p)-n), e.grainSize-inGrain)
+		dst := p[n : n+int(chunk)]
+
+		entry, err := e.grainEntry(off)
+		if err != nil {
+			return n, err
+		}
+
+		switch {
+		case entry == 0 || (entry == 1 && e.zeroGrain):
+			clear(dst)
+
+		case e.compressed:
+			grain, err := e.compressedGrain(entry)
+			if err != nil {
+				return n, err
+			}
+			copy(dst, grain[inGrain:])
+
+		default:
+			if _, err := e.f.ReadAt(dst, int64(entry)*vmdkSector+inGrain); err != nil {
+				return n, fmt.Errorf("failed_to_read_vmdk_grain: %w", err)
+			}
+		}
+
+		n += int(chunk)
+		off += chunk
+	}
+
+	return n, nil
+}
+
+func (e *vmdkSparseExtent) allocated(off, length int64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for end := off + length; off < end; off = (off/e.grainSize + 1) * e.grainSize {
+		entry, err := e.grainEntry(off)
+		if err != nil {
+			return true
+		}
+
+		if entry > 1 || (entry == 1 && !e.zeroGrain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *vmdkImage) Format() string      { return FormatVMDK }
+func (v *vmdkImage) Compression() string { return "" }
+func (v *vmdkImage) VirtualSize() int64  { return v.size }
+
+func (v *vmdkImage) Close() error {
+	var firstErr error
+	for _, f := range v.files {
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (v *vmdkImage) Reader() io.Reader {
+	return io.NewSectionReader(v, 0, v.size)
+}
+
+// forEach calls fn for every extent piece of the range.
+func (v *vmdkImage) forEach(off, length int64, fn func(e *vmdkExtent, off int64, n int64) (bool, error)) error {
+	end := off + length
+	for i := range v.extents {
+		e := &v.extents[i]
+		if off >= end {
+			break
+		}
+		if off >= e.start+e.size || off < e.start {
+			continue
+		}
+
+		n := min(end, e.start+e.size) - off
+		stop, err := fn(e, off-e.start, n)
+		if err != nil || stop {
+			return err
+		}
+		off += n
+	}
+
+	return nil
+}
+
+func (v *vmdkImage) ReadAt(p []byte, off int64) (int, error) {
+	if off >= v.size {
+		return 0, io.EOF
+	}
+
+	length := min(int64(len(p)), v.size-off)
+	n := 0
+
+	err := v.forEach(off, length, func(e *vmdkExtent, eoff, size int64) (bool, error) {
+		dst := p[n : n+int(size)]
+
+		switch {
+		case e.sparse != nil:
+			if _, err := e.sparse.ReadAt(dst, eoff); err != nil {
+				return true, err
+			}
+		case e.flat != nil:
+			if _, err := e.flat.ReadAt(dst, e.offset+eoff); err != nil && err != io.EOF {
+				return true, fmt.Errorf("failed_to_read_vmdk_extent: %w", err)
+			}
+		default:
+			clear(dst)
+		}
+
+		n += int(size)
+		return false, nil
+	})
+	if err != nil {
+		return n, err
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (v *vmdkImage) allocated(off, length int64) bool {
+	found := false
+
+	v.forEach(off, length, func(e *vmdkExtent, eoff, size int64) (bool, error) {
+		switch {
+		case e.sparse != nil:
+			found = e.sparse.allocated(eoff, size)
+		case e.flat != nil:
+			found = true
+		}
+		return found, nil
+	})
+
+	return found
+}
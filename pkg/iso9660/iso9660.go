@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+// Package iso9660 writes small single directory ISO9660 images, enough for
+// configuration media like cloud-init seeds. File names are kept as they are
+// through Joliet extensions, the primary volume gets 8.3 names.
+package iso9660
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const sectorSize = 2048
+
+// File is a file in the root directory of the image.
+type File struct {
+	Name string
+	Data []byte
+}
+
+type entry struct {
+	id     []byte
+	extent uint32
+	size   uint32
+}
+
+func bothEndian32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b, v)
+	binary.BigEndian.PutUint32(b[4:], v)
+}
+
+func bothEndian16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b, v)
+	binary.BigEndian.PutUint16(b[2:], v)
+}
+
+func sectors(size int) uint32 {
+	return uint32((size + sectorSize - 1) / sectorSize)
+}
+
+// primaryName maps a file name to an ISO9660 level 1 identifier.
+func primaryName(name string) string {
+	base, ext, _ := strings.Cut(strings.ToUpper(name), ".")
+
+	clean := func(s string, n int) string {
+		out := make([]byte, 0, n)
+		for i := 0; i < len(s) && len(out) < n; i++ {
+			c := s[i]
+			if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+				out = append(out, c)
+			} else {
+				out = append(out, '_')
+			}
+		}
+		return string(out)
+	}
+
+	return clean(base, 8) + "." + clean(ext, 3) + ";1"
+}
+
+func jolietName(name string) []byte {
+	return ucs2(name + ";1")
+}
+
+func ucs2(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.BigEndian.PutUint16(b[i*2:], u)
+	}
+	return b
+}
+
+// padText fills a fixed size text field, with spaces encoded as UCS-2 when
+// joliet is set.
+func padText(b []byte, s string, joliet bool) {
+	if joliet {
+		enc := ucs2(s)
+		for i := 0; i+1 < len(b); i += 2 {
+			if i+1 < len(enc) {
+				b[i], b[i+1] = enc[i], enc[i+1]
+			} else {
+				b[i], b[i+1] = 0x00, 0x20
+			}
+		}
+		return
+	}
+
+	for i := range b {
+		if i < len(s) {
+			b[i] = s[i]
+		} else {
+			b[i] = ' '
+		}
+	}
+}
+
+func recordingDate(t time.Time) []byte {
+	t = t.UTC()
+	return []byte{
+		byte(t.Year() - 1900), byte(t.Month()), byte(t.Day()),
+		byte(t.Hour()), byte(t.Minute()), byte(t.Second()), 0,
+	}
+}
+
+func volumeDate(t time.Time) []byte {
+	b := []byte(t.UTC().Format("20060102150405") + "00")
+	return append(b, 0)
+}
+
+func dirRecord(id []byte, extent, size uint32, dir bool, t time.Time) []byte {
+	length := 33 + len(id)
+	if length%2 == 1 {
+		length++
+	}
+
+	r := make([]byte, length)
+	r[0] = byte(length)
+	bothEndian32(r[2:], extent)
+	bothEndian32(r[10:], size)
+	copy(r[18:], recordingDate(t))
+	if dir {
+		r[25] = 2
+	}
+	bothEndian16(r[28:], 1)
+	r[32] = byte(len(id))
+	copy(r[33:], id)
+
+	return r
+}
+
+func directory(self uint32, entries []entry, t time.Time) []byte {
+	var buf bytes.Buffer
+	buf.Write(dirRecord([]byte{0}, self, sectorSize, true, t))
+	buf.Write(dirRecord([]byte{1}, self, sectorSize, true, t))
+
+	for _, e := range entries {
+		buf.Write(dirRecord(e.id, e.extent, e.size, false, t))
+	}
+
+	return buf.Bytes()
+}
+
+func pathTable(root uint32, bigEndian bool) []byte {
+	t := make([]byte, 10)
+	t[0] = 1
+	if bigEndian {
+		binary.BigEndian.PutUint32(t[2:], root)
+		binary.BigEndian.PutUint16(t[6:], 1)
+	} else {
+		binary.LittleEndian.PutUint32(t[2:], root)
+		binary.LittleEndian.PutUint16(t[6:], 1)
+	}
+	return t
+}
+
+func volumeDescriptor(joliet bool, volumeID string, total, pathL, pathM, root uint32, t time.Time) []byte {
+	d := make([]byte, sectorSize)
+	d[0] = 1
+	if joliet {
+		d[0] = 2
+	}
+	copy(d[1:], "CD001")
+	d[6] = 1
+
+	padText(d[8:40], "", joliet)
+	padText(d[40:72], volumeID, joliet)
+	bothEndian32(d[80:], total)
+	if joliet {
+		// UCS-2 level 3.
+		copy(d[88:], "%/E")
+	}
+	bothEndian16(d[120:], 1)
+	bothEndian16(d[124:], 1)
+	bothEndian16(d[128:], sectorSize)
+	bothEndian32(d[132:], 10)
+	binary.LittleEndian.PutUint32(d[140:], pathL)
+	binary.BigEndian.PutUint32(d[148:], pathM)
+	copy(d[156:190], dirRecord([]byte{0}, root, sectorSize, true, t))
+
+	for _, field := range [][2]int{{190, 318}, {318, 446}, {446, 574}, {574, 702}, {702, 739}, {739, 776}, {776, 813}} {
+		padText(d[field[0]:field[1]], "", joliet)
+	}
+
+	copy(d[813:], volumeDate(t))
+	copy(d[830:], volumeDate(t))
+	copy(d[847:], "0000000000000000")
+	copy(d[864:], volumeDate(t))
+	d[881] = 1
+
+	return d
+}
+
+// Write writes an image holding files in its root directory. The volume ID
+// is stored upper cased in the primary volume and as given in the Joliet one.
+func Write(w io.Writer, volumeID string, files []File) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no_files")
+	}
+
+	if volumeID == "" || len(volumeID) > 16 {
+		return fmt.Errorf("invalid_volume_id")
+	}
+
+	const (
+		primaryPathL = 19
+		primaryPathM = 20
+		jolietPathL  = 21
+		jolietPathM  = 22
+		primaryRoot  = 23
+		jolietRoot   = 24
+		firstData    = 25
+	)
+
+	now := time.Now()
+
+	var primary, joliet []entry
+	seen := make(map[string]bool, len(files))
+	extent := uint32(firstData)
+
+	for _, f := range files {
+		name := primaryName(f.Name)
+		if seen[name] {
+			return fmt.Errorf("duplicate_file_name: %s", f.Name)
+		}
+		seen[name] = true
+
+		primary = append(primary, entry{id: []byte(name), extent: extent, size: uint32(len(f.Data))})
+		joliet = append(joliet, entry{id: jolietName(f.Name), extent: extent, size: uint32(len(f.Data))})
+
+		extent += max(sectors(len(f.Data)), 1)
+	}
+
+	byID := func(entries []entry) func(i, j int) bool {
+		return func(i, j int) bool { return bytes.Compare(entries[i].id, entries[j].id) < 0 }
+	}
+	sort.Slice(primary, byID(primary))
+	sort.Slice(joliet, byID(joliet))
+
+	primaryDir := directory(primaryRoot, primary, now)
+	jolietDir := directory(jolietRoot, joliet, now)
+	if len(primaryDir) > sectorSize || len(jolietDir) > sectorSize {
+		return fmt.Errorf("too_many_files")
+	}
+
+	image := make([]byte, int(extent)*sectorSize)
+
+	copy(image[16*sectorSize:], volumeDescriptor(false, strings.ToUpper(volumeID), extent, primaryPathL, primaryPathM, primaryRoot, now))
+	copy(image[17*sectorSize:], volumeDescriptor(true, volumeID, extent, jolietPathL, jolietPathM, jolietRoot, now))
+
+	terminator := image[18*sectorSize:]
+	terminator[0] = 255
+	copy(terminator[1:], "CD001")
+	terminator[6] = 1
+
+	copy(image[primaryPathL*sectorSize:], pathTable(primaryRoot, false))
+	copy(image[primaryPathM*sectorSize:], pathTable(primaryRoot, true))
+	copy(image[jolietPathL*sectorSize:], pathTable(jolietRoot, false))
+	copy(image[jolietPathM*sectorSize:], pathTable(jolietRoot, true))
+
+	copy(image[primaryRoot*sectorSize:], primaryDir)
+	copy(image[jolietRoot*sectorSize:], jolietDir)
+
+	extent = firstData
+	for _, f := range files {
+		copy(image[int(extent)*sectorSize:], f.Data)
+		extent += max(sectors(len(f.Data)), 1)
+	}
+
+	_, err := w.Write(image)
+	return err
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package iso9660
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// readRoot lists the root directory of the volume described at sector vd.
+func readRoot(t *testing.T, image []byte, vd int) map[string][]byte {
+	t.Helper()
+
+	d := image[vd*sectorSize:]
+	root := d[156:190]
+	extent := binary.LittleEndian.Uint32(root[2:])
+
+	files := make(map[string][]byte)
+	dir := image[int(extent)*sectorSize:][:sectorSize]
+
+	for off := 0; off < len(dir) && dir[off] != 0; off += int(dir[off]) {
+		r := dir[off:]
+		idLen := int(r[32])
+		id := r[33 : 33+idLen]
+		if idLen == 1 && (id[0] == 0 || id[0] == 1) {
+			continue
+		}
+
+		start := binary.LittleEndian.Uint32(r[2:])
+		size := binary.LittleEndian.Uint32(r[10:])
+		files[string(id)] = image[int(start)*sectorSize:][:size]
+	}
+
+	return files
+}
+
+func TestWrite(t *testing.T) {
+	files := []File{
+		{Name: "user-data", Data: []byte("#cloud-config\nhostname: test\n")},
+		{Name: "meta-data", Data: []byte("instance-id: 100\n")},
+		{Name: "network-config", Data: bytes.Repeat([]byte("x"), 3000)},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "cidata", files); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	image := buf.Bytes()
+	if len(image)%sectorSize != 0 {
+		t.Fatalf("image size %d is not a multiple of the sector size", len(image))
+	}
+
+	pvd := image[16*sectorSize:]
+	if pvd[0] != 1 || string(pvd[1:6]) != "CD001" {
+		t.Fatalf("missing primary volume descriptor")
+	}
+	if got := string(bytes.TrimRight(pvd[40:72], " ")); got != "CIDATA" {
+		t.Errorf("primary volume id = %q, want CIDATA", got)
+	}
+	if got := binary.LittleEndian.Uint32(pvd[80:]); int(got)*sectorSize != len(image) {
+		t.Errorf("volume space size = %d sectors, image has %d", got, len(image)/sectorSize)
+	}
+
+	svd := image[17*sectorSize:]
+	if svd[0] != 2 || string(svd[88:91]) != "%/E" {
+		t.Fatalf("missing Joliet volume descriptor")
+	}
+	if got := svd[40:52]; !bytes.Equal(got, ucs2("cidata")) {
+		t.Errorf("joliet volume id = %x, want cidata", got)
+	}
+
+	if image[18*sectorSize] != 255 {
+		t.Errorf("missing volume descriptor set terminator")
+	}
+
+	primary := readRoot(t, image, 16)
+	if got := primary["USER_DAT.;1"]; string(got) != string(files[0].Data) {
+		t.Errorf("primary USER_DAT.;1 = %q", got)
+	}
+
+	joliet := readRoot(t, image, 17)
+	for _, f := range files {
+		got, ok := joliet[string(jolietName(f.Name))]
+		if !ok {
+			t.Errorf("joliet directory is missing %s", f.Name)
+			continue
+		}
+		if !bytes.Equal(got, f.Data) {
+			t.Errorf("%s holds %d bytes, want %d", f.Name, len(got), len(f.Data))
+		}
+	}
+}
+
+func TestPrimaryName(t *testing.T) {
+	tests := map[string]string{
+		"meta-data":      "META_DAT.;1",
+		"network-config": "NETWORK_.;1",
+		"vendor.data.v2": "VENDOR.DAT;1",
+	}
+
+	for in, want := range tests {
+		if got := primaryName(in); got != want {
+			t.Errorf("primaryName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteDuplicateNames(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "cidata", []File{
+		{Name: "network-config", Data: []byte("a")},
+		{Name: "network-configuration", Data: []byte("b")},
+	})
+	if err == nil {
+		t.Fatal("expected an error for names that map to the same identifier")
+	}
+}
@@ -1,6 +1,7 @@
 package s3
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -168,3 +169,139 @@ func Delete(endpoint, region, bucket, accessKey, secretKey, key string) error {
 	}
 	return nil
 }
+
+const (
+	DefaultPartSize = 64 * 1024 * 1024
+	MinPartSize     = 5 * 1024 * 1024
+	MaxPartSize     = 5 * 1024 * 1024 * 1024
+	MaxParts        = 10000
+)
+
+// PartSize returns the part size for a multipart upload of about size bytes,
+// leaving a quarter of headroom so the upload stays within MaxParts even if
+// the stream turns out larger than estimated. Parts are whole MiB and never
+// smaller than DefaultPartSize.
+func PartSize(size int64) (int64, error) {
+	const mib = 1024 * 1024
+
+	need := size + size/4
+	part := (need + MaxParts - 1) / MaxParts
+	part = (part + mib - 1) / mib * mib
+
+	if part > MaxPartSize {
+		return 0, fmt.Errorf("upload_too_large: %d bytes", size)
+	}
+
+	return max(part, DefaultPartSize), nil
+}
+
+func Upload(endpoint, region, bucket, accessKey, secretKey, key string, body io.Reader, partSize int64) (int64, error) {
+	if partSize < MinPartSize {
+		partSize = DefaultPartSize
+	}
+
+	ctx := context.Background()
+
+	s3, err := buildClient(ctx, endpoint, region, accessKey, secretKey)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s3.CreateMultipartUpload(ctx, &awss3.CreateMultipartUploadInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("create_multipart_upload_failed: %w", err)
+	}
+
+	abort := func() {
+		_, _ = s3.AbortMultipartUpload(ctx, &awss3.AbortMultipartUploadInput{
+			Bucket:   &bucket,
+			Key:      &key,
+			UploadId: created.UploadId,
+		})
+	}
+
+	var parts []types.CompletedPart
+	var total int64
+
+	buf := make([]byte, partSize)
+
+	for partNumber := int32(1); ; partNumber++ {
+		n, rErr := io.ReadFull(body, buf)
+
+		if partNumber > MaxParts && n > 0 {
+			abort()
+			return total, fmt.Errorf("upload_exceeds_max_parts: part size %d", partSize)
+		}
+
+		if n > 0 || partNumber == 1 {
+			out, err := s3.UploadPart(ctx, &awss3.UploadPartInput{
+				Bucket:     &bucket,
+				Key:        &key,
+				UploadId:   created.UploadId,
+				PartNumber: aws.Int32(partNumber),
+				Body:       bytes.NewReader(buf[:n]),
+			})
+			if err != nil {
+				abort()
+				return total, fmt.Errorf("upload_part_failed: %w", err)
+			}
+
+			parts = append(parts, types.CompletedPart{
+				ETag:       out.ETag,
+				PartNumber: aws.Int32(partNumber),
+			})
+
+			total += int64(n)
+		}
+
+		if rErr == io.EOF || rErr == io.ErrUnexpectedEOF {
+			break
+		}
+
+		if rErr != nil {
+			abort()
+			return total, fmt.Errorf("read_failed: %w", rErr)
+		}
+	}
+
+	if _, err := s3.CompleteMultipartUpload(ctx, &awss3.CompleteMultipartUploadInput{
+		Bucket:          &bucket,
+		Key:             &key,
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	}); err != nil {
+		abort()
+		return total, fmt.Errorf("complete_multipart_upload_failed: %w", err)
+	}
+
+	return total, nil
+}
+
+func Get(endpoint, region, bucket, accessKey, secretKey, key string) (io.ReadCloser, error) {
+	ctx := context.Background()
+
+	s3, err := buildClient(ctx, endpoint, region, accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s3.GetObject(ctx, &awss3.GetObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get_failed: %w", err)
+	}
+
+	return out.Body, nil
+}
+
+func IsNotFound(err error) bool {
+	var nsk *types.NoSuchKey
+	var nf *types.NotFound
+
+	return errors.As(err, &nsk) || errors.As(err, &nf)
+}
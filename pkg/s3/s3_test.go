@@ -0,0 +1,33 @@
+package s3
+
+import "testing"
+
+func TestPartSize(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+
+	tests := []struct {
+		size int64
+		want int64
+		err  bool
+	}{
+		{size: 0, want: DefaultPartSize},
+		{size: 100 * gib, want: DefaultPartSize},
+		{size: 1024 * gib, want: 132 * 1024 * 1024},
+		{size: 50000 * gib, want: 0, err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := PartSize(tt.size)
+		if (err != nil) != tt.err {
+			t.Fatalf("PartSize(%d) error = %v, want error %v", tt.size, err, tt.err)
+		}
+
+		if got != tt.want {
+			t.Errorf("PartSize(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+
+		if err == nil && tt.size+tt.size/4 > got*MaxParts {
+			t.Errorf("PartSize(%d) = %d needs more than %d parts", tt.size, got, MaxParts)
+		}
+	}
+}
@@ -31,6 +31,9 @@ import (
 var (
 	once         sync.Once
 	sharedClient *http.Client
+
+	streamOnce   sync.Once
+	streamClient *http.Client
 )
 
 func GetTokenFromHeader(r http.Header) (string, error) {
@@ -199,6 +202,24 @@ func intraClusterClient() *http.Client {
 	return sharedClient
 }
 
+func intraClusterStreamClient() *http.Client {
+	streamOnce.Do(func() {
+		tr := &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+		}
+		streamClient = &http.Client{
+			Transport: tr,
+		}
+	})
+	return streamClient
+}
+
 func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
 	if ctx != nil {
 		return context.WithTimeout(ctx, d)
@@ -345,3 +366,35 @@ func HTTPGetJSONRead(url string, headers map[string]string) ([]byte, int, error)
 	}
 	return data, resp.StatusCode, nil
 }
+
+func HTTPPostStream(ctx context.Context, url string, body io.Reader, headers map[string]string) ([]byte, int, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	resp, err := intraClusterStreamClient().Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, resp.StatusCode, fmt.Errorf("http error %d: %s", resp.StatusCode, string(data))
+	}
+	return data, resp.StatusCode, nil
+}
@@ -0,0 +1,343 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+// Package vmarchive writes the tar entries VM archives are made of. Disks
+// are stored as PAX sparse files (GNU sparse format 1.0), so all-zero ranges
+// take no space and any tar that understands the format extracts them as
+// sparse files.
+package vmarchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// blockSize is the granularity holes are detected at.
+const blockSize = 64 * 1024
+
+// Region is a range of a disk that holds data.
+type Region struct {
+	Offset int64
+	Length int64
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// DataRegions scans size bytes of src for blocks that are not all zeroes,
+// adjacent data blocks are merged into one region.
+func DataRegions(src io.ReaderAt, size int64) ([]Region, error) {
+	var regions []Region
+	buf := make([]byte, blockSize)
+
+	for off := int64(0); off < size; off += blockSize {
+		b := buf[:min(blockSize, size-off)]
+		if _, err := src.ReadAt(b, off); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if isZero(b) {
+			continue
+		}
+
+		if n := len(regions); n > 0 && regions[n-1].Offset+regions[n-1].Length == off {
+			regions[n-1].Length += int64(len(b))
+		} else {
+			regions = append(regions, Region{Offset: off, Length: int64(len(b))})
+		}
+	}
+
+	return regions, nil
+}
+
+// sparseMap encodes regions the way GNU sparse 1.0 stores them in front of
+// the data, a decimal count and offset/length pairs padded to a block. A
+// file ending in a hole gets an empty region at its end so extractors know
+// its size.
+func sparseMap(regions []Region, size int64) []byte {
+	if n := len(regions); n == 0 || regions[n-1].Offset+regions[n-1].Length < size {
+		regions = append(regions, Region{Offset: size})
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d\n", len(regions))
+	for _, r := range regions {
+		fmt.Fprintf(&b, "%d\n%d\n", r.Offset, r.Length)
+	}
+
+	if pad := b.Len() % 512; pad != 0 {
+		b.Write(make([]byte, 512-pad))
+	}
+
+	return b.Bytes()
+}
+
+// Writer writes a tar archive. Regular entries go through archive/tar,
+// which refuses to write GNU sparse records, so sparse entries have their
+// headers written here.
+type Writer struct {
+	w  io.Writer
+	tw *tar.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, tw: tar.NewWriter(w)}
+}
+
+// Close writes the end of archive marker, it does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	return w.tw.Close()
+}
+
+// WriteFile adds a regular file held in memory.
+func (w *Writer) WriteFile(name string, data []byte) error {
+	return w.WriteSized(name, bytes.NewReader(data), int64(len(data)))
+}
+
+// WriteSized adds a regular file of a known size read from r.
+func (w *Writer) WriteSized(name string, r io.Reader, size int64) error {
+	if err := w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(w.tw, r, size); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// maxOctalSize is the largest size the 12 byte ustar field holds, bigger
+// entries carry their size in a PAX record.
+const maxOctalSize = 1<<33 - 1
+
+func formatOctal(dst []byte, v int64) {
+	copy(dst, fmt.Sprintf("%0*o", len(dst)-1, v))
+	dst[len(dst)-1] = 0
+}
+
+func ustarHeader(name string, typeflag byte, size int64) []byte {
+	b := make([]byte, 512)
+
+	copy(b[0:100], name)
+	formatOctal(b[100:108], 0644)
+	formatOctal(b[108:116], 0)
+	formatOctal(b[116:124], 0)
+	if size <= maxOctalSize {
+		formatOctal(b[124:136], size)
+	} else {
+		formatOctal(b[124:136], 0)
+	}
+	formatOctal(b[136:148], time.Now().Unix())
+	b[156] = typeflag
+	copy(b[257:263], "ustar\x00")
+	copy(b[263:265], "00")
+
+	copy(b[148:156], "        ")
+	sum := 0
+	for _, c := range b {
+		sum += int(c)
+	}
+	copy(b[148:156], fmt.Sprintf("%06o\x00 ", sum))
+
+	return b
+}
+
+// paxRecords encodes records as "length key=value\n" lines, the length
+// counting itself.
+func paxRecords(records map[string]string) []byte {
+	keys := make([]string, 0, len(records))
+	for k := range records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	for _, k := range keys {
+		line := fmt.Sprintf(" %s=%s\n", k, records[k])
+		n := len(line)
+		for len(strconv.Itoa(n))+len(line) != n {
+			n = len(strconv.Itoa(n)) + len(line)
+		}
+		fmt.Fprintf(&b, "%d%s", n, line)
+	}
+
+	return b.Bytes()
+}
+
+func (w *Writer) pad(n int64) error {
+	if rem := n % 512; rem != 0 {
+		_, err := w.w.Write(make([]byte, 512-rem))
+		return err
+	}
+	return nil
+}
+
+// WriteSparse adds size bytes of src as a sparse file. src is read twice,
+// once to find the holes and once for the data.
+func (w *Writer) WriteSparse(name string, src io.ReaderAt, size int64) error {
+	dir, file := path.Split(name)
+	stub := path.Join(dir, "GNUSparseFile.0", file)
+	paxName := path.Join(dir, "PaxHeaders.0", file)
+
+	if len(stub) > 100 || len(paxName) > 100 {
+		return fmt.Errorf("entry_name_too_long: %s", name)
+	}
+
+	regions, err := DataRegions(src, size)
+	if err != nil {
+		return fmt.Errorf("failed_to_scan_disk: %w", err)
+	}
+
+	spMap := sparseMap(regions, size)
+	stored := int64(len(spMap))
+	for _, r := range regions {
+		stored += r.Length
+	}
+
+	records := paxRecords(map[string]string{
+		"GNU.sparse.major":    "1",
+		"GNU.sparse.minor":    "0",
+		"GNU.sparse.name":     name,
+		"GNU.sparse.realsize": strconv.FormatInt(size, 10),
+		"size":                strconv.FormatInt(stored, 10),
+	})
+
+	if err := w.tw.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(ustarHeader(paxName, tar.TypeXHeader, int64(len(records)))); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(records); err != nil {
+		return err
+	}
+
+	if err := w.pad(int64(len(records))); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(ustarHeader(stub, tar.TypeReg, stored)); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(spMap); err != nil {
+		return err
+	}
+
+	for _, r := range regions {
+		if _, err := io.Copy(w.w, io.NewSectionReader(src, r.Offset, r.Length)); err != nil {
+			return err
+		}
+	}
+
+	return w.pad(stored)
+}
+
+// CopySparse copies r to dst, leaving blocks that are all zeroes unwritten.
+// dst has to read back as zeroes where nothing is written, like a fresh
+// volume or a truncated file.
+func CopySparse(dst io.WriterAt, r io.Reader) (int64, error) {
+	buf := make([]byte, blockSize)
+	var off int64
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 && !isZero(buf[:n]) {
+			if _, werr := dst.WriteAt(buf[:n], off); werr != nil {
+				return off, werr
+			}
+		}
+
+		off += int64(n)
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return off, nil
+		}
+		if err != nil {
+			return off, err
+		}
+	}
+}
+
+// Compress writes r to w compressed with zstd(1) from base.
+func Compress(w io.Writer, r io.Reader) error {
+	cmd := exec.Command("zstd", "-q", "-c", "-T0")
+	cmd.Stdin = r
+	cmd.Stdout = w
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("zstd_failed: %w: %s", err, stderr.String())
+	}
+
+	return nil
+}
+
+// Decompressor streams the zstd compressed data it is given through
+// zstd(1).
+type Decompressor struct {
+	cmd    *exec.Cmd
+	out    io.ReadCloser
+	stderr bytes.Buffer
+}
+
+func NewDecompressor(r io.Reader) (*Decompressor, error) {
+	d := &Decompressor{cmd: exec.Command("zstd", "-q", "-d", "-c")}
+	d.cmd.Stdin = r
+	d.cmd.Stderr = &d.stderr
+
+	out, err := d.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed_to_start_zstd: %w", err)
+	}
+
+	d.out = out
+	return d, nil
+}
+
+func (d *Decompressor) Read(p []byte) (int, error) {
+	return d.out.Read(p)
+}
+
+// Close waits for zstd to exit and reports corrupt input.
+func (d *Decompressor) Close() error {
+	io.Copy(io.Discard, d.out)
+	if err := d.cmd.Wait(); err != nil {
+		return fmt.Errorf("zstd_failed: %w: %s", err, d.stderr.String())
+	}
+	return nil
+}
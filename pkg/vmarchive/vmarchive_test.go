@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: BSD-2-Clause
+//
+// Copyright (c) 2025 The FreeBSD Foundation.
+//
+// This software was developed by Hayzam Sherif <hayzam@alchemilla.io>
+// of Alchemilla Ventures Pvt. Ltd. <hello@alchemilla.io>,
+// under sponsorship from the FreeBSD Foundation.
+
+package vmarchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestDataRegions(t *testing.T) {
+	disk := make([]byte, 5*blockSize+100)
+	disk[10] = 1
+	disk[blockSize+5] = 1
+	disk[4*blockSize] = 1
+	disk[len(disk)-1] = 1
+
+	regions, err := DataRegions(bytes.NewReader(disk), int64(len(disk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Region{
+		{Offset: 0, Length: 2 * blockSize},
+		{Offset: 4 * blockSize, Length: blockSize + 100},
+	}
+
+	if len(regions) != len(want) {
+		t.Fatalf("got %v, want %v", regions, want)
+	}
+
+	for i := range want {
+		if regions[i] != want[i] {
+			t.Fatalf("region %d: got %v, want %v", i, regions[i], want[i])
+		}
+	}
+}
+
+func TestWriteSparseRoundTrip(t *testing.T) {
+	cases := map[string][]byte{
+		"empty":        make([]byte, 3*blockSize),
+		"trailingHole": append(bytes.Repeat([]byte{7}, 1000), make([]byte, 4*blockSize)...),
+		"leadingHole":  append(make([]byte, 2*blockSize), bytes.Repeat([]byte{9}, 300)...),
+	}
+
+	for name, disk := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf)
+
+			if err := w.WriteFile("manifest.json", []byte("{}")); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteSparse("disks/0.raw", bytes.NewReader(disk), int64(len(disk))); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteFile("uefi_vars.fd", []byte("vars")); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.Len() >= len(disk) {
+				t.Fatalf("archive of %d bytes is not smaller than the %d byte disk", buf.Len(), len(disk))
+			}
+
+			tr := tar.NewReader(&buf)
+
+			hdr, err := tr.Next()
+			if err != nil || hdr.Name != "manifest.json" {
+				t.Fatalf("first entry: %v, %v", hdr, err)
+			}
+
+			hdr, err = tr.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if hdr.Name != "disks/0.raw" {
+				t.Fatalf("sparse entry named %q", hdr.Name)
+			}
+
+			if hdr.Size != int64(len(disk)) {
+				t.Fatalf("sparse entry size %d, want %d", hdr.Size, len(disk))
+			}
+
+			got, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, disk) {
+				t.Fatal("disk contents differ after round trip")
+			}
+
+			hdr, err = tr.Next()
+			if err != nil || hdr.Name != "uefi_vars.fd" {
+				t.Fatalf("entry after sparse file: %v, %v", hdr, err)
+			}
+
+			if _, err := tr.Next(); err != io.EOF {
+				t.Fatalf("expected end of archive, got %v", err)
+			}
+		})
+	}
+}
+
+type recordingWriterAt struct {
+	data   []byte
+	writes []int64
+}
+
+func (r *recordingWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	r.writes = append(r.writes, off)
+	copy(r.data[off:], p)
+	return len(p), nil
+}
+
+func TestCopySparse(t *testing.T) {
+	disk := make([]byte, 3*blockSize+10)
+	disk[5] = 1
+	disk[len(disk)-1] = 1
+
+	dst := &recordingWriterAt{data: make([]byte, len(disk))}
+
+	n, err := CopySparse(dst, bytes.NewReader(disk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != int64(len(disk)) {
+		t.Fatalf("copied %d bytes, want %d", n, len(disk))
+	}
+
+	if !bytes.Equal(dst.data, disk) {
+		t.Fatal("contents differ after copy")
+	}
+
+	if len(dst.writes) != 2 || dst.writes[0] != 0 || dst.writes[1] != 3*blockSize {
+		t.Fatalf("unexpected writes at %v", dst.writes)
+	}
+}
@@ -9,30 +9,34 @@ import (
 )
 
 type Dataset struct {
-	z             *zfs   `json:"-"`
-	Name          string `json:"name"`
-	GUID          string `json:"guid"`
-	Origin        string `json:"origin"`
-	Used          uint64 `json:"used"`
-	Avail         uint64 `json:"avail"`
-	Recordsize    uint64 `json:"recordsize"`
-	Mountpoint    string `json:"mountpoint"`
-	Compression   string `json:"compression"`
-	Type          string `json:"type"`
-	Written       uint64 `json:"written"`
-	Volsize       uint64 `json:"volsize"`
-	VolBlockSize  uint64 `json:"volblocksize"`
-	Logicalused   uint64 `json:"logicalused"`
-	Usedbydataset uint64 `json:"usedbydataset"`
-	Quota         uint64 `json:"quota"`
-	Referenced    uint64 `json:"referenced"`
-	Mounted       string `json:"mounted"`
-	Checksum      string `json:"checksum"`
-	Dedup         string `json:"dedup"`
-	ACLInherit    string `json:"aclinherit"`
-	ACLMode       string `json:"aclmode"`
-	PrimaryCache  string `json:"primarycache"`
-	VolMode       string `json:"volmode"`
+	z              *zfs   `json:"-"`
+	Name           string `json:"name"`
+	GUID           string `json:"guid"`
+	Origin         string `json:"origin"`
+	Used           uint64 `json:"used"`
+	Avail          uint64 `json:"avail"`
+	Recordsize     uint64 `json:"recordsize"`
+	Mountpoint     string `json:"mountpoint"`
+	Compression    string `json:"compression"`
+	Type           string `json:"type"`
+	Written        uint64 `json:"written"`
+	Volsize        uint64 `json:"volsize"`
+	VolBlockSize   uint64 `json:"volblocksize"`
+	Logicalused    uint64 `json:"logicalused"`
+	Usedbydataset  uint64 `json:"usedbydataset"`
+	Quota          uint64 `json:"quota"`
+	Referenced     uint64 `json:"referenced"`
+	Mounted        string `json:"mounted"`
+	Checksum       string `json:"checksum"`
+	Dedup          string `json:"dedup"`
+	ACLInherit     string `json:"aclinherit"`
+	ACLMode        string `json:"aclmode"`
+	PrimaryCache   string `json:"primarycache"`
+	VolMode        string `json:"volmode"`
+	Encryption     string `json:"encryption"`
+	EncryptionRoot string `json:"encryptionroot"`
+	KeyStatus      string `json:"keystatus"`
+	KeyFormat      string `json:"keyformat"`
 
 	Props map[string]string `json:"properties"`
 }
@@ -162,6 +166,19 @@ func (d *Dataset) GetProperty(key string) (string, error) {
 	return out[0][2], nil
 }
 
+// ResumeToken returns the receive_resume_token of a dataset that an
+// interrupted resumable receive left behind, or "" if there is none. Props
+// are keyed by the column headers of `zfs list -o all`, where the property
+// is called RESUMETOK.
+func (d *Dataset) ResumeToken() string {
+	token := d.Props["resumetok"]
+	if token == "-" {
+		return ""
+	}
+
+	return token
+}
+
 func (d *Dataset) GetProperties(keys ...string) ([]string, error) {
 	if len(keys) == 0 {
 		return nil, nil
@@ -204,16 +221,15 @@ func (d *Dataset) GetAllProperties() (map[string]string, error) {
 }
 
 func (d *Dataset) Rename(name string, createParent, recursiveRenameSnapshots bool) (*Dataset, error) {
-	args := make([]string, 3, 5)
+	args := make([]string, 1, 5)
 	args[0] = "rename"
-	args[1] = d.Name
-	args[2] = name
 	if createParent {
 		args = append(args, "-p")
 	}
 	if recursiveRenameSnapshots {
 		args = append(args, "-r")
 	}
+	args = append(args, d.Name, name)
 	if err := d.z.do(args...); err != nil {
 		return d, err
 	}
@@ -221,6 +237,13 @@ func (d *Dataset) Rename(name string, createParent, recursiveRenameSnapshots boo
 	return d.z.GetDataset(name)
 }
 
+func (d *Dataset) Promote() error {
+	if d.Origin == "" || d.Origin == "-" {
+		return errors.New("can only promote clones")
+	}
+	return d.z.do("promote", d.Name)
+}
+
 func (d *Dataset) Snapshots() ([]*Dataset, error) {
 	return d.z.Snapshots(d.Name)
 }
@@ -241,6 +264,43 @@ func (d *Dataset) IncrementalSend(baseSnapshot *Dataset, output io.Writer) error
 	return err
 }
 
+type SendOptions struct {
+	// Base is the snapshot or bookmark the incremental stream starts from.
+	Base         string
+	Intermediate bool
+	// Raw sends encrypted datasets as they are on disk, the receiving side
+	// never needs the key.
+	Raw bool
+}
+
+func (d *Dataset) Send(opts SendOptions, output io.Writer) error {
+	if d.Type != DatasetSnapshot {
+		return errors.New("can only send snapshots")
+	}
+
+	// A bookmark does not keep the snapshots between it and the target, so
+	// it can only be the base of a plain incremental.
+	if opts.Intermediate && strings.Contains(opts.Base, "#") {
+		return errors.New("cannot send intermediate snapshots from a bookmark")
+	}
+
+	args := []string{"send"}
+	if opts.Raw {
+		args = append(args, "-w")
+	}
+	if opts.Base != "" {
+		if opts.Intermediate {
+			args = append(args, "-I", opts.Base)
+		} else {
+			args = append(args, "-i", opts.Base)
+		}
+	}
+	args = append(args, d.Name)
+
+	_, err := d.z.run(nil, output, "zfs", args...)
+	return err
+}
+
 func (d *Dataset) Snapshot(name string, recursive bool) (*Dataset, error) {
 	args := make([]string, 1, 4)
 	args[0] = "snapshot"
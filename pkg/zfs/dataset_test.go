@@ -0,0 +1,67 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+)
+
+// Header and values of `zfs list -p -o all` for a dataset with an
+// interrupted resumable receive, as printed by OpenZFS 2.2.
+const zfsListAllHeader = "NAME TYPE CREATION USED AVAIL REFER RATIO MOUNTED ORIGIN QUOTA RESERV VOLSIZE " +
+	"VOLBLOCK RECSIZE MOUNTPOINT SHARENFS CHECKSUM COMPRESS ATIME DEVICES EXEC SETUID RDONLY JAILED " +
+	"SNAPDIR ACLMODE ACLINHERIT CREATETXG CANMOUNT XATTR COPIES VERSION UTF8ONLY NORMALIZATION CASE VSCAN " +
+	"NBMAND SHARESMB REFQUOTA REFRESERV GUID PRIMARYCACHE SECONDARYCACHE USEDSNAP USEDDS USEDCHILD USEDREFRESERV DEFER_DESTROY " +
+	"USERREFS LOGBIAS OBJSETID DEDUP MLSLABEL SYNC DNSIZE REFRATIO WRITTEN LUSED LREFER VOLMODE " +
+	"FILESYSTEM_LIMIT SNAPSHOT_LIMIT FILESYSTEM_COUNT SNAPSHOT_COUNT SNAPDEV ACLTYPE CONTEXT FSCONTEXT DEFCONTEXT ROOTCONTEXT RELATIME REDUNDANT_METADATA " +
+	"OVERLAY ENCRYPTION KEYLOCATION KEYFORMAT PBKDF2ITERS ENCROOT KEYSTATUS SPECIAL_SMALL_BLOCKS RESUMETOK PREFETCH"
+
+const zfsListAllPartial = "tank/vm/100 filesystem 1760000000 1052672 9664307200 1052672 1.00 no - 0 0 - " +
+	"- 131072 /tank/vm/100 off on lz4 on on on on off off " +
+	"hidden discard restricted 1234 on on 1 5 off none sensitive off " +
+	"off off 0 0 4928519374265188316 all all 0 1052672 0 0 - " +
+	"- latency 1547 off - standard legacy 1.00 1052672 1048576 1048576 default " +
+	"18446744073709551615 18446744073709551615 18446744073709551615 18446744073709551615 hidden nfsv4 none none none none on all " +
+	"on off none none 0 - - 0 1-e604ea4bf-e0-789c636064000310a500c4ec50360710e72765a526973030c8a8c22090fc0e5d5ce4e6b0d50200f4a507b0 all"
+
+const zfsListAllComplete = "tank/vm/100 filesystem 1760000000 1052672 9664307200 1052672 1.00 no - 0 0 - " +
+	"- 131072 /tank/vm/100 off on lz4 on on on on off off " +
+	"hidden discard restricted 1234 on on 1 5 off none sensitive off " +
+	"off off 0 0 4928519374265188316 all all 0 1052672 0 0 - " +
+	"- latency 1547 off - standard legacy 1.00 1052672 1048576 1048576 default " +
+	"18446744073709551615 18446744073709551615 18446744073709551615 18446744073709551615 hidden nfsv4 none none none none on all " +
+	"on off none none 0 - - 0 - all"
+
+func parseListAll(t *testing.T, values string) *Dataset {
+	t.Helper()
+
+	d := &Dataset{Props: make(map[string]string)}
+	if err := d.parseProps([][]string{strings.Fields(zfsListAllHeader), strings.Fields(values)}); err != nil {
+		t.Fatalf("parseProps: %v", err)
+	}
+
+	return d
+}
+
+func TestParsePropsListAll(t *testing.T) {
+	d := parseListAll(t, zfsListAllPartial)
+
+	if d.Name != "tank/vm/100" || d.GUID != "4928519374265188316" || d.Mounted != "no" {
+		t.Errorf("unexpected dataset %+v", d)
+	}
+
+	if d.Used != 1052672 || d.Referenced != 1052672 || d.Recordsize != 131072 {
+		t.Errorf("unexpected sizes used=%d refer=%d recsize=%d", d.Used, d.Referenced, d.Recordsize)
+	}
+}
+
+func TestResumeToken(t *testing.T) {
+	want := "1-e604ea4bf-e0-789c636064000310a500c4ec50360710e72765a526973030c8a8c22090fc0e5d5ce4e6b0d50200f4a507b0"
+
+	if got := parseListAll(t, zfsListAllPartial).ResumeToken(); got != want {
+		t.Errorf("expected resume token %q, got %q", want, got)
+	}
+
+	if got := parseListAll(t, zfsListAllComplete).ResumeToken(); got != "" {
+		t.Errorf("expected no resume token, got %q", got)
+	}
+}
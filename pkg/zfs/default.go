@@ -2,6 +2,7 @@ package zfs
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/alchemillahq/sylve/pkg/exe"
 )
@@ -30,6 +31,26 @@ func Volumes(filter string) ([]*Dataset, error) {
 	return z.Volumes(filter)
 }
 
+func GetDataset(name string) (*Dataset, error) {
+	return z.GetDataset(name)
+}
+
+func ReceiveSnapshot(input io.Reader, name string, force ...bool) (*Dataset, error) {
+	return z.ReceiveSnapshot(input, name, force...)
+}
+
+func ReceiveResumable(input io.Reader, name string, force bool) (*Dataset, error) {
+	return z.ReceiveResumable(input, name, force)
+}
+
+func ResumeSend(token string, output io.Writer) error {
+	return z.ResumeSend(token, output)
+}
+
+func SnapshotDatasets(datasets []string, name string) error {
+	return z.SnapshotDatasets(datasets, name)
+}
+
 func GetZpool(name string) (*Zpool, error) {
 	return z.GetZpool(name)
 }
@@ -38,6 +59,18 @@ func GetZpoolByGUID(guid string) (*Zpool, error) {
 	return z.GetZpoolByGUID(guid)
 }
 
+func GetZpoolTopology(name string) (*ZpoolTopology, error) {
+	return z.GetZpoolTopology(name)
+}
+
+func ImportablePools() ([]ImportablePool, error) {
+	return z.ImportablePools()
+}
+
+func ImportZpool(nameOrGUID string, opts ImportOptions) (*Zpool, error) {
+	return z.ImportZpool(nameOrGUID, opts)
+}
+
 func CreateZpool(name string, properties map[string]string, args ...string) (*Zpool, error) {
 	return z.CreateZpool(name, properties, args...)
 }
@@ -54,6 +87,10 @@ func GetTotalIODelay() float64 {
 	return z.GetTotalIODelay()
 }
 
+func VdevIOStats(interval int) ([]VdevIOStat, error) {
+	return z.VdevIOStats(interval)
+}
+
 func DestroyPool(guid string) error {
 	var pools []*Zpool
 	pools, err := ListZpools()
@@ -0,0 +1,91 @@
+package zfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	KeyStatusAvailable   = "available"
+	KeyStatusUnavailable = "unavailable"
+)
+
+// IsEncrypted reports whether encryption is enabled on the dataset.
+func (d *Dataset) IsEncrypted() bool {
+	return d.Encryption != "" && d.Encryption != "off"
+}
+
+// IsEncryptionRoot reports whether the dataset holds its own key rather
+// than inheriting it from a parent.
+func (d *Dataset) IsEncryptionRoot() bool {
+	return d.IsEncrypted() && d.EncryptionRoot == d.Name
+}
+
+// encryptionInput takes the encryptionKey pseudo property out of properties
+// and returns it as stdin for zfs create, with keylocation set to prompt so
+// the key never touches the disk.
+func encryptionInput(properties map[string]string) (io.Reader, error) {
+	key, ok := properties["encryptionKey"]
+	delete(properties, "encryptionKey")
+
+	if !ok || key == "" || properties["encryption"] == "" || properties["encryption"] == "off" {
+		return nil, nil
+	}
+
+	if properties["keyformat"] == "" {
+		properties["keyformat"] = "passphrase"
+	}
+
+	if properties["keyformat"] == "passphrase" && (len([]byte(key)) < 32 || len([]byte(key)) > 512) {
+		return nil, fmt.Errorf("invalid_encryption_key_length")
+	}
+
+	properties["keylocation"] = "prompt"
+
+	return strings.NewReader(key), nil
+}
+
+// LoadKey loads the key of an encryption root. With a nil key zfs reads it
+// from the configured keylocation, otherwise the key is passed on stdin.
+func (d *Dataset) LoadKey(key []byte) error {
+	if d.Type == DatasetSnapshot {
+		return errors.New("cannot load keys of snapshots")
+	}
+
+	if key == nil {
+		return d.z.do("load-key", d.Name)
+	}
+
+	_, err := d.z.run(bytes.NewReader(key), nil, "zfs", "load-key", "-L", "prompt", d.Name)
+	return err
+}
+
+func (d *Dataset) UnloadKey() error {
+	if d.Type == DatasetSnapshot {
+		return errors.New("cannot unload keys of snapshots")
+	}
+
+	return d.z.do("unload-key", d.Name)
+}
+
+// ChangeKey replaces the wrapping key of an encryption root. The current key
+// has to be loaded. A key given here is passed on stdin, so location must
+// be "prompt" in that case.
+func (d *Dataset) ChangeKey(format string, location string, key []byte) error {
+	if d.Type == DatasetSnapshot {
+		return errors.New("cannot change keys of snapshots")
+	}
+
+	args := []string{"change-key", "-o", "keyformat=" + format, "-o", "keylocation=" + location, d.Name}
+
+	var in io.Reader
+	if key != nil {
+		in = bytes.NewReader(key)
+	}
+
+	_, err := d.z.run(in, nil, "zfs", args...)
+	return err
+}
@@ -0,0 +1,207 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EventClassStateChange = "resource.fs.zfs.statechange"
+	EventClassRemoved     = "resource.fs.zfs.removed"
+	EventClassChecksum    = "ereport.fs.zfs.checksum"
+	EventClassIO          = "ereport.fs.zfs.io"
+	EventClassData        = "ereport.fs.zfs.data"
+)
+
+// Event is one entry of `zpool events -v`. The common payload members are
+// lifted into fields, everything else stays in Attrs.
+type Event struct {
+	Time          time.Time         `json:"time"`
+	Class         string            `json:"class"`
+	EID           uint64            `json:"eid"`
+	Pool          string            `json:"pool,omitempty"`
+	PoolGUID      string            `json:"poolGuid,omitempty"`
+	VdevPath      string            `json:"vdevPath,omitempty"`
+	VdevGUID      string            `json:"vdevGuid,omitempty"`
+	VdevType      string            `json:"vdevType,omitempty"`
+	VdevState     string            `json:"vdevState,omitempty"`
+	VdevLastState string            `json:"vdevLastState,omitempty"`
+	Attrs         map[string]string `json:"attrs"`
+}
+
+const eventTimeLayout = "Jan _2 2006 15:04:05.000000000"
+
+// vdevStates follows vdev_state_t, with the names zpool status prints.
+var vdevStates = map[uint64]string{
+	0: "UNKNOWN",
+	1: "CLOSED",
+	2: "OFFLINE",
+	3: "REMOVED",
+	4: "UNAVAIL",
+	5: "FAULTED",
+	6: "DEGRADED",
+	7: "ONLINE",
+}
+
+// parseEventValue strips the quotes of string members. Enums are printed
+// as `"NAME" (0x7)`, only the name is kept for those.
+func parseEventValue(value string) string {
+	if quoted, _, ok := strings.Cut(value, " (0x"); ok && strings.HasPrefix(quoted, `"`) {
+		value = quoted
+	}
+
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	return value
+}
+
+// parseEventUint parses the hex numbers zpool events prints for integers.
+func parseEventUint(value string) (uint64, bool) {
+	if !strings.HasPrefix(value, "0x") {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(value[2:], 16, 64)
+	return n, err == nil
+}
+
+func vdevStateName(value string) string {
+	if n, ok := parseEventUint(value); ok {
+		return vdevStates[n]
+	}
+	return value
+}
+
+func parseEventHeader(line string) (Event, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return Event{}, false
+	}
+
+	t, err := time.ParseInLocation(eventTimeLayout, strings.Join(fields[:4], " "), time.Local)
+	if err != nil {
+		return Event{}, false
+	}
+
+	return Event{Time: t, Class: fields[len(fields)-1], Attrs: map[string]string{}}, true
+}
+
+func (e *Event) setAttr(key string, value string) {
+	value = parseEventValue(value)
+	e.Attrs[key] = value
+
+	switch key {
+	case "eid":
+		e.EID, _ = parseEventUint(value)
+	case "pool":
+		e.Pool = value
+	case "pool_guid":
+		if n, ok := parseEventUint(value); ok {
+			e.PoolGUID = strconv.FormatUint(n, 10)
+		}
+	case "vdev_path":
+		e.VdevPath = value
+	case "vdev_guid":
+		if n, ok := parseEventUint(value); ok {
+			e.VdevGUID = strconv.FormatUint(n, 10)
+		}
+	case "vdev_type":
+		e.VdevType = value
+	case "vdev_state":
+		e.VdevState = vdevStateName(value)
+	case "vdev_laststate":
+		e.VdevLastState = vdevStateName(value)
+	}
+}
+
+// ParseEvents reads `zpool events -v` output and calls fn for every complete
+// event. An event starts with an unindented "time class" line followed by
+// indented "key = value" members, and ends at a blank line or the next
+// header. It returns when r is exhausted.
+func ParseEvents(r io.Reader, fn func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var current *Event
+	flush := func() {
+		if current != nil {
+			fn(*current)
+			current = nil
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		if line[0] != ' ' && line[0] != '\t' {
+			flush()
+			if event, ok := parseEventHeader(line); ok {
+				current = &event
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " = ")
+		if !ok {
+			continue
+		}
+
+		current.setAttr(key, value)
+	}
+
+	flush()
+
+	return scanner.Err()
+}
+
+// FollowEvents streams pool events until ctx is done or zpool exits. Events
+// already in the kernel's queue are replayed first.
+func FollowEvents(ctx context.Context, fn func(Event)) error {
+	cmd := exec.CommandContext(ctx, "zpool", "events", "-f", "-v")
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	parseErr := ParseEvents(stdout, fn)
+	waitErr := cmd.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if waitErr != nil {
+		return fmt.Errorf("zpool events exited: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+
+	if parseErr != nil {
+		return parseErr
+	}
+
+	return errors.New("zpool events exited")
+}
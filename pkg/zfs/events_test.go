@@ -0,0 +1,74 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+)
+
+const zpoolEventsFixture = `TIME                           CLASS
+Oct 07 2026 10:11:12.123456789 sysevent.fs.zfs.config_sync
+        version = 0x0
+        class = "sysevent.fs.zfs.config_sync"
+        pool = "tank"
+        pool_guid = 0x3e4d2f1a5b6c7d8e
+        pool_state = 0x0
+        pool_context = 0x0
+        time = 0x67103e10 0x75bcd15
+        eid = 0x1
+
+Oct 07 2026 10:15:00.000000001 resource.fs.zfs.statechange
+        version = 0x0
+        class = "resource.fs.zfs.statechange"
+        pool = "tank"
+        pool_guid = 0x3e4d2f1a5b6c7d8e
+        vdev_guid = 0x1a2b3c4d5e6f7081
+        vdev_state = "FAULTED" (0x5)
+        vdev_path = "/dev/ada1"
+        vdev_laststate = 0x7
+        eid = 0x2
+
+Oct 07 2026 10:16:00.000000002 ereport.fs.zfs.checksum
+        class = "ereport.fs.zfs.checksum"
+        pool = "tank"
+        vdev_type = "disk"
+        vdev_path = "/dev/ada2"
+        vdev_state = 0x7
+        eid = 0x3
+`
+
+func TestParseEvents(t *testing.T) {
+	var events []Event
+	if err := ParseEvents(strings.NewReader(zpoolEventsFixture), func(e Event) {
+		events = append(events, e)
+	}); err != nil {
+		t.Fatalf("ParseEvents failed: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+
+	sync := events[0]
+	if sync.Class != "sysevent.fs.zfs.config_sync" || sync.Pool != "tank" || sync.EID != 1 {
+		t.Errorf("unexpected first event: %+v", sync)
+	}
+	if sync.PoolGUID != "4489296193807744398" {
+		t.Errorf("expected decimal pool guid, got %s", sync.PoolGUID)
+	}
+	if sync.Time.Day() != 7 || sync.Time.Nanosecond() != 123456789 {
+		t.Errorf("unexpected event time %v", sync.Time)
+	}
+
+	change := events[1]
+	if change.Class != EventClassStateChange || change.VdevPath != "/dev/ada1" {
+		t.Errorf("unexpected statechange event: %+v", change)
+	}
+	if change.VdevState != "FAULTED" || change.VdevLastState != "ONLINE" {
+		t.Errorf("expected ONLINE -> FAULTED, got %s -> %s", change.VdevLastState, change.VdevState)
+	}
+
+	checksum := events[2]
+	if checksum.Class != EventClassChecksum || checksum.VdevType != "disk" || checksum.VdevState != "ONLINE" {
+		t.Errorf("unexpected checksum event: %+v", checksum)
+	}
+}
@@ -31,7 +31,7 @@ const (
 )
 
 var (
-	dsPropList           = []string{"name", "origin", "used", "avail", "recordsize", "mountpoint", "compression", "type", "volsize", "quota", "referenced", "written", "logicalused", "usedbydataset", "guid", "mounted", "checksum", "aclmode", "aclinherit", "primarycache", "volmode"}
+	dsPropList           = []string{"name", "origin", "used", "avail", "recordsize", "mountpoint", "compression", "type", "volsize", "quota", "referenced", "written", "logicalused", "usedbydataset", "guid", "mounted", "checksum", "aclmode", "aclinherit", "primarycache", "volmode", "encryption", "encryptionroot", "keystatus", "keyformat"}
 	zpoolPropList        = []string{"name", "health", "allocated", "size", "free", "readonly", "dedupratio", "fragmentation", "freeing", "leaked", "guid"}
 	zpoolPropListOptions = strings.Join(zpoolPropList, ",")
 	zpoolArgs            = []string{"get", "-Hp", zpoolPropListOptions}
@@ -60,6 +60,44 @@ var inodeTypeMap = map[string]InodeType{
 	"F": File,
 }
 
+func (c ChangeType) String() string {
+	switch c {
+	case Removed:
+		return "removed"
+	case Created:
+		return "created"
+	case Modified:
+		return "modified"
+	case Renamed:
+		return "renamed"
+	}
+	return "unknown"
+}
+
+func (t InodeType) String() string {
+	switch t {
+	case BlockDevice:
+		return "block_device"
+	case CharacterDevice:
+		return "character_device"
+	case Directory:
+		return "directory"
+	case Door:
+		return "door"
+	case NamedPipe:
+		return "named_pipe"
+	case SymbolicLink:
+		return "symbolic_link"
+	case EventPort:
+		return "event_port"
+	case Socket:
+		return "socket"
+	case File:
+		return "file"
+	}
+	return "unknown"
+}
+
 var referenceCountRegex = regexp.MustCompile(`\(([+-]\d+?)\)`)
 
 func parseInodeChange(line []string) (*InodeChange, error) {
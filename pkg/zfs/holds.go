@@ -0,0 +1,126 @@
+package zfs
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Hold is a user hold on a snapshot. A held snapshot cannot be destroyed
+// until every hold on it has been released.
+type Hold struct {
+	Snapshot string    `json:"snapshot"`
+	Tag      string    `json:"tag"`
+	Created  time.Time `json:"created"`
+}
+
+// parseHolds parses tab separated `zfs holds -Hp` output. Tags may contain
+// spaces, so lines are not split on whitespace.
+func parseHolds(output string) []Hold {
+	holds := []Hold{}
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+
+		hold := Hold{Snapshot: fields[0], Tag: fields[1]}
+		if ts, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			hold.Created = time.Unix(ts, 0)
+		}
+
+		holds = append(holds, hold)
+	}
+
+	return holds
+}
+
+// Holds lists the holds on the snapshot, and on the snapshots of the same
+// name in descendent datasets when recursive.
+func (d *Dataset) Holds(recursive bool) ([]Hold, error) {
+	if d.Type != DatasetSnapshot {
+		return nil, errors.New("can only list holds of snapshots")
+	}
+
+	args := []string{"holds", "-Hp"}
+	if recursive {
+		args = append(args, "-r")
+	}
+	args = append(args, d.Name)
+
+	var out bytes.Buffer
+	if _, err := d.z.run(nil, &out, "zfs", args...); err != nil {
+		return nil, err
+	}
+
+	return parseHolds(out.String()), nil
+}
+
+func (d *Dataset) Hold(tag string, recursive bool) error {
+	if d.Type != DatasetSnapshot {
+		return errors.New("can only hold snapshots")
+	}
+
+	args := []string{"hold"}
+	if recursive {
+		args = append(args, "-r")
+	}
+
+	return d.z.do(append(args, tag, d.Name)...)
+}
+
+func (d *Dataset) Release(tag string, recursive bool) error {
+	if d.Type != DatasetSnapshot {
+		return errors.New("can only release snapshots")
+	}
+
+	args := []string{"release"}
+	if recursive {
+		args = append(args, "-r")
+	}
+
+	return d.z.do(append(args, tag, d.Name)...)
+}
+
+// Bookmark creates a bookmark of the snapshot on its dataset. A bookmark
+// keeps nothing but the point in time, which is enough to serve as the base
+// of an incremental send after the snapshot itself is gone.
+func (d *Dataset) Bookmark(name string) (*Dataset, error) {
+	if d.Type != DatasetSnapshot {
+		return nil, errors.New("can only bookmark snapshots")
+	}
+
+	parent, _, _ := strings.Cut(d.Name, "@")
+	bookmark := parent + "#" + name
+
+	if err := d.z.do("bookmark", d.Name, bookmark); err != nil {
+		return nil, err
+	}
+
+	return d.z.GetDataset(bookmark)
+}
+
+// Bookmarks lists the bookmarks of the dataset itself, not those of its
+// descendents.
+func (d *Dataset) Bookmarks() ([]*Dataset, error) {
+	datasets, err := d.z.listByType(DatasetBookmark, d.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := []*Dataset{}
+	for _, ds := range datasets {
+		if strings.HasPrefix(ds.Name, d.Name+"#") {
+			bookmarks = append(bookmarks, ds)
+		}
+	}
+
+	return bookmarks, nil
+}
@@ -0,0 +1,31 @@
+package zfs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const zfsHoldsFixture = "tank/vm@daily-1\tsylve-backup\t1760700000\n" +
+	"tank/vm@daily-1\tkeep forever\t1760703600\n" +
+	"tank/vm/disk0@daily-1\tsylve-backup\tbogus\n"
+
+func TestParseHolds(t *testing.T) {
+	holds := parseHolds(zfsHoldsFixture)
+
+	want := []Hold{
+		{Snapshot: "tank/vm@daily-1", Tag: "sylve-backup", Created: time.Unix(1760700000, 0)},
+		{Snapshot: "tank/vm@daily-1", Tag: "keep forever", Created: time.Unix(1760703600, 0)},
+		{Snapshot: "tank/vm/disk0@daily-1", Tag: "sylve-backup"},
+	}
+
+	if !reflect.DeepEqual(holds, want) {
+		t.Errorf("expected %+v, got %+v", want, holds)
+	}
+}
+
+func TestParseHoldsEmpty(t *testing.T) {
+	if holds := parseHolds(""); len(holds) != 0 {
+		t.Errorf("expected no holds, got %+v", holds)
+	}
+}
@@ -0,0 +1,201 @@
+package zfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type ImportableDevice struct {
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	Message string `json:"message,omitempty"`
+}
+
+type ImportablePool struct {
+	Name           string             `json:"name"`
+	GUID           string             `json:"guid"`
+	State          string             `json:"state"`
+	Status         string             `json:"status,omitempty"`
+	Action         string             `json:"action,omitempty"`
+	Comment        string             `json:"comment,omitempty"`
+	Devices        []ImportableDevice `json:"devices"`
+	MissingDevices []string           `json:"missingDevices"`
+}
+
+type ImportOptions struct {
+	NewName  string
+	ReadOnly bool
+	AltRoot  string
+	Force    bool
+}
+
+var missingDeviceStates = map[string]bool{
+	"UNAVAIL": true,
+	"FAULTED": true,
+	"REMOVED": true,
+	"OFFLINE": true,
+}
+
+// parseImportablePools parses the listing printed by `zpool import` when no
+// pool is given. Each pool starts with a "pool:" line followed by key/value
+// pairs, values may wrap onto tab indented lines, and a config block whose
+// hierarchy is expressed through indentation.
+func parseImportablePools(output string) []ImportablePool {
+	pools := []ImportablePool{}
+
+	var current *ImportablePool
+	var lastKey string
+	inConfig := false
+
+	setField := func(key, value string) {
+		switch key {
+		case "pool":
+			current.Name = value
+		case "id":
+			current.GUID = value
+		case "state":
+			current.State = value
+		case "status":
+			current.Status = joinWrapped(current.Status, value)
+		case "action":
+			current.Action = joinWrapped(current.Action, value)
+		case "comment":
+			current.Comment = joinWrapped(current.Comment, value)
+		}
+	}
+
+	for _, raw := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(raw)
+
+		if key, value, ok := strings.Cut(trimmed, ":"); ok && !strings.HasPrefix(raw, "\t") && !strings.Contains(key, " ") {
+			value = strings.TrimSpace(value)
+
+			if key == "pool" {
+				pools = append(pools, ImportablePool{
+					Devices:        []ImportableDevice{},
+					MissingDevices: []string{},
+				})
+				current = &pools[len(pools)-1]
+				inConfig = false
+			}
+
+			if current == nil {
+				continue
+			}
+
+			if key == "config" {
+				inConfig = true
+				continue
+			}
+
+			lastKey = key
+			setField(key, value)
+			continue
+		}
+
+		if current == nil || trimmed == "" {
+			continue
+		}
+
+		if !inConfig {
+			setField(lastKey, trimmed)
+			continue
+		}
+
+		line := strings.TrimPrefix(raw, "\t")
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		fields := strings.Fields(line)
+
+		// The pool itself and section headers (logs, cache, spares, ...)
+		// sit at the top level, grouping vdevs are never missing devices.
+		if indent == 0 || isVdevGroup(fields[0]) {
+			continue
+		}
+
+		device := ImportableDevice{Name: fields[0]}
+		if len(fields) > 1 {
+			device.State = fields[1]
+		}
+		if len(fields) > 2 {
+			device.Message = strings.Join(fields[2:], " ")
+		}
+
+		current.Devices = append(current.Devices, device)
+
+		if missingDeviceStates[device.State] {
+			current.MissingDevices = append(current.MissingDevices, device.Name)
+		}
+	}
+
+	return pools
+}
+
+func joinWrapped(existing, value string) string {
+	if existing == "" {
+		return value
+	}
+	return existing + " " + value
+}
+
+func (z *zfs) ImportablePools() ([]ImportablePool, error) {
+	var out bytes.Buffer
+	if _, err := z.run(nil, &out, "zpool", "import"); err != nil {
+		var zErr *Error
+		if errors.As(err, &zErr) && strings.Contains(zErr.Stderr, "no pools available") {
+			return []ImportablePool{}, nil
+		}
+		return nil, err
+	}
+
+	return parseImportablePools(out.String()), nil
+}
+
+// ImportZpool imports a pool by name or numeric GUID, optionally under a
+// new name.
+func (z *zfs) ImportZpool(nameOrGUID string, opts ImportOptions) (*Zpool, error) {
+	if nameOrGUID == "" {
+		return nil, fmt.Errorf("pool name or guid cannot be empty")
+	}
+
+	cli := []string{"import"}
+	if opts.Force {
+		cli = append(cli, "-f")
+	}
+	if opts.ReadOnly {
+		cli = append(cli, "-o", "readonly=on")
+	}
+	if opts.AltRoot != "" {
+		cli = append(cli, "-R", opts.AltRoot)
+	}
+
+	cli = append(cli, nameOrGUID)
+	if opts.NewName != "" {
+		cli = append(cli, opts.NewName)
+	}
+
+	if err := z.zpool(cli...); err != nil {
+		return nil, err
+	}
+
+	name := opts.NewName
+	if name == "" {
+		pool, err := z.GetZpoolByGUID(nameOrGUID)
+		if err == nil {
+			return pool, nil
+		}
+		name = nameOrGUID
+	}
+
+	return z.GetZpool(name)
+}
+
+func (z *Zpool) Export(force bool) error {
+	cli := []string{"export"}
+	if force {
+		cli = append(cli, "-f")
+	}
+
+	return z.z.zpool(append(cli, z.Name)...)
+}
@@ -0,0 +1,80 @@
+package zfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+const zpoolImportFixture = `   pool: tank
+     id: 4489529826581349207
+  state: ONLINE
+ action: The pool can be imported using its name or numeric identifier.
+ config:
+
+	tank        ONLINE
+	  mirror-0  ONLINE
+	    ada1    ONLINE
+	    ada2    ONLINE
+
+   pool: backup
+     id: 15827442104834522376
+  state: DEGRADED
+ status: One or more devices are missing from the system.
+ action: The pool can be imported despite missing or damaged devices.  The
+	fault tolerance of the pool may be compromised if imported.
+   see: https://openzfs.github.io/openzfs-docs/msg/ZFS-8000-2Q
+ config:
+
+	backup                    DEGRADED
+	  raidz1-0                DEGRADED
+	    ada3                  ONLINE
+	    ada4                  ONLINE
+	    9310293818283844123   UNAVAIL  cannot open
+	logs
+	  ada5                    ONLINE
+`
+
+func TestParseImportablePools(t *testing.T) {
+	pools := parseImportablePools(zpoolImportFixture)
+	if len(pools) != 2 {
+		t.Fatalf("expected 2 pools, got %d", len(pools))
+	}
+
+	tank := pools[0]
+	if tank.Name != "tank" || tank.GUID != "4489529826581349207" || tank.State != "ONLINE" {
+		t.Errorf("unexpected pool header: %+v", tank)
+	}
+
+	if len(tank.Devices) != 2 || len(tank.MissingDevices) != 0 {
+		t.Errorf("unexpected devices for tank: %+v", tank.Devices)
+	}
+
+	backup := pools[1]
+	if backup.State != "DEGRADED" {
+		t.Errorf("unexpected state: %s", backup.State)
+	}
+
+	wantAction := "The pool can be imported despite missing or damaged devices.  The fault tolerance of the pool may be compromised if imported."
+	if backup.Action != wantAction {
+		t.Errorf("unexpected action: got %q, want %q", backup.Action, wantAction)
+	}
+
+	if backup.Status != "One or more devices are missing from the system." {
+		t.Errorf("unexpected status: %q", backup.Status)
+	}
+
+	if !reflect.DeepEqual(backup.MissingDevices, []string{"9310293818283844123"}) {
+		t.Errorf("unexpected missing devices: %v", backup.MissingDevices)
+	}
+
+	want := ImportableDevice{Name: "9310293818283844123", State: "UNAVAIL", Message: "cannot open"}
+	if len(backup.Devices) != 4 || backup.Devices[2] != want {
+		t.Errorf("unexpected devices for backup: %+v", backup.Devices)
+	}
+}
+
+func TestParseImportablePools_Empty(t *testing.T) {
+	if pools := parseImportablePools(""); len(pools) != 0 {
+		t.Errorf("expected no pools, got %+v", pools)
+	}
+}
@@ -0,0 +1,146 @@
+package zfs
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// VdevIOStat is one row of `zpool iostat -vlp`: a pool, a grouping vdev
+// (mirror, raidz) or a leaf device. Bandwidth is in bytes per second and
+// latencies are averages in nanoseconds.
+type VdevIOStat struct {
+	Pool           string `json:"pool"`
+	Name           string `json:"name"`
+	Parent         string `json:"parent"`
+	Type           string `json:"type"`
+	ReadOps        uint64 `json:"readOps"`
+	WriteOps       uint64 `json:"writeOps"`
+	ReadBytes      uint64 `json:"readBytes"`
+	WriteBytes     uint64 `json:"writeBytes"`
+	TotalReadWait  uint64 `json:"totalReadWait"`
+	TotalWriteWait uint64 `json:"totalWriteWait"`
+	DiskReadWait   uint64 `json:"diskReadWait"`
+	DiskWriteWait  uint64 `json:"diskWriteWait"`
+}
+
+const (
+	IOStatPool   = "pool"
+	IOStatVdev   = "vdev"
+	IOStatDevice = "device"
+)
+
+var iostatClasses = map[string]bool{
+	"logs":    true,
+	"cache":   true,
+	"spares":  true,
+	"special": true,
+	"dedup":   true,
+}
+
+func parseIOStatValue(value string) uint64 {
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseVdevIOStats parses the last report of `zpool iostat -vlp`. Rows are
+// nested through indentation, pools and the logs, cache, ... sections sit
+// at the left edge.
+func parseVdevIOStats(output string) []VdevIOStat {
+	lines := strings.Split(output, "\n")
+
+	start := 0
+	for i, line := range lines {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "capacity" {
+			start = i
+		}
+	}
+
+	stats := []VdevIOStat{}
+
+	type level struct {
+		indent int
+		name   string
+	}
+
+	var pool string
+	var parents []level
+
+	for _, line := range lines[start:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "-") ||
+			fields[0] == "capacity" || (fields[0] == "pool" && len(fields) > 1 && fields[1] == "alloc") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		name := fields[0]
+
+		if indent == 0 {
+			if pool != "" && iostatClasses[name] {
+				parents = []level{{indent: 0, name: name}}
+				continue
+			}
+
+			pool = name
+			parents = nil
+		}
+
+		if pool == "" || len(fields) < 13 {
+			continue
+		}
+
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+
+		stat := VdevIOStat{
+			Pool:           pool,
+			Name:           name,
+			Type:           IOStatDevice,
+			ReadOps:        parseIOStatValue(fields[3]),
+			WriteOps:       parseIOStatValue(fields[4]),
+			ReadBytes:      parseIOStatValue(fields[5]),
+			WriteBytes:     parseIOStatValue(fields[6]),
+			TotalReadWait:  parseIOStatValue(fields[7]),
+			TotalWriteWait: parseIOStatValue(fields[8]),
+			DiskReadWait:   parseIOStatValue(fields[9]),
+			DiskWriteWait:  parseIOStatValue(fields[10]),
+		}
+
+		switch {
+		case indent == 0:
+			stat.Type = IOStatPool
+		case isVdevGroup(name):
+			stat.Type = IOStatVdev
+		}
+
+		if len(parents) > 0 {
+			stat.Parent = parents[len(parents)-1].name
+		}
+
+		parents = append(parents, level{indent: indent, name: name})
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// VdevIOStats samples I/O statistics of all pools over interval seconds.
+// The first report of zpool iostat covers the time since import and is
+// skipped.
+func (z *zfs) VdevIOStats(interval int) ([]VdevIOStat, error) {
+	if interval <= 0 {
+		interval = 1
+	}
+
+	var out bytes.Buffer
+	if _, err := z.run(nil, &out, "zpool", "iostat", "-vlp", strconv.Itoa(interval), "2"); err != nil {
+		return nil, err
+	}
+
+	return parseVdevIOStats(out.String()), nil
+}
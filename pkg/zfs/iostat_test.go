@@ -0,0 +1,51 @@
+package zfs
+
+import "testing"
+
+const zpoolIOStatFixture = `              capacity     operations     bandwidth    total_wait     disk_wait    syncq_wait    asyncq_wait  scrub   trim
+pool        alloc   free   read  write   read  write   read  write   read  write   read  write   read  write   wait   wait
+----------  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----
+tank         1000   9000    900    900   9000   9000      9      9      9      9      -      -      -      -      -      -
+  mirror-0   1000   9000    900    900   9000   9000      9      9      9      9      -      -      -      -      -      -
+    ada1        -      -    450    450   4500   4500      9      9      9      9      -      -      -      -      -      -
+    ada2        -      -    450    450   4500   4500      9      9      9      9      -      -      -      -      -      -
+----------  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----
+              capacity     operations     bandwidth    total_wait     disk_wait    syncq_wait    asyncq_wait  scrub   trim
+pool        alloc   free   read  write   read  write   read  write   read  write   read  write   read  write   wait   wait
+----------  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----
+tank         1000   9000     20     10  81920  40960 250000 500000 200000 400000      -      -      -      -      -      -
+  mirror-0   1000   9000     20     10  81920  40960 250000 500000 200000 400000      -      -      -      -      -      -
+    ada1        -      -     15      5  61440  20480 100000 300000  90000 250000      -      -      -      -      -      -
+    ada2        -      -      5      5  20480  20480 700000 700000 650000 600000      -      -      -      -      -      -
+logs            -      -      -      -      -      -      -      -      -      -      -      -      -      -      -      -
+  ada3          0   1000      0      3      0  12288  50000  60000  40000  50000      -      -      -      -      -      -
+----------  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----
+`
+
+func TestParseVdevIOStats(t *testing.T) {
+	stats := parseVdevIOStats(zpoolIOStatFixture)
+	if len(stats) != 5 {
+		t.Fatalf("expected 5 rows from the last report, got %d: %+v", len(stats), stats)
+	}
+
+	if stats[0].Type != IOStatPool || stats[0].Name != "tank" || stats[0].ReadOps != 20 {
+		t.Errorf("unexpected pool row: %+v", stats[0])
+	}
+
+	if stats[1].Type != IOStatVdev || stats[1].Parent != "tank" {
+		t.Errorf("unexpected vdev row: %+v", stats[1])
+	}
+
+	slow := stats[3]
+	if slow.Name != "ada2" || slow.Type != IOStatDevice || slow.Parent != "mirror-0" || slow.Pool != "tank" {
+		t.Errorf("unexpected device row: %+v", slow)
+	}
+	if slow.ReadBytes != 20480 || slow.TotalReadWait != 700000 || slow.DiskWriteWait != 600000 {
+		t.Errorf("unexpected device stats: %+v", slow)
+	}
+
+	log := stats[4]
+	if log.Name != "ada3" || log.Parent != "logs" || log.Pool != "tank" || log.WriteOps != 3 {
+		t.Errorf("unexpected log device row: %+v", log)
+	}
+}
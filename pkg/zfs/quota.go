@@ -0,0 +1,141 @@
+package zfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	QuotaUser    = "user"
+	QuotaGroup   = "group"
+	QuotaProject = "project"
+)
+
+// SpaceUsage is one row of `zfs userspace`, `zfs groupspace` or
+// `zfs projectspace`. A zero quota means none is set.
+type SpaceUsage struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Used     uint64 `json:"used"`
+	Quota    uint64 `json:"quota"`
+	ObjUsed  uint64 `json:"objUsed"`
+	ObjQuota uint64 `json:"objQuota"`
+}
+
+// Available is the space left to the user, group or project: what is left
+// of its quota, capped by what is left on the dataset.
+func (u SpaceUsage) Available(datasetAvail uint64) uint64 {
+	if u.Quota == 0 {
+		return datasetAvail
+	}
+
+	if u.Used >= u.Quota {
+		return 0
+	}
+
+	return min(u.Quota-u.Used, datasetAvail)
+}
+
+const spaceFields = "name,used,quota,objused,objquota"
+
+func parseSpaceUint(value string) uint64 {
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseSpaceUsage parses tab separated `-Hp -o name,used,quota,objused,objquota`
+// output. Names may contain spaces, so lines are not split on whitespace.
+func parseSpaceUsage(kind string, output string) []SpaceUsage {
+	usage := []SpaceUsage{}
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 {
+			continue
+		}
+
+		usage = append(usage, SpaceUsage{
+			Type:     kind,
+			Name:     fields[0],
+			Used:     parseSpaceUint(fields[1]),
+			Quota:    parseSpaceUint(fields[2]),
+			ObjUsed:  parseSpaceUint(fields[3]),
+			ObjQuota: parseSpaceUint(fields[4]),
+		})
+	}
+
+	return usage
+}
+
+// SpaceUsage lists the space charged to each POSIX user, group or project
+// on the dataset, kind being one of QuotaUser, QuotaGroup or QuotaProject.
+func (d *Dataset) SpaceUsage(kind string) ([]SpaceUsage, error) {
+	if d.Type != DatasetFilesystem && d.Type != DatasetSnapshot {
+		return nil, errors.New("space accounting is only available for filesystems")
+	}
+
+	args := []string{kind + "space", "-Hp", "-o", spaceFields}
+
+	switch kind {
+	case QuotaUser:
+		args = append(args, "-t", "posixuser")
+	case QuotaGroup:
+		args = append(args, "-t", "posixgroup")
+	case QuotaProject:
+	default:
+		return nil, fmt.Errorf("invalid quota type %s", kind)
+	}
+
+	var out bytes.Buffer
+	if _, err := d.z.run(nil, &out, "zfs", append(args, d.Name)...); err != nil {
+		return nil, err
+	}
+
+	return parseSpaceUsage(kind, out.String()), nil
+}
+
+// SetSpaceQuota sets the {user,group,project}quota@ and matching objquota@
+// properties for one user, group or project. Zero clears a quota, nil leaves
+// it unchanged.
+func (d *Dataset) SetSpaceQuota(kind string, name string, quota *uint64, objQuota *uint64) error {
+	if kind != QuotaUser && kind != QuotaGroup && kind != QuotaProject {
+		return fmt.Errorf("invalid quota type %s", kind)
+	}
+
+	if name == "" || strings.ContainsAny(name, "=@ \t") {
+		return fmt.Errorf("invalid quota name %q", name)
+	}
+
+	var keyValPairs []string
+
+	value := func(n uint64) string {
+		if n == 0 {
+			return "none"
+		}
+		return strconv.FormatUint(n, 10)
+	}
+
+	if quota != nil {
+		keyValPairs = append(keyValPairs, kind+"quota@"+name, value(*quota))
+	}
+
+	if objQuota != nil {
+		keyValPairs = append(keyValPairs, kind+"objquota@"+name, value(*objQuota))
+	}
+
+	if len(keyValPairs) == 0 {
+		return nil
+	}
+
+	return d.SetProperties(keyValPairs...)
+}
@@ -0,0 +1,49 @@
+package zfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+const zfsUserspaceFixture = "alice\t1073741824\t10737418240\t1532\tnone\n" +
+	"bob\t524288\tnone\t12\t1000\n" +
+	"domain user\t4096\tnone\t1\tnone\n"
+
+func TestParseSpaceUsage(t *testing.T) {
+	usage := parseSpaceUsage(QuotaUser, zfsUserspaceFixture)
+
+	want := []SpaceUsage{
+		{Type: QuotaUser, Name: "alice", Used: 1073741824, Quota: 10737418240, ObjUsed: 1532},
+		{Type: QuotaUser, Name: "bob", Used: 524288, ObjUsed: 12, ObjQuota: 1000},
+		{Type: QuotaUser, Name: "domain user", Used: 4096, ObjUsed: 1},
+	}
+
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("expected %+v, got %+v", want, usage)
+	}
+}
+
+func TestParseSpaceUsageEmpty(t *testing.T) {
+	if usage := parseSpaceUsage(QuotaGroup, ""); len(usage) != 0 {
+		t.Errorf("expected no entries, got %+v", usage)
+	}
+}
+
+func TestSpaceUsageAvailable(t *testing.T) {
+	cases := []struct {
+		usage SpaceUsage
+		avail uint64
+		want  uint64
+	}{
+		{SpaceUsage{Used: 10}, 100, 100},
+		{SpaceUsage{Used: 10, Quota: 50}, 100, 40},
+		{SpaceUsage{Used: 10, Quota: 500}, 100, 100},
+		{SpaceUsage{Used: 60, Quota: 50}, 100, 0},
+	}
+
+	for _, c := range cases {
+		if got := c.usage.Available(c.avail); got != c.want {
+			t.Errorf("%+v.Available(%d) = %d, want %d", c.usage, c.avail, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package zfs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alchemillahq/sylve/pkg/utils"
+)
+
+const (
+	ScanStateNone     = "none"
+	ScanStateScanning = "scanning"
+	ScanStatePaused   = "paused"
+	ScanStateFinished = "finished"
+	ScanStateCanceled = "canceled"
+)
+
+type ScanStatus struct {
+	Function  string     `json:"function"`
+	State     string     `json:"state"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Scanned   uint64     `json:"scanned"`
+	ScanRate  uint64     `json:"scanRate"`
+	Issued    uint64     `json:"issued"`
+	IssueRate uint64     `json:"issueRate"`
+	Total     uint64     `json:"total"`
+	Repaired  uint64     `json:"repaired"`
+	Percent   float64    `json:"percent"`
+	ETA       string     `json:"eta,omitempty"`
+	Duration  string     `json:"duration,omitempty"`
+	Errors    uint64     `json:"errors"`
+}
+
+const scanTimeLayout = "Mon Jan _2 15:04:05 2006"
+
+var (
+	scanDateRe       = `([A-Z][a-z]{2} [A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2} \d{4})`
+	scanInProgressRe = regexp.MustCompile(`^(scrub|resilver) in progress since ` + scanDateRe)
+	scanPausedRe     = regexp.MustCompile(`^(scrub) paused since ` + scanDateRe)
+	scanStartedOnRe  = regexp.MustCompile(`started on ` + scanDateRe)
+	scanFinishedRe   = regexp.MustCompile(`^(?:scrub repaired|(resilver)ed) (\S+) in (.+?) with (\d+) errors on ` + scanDateRe)
+	scanCanceledRe   = regexp.MustCompile(`^(scrub|resilver) canceled on ` + scanDateRe)
+	scanProgressRe   = regexp.MustCompile(`(\S+) scanned(?: at (\S+)/s)?, (\S+) issued(?: at (\S+)/s)?, (\S+) total`)
+	scanDoneRe       = regexp.MustCompile(`(\S+) (?:repaired|resilvered), ([\d.]+)% done(?:, (.+?) to go)?`)
+)
+
+func parseScanTime(value string) *time.Time {
+	t, err := time.ParseInLocation(scanTimeLayout, strings.Join(strings.Fields(value), " "), time.Local)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// ParseScanStatus parses the scan line of `zpool status`, with continuation
+// lines joined by spaces, into a structured progress or result.
+func ParseScanStatus(scan string) ScanStatus {
+	scan = strings.Join(strings.Fields(scan), " ")
+	status := ScanStatus{State: ScanStateNone}
+
+	switch {
+	case scanInProgressRe.MatchString(scan):
+		m := scanInProgressRe.FindStringSubmatch(scan)
+		status.Function = m[1]
+		status.State = ScanStateScanning
+		status.StartedAt = parseScanTime(m[2])
+
+	case scanPausedRe.MatchString(scan):
+		m := scanPausedRe.FindStringSubmatch(scan)
+		status.Function = m[1]
+		status.State = ScanStatePaused
+		if started := scanStartedOnRe.FindStringSubmatch(scan); started != nil {
+			status.StartedAt = parseScanTime(started[1])
+		}
+
+	case scanFinishedRe.MatchString(scan):
+		m := scanFinishedRe.FindStringSubmatch(scan)
+		status.Function = "scrub"
+		if m[1] != "" {
+			status.Function = "resilver"
+		}
+		status.State = ScanStateFinished
+		status.Repaired = utils.HumanFormatToSize(m[2])
+		status.Duration = m[3]
+		status.Errors, _ = strconv.ParseUint(m[4], 10, 64)
+		status.EndedAt = parseScanTime(m[5])
+		status.Percent = 100
+		return status
+
+	case scanCanceledRe.MatchString(scan):
+		m := scanCanceledRe.FindStringSubmatch(scan)
+		status.Function = m[1]
+		status.State = ScanStateCanceled
+		status.EndedAt = parseScanTime(m[2])
+		return status
+
+	default:
+		return status
+	}
+
+	if m := scanProgressRe.FindStringSubmatch(scan); m != nil {
+		status.Scanned = utils.HumanFormatToSize(m[1])
+		status.ScanRate = utils.HumanFormatToSize(m[2])
+		status.Issued = utils.HumanFormatToSize(m[3])
+		status.IssueRate = utils.HumanFormatToSize(m[4])
+		status.Total = utils.HumanFormatToSize(m[5])
+	}
+
+	if m := scanDoneRe.FindStringSubmatch(scan); m != nil {
+		status.Repaired = utils.HumanFormatToSize(m[1])
+		status.Percent, _ = strconv.ParseFloat(m[2], 64)
+		status.ETA = m[3]
+	}
+
+	return status
+}
+
+// Scrub starts a scrub, or resumes one that was paused.
+func (z *Zpool) Scrub() error {
+	return z.z.zpool("scrub", z.Name)
+}
+
+func (z *Zpool) PauseScrub() error {
+	return z.z.zpool("scrub", "-p", z.Name)
+}
+
+func (z *Zpool) CancelScrub() error {
+	return z.z.zpool("scrub", "-s", z.Name)
+}
+
+func (z *Zpool) ScanStatus() (ScanStatus, error) {
+	status, err := z.z.GetZpoolStatus(z.Name)
+	if err != nil {
+		return ScanStatus{}, err
+	}
+
+	return status.ScanStatus, nil
+}
@@ -0,0 +1,100 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScanStatus_InProgress(t *testing.T) {
+	scan := "scrub in progress since Sun Jul 25 16:07:49 2021 " +
+		"1.26T scanned at 1.02G/s, 300G issued at 243M/s, 1.26T total " +
+		"0B repaired, 23.17% done, 01:07:52 to go"
+
+	status := ParseScanStatus(scan)
+
+	if status.Function != "scrub" || status.State != ScanStateScanning {
+		t.Fatalf("unexpected function/state: %s/%s", status.Function, status.State)
+	}
+
+	want := time.Date(2021, time.July, 25, 16, 7, 49, 0, time.Local)
+	if status.StartedAt == nil || !status.StartedAt.Equal(want) {
+		t.Errorf("unexpected start time: %v", status.StartedAt)
+	}
+
+	if status.Scanned != 1385384650997 || status.Issued != 300<<30 || status.Total != 1385384650997 {
+		t.Errorf("unexpected sizes: %+v", status)
+	}
+
+	if status.IssueRate != 243<<20 {
+		t.Errorf("unexpected issue rate: %d", status.IssueRate)
+	}
+
+	if status.Percent != 23.17 || status.ETA != "01:07:52" {
+		t.Errorf("unexpected progress: %v%% eta %q", status.Percent, status.ETA)
+	}
+}
+
+func TestParseScanStatus_Parsable(t *testing.T) {
+	scan := "resilver in progress since Sat Oct 17 10:00:00 2026 " +
+		"1073741824 scanned at 10485760/s, 536870912 issued at 5242880/s, 2147483648 total " +
+		"268435456 resilvered, 25.00% done, no estimated completion time"
+
+	status := ParseScanStatus(scan)
+
+	if status.Function != "resilver" || status.State != ScanStateScanning {
+		t.Fatalf("unexpected function/state: %s/%s", status.Function, status.State)
+	}
+
+	if status.Scanned != 1073741824 || status.ScanRate != 10485760 || status.Total != 2147483648 {
+		t.Errorf("unexpected sizes: %+v", status)
+	}
+
+	if status.Repaired != 268435456 || status.Percent != 25 || status.ETA != "" {
+		t.Errorf("unexpected progress: %+v", status)
+	}
+}
+
+func TestParseScanStatus_Finished(t *testing.T) {
+	status := ParseScanStatus("scrub repaired 1M in 00:11:22 with 2 errors on Sun Oct  5 00:35:23 2025")
+
+	if status.Function != "scrub" || status.State != ScanStateFinished {
+		t.Fatalf("unexpected function/state: %s/%s", status.Function, status.State)
+	}
+
+	if status.Repaired != 1<<20 || status.Errors != 2 || status.Duration != "00:11:22" {
+		t.Errorf("unexpected result: %+v", status)
+	}
+
+	want := time.Date(2025, time.October, 5, 0, 35, 23, 0, time.Local)
+	if status.EndedAt == nil || !status.EndedAt.Equal(want) {
+		t.Errorf("unexpected end time: %v", status.EndedAt)
+	}
+
+	resilver := ParseScanStatus("resilvered 10.2G in 0 days 00:05:12 with 0 errors on Sat Oct 17 10:05:12 2026")
+	if resilver.Function != "resilver" || resilver.Duration != "0 days 00:05:12" {
+		t.Errorf("unexpected resilver result: %+v", resilver)
+	}
+}
+
+func TestParseScanStatus_PausedAndCanceled(t *testing.T) {
+	paused := ParseScanStatus("scrub paused since Sat Oct 17 09:00:00 2026 " +
+		"scrub started on Sat Oct 17 08:00:00 2026 " +
+		"500G scanned, 100G issued, 1T total 0B repaired, 10.00% done")
+
+	if paused.State != ScanStatePaused || paused.StartedAt == nil || paused.StartedAt.Hour() != 8 {
+		t.Errorf("unexpected paused status: %+v", paused)
+	}
+
+	if paused.Scanned != 500<<30 || paused.ScanRate != 0 || paused.Percent != 10 {
+		t.Errorf("unexpected paused progress: %+v", paused)
+	}
+
+	canceled := ParseScanStatus("scrub canceled on Sat Oct 17 09:30:00 2026")
+	if canceled.State != ScanStateCanceled || canceled.EndedAt == nil {
+		t.Errorf("unexpected canceled status: %+v", canceled)
+	}
+
+	if none := ParseScanStatus("none requested"); none.State != ScanStateNone {
+		t.Errorf("unexpected status for no scan: %+v", none)
+	}
+}
@@ -0,0 +1,266 @@
+package zfs
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type TopologyVdev struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	State   string   `json:"state"`
+	Devices []string `json:"devices"`
+}
+
+type ZpoolTopology struct {
+	Data    []TopologyVdev `json:"data"`
+	Log     []TopologyVdev `json:"log"`
+	Special []TopologyVdev `json:"special"`
+	Dedup   []TopologyVdev `json:"dedup"`
+	Cache   []string       `json:"cache"`
+	Spares  []string       `json:"spares"`
+}
+
+// Width is the number of device slots in the vdev, a device that is being
+// replaced is counted once.
+func (v TopologyVdev) Width() int {
+	return len(v.Devices)
+}
+
+// isVdevGroup reports whether a name in the zpool status config is a
+// grouping vdev such as mirror-0, raidz2-1, replacing-0 or spare-2.
+func isVdevGroup(name string) bool {
+	if strings.HasPrefix(name, "/") {
+		return false
+	}
+
+	idx := strings.LastIndex(name, "-")
+	if idx <= 0 {
+		return false
+	}
+
+	_, err := strconv.Atoi(name[idx+1:])
+	return err == nil
+}
+
+func vdevGroupType(name string) string {
+	t := name[:strings.LastIndex(name, "-")]
+	if t == "raidz" {
+		return "raidz1"
+	}
+	return t
+}
+
+func (t *ZpoolTopology) section(name string) *[]TopologyVdev {
+	switch name {
+	case "logs":
+		return &t.Log
+	case "special":
+		return &t.Special
+	case "dedup":
+		return &t.Dedup
+	default:
+		return &t.Data
+	}
+}
+
+// parseZpoolTopology parses the config section of `zpool status -P`. The
+// plain output is used because the vdev hierarchy is only expressed through
+// indentation.
+func parseZpoolTopology(pool string, output string) (*ZpoolTopology, error) {
+	topology := &ZpoolTopology{
+		Data:    []TopologyVdev{},
+		Log:     []TopologyVdev{},
+		Special: []TopologyVdev{},
+		Dedup:   []TopologyVdev{},
+		Cache:   []string{},
+		Spares:  []string{},
+	}
+
+	inConfig := false
+	foundPool := false
+	section := ""
+
+	var current *TopologyVdev
+	nested, nestedCounted := false, false
+
+	for _, raw := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(raw)
+
+		if !inConfig {
+			if trimmed == "config:" {
+				inConfig = true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "errors:") {
+			break
+		}
+
+		if trimmed == "" {
+			continue
+		}
+
+		line := strings.TrimPrefix(raw, "\t")
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		fields := strings.Fields(line)
+
+		if fields[0] == "NAME" {
+			continue
+		}
+
+		state := ""
+		if len(fields) > 1 {
+			state = fields[1]
+		}
+
+		switch {
+		case indent == 0:
+			current = nil
+			if fields[0] == pool {
+				foundPool = true
+				section = "data"
+			} else {
+				section = fields[0]
+			}
+
+		case indent == 2:
+			current = nil
+			switch section {
+			case "cache":
+				topology.Cache = append(topology.Cache, fields[0])
+			case "spares":
+				topology.Spares = append(topology.Spares, fields[0])
+			default:
+				vdevs := topology.section(section)
+				vdev := TopologyVdev{Name: fields[0], State: state, Devices: []string{}}
+
+				if isVdevGroup(fields[0]) {
+					vdev.Type = vdevGroupType(fields[0])
+					if vdev.Type == "replacing" || vdev.Type == "spare" {
+						// A single disk that is being replaced or covered by
+						// a spare, its first child is the original disk.
+						vdev.Type = "disk"
+					}
+				} else {
+					vdev.Type = "disk"
+					vdev.Devices = append(vdev.Devices, fields[0])
+				}
+
+				*vdevs = append(*vdevs, vdev)
+				current = &(*vdevs)[len(*vdevs)-1]
+			}
+
+		default:
+			if current == nil {
+				continue
+			}
+
+			if current.Type == "disk" {
+				if len(current.Devices) == 0 && !isVdevGroup(fields[0]) {
+					current.Devices = append(current.Devices, fields[0])
+				}
+				continue
+			}
+
+			if isVdevGroup(fields[0]) {
+				nested = true
+				nestedCounted = false
+				continue
+			}
+
+			if indent == 4 {
+				nested = false
+				current.Devices = append(current.Devices, fields[0])
+			} else if nested && !nestedCounted {
+				// Devices under replacing-N or spare-N occupy a single slot.
+				nestedCounted = true
+				current.Devices = append(current.Devices, fields[0])
+			}
+		}
+	}
+
+	if !foundPool {
+		return nil, fmt.Errorf("pool %s not found in status output", pool)
+	}
+
+	return topology, nil
+}
+
+func (z *zfs) GetZpoolTopology(name string) (*ZpoolTopology, error) {
+	var out bytes.Buffer
+	if _, err := z.run(nil, &out, "zpool", "status", "-P", name); err != nil {
+		return nil, err
+	}
+
+	return parseZpoolTopology(name, out.String())
+}
+
+func (z *Zpool) Topology() (*ZpoolTopology, error) {
+	return z.z.GetZpoolTopology(z.Name)
+}
+
+// Add adds vdevs to the pool, args follow the same layout as zpool create
+// (e.g. "mirror", "/dev/ada1", "/dev/ada2" or "log", "/dev/nvd0"). With
+// dryRun set zpool only validates the layout without modifying the pool.
+func (z *Zpool) Add(force bool, dryRun bool, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no vdevs specified")
+	}
+
+	cli := []string{"add"}
+	if force {
+		cli = append(cli, "-f")
+	}
+	if dryRun {
+		cli = append(cli, "-n")
+	}
+
+	cli = append(cli, z.Name)
+	cli = append(cli, args...)
+
+	return z.z.zpool(cli...)
+}
+
+func (z *Zpool) Attach(device string, newDevice string, force bool) error {
+	if device == "" || newDevice == "" {
+		return fmt.Errorf("device cannot be empty")
+	}
+
+	cli := []string{"attach"}
+	if force {
+		cli = append(cli, "-f")
+	}
+
+	cli = append(cli, z.Name, device, newDevice)
+
+	return z.z.zpool(cli...)
+}
+
+func (z *Zpool) Detach(device string) error {
+	if device == "" {
+		return fmt.Errorf("device cannot be empty")
+	}
+
+	return z.z.zpool("detach", z.Name, device)
+}
+
+// RemoveVdev evacuates and removes a top-level vdev, log, cache or special
+// device. With dryRun set zpool only reports whether the removal is possible.
+func (z *Zpool) RemoveVdev(name string, dryRun bool) error {
+	if name == "" {
+		return fmt.Errorf("vdev cannot be empty")
+	}
+
+	cli := []string{"remove"}
+	if dryRun {
+		cli = append(cli, "-n")
+	}
+
+	cli = append(cli, z.Name, name)
+
+	return z.z.zpool(cli...)
+}
@@ -0,0 +1,108 @@
+package zfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+const zpoolStatusFixture = `  pool: tank
+ state: DEGRADED
+status: One or more devices is currently being resilvered.
+  scan: resilver in progress since Sat Oct 17 10:00:00 2026
+config:
+
+	NAME                  STATE     READ WRITE CKSUM
+	tank                  DEGRADED     0     0     0
+	  mirror-0            ONLINE       0     0     0
+	    /dev/ada0         ONLINE       0     0     0
+	    /dev/ada1         ONLINE       0     0     0
+	  raidz2-1            DEGRADED     0     0     0
+	    /dev/ada2         ONLINE       0     0     0
+	    /dev/ada3         ONLINE       0     0     0
+	    replacing-2       DEGRADED     0     0     0
+	      /dev/ada4       FAULTED      0     0     0  too many errors
+	      /dev/ada9       ONLINE       0     0     0  (resilvering)
+	    /dev/ada5         ONLINE       0     0     0
+	special
+	  mirror-2            ONLINE       0     0     0
+	    /dev/nvd0         ONLINE       0     0     0
+	    /dev/nvd1         ONLINE       0     0     0
+	logs
+	  /dev/nvd2           ONLINE       0     0     0
+	cache
+	  /dev/nvd3           ONLINE       0     0     0
+	spares
+	  /dev/ada6           AVAIL
+
+errors: No known data errors
+`
+
+func TestParseZpoolTopology(t *testing.T) {
+	topology, err := parseZpoolTopology("tank", zpoolStatusFixture)
+	if err != nil {
+		t.Fatalf("parseZpoolTopology failed: %v", err)
+	}
+
+	data := []TopologyVdev{
+		{Name: "mirror-0", Type: "mirror", State: "ONLINE", Devices: []string{"/dev/ada0", "/dev/ada1"}},
+		{Name: "raidz2-1", Type: "raidz2", State: "DEGRADED", Devices: []string{"/dev/ada2", "/dev/ada3", "/dev/ada4", "/dev/ada5"}},
+	}
+
+	if !reflect.DeepEqual(topology.Data, data) {
+		t.Errorf("unexpected data vdevs: got %+v, want %+v", topology.Data, data)
+	}
+
+	special := []TopologyVdev{
+		{Name: "mirror-2", Type: "mirror", State: "ONLINE", Devices: []string{"/dev/nvd0", "/dev/nvd1"}},
+	}
+
+	if !reflect.DeepEqual(topology.Special, special) {
+		t.Errorf("unexpected special vdevs: got %+v, want %+v", topology.Special, special)
+	}
+
+	logs := []TopologyVdev{
+		{Name: "/dev/nvd2", Type: "disk", State: "ONLINE", Devices: []string{"/dev/nvd2"}},
+	}
+
+	if !reflect.DeepEqual(topology.Log, logs) {
+		t.Errorf("unexpected log vdevs: got %+v, want %+v", topology.Log, logs)
+	}
+
+	if !reflect.DeepEqual(topology.Cache, []string{"/dev/nvd3"}) {
+		t.Errorf("unexpected cache devices: %v", topology.Cache)
+	}
+
+	if !reflect.DeepEqual(topology.Spares, []string{"/dev/ada6"}) {
+		t.Errorf("unexpected spares: %v", topology.Spares)
+	}
+
+	if len(topology.Dedup) != 0 {
+		t.Errorf("expected no dedup vdevs, got %v", topology.Dedup)
+	}
+}
+
+func TestParseZpoolTopology_ReplacingDisk(t *testing.T) {
+	output := `config:
+
+	NAME              STATE     READ WRITE CKSUM
+	zroot             ONLINE       0     0     0
+	  replacing-0     ONLINE       0     0     0
+	    /dev/ada0p3   ONLINE       0     0     0
+	    /dev/ada1p3   ONLINE       0     0     0
+`
+
+	topology, err := parseZpoolTopology("zroot", output)
+	if err != nil {
+		t.Fatalf("parseZpoolTopology failed: %v", err)
+	}
+
+	if len(topology.Data) != 1 || topology.Data[0].Type != "disk" || topology.Data[0].Width() != 1 {
+		t.Fatalf("expected a single disk vdev, got %+v", topology.Data)
+	}
+}
+
+func TestParseZpoolTopology_MissingPool(t *testing.T) {
+	if _, err := parseZpoolTopology("tank", "config:\n\n\tNAME STATE\n\tother ONLINE\n"); err == nil {
+		t.Fatal("expected an error for a missing pool")
+	}
+}
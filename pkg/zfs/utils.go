@@ -72,6 +72,10 @@ func (d *Dataset) parseProps(out [][]string) error {
 	setString(&d.ACLMode, d.Props["aclmode"])
 	setString(&d.PrimaryCache, d.Props["primarycache"])
 	setString(&d.VolMode, d.Props["volmode"])
+	setString(&d.Encryption, d.Props["encryption"])
+	setString(&d.EncryptionRoot, d.Props["encroot"])
+	setString(&d.KeyStatus, d.Props["keystatus"])
+	setString(&d.KeyFormat, d.Props["keyformat"])
 
 	if err = setUint(&d.Used, d.Props["used"]); err != nil {
 		return fmt.Errorf("failed to parse used: %w", err)
@@ -3,11 +3,9 @@ package zfs
 import (
 	"fmt"
 	"io"
-	"os"
 	"strconv"
 
 	"github.com/alchemillahq/sylve/pkg/exe"
-	"github.com/alchemillahq/sylve/pkg/utils"
 )
 
 type InodeType int
@@ -18,6 +16,7 @@ const (
 	DatasetFilesystem = "filesystem"
 	DatasetSnapshot   = "snapshot"
 	DatasetVolume     = "volume"
+	DatasetBookmark   = "bookmark"
 )
 
 const (
@@ -52,14 +51,21 @@ type ZFS interface {
 	Volumes(filter string) ([]*Dataset, error)
 	Snapshots(filter string) ([]*Dataset, error)
 	ReceiveSnapshot(input io.Reader, name string, force ...bool) (*Dataset, error)
+	ReceiveResumable(input io.Reader, name string, force bool) (*Dataset, error)
+	ResumeSend(token string, output io.Writer) error
+	SnapshotDatasets(datasets []string, name string) error
 
 	ListZpools() ([]*Zpool, error)
 	GetZpool(name string) (*Zpool, error)
 	GetZpoolByGUID(guid string) (*Zpool, error)
+	GetZpoolTopology(name string) (*ZpoolTopology, error)
+	ImportablePools() ([]ImportablePool, error)
+	ImportZpool(nameOrGUID string, opts ImportOptions) (*Zpool, error)
 	ScrubPool(name string) error
 	CreateZpool(name string, properties map[string]string, args ...string) (*Zpool, error)
 	GetPoolIODelay(poolName string) (float64, error)
 	GetTotalIODelay() float64
+	VdevIOStats(interval int) ([]VdevIOStat, error)
 	SetZpoolProperty(pool string, property string, value string) error
 }
 
@@ -110,6 +116,39 @@ func (z *zfs) ReceiveSnapshot(input io.Reader, name string, force ...bool) (*Dat
 	return z.GetDataset(name)
 }
 
+func (z *zfs) ReceiveResumable(input io.Reader, name string, force bool) (*Dataset, error) {
+	args := []string{"receive", "-s", "-u"}
+	if force {
+		args = append(args, "-F")
+	}
+	args = append(args, name)
+	if _, err := z.run(input, nil, "zfs", args...); err != nil {
+		return nil, err
+	}
+	return z.GetDataset(name)
+}
+
+func (z *zfs) ResumeSend(token string, output io.Writer) error {
+	_, err := z.run(nil, output, "zfs", "send", "-t", token)
+	return err
+}
+
+// SnapshotDatasets snapshots every dataset under the same snapshot name in a
+// single command, zfs takes these atomically as long as all the datasets
+// are in the same pool.
+func (z *zfs) SnapshotDatasets(datasets []string, name string) error {
+	if len(datasets) == 0 {
+		return fmt.Errorf("no datasets to snapshot")
+	}
+
+	args := []string{"snapshot"}
+	for _, ds := range datasets {
+		args = append(args, fmt.Sprintf("%s@%s", ds, name))
+	}
+
+	return z.do(args...)
+}
+
 func (z *zfs) CreateVolume(name string, size uint64, properties map[string]string) (*Dataset, error) {
 	args := make([]string, 4, 5)
 	args[0] = "create"
@@ -117,29 +156,11 @@ func (z *zfs) CreateVolume(name string, size uint64, properties map[string]strin
 	args[2] = "-V"
 	args[3] = strconv.FormatUint(size, 10)
 
-	if _, ok := properties["encryptionKey"]; ok {
-		if properties["encryptionKey"] != "" && properties["encryption"] != "off" {
-			if len([]byte(properties["encryptionKey"])) < 32 || len([]byte(properties["encryptionKey"])) > 512 {
-				return nil, fmt.Errorf("invalid_encryption_key_length")
-			}
-
-			seed := fmt.Sprintf("%s-%s", name, properties["encryptionKey"])
-			randomFile := fmt.Sprintf("/etc/zfs/keys/%s", utils.GenerateDeterministicUUID(seed))
-
-			if _, err := os.Stat(randomFile); err == nil {
-				return nil, fmt.Errorf("dont_reuse_encryption_keys")
-			}
-
-			if err := os.WriteFile(randomFile, []byte(properties["encryptionKey"]), 0600); err != nil {
-				return nil, fmt.Errorf("failed_to_write_encryption_key")
-			}
-
-			properties["keylocation"] = fmt.Sprintf("file://%s", randomFile)
-			properties["keyformat"] = "passphrase"
-		}
+	input, err := encryptionInput(properties)
+	if err != nil {
+		return nil, err
 	}
 
-	delete(properties, "encryptionKey")
 	delete(properties, "parent")
 	delete(properties, "size")
 
@@ -148,7 +169,7 @@ func (z *zfs) CreateVolume(name string, size uint64, properties map[string]strin
 	}
 
 	args = append(args, name)
-	if err := z.do(args...); err != nil {
+	if _, err := z.run(input, nil, "zfs", args...); err != nil {
 		return nil, err
 	}
 
@@ -191,30 +212,11 @@ func (z *zfs) CreateFilesystem(name string, properties map[string]string) (*Data
 	args := make([]string, 1, 4)
 	args[0] = "create"
 
-	if _, ok := properties["encryptionKey"]; ok {
-		if properties["encryptionKey"] != "" && properties["encryption"] != "off" {
-			if len([]byte(properties["encryptionKey"])) < 32 || len([]byte(properties["encryptionKey"])) > 512 {
-				return nil, fmt.Errorf("invalid_encryption_key_length")
-			}
-
-			seed := fmt.Sprintf("%s-%s", name, properties["encryptionKey"])
-			randomFile := fmt.Sprintf("/etc/zfs/keys/%s", utils.GenerateDeterministicUUID(seed))
-
-			if _, err := os.Stat(randomFile); err == nil {
-				return nil, fmt.Errorf("dont_reuse_encryption_keys")
-			}
-
-			if err := os.WriteFile(randomFile, []byte(properties["encryptionKey"]), 0600); err != nil {
-				return nil, fmt.Errorf("failed_to_write_encryption_key")
-			}
-
-			properties["keylocation"] = fmt.Sprintf("file://%s", randomFile)
-			properties["keyformat"] = "passphrase"
-		}
+	input, err := encryptionInput(properties)
+	if err != nil {
+		return nil, err
 	}
 
-	delete(properties, "encryptionKey")
-
 	if _, ok := properties["quota"]; ok {
 		if properties["quota"] == "" {
 			delete(properties, "quota")
@@ -226,7 +228,7 @@ func (z *zfs) CreateFilesystem(name string, properties map[string]string) (*Data
 	}
 
 	args = append(args, name)
-	if err := z.do(args...); err != nil {
+	if _, err := z.run(input, nil, "zfs", args...); err != nil {
 		return nil, err
 	}
 	return z.GetDataset(name)
@@ -91,13 +91,14 @@ type ZpoolDevice struct {
 }
 
 type ZpoolStatus struct {
-	Name    string         `json:"name"`
-	State   string         `json:"state"`
-	Status  string         `json:"status"`
-	Action  string         `json:"action"`
-	Scan    string         `json:"scan"`
-	Devices []*ZpoolDevice `json:"devices"`
-	Errors  string         `json:"errors"`
+	Name       string         `json:"name"`
+	State      string         `json:"state"`
+	Status     string         `json:"status"`
+	Action     string         `json:"action"`
+	Scan       string         `json:"scan"`
+	ScanStatus ScanStatus     `json:"scanStatus"`
+	Devices    []*ZpoolDevice `json:"devices"`
+	Errors     string         `json:"errors"`
 }
 
 func (z *zfs) zpool(arg ...string) error {
@@ -544,6 +545,8 @@ func (z *zfs) GetZpoolStatus(name string) (ZpoolStatus, error) {
 		}
 	}
 
+	status.ScanStatus = ParseScanStatus(status.Scan)
+
 	return status, nil
 }
 